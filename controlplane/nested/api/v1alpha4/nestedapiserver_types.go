@@ -28,6 +28,57 @@ type NestedAPIServerSpec struct {
 	// required for creating the component.
 	// +optional
 	NestedComponentSpec `json:",inline"`
+
+	// Audit enables the audit logging of the apiserver.
+	// +optional
+	Audit *AuditConfig `json:"audit,omitempty"`
+}
+
+// AuditConfig defines the audit policy and the audit backends of the apiserver.
+type AuditConfig struct {
+	// PolicyConfigMapName is the name of the ConfigMap holding the audit policy
+	// in the policy.yaml key, in the namespace of the NestedAPIServer.
+	PolicyConfigMapName string `json:"policyConfigMapName"`
+
+	// Log writes the audit events to a log file of the apiserver.
+	// +optional
+	Log *AuditLogBackend `json:"log,omitempty"`
+
+	// Webhook sends the audit events to a remote API.
+	// +optional
+	Webhook *AuditWebhookBackend `json:"webhook,omitempty"`
+}
+
+// AuditLogBackend defines the rotation of the audit log file.
+type AuditLogBackend struct {
+	// MaxAge is the number of days the rotated log files are retained.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxAge int32 `json:"maxAge,omitempty"`
+
+	// MaxBackup is the number of rotated log files retained.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxBackup int32 `json:"maxBackup,omitempty"`
+
+	// MaxSize is the size in megabytes of the log file before it is rotated.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxSize int32 `json:"maxSize,omitempty"`
+}
+
+// AuditWebhookBackend defines the remote API receiving the audit events.
+type AuditWebhookBackend struct {
+	// KubeconfigSecretName is the name of the Secret holding the kubeconfig of
+	// the remote API in the kubeconfig key, in the namespace of the
+	// NestedAPIServer.
+	KubeconfigSecretName string `json:"kubeconfigSecretName"`
+
+	// Mode is the strategy to send the audit events.
+	// +kubebuilder:validation:Enum=batch;blocking;blocking-strict
+	// +kubebuilder:default=batch
+	// +optional
+	Mode string `json:"mode,omitempty"`
 }
 
 // NestedAPIServerStatus defines the observed state of NestedAPIServer.
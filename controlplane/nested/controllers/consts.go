@@ -29,4 +29,14 @@ const (
 	// EtcdManifestConfigmapName is the key name of the etcd manifest in the configmap.
 	EtcdManifestConfigmapName = "netcd-manifest"
 	loopbackAddress           = "127.0.0.1"
+
+	// AuditPolicyKey is the key of the audit policy in the ConfigMap referred by
+	// the NestedAPIServer.
+	AuditPolicyKey = "policy.yaml"
+	// AuditWebhookKubeconfigKey is the key of the kubeconfig in the Secret
+	// referred by the NestedAPIServer.
+	AuditWebhookKubeconfigKey = "kubeconfig"
+	auditPolicyDir            = "/etc/kubernetes/audit"
+	auditWebhookDir           = "/etc/kubernetes/audit-webhook"
+	auditLogDir               = "/var/log/kubernetes/audit"
 )
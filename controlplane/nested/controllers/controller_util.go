@@ -47,11 +47,13 @@ import (
 // +kubebuilder:rbac:groups="";apps,resources=services/status;statefulsets/status,verbs=get;update;patch
 
 // createNestedComponentSts will create the StatefulSet that runs the
-// NestedComponent.
+// NestedComponent, the StatefulSet is completed by the component specific
+// mutators before it is created.
 func createNestedComponentSts(ctx context.Context,
 	cli ctrlcli.Client, ncMeta metav1.ObjectMeta,
 	ncSpec controlplanev1.NestedComponentSpec,
-	ncKind, clusterName string, log logr.Logger) error {
+	ncKind, clusterName string, log logr.Logger,
+	mutators ...func(*appsv1.StatefulSet)) error {
 	// setup the ownerReferences for all objects
 	or := metav1.NewControllerRef(&ncMeta,
 		controlplanev1.GroupVersion.WithKind(ncKind))
@@ -60,6 +62,9 @@ func createNestedComponentSts(ctx context.Context,
 	if err != nil {
 		return errors.Errorf("fail to generate the Statefulset object: %v", err)
 	}
+	for _, mutate := range mutators {
+		mutate(ncSts)
+	}
 
 	if ncKind != kubeadm.ControllerManager {
 		// no need to create the service for the NestedControllerManager
@@ -675,3 +680,104 @@ func completeEtcdPodSpec(pod corev1.Pod, clusterName string) corev1.Pod {
 	pod.Spec = ps
 	return pod
 }
+
+// completeKASAudit returns the mutator mounting the audit policy and the
+// audit backends into the kube-apiserver StatefulSet.
+func completeKASAudit(audit *controlplanev1.AuditConfig) func(*appsv1.StatefulSet) {
+	return func(sts *appsv1.StatefulSet) {
+		ps := &sts.Spec.Template.Spec
+		if audit == nil || len(ps.Containers) == 0 {
+			return
+		}
+		var volSrtMode int32 = 420
+		kas := &ps.Containers[0]
+		ps.Volumes = append(ps.Volumes, corev1.Volume{
+			Name: "audit-policy",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: audit.PolicyConfigMapName},
+					DefaultMode:          &volSrtMode,
+				},
+			},
+		})
+		kas.VolumeMounts = append(kas.VolumeMounts, corev1.VolumeMount{
+			MountPath: auditPolicyDir,
+			Name:      "audit-policy",
+			ReadOnly:  true,
+		})
+		kas.Command = append(kas.Command,
+			fmt.Sprintf("--audit-policy-file=%s/%s", auditPolicyDir, AuditPolicyKey))
+
+		if log := audit.Log; log != nil {
+			ps.Volumes = append(ps.Volumes, corev1.Volume{
+				Name:         "audit-log",
+				VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			})
+			kas.VolumeMounts = append(kas.VolumeMounts, corev1.VolumeMount{
+				MountPath: auditLogDir,
+				Name:      "audit-log",
+			})
+			kas.Command = append(kas.Command,
+				fmt.Sprintf("--audit-log-path=%s/audit.log", auditLogDir),
+				"--audit-log-format=json")
+			if log.MaxAge > 0 {
+				kas.Command = append(kas.Command, fmt.Sprintf("--audit-log-maxage=%d", log.MaxAge))
+			}
+			if log.MaxBackup > 0 {
+				kas.Command = append(kas.Command, fmt.Sprintf("--audit-log-maxbackup=%d", log.MaxBackup))
+			}
+			if log.MaxSize > 0 {
+				kas.Command = append(kas.Command, fmt.Sprintf("--audit-log-maxsize=%d", log.MaxSize))
+			}
+		}
+
+		if wh := audit.Webhook; wh != nil {
+			mode := wh.Mode
+			if mode == "" {
+				mode = "batch"
+			}
+			ps.Volumes = append(ps.Volumes, corev1.Volume{
+				Name: "audit-webhook",
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						DefaultMode: &volSrtMode,
+						SecretName:  wh.KubeconfigSecretName,
+					},
+				},
+			})
+			kas.VolumeMounts = append(kas.VolumeMounts, corev1.VolumeMount{
+				MountPath: auditWebhookDir,
+				Name:      "audit-webhook",
+				ReadOnly:  true,
+			})
+			kas.Command = append(kas.Command,
+				fmt.Sprintf("--audit-webhook-config-file=%s/%s", auditWebhookDir, AuditWebhookKubeconfigKey),
+				"--audit-webhook-mode="+mode)
+		}
+	}
+}
+
+// checkAuditRefs checks the ConfigMap and the Secret referred by the audit
+// config exist, otherwise the kube-apiserver pods are stuck on mounting them.
+func checkAuditRefs(ctx context.Context, cli ctrlcli.Client, namespace string, audit *controlplanev1.AuditConfig) error {
+	if audit == nil {
+		return nil
+	}
+	cm := corev1.ConfigMap{}
+	if err := cli.Get(ctx, types.NamespacedName{Namespace: namespace, Name: audit.PolicyConfigMapName}, &cm); err != nil {
+		return errors.Wrap(err, "fail to get the audit policy")
+	}
+	if _, ok := cm.Data[AuditPolicyKey]; !ok {
+		return errors.Errorf("audit policy %s/%s has no %s", namespace, audit.PolicyConfigMapName, AuditPolicyKey)
+	}
+	if wh := audit.Webhook; wh != nil {
+		srt := corev1.Secret{}
+		if err := cli.Get(ctx, types.NamespacedName{Namespace: namespace, Name: wh.KubeconfigSecretName}, &srt); err != nil {
+			return errors.Wrap(err, "fail to get the audit webhook kubeconfig")
+		}
+		if _, ok := srt.Data[AuditWebhookKubeconfigKey]; !ok {
+			return errors.Errorf("audit webhook secret %s/%s has no %s", namespace, wh.KubeconfigSecretName, AuditWebhookKubeconfigKey)
+		}
+	}
+	return nil
+}
@@ -17,11 +17,17 @@ limitations under the License.
 package controllers
 
 import (
+	"context"
 	"reflect"
+	"strings"
 	"testing"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
 	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 const (
@@ -205,3 +211,116 @@ func TestGenInitialClusterArgs(t *testing.T) {
 		t.Run(st.name, tf)
 	}
 }
+
+func TestCompleteKASAudit(t *testing.T) {
+	tests := []struct {
+		name    string
+		audit   *controlplanev1.AuditConfig
+		expect  []string
+		volumes int
+	}{
+		{
+			"no audit",
+			nil,
+			[]string{"kube-apiserver"},
+			0,
+		},
+		{
+			"log",
+			&controlplanev1.AuditConfig{
+				PolicyConfigMapName: "audit-policy",
+				Log:                 &controlplanev1.AuditLogBackend{MaxBackup: 3},
+			},
+			[]string{
+				"kube-apiserver",
+				"--audit-policy-file=/etc/kubernetes/audit/policy.yaml",
+				"--audit-log-path=/var/log/kubernetes/audit/audit.log",
+				"--audit-log-format=json",
+				"--audit-log-maxbackup=3",
+			},
+			2,
+		},
+		{
+			"webhook",
+			&controlplanev1.AuditConfig{
+				PolicyConfigMapName: "audit-policy",
+				Webhook:             &controlplanev1.AuditWebhookBackend{KubeconfigSecretName: "audit-sink", Mode: "blocking"},
+			},
+			[]string{
+				"kube-apiserver",
+				"--audit-policy-file=/etc/kubernetes/audit/policy.yaml",
+				"--audit-webhook-config-file=/etc/kubernetes/audit-webhook/kubeconfig",
+				"--audit-webhook-mode=blocking",
+			},
+			2,
+		},
+	}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				sts := &appsv1.StatefulSet{}
+				sts.Spec.Template.Spec.Containers = []corev1.Container{{Name: "apiserver", Command: []string{"kube-apiserver"}}}
+				completeKASAudit(st.audit)(sts)
+				ps := sts.Spec.Template.Spec
+				if !reflect.DeepEqual(ps.Containers[0].Command, st.expect) {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, ps.Containers[0].Command)
+				}
+				if len(ps.Volumes) != st.volumes || len(ps.Containers[0].VolumeMounts) != st.volumes {
+					t.Fatalf("\t%s\texpect %d volumes mounted, but get %d volumes and %d mounts", failed,
+						st.volumes, len(ps.Volumes), len(ps.Containers[0].VolumeMounts))
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, ps.Containers[0].Command)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestCheckAuditRefs(t *testing.T) {
+	policy := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "audit-policy", Namespace: "default"},
+		Data:       map[string]string{AuditPolicyKey: "apiVersion: audit.k8s.io/v1\nkind: Policy"},
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(policy).Build()
+	tests := []struct {
+		name   string
+		audit  *controlplanev1.AuditConfig
+		expect string
+	}{
+		{
+			"policy",
+			&controlplanev1.AuditConfig{PolicyConfigMapName: "audit-policy"},
+			"",
+		},
+		{
+			"missing policy",
+			&controlplanev1.AuditConfig{PolicyConfigMapName: "missing"},
+			"fail to get the audit policy",
+		},
+		{
+			"missing webhook kubeconfig",
+			&controlplanev1.AuditConfig{
+				PolicyConfigMapName: "audit-policy",
+				Webhook:             &controlplanev1.AuditWebhookBackend{KubeconfigSecretName: "missing"},
+			},
+			"fail to get the audit webhook kubeconfig",
+		},
+	}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				err := checkAuditRefs(context.TODO(), cli, "default", st.audit)
+				if (st.expect == "" && err != nil) || (st.expect != "" && (err == nil || !strings.Contains(err.Error(), st.expect))) {
+					t.Fatalf("\t%s\texpect error %q, but get %v", failed, st.expect, err)
+				}
+				t.Logf("\t%s\texpect error %q, get %v", succeed, st.expect, err)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
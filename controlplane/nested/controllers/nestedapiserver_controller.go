@@ -110,10 +110,16 @@ func (r *NestedAPIServerReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 				return ctrl.Result{}, err
 			}
 
+			if err := checkAuditRefs(ctx, r.Client, nkas.GetNamespace(), nkas.Spec.Audit); err != nil {
+				log.Error(err, "fail to enable the audit of the NestedAPIServer")
+				return ctrl.Result{}, err
+			}
+
 			// the statefulset is not found, create one.
 			if err := createNestedComponentSts(ctx,
 				r.Client, nkas.ObjectMeta, nkas.Spec.NestedComponentSpec,
-				kubeadm.APIServer, cluster.GetName(), log); err != nil {
+				kubeadm.APIServer, cluster.GetName(), log,
+				completeKASAudit(nkas.Spec.Audit)); err != nil {
 				log.Error(err, "fail to create NestedAPIServer StatefulSet")
 				return ctrl.Result{}, err
 			}
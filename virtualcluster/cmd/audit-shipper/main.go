@@ -0,0 +1,75 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/audit/shipper"
+	logrutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/logr"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/version"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/version/verflag"
+)
+
+func main() {
+	var (
+		s          shipper.Shipper
+		period     time.Duration
+		timeout    time.Duration
+		versionOpt bool
+	)
+	flag.StringVar(&s.LogPath, "audit-log-path", "/var/log/kubernetes/audit/audit.log", "The path of the audit log written by the tenant apiserver.")
+	flag.StringVar(&s.SinkURL, "sink-url", "", "The URL of the super cluster audit sink the audit events are posted to.")
+	flag.StringVar(&s.Cluster, "cluster", "", "The root namespace of the tenant control plane.")
+	flag.StringVar(&s.VCNamespace, "vc-namespace", "", "The namespace of the VirtualCluster.")
+	flag.StringVar(&s.VCName, "vc-name", "", "The name of the VirtualCluster.")
+	flag.IntVar(&s.BatchSize, "batch-size", shipper.DefaultBatchSize, "The max number of audit events sent in one request.")
+	flag.DurationVar(&period, "period", 5*time.Second, "How often the audit log is read.")
+	flag.DurationVar(&timeout, "timeout", 30*time.Second, "The timeout of the requests to the audit sink.")
+	flag.BoolVar(&versionOpt, "version", false, "Print the version information")
+	flag.Parse()
+
+	// print version information
+	if versionOpt {
+		fmt.Printf("VirtualCluster %s\n", verflag.GetVersion(version.Get()))
+		os.Exit(0)
+	}
+
+	loggr, err := logrutil.NewLogger("", false)
+	if err != nil {
+		panic(fmt.Sprintf("fail to initialize logr: %s", err))
+	}
+	logf.SetLogger(loggr)
+	log := logf.Log.WithName("audit-shipper")
+
+	if s.SinkURL == "" {
+		log.Error(nil, "--sink-url is required")
+		os.Exit(1)
+	}
+	s.Client = &http.Client{Timeout: timeout}
+	s.Log = log
+
+	log.Info("shipping audit events", "path", s.LogPath, "sink", s.SinkURL, "cluster", s.Cluster)
+	s.Run(ctrl.SetupSignalHandler(), period)
+}
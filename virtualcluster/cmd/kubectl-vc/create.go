@@ -274,6 +274,9 @@ func replaceServerAddr(kubecfg clientcmd.ClientConfig, cli client.Client, cluste
 			return nil, err
 		}
 		newStr = fmt.Sprintf("https://%s:%d", externalIP, apiSvcPort)
+	default:
+		// the apiserver address in the kubeconfig is reachable within the super cluster.
+		return kubecfg, nil
 	}
 
 	rawConfig, err := kubecfg.RawConfig()
@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+)
+
+const (
+	deleteExample = `
+	# Delete a virtualcluster and wait for its control plane to be removed
+	kubectl vc delete -n foo bar
+
+	# Delete a virtualcluster without waiting
+	kubectl vc delete foo/bar --wait=false`
+
+	pollDeletePeriod = 2 * time.Second
+)
+
+type DeleteOptions struct {
+	vcclient  vcclient.Interface
+	namespace string
+	name      string
+	wait      bool
+	timeout   time.Duration
+	output    string
+}
+
+func NewCmdDelete(f Factory) *cobra.Command {
+	o := &DeleteOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "delete VC_NAME",
+		Short:   "Delete a VirtualCluster",
+		Example: deleteExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Run(os.Stdout))
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceDefault, "If present, the namespace scope for this CLI request")
+	cmd.Flags().BoolVar(&o.wait, "wait", true, "If true, wait for the virtualcluster to be gone before returning")
+	cmd.Flags().DurationVar(&o.timeout, "timeout", 5*time.Minute, "The length of time to wait for the virtualcluster to be gone")
+	addOutputFlag(cmd, &o.output)
+
+	return cmd
+}
+
+func (o *DeleteOptions) Complete(f Factory, cmd *cobra.Command, args []string) error {
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return UsageErrorf(cmd, "VC_NAME should not be empty")
+	}
+	o.namespace, o.name = parseVCName(o.namespace, args[0])
+
+	return validateOutput(cmd, o.output)
+}
+
+func (o *DeleteOptions) Run(w io.Writer) error {
+	vcs := o.vcclient.TenancyV1alpha1().VirtualClusters(o.namespace)
+	vc, err := vcs.Get(o.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	propagation := metav1.DeletePropagationForeground
+	if err := vcs.Delete(o.name, &metav1.DeleteOptions{
+		Preconditions:     metav1.NewUIDPreconditions(string(vc.UID)),
+		PropagationPolicy: &propagation,
+	}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	if o.wait {
+		log.Printf("waiting for VirtualCluster %s/%s to be deleted\n", o.namespace, o.name)
+		if err := wait.PollImmediate(pollDeletePeriod, o.timeout, func() (bool, error) {
+			current, err := vcs.Get(o.name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				return true, nil
+			}
+			if err != nil {
+				return false, err
+			}
+			// a virtualcluster with the same name is created again.
+			return current.UID != vc.UID, nil
+		}); err != nil {
+			return errors.Wrapf(err, "wait for VirtualCluster %s/%s to be deleted", o.namespace, o.name)
+		}
+	}
+
+	if o.output != "" {
+		return printStructured(w, vc, o.output)
+	}
+	log.Printf("VirtualCluster %s/%s deleted\n", o.namespace, o.name)
+	return nil
+}
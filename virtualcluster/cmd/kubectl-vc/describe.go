@@ -0,0 +1,205 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/kubernetes"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	describeExample = `
+	# Describe a virtualcluster
+	kubectl vc describe -n foo bar
+
+	# Describe a virtualcluster in json
+	kubectl vc describe foo/bar -o json`
+)
+
+// controlPlaneComponents are the StatefulSets of the tenant control plane.
+var controlPlaneComponents = []string{"etcd", "apiserver", "controller-manager"}
+
+// ComponentStatus is the health of a tenant control plane component.
+type ComponentStatus struct {
+	Name          string `json:"name"`
+	Ready         bool   `json:"ready"`
+	Replicas      int32  `json:"replicas"`
+	ReadyReplicas int32  `json:"readyReplicas"`
+	Message       string `json:"message,omitempty"`
+}
+
+// VirtualClusterDescription is the virtualcluster along with its control plane health.
+type VirtualClusterDescription struct {
+	VirtualCluster *tenancyv1alpha1.VirtualCluster `json:"virtualCluster"`
+	Components     []ComponentStatus               `json:"components"`
+}
+
+type DescribeOptions struct {
+	kubeclient kubernetes.Interface
+	vcclient   vcclient.Interface
+	namespace  string
+	name       string
+	output     string
+}
+
+func NewCmdDescribe(f Factory) *cobra.Command {
+	o := &DescribeOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "describe VC_NAME",
+		Short:   "Show the details of a VirtualCluster",
+		Example: describeExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Run(os.Stdout))
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceDefault, "If present, the namespace scope for this CLI request")
+	addOutputFlag(cmd, &o.output)
+
+	return cmd
+}
+
+func (o *DescribeOptions) Complete(f Factory, cmd *cobra.Command, args []string) error {
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+
+	o.kubeclient, err = f.KubernetesClientSet()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return UsageErrorf(cmd, "VC_NAME should not be empty")
+	}
+	o.namespace, o.name = parseVCName(o.namespace, args[0])
+
+	return validateOutput(cmd, o.output)
+}
+
+func (o *DescribeOptions) Run(w io.Writer) error {
+	vc, err := o.vcclient.TenancyV1alpha1().VirtualClusters(o.namespace).Get(o.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	desc := &VirtualClusterDescription{
+		VirtualCluster: vc,
+		Components:     getComponentStatuses(o.kubeclient, clusterNamespace(vc)),
+	}
+
+	if o.output != "" {
+		return printStructured(w, desc, o.output)
+	}
+	return printDescription(w, desc)
+}
+
+// clusterNamespace returns the namespace of the tenant control plane.
+func clusterNamespace(vc *tenancyv1alpha1.VirtualCluster) string {
+	if vc.Status.ClusterNamespace != "" {
+		return vc.Status.ClusterNamespace
+	}
+	return conversion.ToClusterKey(vc)
+}
+
+// getComponentStatuses checks the StatefulSets of the tenant control plane components.
+func getComponentStatuses(cli kubernetes.Interface, namespace string) []ComponentStatus {
+	statuses := make([]ComponentStatus, 0, len(controlPlaneComponents))
+	for _, name := range controlPlaneComponents {
+		status := ComponentStatus{Name: name}
+		sts, err := cli.AppsV1().StatefulSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			status.Message = fmt.Sprintf("statefulset %s/%s is not found", namespace, name)
+		case err != nil:
+			status.Message = fmt.Sprintf("fail to get statefulset %s/%s: %v", namespace, name, err)
+		default:
+			status.Replicas, status.ReadyReplicas = statefulSetReplicas(sts)
+			status.Ready = status.ReadyReplicas == status.Replicas && sts.Status.ObservedGeneration >= sts.Generation
+			if !status.Ready {
+				status.Message = fmt.Sprintf("%d of %d replicas are ready", status.ReadyReplicas, status.Replicas)
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func statefulSetReplicas(sts *appsv1.StatefulSet) (int32, int32) {
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	return replicas, sts.Status.ReadyReplicas
+}
+
+func printDescription(w io.Writer, desc *VirtualClusterDescription) error {
+	vc := desc.VirtualCluster
+	tw := printers.GetNewTabWriter(w)
+	fmt.Fprintf(tw, "Name:\t%s\n", vc.Name)
+	fmt.Fprintf(tw, "Namespace:\t%s\n", vc.Namespace)
+	fmt.Fprintf(tw, "ClusterVersion:\t%s\n", vc.Spec.ClusterVersionName)
+	if applied, exists := vc.Labels[constants.LabelClusterVersionApplied]; exists {
+		fmt.Fprintf(tw, "ClusterVersion Applied:\t%s\n", applied)
+	}
+	fmt.Fprintf(tw, "Cluster Namespace:\t%s\n", clusterNamespace(vc))
+	fmt.Fprintf(tw, "Phase:\t%s\n", vc.Status.Phase)
+	fmt.Fprintf(tw, "Reason:\t%s\n", vc.Status.Reason)
+	fmt.Fprintf(tw, "Message:\t%s\n", vc.Status.Message)
+	if vc.Status.Retries > 0 {
+		fmt.Fprintf(tw, "Retries:\t%d\n", vc.Status.Retries)
+	}
+	fmt.Fprintf(tw, "Age:\t%s\n", age(vc))
+	if vc.DeletionTimestamp != nil {
+		fmt.Fprintf(tw, "Deleting Since:\t%s\n", vc.DeletionTimestamp.String())
+	}
+
+	fmt.Fprintln(tw, "Components:")
+	fmt.Fprintln(tw, "  NAME\tREADY\tMESSAGE")
+	for _, c := range desc.Components {
+		fmt.Fprintf(tw, "  %s\t%d/%d\t%s\n", c.Name, c.ReadyReplicas, c.Replicas, c.Message)
+	}
+
+	fmt.Fprintln(tw, "Conditions:")
+	if len(vc.Status.Conditions) == 0 {
+		fmt.Fprintln(tw, "  <none>")
+	} else {
+		fmt.Fprintln(tw, "  TYPE\tSTATUS\tREASON\tLAST TRANSITION\tMESSAGE")
+		for _, c := range vc.Status.Conditions {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n", c.Type, c.Status, c.Reason, c.LastTransitionTime.String(), c.Message)
+		}
+	}
+	return tw.Flush()
+}
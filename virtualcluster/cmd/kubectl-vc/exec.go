@@ -23,7 +23,6 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
-	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -89,12 +88,7 @@ func (o *ExecOption) Complete(f Factory, cmd *cobra.Command, args []string) erro
 		return UsageErrorf(cmd, "VC_NAME should not be empty")
 	}
 
-	o.name = args[0]
-	if strings.Contains(o.name, "/") {
-		namespacedName := strings.SplitN(o.name, "/", 2)
-		o.namespace = namespacedName[0]
-		o.name = namespacedName[1]
-	}
+	o.namespace, o.name = parseVCName(o.namespace, args[0])
 
 	return nil
 }
@@ -0,0 +1,131 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+)
+
+const (
+	getKubeConfigExample = `
+	# Print the kubeconfig of a virtualcluster
+	kubectl vc get-kubeconfig -n foo bar
+
+	# Save the kubeconfig of a virtualcluster to a file
+	kubectl vc get-kubeconfig foo/bar --file bar.kubeconfig`
+)
+
+type GetKubeConfigOptions struct {
+	client    client.Client
+	vcclient  vcclient.Interface
+	namespace string
+	name      string
+	filePath  string
+	output    string
+}
+
+func NewCmdGetKubeConfig(f Factory) *cobra.Command {
+	o := &GetKubeConfigOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "get-kubeconfig VC_NAME",
+		Short:   "Get the kubeconfig to access a VirtualCluster",
+		Example: getKubeConfigExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Run(os.Stdout))
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceDefault, "If present, the namespace scope for this CLI request")
+	cmd.Flags().StringVar(&o.filePath, "file", "", "If present, write the kubeconfig to the file instead of stdout")
+	addOutputFlag(cmd, &o.output)
+
+	return cmd
+}
+
+func (o *GetKubeConfigOptions) Complete(f Factory, cmd *cobra.Command, args []string) error {
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+
+	o.client, err = f.GenericClient()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return UsageErrorf(cmd, "VC_NAME should not be empty")
+	}
+	o.namespace, o.name = parseVCName(o.namespace, args[0])
+
+	return validateOutput(cmd, o.output)
+}
+
+func (o *GetKubeConfigOptions) Run(w io.Writer) error {
+	vc, err := o.vcclient.TenancyV1alpha1().VirtualClusters(o.namespace).Get(o.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	cv, err := o.vcclient.TenancyV1alpha1().ClusterVersions().Get(vc.Spec.ClusterVersionName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "cluster version not found")
+	}
+
+	// the kubeconfig is written in yaml.
+	kbBytes, err := genKubeConfig(o.client, vc, cv)
+	if err != nil {
+		return err
+	}
+	if o.output == outputJSON {
+		jsonBytes, err := yaml.YAMLToJSON(kbBytes)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, jsonBytes, "", "    "); err != nil {
+			return err
+		}
+		kbBytes = append(buf.Bytes(), '\n')
+	}
+
+	if o.filePath == "" {
+		_, err = w.Write(kbBytes)
+		return err
+	}
+	if err := ioutil.WriteFile(o.filePath, kbBytes, 0600); err != nil {
+		return err
+	}
+	log.Printf("kubeconfig of VirtualCluster %s/%s is written to %s\n", o.namespace, o.name, o.filePath)
+	return nil
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/cli-runtime/pkg/printers"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+)
+
+const (
+	listExample = `
+	# List the virtualclusters in the default namespace
+	kubectl vc list
+
+	# List the virtualclusters in all namespaces
+	kubectl vc list -A
+
+	# List the virtualclusters in yaml
+	kubectl vc list -n foo -o yaml`
+)
+
+type ListOptions struct {
+	vcclient      vcclient.Interface
+	namespace     string
+	allNamespaces bool
+	output        string
+}
+
+func NewCmdList(f Factory) *cobra.Command {
+	o := &ListOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List VirtualClusters",
+		Example: listExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd))
+			CheckErr(o.Run(os.Stdout))
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceDefault, "If present, the namespace scope for this CLI request")
+	cmd.Flags().BoolVarP(&o.allNamespaces, "all-namespaces", "A", false, "If present, list the virtualclusters across all namespaces")
+	addOutputFlag(cmd, &o.output)
+
+	return cmd
+}
+
+func (o *ListOptions) Complete(f Factory, cmd *cobra.Command) error {
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+
+	if o.allNamespaces {
+		o.namespace = metav1.NamespaceAll
+	}
+
+	return validateOutput(cmd, o.output)
+}
+
+func (o *ListOptions) Run(w io.Writer) error {
+	vcList, err := o.vcclient.TenancyV1alpha1().VirtualClusters(o.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	if o.output != "" {
+		return printStructured(w, vcList, o.output)
+	}
+
+	if len(vcList.Items) == 0 {
+		fmt.Fprintln(os.Stderr, "No virtualcluster is found")
+		return nil
+	}
+
+	tw := printers.GetNewTabWriter(w)
+	if o.allNamespaces {
+		fmt.Fprint(tw, "NAMESPACE\t")
+	}
+	fmt.Fprintln(tw, "NAME\tCLUSTERVERSION\tPHASE\tAGE")
+	for i := range vcList.Items {
+		vc := &vcList.Items[i]
+		if o.allNamespaces {
+			fmt.Fprintf(tw, "%s\t", vc.Namespace)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", vc.Name, vc.Spec.ClusterVersionName, vc.Status.Phase, age(vc))
+	}
+	return tw.Flush()
+}
+
+// age returns the human readable age of the virtualcluster.
+func age(vc *tenancyv1alpha1.VirtualCluster) string {
+	if vc.CreationTimestamp.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(metav1.Now().Sub(vc.CreationTimestamp.Time))
+}
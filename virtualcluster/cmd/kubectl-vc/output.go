@@ -0,0 +1,91 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned/scheme"
+)
+
+const (
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// addOutputFlag adds the -o,--output flag selecting the structured output format.
+func addOutputFlag(cmd *cobra.Command, output *string) {
+	cmd.Flags().StringVarP(output, "output", "o", "", "Output format. One of: json|yaml. The human readable format is used if empty")
+}
+
+// validateOutput checks the output format is supported.
+func validateOutput(cmd *cobra.Command, output string) error {
+	switch output {
+	case "", outputJSON, outputYAML:
+		return nil
+	default:
+		return UsageErrorf(cmd, "unsupported output format %q, one of json|yaml is expected", output)
+	}
+}
+
+// printStructured writes obj to w in the json or yaml output format. The kind of the
+// VirtualCluster API objects is set since the clientset doesn't return it.
+func printStructured(w io.Writer, obj interface{}, output string) error {
+	if o, ok := obj.(runtime.Object); ok {
+		setTypeMeta(o)
+	}
+	data, err := json.MarshalIndent(obj, "", "    ")
+	if err != nil {
+		return err
+	}
+	if output == outputYAML {
+		if data, err = yaml.JSONToYAML(data); err != nil {
+			return err
+		}
+	} else {
+		data = append(data, '\n')
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func setTypeMeta(obj runtime.Object) {
+	if !obj.GetObjectKind().GroupVersionKind().Empty() {
+		return
+	}
+	gvks, _, err := scheme.Scheme.ObjectKinds(obj)
+	if err != nil || len(gvks) == 0 {
+		return
+	}
+	obj.GetObjectKind().SetGroupVersionKind(gvks[0])
+}
+
+// parseVCName returns the namespace and name of the VirtualCluster given as NAME or
+// NAMESPACE/NAME, the namespace defaults to the given one.
+func parseVCName(namespace, arg string) (string, string) {
+	if strings.Contains(arg, "/") {
+		namespacedName := strings.SplitN(arg, "/", 2)
+		return namespacedName[0], namespacedName[1]
+	}
+	return namespace, arg
+}
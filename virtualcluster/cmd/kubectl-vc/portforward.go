@@ -0,0 +1,300 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	portForwardExample = `
+	# Forward a random local port to the apiserver of a virtualcluster until interrupted
+	kubectl vc port-forward -n foo bar
+
+	# Forward the local port 6443 to the apiserver of a virtualcluster
+	kubectl vc port-forward foo/bar --port 6443`
+
+	proxyExample = `
+	# Switch to a virtualcluster through a port-forward to its apiserver
+	kubectl vc proxy -n foo bar`
+
+	apiServerComponentName = "apiserver"
+	localhostAddress       = "127.0.0.1"
+)
+
+// PortForwardOptions forwards a local port to the tenant apiserver pod through the super
+// cluster, so that a virtualcluster whose apiserver is only reachable within the super
+// cluster can be accessed with a kubeconfig pointing at localhost.
+type PortForwardOptions struct {
+	config      *rest.Config
+	client      client.Client
+	kubeclient  kubernetes.Interface
+	vcclient    vcclient.Interface
+	namespace   string
+	name        string
+	localPort   int
+	kubeFileDir string
+	shell       bool
+}
+
+func NewCmdPortForward(f Factory) *cobra.Command {
+	o := &PortForwardOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "port-forward VC_NAME",
+		Short:   "Forward a local port to the apiserver of a VirtualCluster",
+		Example: portForwardExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Run())
+		},
+	}
+	o.addFlags(cmd, false)
+
+	return cmd
+}
+
+func NewCmdProxy(f Factory) *cobra.Command {
+	o := &PortForwardOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "proxy VC_NAME",
+		Short:   "Switch to virtualcluster workspace through a port-forward to its apiserver",
+		Example: proxyExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Run())
+		},
+	}
+	o.addFlags(cmd, true)
+
+	return cmd
+}
+
+func (o *PortForwardOptions) addFlags(cmd *cobra.Command, shell bool) {
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceDefault, "If present, the namespace scope for this CLI request")
+	cmd.Flags().IntVar(&o.localPort, "port", 0, "The local port to forward to the apiserver, a random port is used if 0")
+	cmd.Flags().StringVar(&o.kubeFileDir, "kubeconfig-file-dir", filepath.Join(os.Getenv("HOME"), ".kube/vc/"), "The directory to place the temporary kubeconfig of specific vc")
+	cmd.Flags().BoolVar(&o.shell, "shell", shell, "If true, enter a shell using the temporary kubeconfig and stop forwarding when it exits")
+}
+
+func (o *PortForwardOptions) Complete(f Factory, cmd *cobra.Command, args []string) error {
+	var err error
+	o.config, err = f.RESTConfig()
+	if err != nil {
+		return err
+	}
+
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+
+	o.kubeclient, err = f.KubernetesClientSet()
+	if err != nil {
+		return err
+	}
+
+	o.client, err = f.GenericClient()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return UsageErrorf(cmd, "VC_NAME should not be empty")
+	}
+	o.namespace, o.name = parseVCName(o.namespace, args[0])
+
+	if o.localPort < 0 || o.localPort > 65535 {
+		return UsageErrorf(cmd, "--port %d is not a valid port", o.localPort)
+	}
+	return nil
+}
+
+func (o *PortForwardOptions) Run() error {
+	vc, err := o.vcclient.TenancyV1alpha1().VirtualClusters(o.namespace).Get(o.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	cv, err := o.vcclient.TenancyV1alpha1().ClusterVersions().Get(vc.Spec.ClusterVersionName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "cluster version not found")
+	}
+	if cv.Spec.APIServer == nil || cv.Spec.APIServer.Service == nil {
+		return fmt.Errorf("no apiserver service is specified in cluster version %s", cv.Name)
+	}
+	remotePort, err := getAPISvcPort(cv.Spec.APIServer.Service)
+	if err != nil {
+		return err
+	}
+
+	ns := clusterNamespace(vc)
+	pod, err := getReadyAPIServerPod(o.kubeclient, ns)
+	if err != nil {
+		return err
+	}
+
+	stopCh := make(chan struct{})
+	readyCh := make(chan struct{})
+	var out io.Writer = os.Stdout
+	if o.shell {
+		out = ioutil.Discard
+	}
+	fw, err := o.newPortForwarder(pod, remotePort, stopCh, readyCh, out)
+	if err != nil {
+		return err
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- fw.ForwardPorts()
+	}()
+	select {
+	case <-readyCh:
+	case err := <-errCh:
+		return errors.Wrapf(err, "forward to pod %s/%s", pod.Namespace, pod.Name)
+	}
+	defer close(stopCh)
+
+	ports, err := fw.GetPorts()
+	if err != nil {
+		return err
+	}
+	kbFilePath, err := o.placeLocalKubeconfig(vc, ns, int(ports[0].Local))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(kbFilePath)
+	}()
+
+	if o.shell {
+		return enterVCShell(kbFilePath, o.namespace, o.name)
+	}
+
+	fmt.Printf("kubeconfig for virtualcluster %s/%s is placed at:\n\n\t%s\n\nPress Ctrl+C to stop forwarding\n", o.namespace, o.name, kbFilePath)
+	select {
+	case <-sigCh:
+		return nil
+	case err := <-errCh:
+		return err
+	}
+}
+
+func (o *PortForwardOptions) newPortForwarder(pod *corev1.Pod, remotePort int, stopCh, readyCh chan struct{}, out io.Writer) (*portforward.PortForwarder, error) {
+	transport, upgrader, err := spdy.RoundTripperFor(o.config)
+	if err != nil {
+		return nil, err
+	}
+	u := o.kubeclient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("portforward").URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, u)
+	ports := []string{fmt.Sprintf("%d:%d", o.localPort, remotePort)}
+	return portforward.NewOnAddresses(dialer, []string{localhostAddress}, ports, stopCh, readyCh, out, os.Stderr)
+}
+
+// placeLocalKubeconfig writes the admin kubeconfig of the virtualcluster with the server
+// pointing at the local port. The apiserver certificate is still verified against the
+// name of the original server.
+func (o *PortForwardOptions) placeLocalKubeconfig(vc *tenancyv1alpha1.VirtualCluster, ns string, localPort int) (string, error) {
+	kbBytes, err := getVcKubeConfig(o.client, ns, "admin-kubeconfig")
+	if err != nil {
+		return "", err
+	}
+	config, err := clientcmd.Load(kbBytes)
+	if err != nil {
+		return "", err
+	}
+	for _, cluster := range config.Clusters {
+		if u, err := url.Parse(cluster.Server); err == nil && cluster.TLSServerName == "" {
+			cluster.TLSServerName = u.Hostname()
+		}
+		cluster.Server = fmt.Sprintf("https://%s:%d", localhostAddress, localPort)
+	}
+
+	if err = os.MkdirAll(o.kubeFileDir, 0755); err != nil {
+		return "", err
+	}
+	kbFilePath := filepath.Join(o.kubeFileDir, conversion.ToClusterKey(vc)+"-local.kubeconfig")
+	return kbFilePath, clientcmd.WriteToFile(*config, kbFilePath)
+}
+
+// getReadyAPIServerPod returns a ready pod of the apiserver StatefulSet.
+func getReadyAPIServerPod(cli kubernetes.Interface, namespace string) (*corev1.Pod, error) {
+	sts, err := cli.AppsV1().StatefulSets(namespace).Get(context.TODO(), apiServerComponentName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "get statefulset %s/%s", namespace, apiServerComponentName)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(sts.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := cli.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods.Items {
+		if isPodReady(&pods.Items[i]) {
+			return &pods.Items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no ready apiserver pod is found in namespace %s", namespace)
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+		return false
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
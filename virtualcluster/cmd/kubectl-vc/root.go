@@ -42,6 +42,13 @@ func main() {
 
 	rootCmd.AddCommand(NewCmdCreate(f))
 	rootCmd.AddCommand(NewCmdExec(f))
+	rootCmd.AddCommand(NewCmdList(f))
+	rootCmd.AddCommand(NewCmdDescribe(f))
+	rootCmd.AddCommand(NewCmdDelete(f))
+	rootCmd.AddCommand(NewCmdGetKubeConfig(f))
+	rootCmd.AddCommand(NewCmdUpgrade(f))
+	rootCmd.AddCommand(NewCmdPortForward(f))
+	rootCmd.AddCommand(NewCmdProxy(f))
 
 	CheckErr(rootCmd.Execute())
 }
@@ -0,0 +1,221 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+const (
+	upgradeExample = `
+	# Apply the latest revision of the current ClusterVersion to a virtualcluster
+	kubectl vc upgrade -n foo bar
+
+	# Upgrade a virtualcluster to another ClusterVersion
+	kubectl vc upgrade foo/bar --cluster-version cv-sample-np-1.20
+
+	# Apply other parameter values of the ClusterVersion to a virtualcluster
+	kubectl vc upgrade foo/bar --set apiserverCPU=2 --set featureGates=EphemeralContainers=true
+
+	# Upgrade a virtualcluster, rolling it back automatically if the upgrade fails
+	kubectl vc upgrade foo/bar --cluster-version cv-sample-np-1.20 --auto-rollback
+
+	# Roll a virtualcluster back to the ClusterVersion applied before the last upgrade
+	kubectl vc upgrade foo/bar --rollback`
+)
+
+type UpgradeOptions struct {
+	vcclient       vcclient.Interface
+	namespace      string
+	name           string
+	clusterVersion string
+	autoRollback   bool
+	rollback       bool
+	set            []string
+	parameters     map[string]string
+	output         string
+}
+
+func NewCmdUpgrade(f Factory) *cobra.Command {
+	o := &UpgradeOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "upgrade VC_NAME",
+		Short: "Upgrade the control plane of a VirtualCluster to a ClusterVersion",
+		Long: `Upgrade the control plane of a VirtualCluster to a ClusterVersion.
+
+The upgrade is applied by vc-manager, which must run with the ClusterVersionPartialUpgrade
+feature gate enabled. The apiserver and then the controller-manager are rolled, the
+upgrade stops if the tenant apiserver is not ready after one of them. The etcd of the
+virtualcluster is not upgraded.`,
+		Example: upgradeExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Run(os.Stdout))
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceDefault, "If present, the namespace scope for this CLI request")
+	cmd.Flags().StringVar(&o.clusterVersion, "cluster-version", "", "The ClusterVersion to upgrade to, the current ClusterVersion is re-applied if empty")
+	cmd.Flags().BoolVar(&o.autoRollback, "auto-rollback", false, "If true, roll the control plane back to its previous ClusterVersion when the upgrade fails")
+	cmd.Flags().BoolVar(&o.rollback, "rollback", false, "If true, roll the control plane back to the ClusterVersion applied before the last upgrade")
+	cmd.Flags().StringArrayVar(&o.set, "set", nil, "Set a parameter of the ClusterVersion as name=value, can be repeated")
+	addOutputFlag(cmd, &o.output)
+
+	return cmd
+}
+
+func (o *UpgradeOptions) Complete(f Factory, cmd *cobra.Command, args []string) error {
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return UsageErrorf(cmd, "VC_NAME should not be empty")
+	}
+	o.namespace, o.name = parseVCName(o.namespace, args[0])
+
+	if o.rollback && (o.clusterVersion != "" || o.autoRollback || len(o.set) != 0) {
+		return UsageErrorf(cmd, "--rollback can't be used with --cluster-version, --auto-rollback or --set")
+	}
+	o.parameters = make(map[string]string, len(o.set))
+	for _, param := range o.set {
+		name, value, ok := cutParameter(param)
+		if !ok {
+			return UsageErrorf(cmd, "--set %q should be name=value", param)
+		}
+		o.parameters[name] = value
+	}
+	return validateOutput(cmd, o.output)
+}
+
+func (o *UpgradeOptions) Run(w io.Writer) error {
+	vcs := o.vcclient.TenancyV1alpha1().VirtualClusters(o.namespace)
+	vc, err := vcs.Get(o.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if vc.Status.Phase != tenancyv1alpha1.ClusterRunning {
+		return fmt.Errorf("VirtualCluster %s/%s is %s, only a running virtualcluster can be upgraded", o.namespace, o.name, vc.Status.Phase)
+	}
+	if o.rollback {
+		return o.requestRollback(w)
+	}
+
+	cvName := o.clusterVersion
+	if cvName == "" {
+		cvName = vc.Spec.ClusterVersionName
+	}
+	cv, err := o.vcclient.TenancyV1alpha1().ClusterVersions().Get(cvName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "cluster version not found")
+	}
+
+	if cvName == vc.Spec.ClusterVersionName && len(o.parameters) == 0 && (vc.Labels[constants.LabelClusterVersionApplied] == cv.AppliedVersion() ||
+		vc.Labels[constants.LabelClusterVersionSpecHash] == cv.SpecHash()) {
+		log.Printf("VirtualCluster %s/%s is already in ClusterVersion %s\n", o.namespace, o.name, cvName)
+		if o.output != "" {
+			return printStructured(w, vc, o.output)
+		}
+		return nil
+	}
+
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		vc, err = vcs.Get(o.name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		vc.Spec.ClusterVersionName = cvName
+		if len(o.parameters) != 0 && vc.Spec.Parameters == nil {
+			vc.Spec.Parameters = map[string]string{}
+		}
+		for name, value := range o.parameters {
+			vc.Spec.Parameters[name] = value
+		}
+		if vc.Labels == nil {
+			vc.Labels = map[string]string{}
+		}
+		vc.Labels[constants.LabelVCReadyForUpgrade] = "true"
+		if o.autoRollback {
+			if vc.Annotations == nil {
+				vc.Annotations = map[string]string{}
+			}
+			vc.Annotations[constants.LabelVCUpgradeAutoRollback] = "true"
+		}
+		vc, err = vcs.Update(vc)
+		return err
+	}); err != nil {
+		return errors.Wrapf(err, "request upgrade of VirtualCluster %s/%s", o.namespace, o.name)
+	}
+
+	if o.output != "" {
+		return printStructured(w, vc, o.output)
+	}
+	log.Printf("upgrade of VirtualCluster %s/%s to ClusterVersion %s is requested, it is applied by vc-manager if ClusterVersionPartialUpgrade is enabled\n", o.namespace, o.name, cvName)
+	return nil
+}
+
+// requestRollback annotates the virtualcluster to be rolled back by vc-manager.
+func (o *UpgradeOptions) requestRollback(w io.Writer) error {
+	vcs := o.vcclient.TenancyV1alpha1().VirtualClusters(o.namespace)
+	var vc *tenancyv1alpha1.VirtualCluster
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var err error
+		vc, err = vcs.Get(o.name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if vc.Annotations == nil {
+			vc.Annotations = map[string]string{}
+		}
+		vc.Annotations[constants.LabelVCRollback] = "true"
+		vc, err = vcs.Update(vc)
+		return err
+	}); err != nil {
+		return errors.Wrapf(err, "request rollback of VirtualCluster %s/%s", o.namespace, o.name)
+	}
+
+	if o.output != "" {
+		return printStructured(w, vc, o.output)
+	}
+	log.Printf("rollback of VirtualCluster %s/%s is requested, it is applied by vc-manager if ClusterVersionPartialUpgrade is enabled\n", o.namespace, o.name)
+	return nil
+}
+
+// cutParameter splits a name=value parameter, the value may contain '='.
+func cutParameter(param string) (name, value string, ok bool) {
+	i := strings.Index(param, "=")
+	if i <= 0 {
+		return "", "", false
+	}
+	return param[:i], param[i+1:], true
+}
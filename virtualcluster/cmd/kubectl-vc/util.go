@@ -45,6 +45,9 @@ type Factory interface {
 
 	// VirtualClusterClientSet is the virtualcluster clientset
 	VirtualClusterClientSet() (vcclient.Interface, error)
+
+	// RESTConfig is the config to access the super cluster
+	RESTConfig() (*rest.Config, error)
 }
 
 type factoryImpl struct {
@@ -72,6 +75,10 @@ func (f *factoryImpl) VirtualClusterClientSet() (vcclient.Interface, error) {
 	return vcclient.NewForConfig(f.config)
 }
 
+func (f *factoryImpl) RESTConfig() (*rest.Config, error) {
+	return rest.CopyConfig(f.config), nil
+}
+
 func UsageErrorf(cmd *cobra.Command, format string, args ...interface{}) error {
 	msg := fmt.Sprintf(format, args...)
 	return fmt.Errorf("%s\nSee '%s -h' for help and examples", msg, cmd.CommandPath())
@@ -20,6 +20,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
@@ -37,6 +38,8 @@ import (
 	cliflag "k8s.io/component-base/cli/flag"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers/provisioner"
 	logrutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/logr"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/version"
@@ -56,7 +59,12 @@ func main() {
 		versionOpt                        bool
 		disableStacktrace                 bool
 		enableWebhook                     bool
+		webhookCertManagerCertificate     string
 		provisionerTimeout                time.Duration
+		pkiRenewBefore                    time.Duration
+		pkiCAOverlap                      time.Duration
+		auditShipperImage                 string
+		auditSinkURL                      string
 
 		featureGates map[string]bool
 	)
@@ -65,7 +73,8 @@ func main() {
 	flag.StringVar(&controlPlaneProvisionerDeprecated, "master-prov", "",
 		"DEPRECATED. Use --provisioner flag instead.")
 	flag.StringVar(&controlPlaneProvisioner, "provisioner", "native",
-		"The underlying platform that will provision control plane for virtualcluster.")
+		fmt.Sprintf("The underlying platform that will provision control plane for virtualcluster, capi or one of the registered provisioners: %s.",
+			strings.Join(provisioner.Registered(), ", ")))
 	flag.BoolVar(&leaderElection, "leader-election", true, "If enable leaderelection for vc-manager")
 	flag.StringVar(&leaderElectionCmName, "le-cm-name", "vc-manager-leaderelection-lock",
 		"The name of the configmap that will be used as the resourcelook for leaderelection")
@@ -75,7 +84,17 @@ func main() {
 	flag.BoolVar(&versionOpt, "version", false, "Print the version information")
 	flag.BoolVar(&disableStacktrace, "disable-stacktrace", false, "If set, the automatic stacktrace is disabled")
 	flag.BoolVar(&enableWebhook, "enable-webhook", false, "If set, the virtualcluster webhook is enabled")
+	flag.StringVar(&webhookCertManagerCertificate, "webhook-cert-manager-certificate", "",
+		"The namespace/name of the cert-manager Certificate whose secret is mounted as the serving certificate of the webhook. If not set, a self-signed certificate is generated and renewed before expiry.")
 	flag.DurationVar(&provisionerTimeout, "provisioner-timeout", 10*time.Minute, "The timeout for provision control-plane statefulsets")
+	flag.DurationVar(&pkiRenewBefore, "pki-renew-before", controllers.DefaultPKIRenewBefore,
+		"How long before expiry the certificates of the tenant control planes are renewed when the PKIRotation feature is enabled.")
+	flag.DurationVar(&pkiCAOverlap, "pki-ca-overlap", controllers.DefaultPKICAOverlap,
+		"How long the previous root CA of a tenant control plane is trusted after the root CA is rotated.")
+	flag.StringVar(&auditShipperImage, "audit-shipper-image", "",
+		"The image of the sidecar shipping the audit log of the tenant apiservers whose VirtualCluster sets spec.audit.log.ship.")
+	flag.StringVar(&auditSinkURL, "audit-sink-url", "",
+		"The URL of the super cluster audit sink the audit events of the tenant apiservers are posted to. If not set, the audit log is not shipped.")
 
 	flag.Var(cliflag.NewMapStringBool(&featureGates), "feature-gates", "A set of key=value pairs that describe featuregate gates for various features.")
 
@@ -160,6 +179,12 @@ func main() {
 		ProvisionerName:         controlPlaneProvisioner,
 		ProvisionerTimeout:      provisionerTimeout,
 		MaxConcurrentReconciles: maxConcurrentReconciles,
+		PKIRenewBefore:          pkiRenewBefore,
+		PKICAOverlap:            pkiCAOverlap,
+		AuditShipper: provisioner.AuditShipperConfig{
+			Image:   auditShipperImage,
+			SinkURL: auditSinkURL,
+		},
 	}).SetupWithManager(mgr); err != nil {
 		log.Error(err, "unable to register controllers to the manager")
 		os.Exit(1)
@@ -167,7 +192,10 @@ func main() {
 
 	if enableWebhook {
 		log.Info("setting up webhooks")
-		if err := webhook.AddToManager(mgr, mgrOpt.CertDir); err != nil {
+		if err := webhook.AddToManager(mgr, webhook.Options{
+			CertDir:                mgrOpt.CertDir,
+			CertManagerCertificate: webhookCertManagerCertificate,
+		}); err != nil {
 			log.Error(err, "unable to register webhooks to the manager")
 			os.Exit(1)
 		}
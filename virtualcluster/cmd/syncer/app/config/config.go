@@ -25,6 +25,7 @@ import (
 
 	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
 	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer"
 	syncerconfig "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
 )
 
@@ -44,6 +45,9 @@ type Config struct {
 	SuperClusterClient          clientset.Interface
 	SuperClusterInformerFactory informers.SharedInformerFactory
 
+	// the super clusters managed by the syncer, if it manages more than the super cluster above.
+	SuperClusters []syncer.SuperCluster
+
 	// the client only used for leader election
 	LeaderElectionClient clientset.Interface
 
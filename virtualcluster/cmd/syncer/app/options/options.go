@@ -46,6 +46,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis"
 	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
 	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer"
 	syncerconfig "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
@@ -95,6 +96,11 @@ func NewResourceSyncerOptions() (*ResourceSyncerOptions, error) {
 			VNAgentPort:                int32(10550),
 			VNAgentNamespacedName:      "vc-manager/vn-agent",
 			VNAgentLabelSelector:       "app=vn-agent",
+			AggregatedVNodeCount:       1,
+			VNodeCapacityPolicy:        "Node",
+			VNodeConditionTypes:        []string{string(corev1.NodeReady), string(corev1.NodeMemoryPressure), string(corev1.NodeDiskPressure), string(corev1.NodePIDPressure), string(corev1.NodeNetworkUnavailable)},
+			VNodeHeartbeatPeriod:       30 * time.Second,
+			PatrolBackstopPeriod:       30 * time.Minute,
 			FeatureGates: map[string]bool{
 				featuregate.SuperClusterPooling:        false,
 				featuregate.SuperClusterServiceNetwork: false,
@@ -135,6 +141,15 @@ func (o *ResourceSyncerOptions) Flags() cliflag.NamedFlagSets {
 	fs.StringVar(&o.ComponentConfig.VNAgentNamespacedName, "vn-agent-namespace-name", "vc-manager/vn-agent", "Namespace/Name of the vn-agent running in cluster, used for VNodeProviderService")
 	fs.Var(cliflag.NewMapStringString(&o.DNSOptions), "dns-options", "DNSOptions is the default DNS options attached to each pod")
 	fs.StringVar(&o.ComponentConfig.VNAgentLabelSelector, "vn-agent-label-selector", "app=vn-agent", "Label key=value of the vn-agent running in cluster, used for VNodeProviderPodIP")
+	fs.IntVar(&o.ComponentConfig.AggregatedVNodeCount, "aggregated-vnode-count", o.ComponentConfig.AggregatedVNodeCount, "Number of vNodes presenting the super cluster nodes to each tenant, used for VNodeProviderAggregated. The tenant pods bound to a vNode are deleted if it changes.")
+	fs.StringVar(&o.ComponentConfig.VNodeCapacityPolicy, "vnode-capacity-policy", o.ComponentConfig.VNodeCapacityPolicy, "How the vNode capacity and allocatable are computed for each tenant. Node presents the physical node, ResourceQuota caps it with the tenant resource quotas in the super cluster, Fraction scales it with the tenancy.x-k8s.io/vnode-capacity-fraction annotation on the VirtualCluster, Free subtracts the requests of the other tenants' pods on the node.")
+	fs.StringSliceVar(&o.ComponentConfig.VNodeConditionTypes, "vnode-condition-types", o.ComponentConfig.VNodeConditionTypes, "The physical node condition types mirrored on the vNodes. Ready must be included for the tenant pods to be marked when their node fails.")
+	fs.DurationVar(&o.ComponentConfig.VNodeHeartbeatPeriod, "vnode-heartbeat-period", o.ComponentConfig.VNodeHeartbeatPeriod, "Minimum period between two vNode updates that only refresh the condition heartbeats.")
+	fs.BoolVar(&o.ComponentConfig.PatrolDryRun, "patrol-dry-run", o.ComponentConfig.PatrolDryRun, "PatrolDryRun makes the periodic checkers report the mismatched objects without remediating them.")
+	fs.StringVar(&o.ComponentConfig.PatrolReportNamespace, "patrol-report-namespace", o.ComponentConfig.PatrolReportNamespace, "Super cluster namespace where the report of each checker run is stored as a configmap. Reports are not stored if empty.")
+	fs.Var(cliflag.NewMapStringString(&o.ComponentConfig.SuperClusterKubeconfigs), "super-cluster-kubeconfigs", "A set of id=kubeconfig pairs of the super clusters managed by the syncer. The tenant namespaces are synced to the super clusters by their placement annotations. If empty, the syncer manages the super cluster of super-master-kubeconfig only.")
+	fs.StringVar(&o.ComponentConfig.DefaultSuperClusterID, "default-super-cluster", o.ComponentConfig.DefaultSuperClusterID, "Id of the super cluster the tenant namespaces without placement are synced to, used with super-cluster-kubeconfigs.")
+	fs.DurationVar(&o.ComponentConfig.PatrolBackstopPeriod, "patrol-backstop-period", o.ComponentConfig.PatrolBackstopPeriod, "Period of the full scans done by the periodic checkers when the IncrementalPatrol feature is enabled.")
 
 	serverFlags := fss.FlagSet("metricsServer")
 	serverFlags.StringVar(&o.Address, "address", o.Address, "The server address.")
@@ -255,6 +270,10 @@ func (o *ResourceSyncerOptions) Config() (*syncerappconfig.Config, error) {
 	c.MetaClusterClient = metaClusterClient
 	c.SuperClusterClient = superClusterClient
 	c.SuperClusterInformerFactory = informers.NewSharedInformerFactory(superClusterClient, 0)
+	c.SuperClusters, err = getSuperClusters(c.ComponentConfig.ClientConnection, c.ComponentConfig.SuperClusterKubeconfigs, o.ComponentConfig.Timeout)
+	if err != nil {
+		return nil, err
+	}
 	c.Broadcaster = eventBroadcaster
 	c.Recorder = recorder
 	c.LeaderElectionClient = leaderElectionClient
@@ -268,6 +287,29 @@ func (o *ResourceSyncerOptions) Config() (*syncerappconfig.Config, error) {
 	return c, nil
 }
 
+// getSuperClusters creates the clients of each super cluster managed by the syncer.
+func getSuperClusters(config componentbaseconfig.ClientConnectionConfiguration, kubeconfigs map[string]string, timeout string) ([]syncer.SuperCluster, error) {
+	var superClusters []syncer.SuperCluster
+	for id, kubeconfig := range kubeconfigs {
+		config.Kubeconfig = kubeconfig
+		restConfig, err := getClientConfig(config, "", timeout, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kubeconfig of super cluster %s: %v", id, err)
+		}
+		client, err := clientset.NewForConfig(restclient.AddUserAgent(restConfig, constants.ResourceSyncerUserAgent))
+		if err != nil {
+			return nil, err
+		}
+		superClusters = append(superClusters, syncer.SuperCluster{
+			ID:              id,
+			RestConfig:      restConfig,
+			Client:          client,
+			InformerFactory: informers.NewSharedInformerFactory(client, 0),
+		})
+	}
+	return superClusters, nil
+}
+
 // makeLeaderElectionConfig builds a leader election configuration. It will
 // create a new resource lock associated with the configuration.
 func makeLeaderElectionConfig(config syncerconfig.SyncerLeaderElectionConfiguration, client clientset.Interface, recorder record.EventRecorder, syncername string) (*leaderelection.LeaderElectionConfig, error) {
@@ -94,13 +94,26 @@ custom resources on behalf of the tenant users in super cluster.`,
 }
 
 func Run(cc *syncerconfig.CompletedConfig, stopCh <-chan struct{}) error {
-	ss, err := syncer.New(&cc.ComponentConfig,
-		cc.VirtualClusterClient,
-		cc.VirtualClusterInformer,
-		cc.MetaClusterClient,
-		cc.SuperClusterClient,
-		cc.SuperClusterInformerFactory,
-		cc.Recorder)
+	var (
+		ss  syncer.Bootstrap
+		err error
+	)
+	if len(cc.SuperClusters) > 0 {
+		ss, err = syncer.NewMultiSyncer(&cc.ComponentConfig,
+			cc.VirtualClusterClient,
+			cc.VirtualClusterInformer,
+			cc.MetaClusterClient,
+			cc.SuperClusters,
+			cc.Recorder)
+	} else {
+		ss, err = syncer.New(&cc.ComponentConfig,
+			cc.VirtualClusterClient,
+			cc.VirtualClusterInformer,
+			cc.MetaClusterClient,
+			cc.SuperClusterClient,
+			cc.SuperClusterInformerFactory,
+			cc.Recorder)
+	}
 
 	if err != nil {
 		return fmt.Errorf("new syncer: %v", err)
@@ -115,8 +128,15 @@ func Run(cc *syncerconfig.CompletedConfig, stopCh <-chan struct{}) error {
 	go cc.VirtualClusterInformer.Informer().Run(stopCh)
 	cc.SuperClusterInformerFactory.Start(stopCh)
 
+	for _, sc := range cc.SuperClusters {
+		sc.InformerFactory.Start(stopCh)
+	}
+
 	// Wait for all caches to sync before resource sync.
 	cc.SuperClusterInformerFactory.WaitForCacheSync(stopCh)
+	for _, sc := range cc.SuperClusters {
+		sc.InformerFactory.WaitForCacheSync(stopCh)
+	}
 
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
@@ -18,10 +18,12 @@ package options
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"os"
 
 	"github.com/pkg/errors"
+	certutil "k8s.io/client-go/util/cert"
 	cliflag "k8s.io/component-base/cli/flag"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
@@ -60,6 +62,21 @@ type ServerOption struct {
 	// Kubeconfig is the supercluster Kubeconfig to connect to
 	Kubeconfig string
 
+	// VerifyTenantClientCerts verifies the client certificates against the root CA of
+	// the tenant named by their CommonName, the root CAs are read from the root namespaces
+	// of the super cluster.
+	VerifyTenantClientCerts bool
+
+	// DebugTenants are the tenants allowed to access the kubelet debug handlers.
+	DebugTenants []string
+
+	// AuditLogPath is the file the audit records of the interactive sessions are appended to.
+	AuditLogPath string
+	// AuditWebhookURL is the webhook the audit records of the interactive sessions are posted to.
+	AuditWebhookURL string
+	// AuditSessionRecordingDir is the directory the TTY sessions are recorded to.
+	AuditSessionRecordingDir string
+
 	// FeatureGates enabled by the user.
 	FeatureGates map[string]bool
 }
@@ -73,6 +90,10 @@ type KubeletClientConfig struct {
 	CertFile string
 	// Server requires TLS client certificate authentication
 	KeyFile string
+	// CAFile is the CA to verify the kubelet serving certificate, it is not verified if empty.
+	CAFile string
+	// ServerName is used to verify the hostname of the kubelet serving certificate.
+	ServerName string
 }
 
 func NewVnAgentOptions() (*Options, error) {
@@ -98,11 +119,21 @@ func (o *Options) Flags() cliflag.NamedFlagSets {
 	serverFS.StringVar(&o.MetricsAddr, "metrics-addr", ":9100", "Bind address for the metrics server.")
 	serverFS.BoolVar(&o.EnableMetrics, "enable-metrics", true, "Enable metrics server.")
 	serverFS.Var(cliflag.NewMapStringBool(&o.ServerOption.FeatureGates), "feature-gates", "A set of key=value pairs that describe featuregate gates for various features.")
+	serverFS.BoolVar(&o.VerifyTenantClientCerts, "verify-tenant-client-certs", false, "Verify each client certificate against the root CA of the tenant named by its CommonName. The root CA secrets of the root namespaces are watched in the super cluster, it is exclusive with --client-ca-file.")
+
+	serverFS.StringSliceVar(&o.DebugTenants, "debug-tenants", o.DebugTenants, "Tenants allowed to access the kubelet /debug/pprof handlers when the KubeletDebugHandlers feature is enabled.")
+
+	auditFS := fss.FlagSet("audit")
+	auditFS.StringVar(&o.AuditLogPath, "audit-log-path", o.AuditLogPath, "If set, the exec, attach and port-forward sessions are audited to this file as JSON lines.")
+	auditFS.StringVar(&o.AuditWebhookURL, "audit-webhook-url", o.AuditWebhookURL, "If set, the exec, attach and port-forward sessions are audited to this webhook.")
+	auditFS.StringVar(&o.AuditSessionRecordingDir, "audit-session-recording-dir", o.AuditSessionRecordingDir, "If set, the audited TTY sessions are recorded to this directory.")
 
 	kubeletFS := fss.FlagSet("kubelet")
 	kubeletFS.StringVar(&o.KubeletOption.CertFile, "kubelet-client-certificate", o.KubeletOption.CertFile, "Path to a client cert file for TLS")
 	kubeletFS.StringVar(&o.KubeletOption.KeyFile, "kubelet-client-key", o.KubeletOption.KeyFile, "Path to a client key file for TLS")
 	kubeletFS.UintVar(&o.KubeletOption.Port, "kubelet-port", 10250, "Kubelet security port")
+	kubeletFS.StringVar(&o.KubeletOption.CAFile, "kubelet-certificate-authority", o.KubeletOption.CAFile, "Path to a cert file for the certificate authority of the kubelet serving certificate, the certificate is not verified if empty")
+	kubeletFS.StringVar(&o.KubeletOption.ServerName, "kubelet-tls-server-name", o.KubeletOption.ServerName, "Server name used to verify the kubelet serving certificate, defaults to the kubelet host")
 
 	return fss
 }
@@ -117,6 +148,12 @@ func fileNotExistOrEmpty(fn string) bool {
 
 // Config is the config to create a vn-agent server handler.
 func (o *Options) Config() (*config.Config, *ServerOption, error) {
+	var err error
+	featuregate.DefaultFeatureGate, err = featuregate.NewFeatureGate(o.ServerOption.FeatureGates)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// vc-kubelet-client may be a place holder that contains empty certificate and key data
 	if fileNotExistOrEmpty(o.KubeletOption.CertFile) || fileNotExistOrEmpty(o.KubeletOption.KeyFile) {
 		return &config.Config{KubeletClientCert: nil}, &o.ServerOption, nil
@@ -126,13 +163,18 @@ func (o *Options) Config() (*config.Config, *ServerOption, error) {
 		return nil, nil, errors.Wrapf(err, "failed to load kubelet tls config")
 	}
 
-	featuregate.DefaultFeatureGate, err = featuregate.NewFeatureGate(o.ServerOption.FeatureGates)
-	if err != nil {
-		return nil, nil, err
+	var kubeletCAs *x509.CertPool
+	if o.KubeletOption.CAFile != "" {
+		kubeletCAs, err = certutil.NewPool(o.KubeletOption.CAFile)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to load kubelet certificate authority")
+		}
 	}
 
 	return &config.Config{
 		KubeletClientCert: &kubeletClientCertPair,
 		KubeletServerHost: fmt.Sprintf("https://127.0.0.1:%v", o.KubeletOption.Port),
+		KubeletCAs:        kubeletCAs,
+		KubeletServerName: o.KubeletOption.ServerName,
 	}, &o.ServerOption, nil
 }
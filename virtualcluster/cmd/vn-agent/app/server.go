@@ -28,6 +28,10 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/informers"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
 	certutil "k8s.io/client-go/util/cert"
 	cliflag "k8s.io/component-base/cli/flag"
 	"k8s.io/component-base/cli/globalflag"
@@ -93,6 +97,20 @@ func NewVnAgentCommand(stopChan <-chan struct{}) *cobra.Command {
 
 // Run start the vn-agent server.
 func Run(c *config.Config, serverOption *options.ServerOption, stopCh <-chan struct{}) error {
+	if c.KubeletClientCert != nil {
+		// the kubelet responses are filtered and renamed by the super cluster namespaces.
+		superClient, err := newSuperClient(serverOption.Kubeconfig)
+		if err != nil {
+			return err
+		}
+		nsInformer := informers.NewSharedInformerFactory(superClient, 10*time.Minute).Core().V1().Namespaces()
+		go nsInformer.Informer().Run(stopCh)
+		if !cache.WaitForCacheSync(stopCh, nsInformer.Informer().HasSynced) {
+			return errors.New("failed to wait for namespaces to sync")
+		}
+		c.NamespaceLister = nsInformer.Lister()
+	}
+
 	handler, err := server.NewServer(c, serverOption)
 	if err != nil {
 		return errors.Wrapf(err, "create server")
@@ -108,7 +126,22 @@ func Run(c *config.Config, serverOption *options.ServerOption, stopCh <-chan str
 		},
 	}
 
-	if serverOption.ClientCAFile != "" {
+	if serverOption.VerifyTenantClientCerts {
+		if serverOption.ClientCAFile != "" {
+			return errors.New("--client-ca-file and --verify-tenant-client-certs are exclusive")
+		}
+		superClient, err := newSuperClient(serverOption.Kubeconfig)
+		if err != nil {
+			return err
+		}
+		tenantCAs := certificate.NewTenantCAStore(superClient)
+		if err := tenantCAs.Run(stopCh); err != nil {
+			return err
+		}
+		// the certificates are verified by the tenant root CAs instead of ClientCAs.
+		s.TLSConfig.ClientAuth = tls.RequireAnyClientCert
+		s.TLSConfig.VerifyPeerCertificate = tenantCAs.VerifyPeerCertificate
+	} else if serverOption.ClientCAFile != "" {
 		clientCAs, err := certutil.CertsFromFile(serverOption.ClientCAFile)
 		if err != nil {
 			return errors.Wrapf(err, "unable to load client CA file")
@@ -167,3 +200,17 @@ func Run(c *config.Config, serverOption *options.ServerOption, stopCh <-chan str
 
 	return nil
 }
+
+// newSuperClient creates the super cluster client with the kubeconfig, or the in-cluster
+// config if it is empty.
+func newSuperClient(kubeconfig string) (clientset.Interface, error) {
+	restConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to build super cluster client config")
+	}
+	superClient, err := clientset.NewForConfig(restConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create super cluster client")
+	}
+	return superClient, nil
+}
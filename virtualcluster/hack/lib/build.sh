@@ -20,6 +20,7 @@ readonly VC_ALL_TARGETS=(
   cmd/manager
   cmd/syncer
   cmd/vn-agent
+  cmd/audit-shipper
   cmd/kubectl-vc
 )
 readonly VC_ALL_BINARIES=("${VC_ALL_TARGETS[@]##*/}")
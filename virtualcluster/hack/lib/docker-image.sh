@@ -34,6 +34,7 @@ get_docker_wrapped_binaries() {
       manager,"${VC_BASE_IMAGE_REGISTRY}/debian-base-${arch}:${debian_base_version}"
       syncer,"${VC_BASE_IMAGE_REGISTRY}/debian-base-${arch}:${debian_base_version}"
       vn-agent,"${VC_BASE_IMAGE_REGISTRY}/debian-base-${arch}:${debian_base_version}"
+      audit-shipper,"${VC_BASE_IMAGE_REGISTRY}/debian-base-${arch}:${debian_base_version}"
     )
   fi
 
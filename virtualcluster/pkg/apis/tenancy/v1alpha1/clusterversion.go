@@ -16,7 +16,16 @@ limitations under the License.
 
 package v1alpha1
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
 
 // GetEtcdDomain returns the dns of etcd service, note that, though the
 // complete etcd svc dns is {etcdSvcName}.{namespace}.svc.{clusterdomain},
@@ -44,3 +53,87 @@ func (cv *ClusterVersion) GetEtcdServers() (etcdServers []string) {
 func (cv *ClusterVersion) GetAPIServerDomain(namespace string) string {
 	return cv.Spec.APIServer.Service.Name + "." + namespace
 }
+
+// SpecHash returns the hash of the spec, it identifies the spec applied to the
+// control plane of a VirtualCluster
+func (cv *ClusterVersion) SpecHash() string {
+	hasher := fnv.New64a()
+	// the spec only holds json serializable fields
+	data, _ := json.Marshal(cv.Spec)
+	_, _ = hasher.Write(data)
+	return fmt.Sprintf("%x", hasher.Sum64())
+}
+
+// AppliedVersion returns the generation of the spec, the VirtualClusters whose control
+// plane runs the spec are labeled with it. The generation, unlike the resourceVersion,
+// is not changed by the status updates.
+func (cv *ClusterVersion) AppliedVersion() string {
+	return strconv.FormatInt(cv.Generation, 10)
+}
+
+// ValidateValue checks the value is of the type of the parameter
+func (p *ClusterVersionParameter) ValidateValue(value string) error {
+	var err error
+	switch p.Type {
+	case "", ParameterTypeString:
+	case ParameterTypeInteger:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case ParameterTypeBoolean:
+		_, err = strconv.ParseBool(value)
+	case ParameterTypeQuantity:
+		_, err = resource.ParseQuantity(value)
+	default:
+		return fmt.Errorf("unknown type %q of parameter %s", p.Type, p.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("parameter %s must be of type %s, got %q", p.Name, p.Type, value)
+	}
+	return nil
+}
+
+// ResolveParameters returns the value of every parameter of the ClusterVersion given the
+// values set by a VirtualCluster, the defaults are used for the parameters not set
+func (cv *ClusterVersion) ResolveParameters(values map[string]string) (map[string]string, error) {
+	var errs []error
+	resolved := make(map[string]string, len(cv.Spec.Parameters))
+	for i := range cv.Spec.Parameters {
+		param := &cv.Spec.Parameters[i]
+		value, set := values[param.Name]
+		if !set {
+			if param.Default == nil {
+				errs = append(errs, fmt.Errorf("parameter %s is required", param.Name))
+				continue
+			}
+			value = *param.Default
+		}
+		if err := param.ValidateValue(value); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		resolved[param.Name] = value
+	}
+
+	var unknown []string
+	for name := range values {
+		if !cv.declaresParameter(name) {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs = append(errs, fmt.Errorf("parameter %s is not declared by ClusterVersion %s", name, cv.Name))
+	}
+	if len(errs) != 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+	return resolved, nil
+}
+
+func (cv *ClusterVersion) declaresParameter(name string) bool {
+	for _, param := range cv.Spec.Parameters {
+		if param.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -32,6 +32,65 @@ type ClusterVersionSpec struct {
 
 	// ETCD configuration of the virtual cluster
 	ETCD *StatefulSetSvcBundle `json:"etcd,omitempty"`
+
+	// Parameters of the bundles that the VirtualClusters may set, they are
+	// referred as {{ .name }} in the bundles and their patches
+	// +optional
+	Parameters []ClusterVersionParameter `json:"parameters,omitempty"`
+}
+
+// ClusterVersionParameterType is the type of the value of a parameter
+type ClusterVersionParameterType string
+
+const (
+	// ParameterTypeString is any string, it is the default type
+	ParameterTypeString ClusterVersionParameterType = "string"
+	// ParameterTypeInteger is a decimal integer, e.g. "3"
+	ParameterTypeInteger ClusterVersionParameterType = "integer"
+	// ParameterTypeBoolean is "true" or "false"
+	ParameterTypeBoolean ClusterVersionParameterType = "boolean"
+	// ParameterTypeQuantity is a resource quantity, e.g. "500m" or "2Gi"
+	ParameterTypeQuantity ClusterVersionParameterType = "quantity"
+)
+
+// ClusterVersionParameter is a parameter of the bundles set per VirtualCluster
+type ClusterVersionParameter struct {
+	// Name of the parameter, it must be a valid Go identifier
+	Name string `json:"name"`
+
+	// Type of the parameter value, string if not set
+	// +optional
+	Type ClusterVersionParameterType `json:"type,omitempty"`
+
+	// Default value of the parameter, the VirtualClusters must set the
+	// parameter if there is no default
+	// +optional
+	Default *string `json:"default,omitempty"`
+
+	// Description of the parameter
+	// +optional
+	Description string `json:"description,omitempty"`
+}
+
+// BundlePatchTarget is the object of a bundle a patch applies to
+type BundlePatchTarget string
+
+const (
+	// BundlePatchStatefulSet targets the StatefulSet of the bundle
+	BundlePatchStatefulSet BundlePatchTarget = "statefulset"
+	// BundlePatchService targets the Service of the bundle
+	BundlePatchService BundlePatchTarget = "service"
+)
+
+// BundlePatch is a JSON patch (RFC 6902) of the StatefulSet or the Service of
+// a bundle, it is rendered as a Go template with the parameters before being applied
+type BundlePatch struct {
+	// Target of the patch, statefulset or service
+	Target BundlePatchTarget `json:"target"`
+
+	// Patch is the template of the JSON patch, e.g.
+	// [{"op": "replace", "path": "/spec/replicas", "value": {{ .replicas }}}]
+	Patch string `json:"patch"`
 }
 
 // StatefulSetSvcBundle contains a StatefulSet and the Service that exposed
@@ -46,10 +105,50 @@ type StatefulSetSvcBundle struct {
 	// Service that exposes the StatefulSet
 	// +kubebuilder:validation:XEmbeddedResource
 	Service *corev1.Service `json:"service,omitempty"`
+
+	// Patches applied to the StatefulSet and the Service in order, after
+	// their string fields are rendered with the parameters
+	// +optional
+	Patches []BundlePatch `json:"patches,omitempty"`
 }
 
 // ClusterVersionStatus defines the observed state of ClusterVersion
 type ClusterVersionStatus struct {
+	// SpecHash is the hash of the current spec, the VirtualClusters running
+	// it are labeled with the hash
+	// +optional
+	SpecHash string `json:"specHash,omitempty"`
+
+	// VirtualClusters are the VirtualClusters referencing the ClusterVersion
+	// +optional
+	VirtualClusters []ClusterVersionReference `json:"virtualClusters,omitempty"`
+
+	// UpToDateVirtualClusters is the number of VirtualClusters running the current spec
+	// +optional
+	UpToDateVirtualClusters int32 `json:"upToDateVirtualClusters,omitempty"`
+
+	// Deprecated is true if the ClusterVersion is annotated as deprecated
+	// +optional
+	Deprecated bool `json:"deprecated,omitempty"`
+
+	// DeprecationMessage is the value of the deprecation annotation, e.g. the
+	// ClusterVersion to use instead
+	// +optional
+	DeprecationMessage string `json:"deprecationMessage,omitempty"`
+
+	// ValidationErrors are the problems found in the bundles, the ClusterVersions
+	// created before the validating webhook may have some
+	// +optional
+	ValidationErrors []string `json:"validationErrors,omitempty"`
+}
+
+// ClusterVersionReference refers to a VirtualCluster using the ClusterVersion
+type ClusterVersionReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	// UpToDate is true if the VirtualCluster runs the current spec
+	UpToDate bool `json:"upToDate"`
 }
 
 // +kubebuilder:object:root=true
@@ -57,6 +156,7 @@ type ClusterVersionStatus struct {
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/client.Object
 // +genclient:nonNamespaced
 // +kubebuilder:resource:scope=Cluster,shortName=cv
+// +kubebuilder:subresource:status
 
 // ClusterVersion is the Schema for the clusterversions API
 // +k8s:openapi-gen=true
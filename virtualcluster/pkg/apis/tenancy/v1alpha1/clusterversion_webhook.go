@@ -0,0 +1,206 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var cvlog = logf.Log.WithName("clusterversion-webhook")
+
+// parameterNameRegexp matches the Go identifiers, so that the parameters can be
+// referred as {{ .name }} in the templates
+var parameterNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (cv *ClusterVersion) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	cvlog.Info("setup clusterversion validation webhook")
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(cv).
+		Complete()
+}
+
+var _ webhook.Defaulter = &ClusterVersion{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (cv *ClusterVersion) Default() {
+	cvlog.Info("default", "cv-name", cv.Name)
+	for i := range cv.Spec.Parameters {
+		if cv.Spec.Parameters[i].Type == "" {
+			cv.Spec.Parameters[i].Type = ParameterTypeString
+		}
+	}
+}
+
+var _ webhook.Validator = &ClusterVersion{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (cv *ClusterVersion) ValidateCreate() error {
+	cvlog.Info("validate create", "cv-name", cv.Name)
+	return cv.validateClusterVersion()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (cv *ClusterVersion) ValidateUpdate(old runtime.Object) error {
+	cvlog.Info("validate update", "cv-name", cv.Name)
+	return cv.validateClusterVersion()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (cv *ClusterVersion) ValidateDelete() error {
+	cvlog.Info("validate delete", "cv-name", cv.Name)
+	// do nothing for delete request
+	return nil
+}
+
+func (cv *ClusterVersion) validateClusterVersion() error {
+	allErrs := cv.ValidateBundles()
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(
+		schema.GroupKind{Group: "tenancy.x-k8s.io", Kind: "ClusterVersion"},
+		cv.Name, allErrs)
+}
+
+// ValidateBundles checks the bundles hold what the native provisioner relies on to
+// deploy the control plane components
+func (cv *ClusterVersion) ValidateBundles() field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	etcdPath := specPath.Child("etcd")
+	allErrs = append(allErrs, validateBundle(cv.Spec.ETCD, "etcd", etcdPath, true)...)
+	if cv.Spec.ETCD != nil && cv.Spec.ETCD.StatefulSet != nil && cv.Spec.ETCD.StatefulSet.Spec.Replicas == nil {
+		// the etcd peers are listed by the number of replicas
+		allErrs = append(allErrs, field.Required(etcdPath.Child("statefulset", "spec", "replicas"), "the number of etcd members must be set"))
+	}
+	apiServerPath := specPath.Child("apiServer")
+	allErrs = append(allErrs, validateBundle(cv.Spec.APIServer, "apiserver", apiServerPath, true)...)
+	if cv.Spec.APIServer != nil && cv.Spec.APIServer.Service != nil && len(cv.Spec.APIServer.Service.Spec.Ports) == 0 {
+		// the kubeconfigs of the tenant are pointed to the apiserver service
+		allErrs = append(allErrs, field.Required(apiServerPath.Child("service", "spec", "ports"), "the apiserver service must expose a port"))
+	}
+	if cv.Spec.ControllerManager != nil {
+		allErrs = append(allErrs, validateBundle(cv.Spec.ControllerManager, "controller-manager", specPath.Child("controllerManager"), false)...)
+	}
+	allErrs = append(allErrs, validateParameters(cv.Spec.Parameters, specPath.Child("parameters"))...)
+	return allErrs
+}
+
+// validateParameters checks the parameters are uniquely named and their defaults are of their type
+func validateParameters(params []ClusterVersionParameter, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	names := make(map[string]bool, len(params))
+	for i := range params {
+		param := &params[i]
+		paramPath := fldPath.Index(i)
+		if !parameterNameRegexp.MatchString(param.Name) {
+			allErrs = append(allErrs, field.Invalid(paramPath.Child("name"), param.Name, "must be a valid Go identifier"))
+		} else if names[param.Name] {
+			allErrs = append(allErrs, field.Duplicate(paramPath.Child("name"), param.Name))
+		}
+		names[param.Name] = true
+
+		switch param.Type {
+		case "", ParameterTypeString, ParameterTypeInteger, ParameterTypeBoolean, ParameterTypeQuantity:
+			if param.Default != nil {
+				if err := param.ValidateValue(*param.Default); err != nil {
+					allErrs = append(allErrs, field.Invalid(paramPath.Child("default"), *param.Default, err.Error()))
+				}
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(paramPath.Child("type"), param.Type,
+				[]string{string(ParameterTypeString), string(ParameterTypeInteger), string(ParameterTypeBoolean), string(ParameterTypeQuantity)}))
+		}
+	}
+	return allErrs
+}
+
+// validateBundle validates the bundle of the given component. The provisioner deploys
+// a bundle by its name and waits for the StatefulSet of the same name to be ready.
+func validateBundle(bdl *StatefulSetSvcBundle, component string, fldPath *field.Path, serviceRequired bool) field.ErrorList {
+	var allErrs field.ErrorList
+	if bdl == nil {
+		return append(allErrs, field.Required(fldPath, fmt.Sprintf("the %s bundle must be defined", component)))
+	}
+	if bdl.Name != component {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("metadata", "name"), bdl.Name, fmt.Sprintf("must be %q", component)))
+	}
+
+	stsPath := fldPath.Child("statefulset")
+	if bdl.StatefulSet == nil {
+		allErrs = append(allErrs, field.Required(stsPath, "the statefulset must be defined"))
+	} else {
+		if bdl.StatefulSet.Name != bdl.Name {
+			allErrs = append(allErrs, field.Invalid(stsPath.Child("metadata", "name"), bdl.StatefulSet.Name, fmt.Sprintf("must be the bundle name %q", bdl.Name)))
+		}
+		allErrs = append(allErrs, validatePodSpec(&bdl.StatefulSet.Spec.Template.Spec, stsPath.Child("spec", "template", "spec"))...)
+	}
+
+	if bdl.Service == nil && serviceRequired {
+		allErrs = append(allErrs, field.Required(fldPath.Child("service"), fmt.Sprintf("the %s service must be defined", component)))
+	}
+
+	for i, patch := range bdl.Patches {
+		patchPath := fldPath.Child("patches").Index(i)
+		switch patch.Target {
+		case BundlePatchStatefulSet, BundlePatchService:
+		default:
+			allErrs = append(allErrs, field.NotSupported(patchPath.Child("target"), patch.Target,
+				[]string{string(BundlePatchStatefulSet), string(BundlePatchService)}))
+		}
+		if _, err := template.New("patch").Parse(patch.Patch); err != nil {
+			allErrs = append(allErrs, field.Invalid(patchPath.Child("patch"), patch.Patch, err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// validatePodSpec checks the pod has containers and mounts the volumes it defines
+func validatePodSpec(spec *corev1.PodSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if len(spec.Containers) == 0 {
+		return append(allErrs, field.Required(fldPath.Child("containers"), "the component container must be defined"))
+	}
+	volumes := make(map[string]bool, len(spec.Volumes))
+	for _, volume := range spec.Volumes {
+		volumes[volume.Name] = true
+	}
+	for i, container := range spec.Containers {
+		containerPath := fldPath.Child("containers").Index(i)
+		if container.Image == "" {
+			allErrs = append(allErrs, field.Required(containerPath.Child("image"), ""))
+		}
+		for j, mount := range container.VolumeMounts {
+			if !volumes[mount.Name] {
+				allErrs = append(allErrs, field.NotFound(containerPath.Child("volumeMounts").Index(j).Child("name"), mount.Name))
+			}
+		}
+	}
+	return allErrs
+}
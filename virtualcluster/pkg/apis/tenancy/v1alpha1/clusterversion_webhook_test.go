@@ -0,0 +1,189 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func loadSampleClusterVersion(t *testing.T, name string) *ClusterVersion {
+	data, err := ioutil.ReadFile("../../../../config/sampleswithspec/" + name)
+	if err != nil {
+		t.Fatalf("fail to read sample %s: %v", name, err)
+	}
+	cv := &ClusterVersion{}
+	if err := yaml.Unmarshal(data, cv); err != nil {
+		t.Fatalf("fail to decode sample %s: %v", name, err)
+	}
+	return cv
+}
+
+func TestValidateBundlesSamples(t *testing.T) {
+	for _, name := range []string{"clusterversion_v1_nodeport.yaml", "clusterversion_v1_loadbalancer.yaml"} {
+		cv := loadSampleClusterVersion(t, name)
+		if errs := cv.ValidateBundles(); len(errs) != 0 {
+			t.Errorf("expected sample %s to be valid, got %v", name, errs)
+		}
+	}
+}
+
+func TestValidateBundles(t *testing.T) {
+	tests := map[string]struct {
+		mutate func(cv *ClusterVersion)
+		errs   []string
+	}{
+		"missing etcd": {
+			mutate: func(cv *ClusterVersion) { cv.Spec.ETCD = nil },
+			errs:   []string{"spec.etcd: Required value"},
+		},
+		"renamed apiserver": {
+			mutate: func(cv *ClusterVersion) { cv.Spec.APIServer.Name = "kube-apiserver" },
+			errs:   []string{"spec.apiServer.metadata.name: Invalid value", "spec.apiServer.statefulset.metadata.name: Invalid value"},
+		},
+		"etcd without replicas": {
+			mutate: func(cv *ClusterVersion) { cv.Spec.ETCD.StatefulSet.Spec.Replicas = nil },
+			errs:   []string{"spec.etcd.statefulset.spec.replicas: Required value"},
+		},
+		"apiserver without service": {
+			mutate: func(cv *ClusterVersion) { cv.Spec.APIServer.Service = nil },
+			errs:   []string{"spec.apiServer.service: Required value"},
+		},
+		"controller-manager without containers": {
+			mutate: func(cv *ClusterVersion) { cv.Spec.ControllerManager.StatefulSet.Spec.Template.Spec.Containers = nil },
+			errs:   []string{"spec.controllerManager.statefulset.spec.template.spec.containers: Required value"},
+		},
+		"mount of undefined volume": {
+			mutate: func(cv *ClusterVersion) {
+				volumes := cv.Spec.APIServer.StatefulSet.Spec.Template.Spec.Volumes
+				cv.Spec.APIServer.StatefulSet.Spec.Template.Spec.Volumes = volumes[1:]
+			},
+			errs: []string{"spec.apiServer.statefulset.spec.template.spec.containers[0].volumeMounts[0].name: Not found"},
+		},
+		"service without port": {
+			mutate: func(cv *ClusterVersion) { cv.Spec.APIServer.Service.Spec.Ports = nil },
+			errs:   []string{"spec.apiServer.service.spec.ports: Required value"},
+		},
+		"invalid parameter name": {
+			mutate: func(cv *ClusterVersion) {
+				cv.Spec.Parameters = []ClusterVersionParameter{{Name: "apiserver-cpu"}}
+			},
+			errs: []string{"spec.parameters[0].name: Invalid value"},
+		},
+		"duplicated parameter": {
+			mutate: func(cv *ClusterVersion) {
+				cv.Spec.Parameters = []ClusterVersionParameter{{Name: "cpu"}, {Name: "cpu"}}
+			},
+			errs: []string{"spec.parameters[1].name: Duplicate value"},
+		},
+		"default of another type": {
+			mutate: func(cv *ClusterVersion) {
+				def := "many"
+				cv.Spec.Parameters = []ClusterVersionParameter{{Name: "replicas", Type: ParameterTypeInteger, Default: &def}}
+			},
+			errs: []string{"spec.parameters[0].default: Invalid value"},
+		},
+		"unknown parameter type": {
+			mutate: func(cv *ClusterVersion) {
+				cv.Spec.Parameters = []ClusterVersionParameter{{Name: "cpu", Type: "float"}}
+			},
+			errs: []string{"spec.parameters[0].type: Unsupported value"},
+		},
+		"invalid patch": {
+			mutate: func(cv *ClusterVersion) {
+				cv.Spec.APIServer.Patches = []BundlePatch{{Target: "pod", Patch: `[{"op": "add", "value": {{ .cpu }]`}}
+			},
+			errs: []string{"spec.apiServer.patches[0].target: Unsupported value", "spec.apiServer.patches[0].patch: Invalid value"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cv := loadSampleClusterVersion(t, "clusterversion_v1_nodeport.yaml")
+			tc.mutate(cv)
+			errs := cv.ValidateBundles()
+			if len(errs) != len(tc.errs) {
+				t.Fatalf("expected %d errors, got %v", len(tc.errs), errs)
+			}
+			for i, err := range errs {
+				if !strings.HasPrefix(err.Error(), tc.errs[i]) {
+					t.Errorf("expected error %q, got %q", tc.errs[i], err.Error())
+				}
+			}
+			if cv.ValidateCreate() == nil {
+				t.Errorf("expected the ClusterVersion to be rejected")
+			}
+		})
+	}
+}
+
+func TestSpecHash(t *testing.T) {
+	cv := loadSampleClusterVersion(t, "clusterversion_v1_nodeport.yaml")
+	hash := cv.SpecHash()
+	if hash != cv.DeepCopy().SpecHash() {
+		t.Errorf("expected the hash of the same spec to be stable")
+	}
+
+	cv.Status.SpecHash = hash
+	cv.ResourceVersion = "2"
+	if cv.SpecHash() != hash {
+		t.Errorf("expected the hash not to depend on the metadata and the status")
+	}
+
+	cv.Spec.APIServer.StatefulSet.Spec.Template.Spec.Containers[0].Image = "virtualcluster/apiserver-v1.20.0"
+	if cv.SpecHash() == hash {
+		t.Errorf("expected the hash to change with the spec")
+	}
+}
+
+func TestResolveParameters(t *testing.T) {
+	cpu, debug := "500m", "false"
+	cv := &ClusterVersion{}
+	cv.Name = "cv"
+	cv.Spec.Parameters = []ClusterVersionParameter{
+		{Name: "cpu", Type: ParameterTypeQuantity, Default: &cpu},
+		{Name: "debug", Type: ParameterTypeBoolean, Default: &debug},
+		{Name: "storageClass"},
+	}
+
+	resolved, err := cv.ResolveParameters(map[string]string{"cpu": "2", "storageClass": "ssd"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{"cpu": "2", "debug": "false", "storageClass": "ssd"}
+	if !reflect.DeepEqual(resolved, expected) {
+		t.Errorf("expected %v, got %v", expected, resolved)
+	}
+
+	_, err = cv.ResolveParameters(map[string]string{"cpu": "two", "replicas": "3"})
+	if err == nil {
+		t.Fatalf("expected the values to be rejected")
+	}
+	for _, msg := range []string{
+		`parameter cpu must be of type quantity, got "two"`,
+		"parameter storageClass is required",
+		"parameter replicas is not declared by ClusterVersion cv",
+	} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Errorf("expected error %q in %q", msg, err.Error())
+		}
+	}
+}
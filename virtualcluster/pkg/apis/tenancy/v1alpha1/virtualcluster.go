@@ -0,0 +1,111 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetCondition returns the condition of the given type, or nil if it is not set
+func (vc *VirtualCluster) GetCondition(conditionType ClusterConditionType) *ClusterCondition {
+	for i := range vc.Status.Conditions {
+		if vc.Status.Conditions[i].Type == conditionType {
+			return &vc.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsConditionTrue returns true if the condition of the given type is set and true
+func (vc *VirtualCluster) IsConditionTrue(conditionType ClusterConditionType) bool {
+	condition := vc.GetCondition(conditionType)
+	return condition != nil && condition.Status == corev1.ConditionTrue
+}
+
+// SetCondition sets the condition of the given type, its LastTransitionTime is only
+// updated when the status changes. It returns true if the condition is changed
+func (vc *VirtualCluster) SetCondition(conditionType ClusterConditionType, status corev1.ConditionStatus, reason, message string) bool {
+	condition := vc.GetCondition(conditionType)
+	if condition == nil {
+		vc.Status.Conditions = append(vc.Status.Conditions, ClusterCondition{
+			Type:               conditionType,
+			Status:             status,
+			LastTransitionTime: metav1.Now(),
+			Reason:             reason,
+			Message:            message,
+		})
+		return true
+	}
+	if condition.Status == status && condition.Reason == reason && condition.Message == message {
+		return false
+	}
+	if condition.Status != status {
+		condition.LastTransitionTime = metav1.Now()
+	}
+	condition.Status = status
+	condition.Reason = reason
+	condition.Message = message
+	return true
+}
+
+// DefaultClusterDomain is the domain of the virtual cluster if ClusterDomain is not set
+const DefaultClusterDomain = "cluster.local"
+
+// GetClusterDomain returns the domain of the virtual cluster
+func (vc *VirtualCluster) GetClusterDomain() string {
+	if vc.Spec.ClusterDomain == "" {
+		return DefaultClusterDomain
+	}
+	return vc.Spec.ClusterDomain
+}
+
+const (
+	// AuditPolicyKey is the key of the audit policy in the ConfigMap referred by AuditConfig
+	AuditPolicyKey = "policy.yaml"
+	// AuditWebhookKubeconfigKey is the key of the kubeconfig in the Secret referred by
+	// AuditWebhookBackend
+	AuditWebhookKubeconfigKey = "kubeconfig"
+
+	// AuditWebhookModeBatch buffers the audit events and sends them asynchronously
+	AuditWebhookModeBatch = "batch"
+	// AuditWebhookModeBlocking blocks the requests on sending the audit events
+	AuditWebhookModeBlocking = "blocking"
+	// AuditWebhookModeBlockingStrict is the same as blocking, the requests fail if the
+	// audit events can't be sent
+	AuditWebhookModeBlockingStrict = "blocking-strict"
+)
+
+// GetMode returns the strategy to send the audit events, batch if Mode is not set
+func (wh *AuditWebhookBackend) GetMode() string {
+	if wh.Mode == "" {
+		return AuditWebhookModeBatch
+	}
+	return wh.Mode
+}
+
+// DefaultExternalKubeconfigSecretKey is the key of the kubeconfig in the Secret referred by
+// ExternalControlPlane if KubeconfigSecretKey is not set
+const DefaultExternalKubeconfigSecretKey = "kubeconfig"
+
+// GetKubeconfigSecretKey returns the key of the kubeconfig in the Secret
+func (ref *ExternalControlPlane) GetKubeconfigSecretKey() string {
+	if ref.KubeconfigSecretKey == "" {
+		return DefaultExternalKubeconfigSecretKey
+	}
+	return ref.KubeconfigSecretKey
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetCondition(t *testing.T) {
+	transition := metav1.Date(2022, 1, 1, 0, 0, 0, 0, metav1.Now().Location())
+	vc := &VirtualCluster{
+		Status: VirtualClusterStatus{
+			Conditions: []ClusterCondition{
+				{Status: corev1.ConditionTrue, Reason: "SyncPaused", LastTransitionTime: transition},
+				{Type: EtcdReady, Status: corev1.ConditionTrue, Reason: "ComponentReady", LastTransitionTime: transition},
+			},
+		},
+	}
+
+	if vc.SetCondition(EtcdReady, corev1.ConditionTrue, "ComponentReady", "") {
+		t.Errorf("expected the unchanged condition not to be set")
+	}
+	if !vc.SetCondition(EtcdReady, corev1.ConditionTrue, "ComponentReady", "statefulset etcd is ready") {
+		t.Errorf("expected the condition message to be set")
+	}
+	if condition := vc.GetCondition(EtcdReady); !condition.LastTransitionTime.Equal(&transition) {
+		t.Errorf("expected the transition time to be kept if the status doesn't change, got %v", condition.LastTransitionTime)
+	}
+
+	if !vc.SetCondition(EtcdReady, corev1.ConditionFalse, "ComponentNotReady", "timeout") {
+		t.Errorf("expected the condition status to be set")
+	}
+	if condition := vc.GetCondition(EtcdReady); condition.LastTransitionTime.Equal(&transition) {
+		t.Errorf("expected the transition time to be updated if the status changes")
+	}
+	if vc.IsConditionTrue(EtcdReady) {
+		t.Errorf("expected EtcdReady to be false")
+	}
+
+	if !vc.SetCondition(APIServerReady, corev1.ConditionTrue, "ComponentReady", "") || !vc.IsConditionTrue(APIServerReady) {
+		t.Errorf("expected APIServerReady to be added")
+	}
+	if len(vc.Status.Conditions) != 3 || vc.Status.Conditions[0].Reason != "SyncPaused" {
+		t.Errorf("expected the untyped condition to be kept, got %+v", vc.Status.Conditions)
+	}
+}
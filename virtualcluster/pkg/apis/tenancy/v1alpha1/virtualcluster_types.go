@@ -51,6 +51,80 @@ type VirtualClusterSpec struct {
 	// Service CIDRs used by VirtualCluster
 	// +optional
 	ServiceCidr string `json:"serviceCidr,omitempty"`
+
+	// Values of the parameters declared by the ClusterVersion, the default
+	// values are used for the parameters not set
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// ExternalControlPlane is the existing control plane attached as the tenant
+	// control plane by the external provisioner
+	// +optional
+	ExternalControlPlane *ExternalControlPlane `json:"externalControlPlane,omitempty"`
+
+	// Audit enables the audit logging of the tenant apiserver deployed by the native
+	// provisioner
+	// +optional
+	Audit *AuditConfig `json:"audit,omitempty"`
+}
+
+// AuditConfig configures the audit policy and the audit backends of the tenant apiserver
+type AuditConfig struct {
+	// PolicyConfigMapName is the name of the ConfigMap holding the audit policy in the
+	// "policy.yaml" key, in the namespace of the VirtualCluster
+	PolicyConfigMapName string `json:"policyConfigMapName"`
+
+	// Log writes the audit events to a log file of the apiserver
+	// +optional
+	Log *AuditLogBackend `json:"log,omitempty"`
+
+	// Webhook sends the audit events to a remote API
+	// +optional
+	Webhook *AuditWebhookBackend `json:"webhook,omitempty"`
+}
+
+// AuditLogBackend configures the rotation of the audit log file
+type AuditLogBackend struct {
+	// MaxAge is the number of days the rotated log files are retained
+	// +optional
+	MaxAge int32 `json:"maxAge,omitempty"`
+
+	// MaxBackup is the number of rotated log files retained
+	// +optional
+	MaxBackup int32 `json:"maxBackup,omitempty"`
+
+	// MaxSize is the size in megabytes of the log file before it is rotated
+	// +optional
+	MaxSize int32 `json:"maxSize,omitempty"`
+
+	// Ship forwards the audit events of the log file to the audit sink of the super
+	// cluster, if vc-manager is configured with one
+	// +optional
+	Ship bool `json:"ship,omitempty"`
+}
+
+// AuditWebhookBackend refers to the kubeconfig of the remote API receiving the audit events
+type AuditWebhookBackend struct {
+	// KubeconfigSecretName is the name of the Secret holding the kubeconfig in the
+	// "kubeconfig" key, in the namespace of the VirtualCluster
+	KubeconfigSecretName string `json:"kubeconfigSecretName"`
+
+	// Mode is the strategy to send the audit events, one of batch, blocking and
+	// blocking-strict, batch if not set
+	// +optional
+	Mode string `json:"mode,omitempty"`
+}
+
+// ExternalControlPlane refers to the kubeconfig of an existing apiserver, e.g. an
+// EKS, GKE or kind cluster
+type ExternalControlPlane struct {
+	// KubeconfigSecretName is the name of the Secret holding the kubeconfig, in the
+	// namespace of the VirtualCluster
+	KubeconfigSecretName string `json:"kubeconfigSecretName"`
+
+	// KubeconfigSecretKey is the key of the kubeconfig in the Secret, "kubeconfig" if not set
+	// +optional
+	KubeconfigSecretKey string `json:"kubeconfigSecretKey,omitempty"`
 }
 
 // VirtualClusterStatus defines the observed state of VirtualCluster
@@ -76,6 +150,16 @@ type VirtualClusterStatus struct {
 
 	// Cluster Conditions
 	Conditions []ClusterCondition `json:"conditions,omitempty"`
+
+	// The number of failed attempts to provision the control plane since it is
+	// created or a retry is requested with the retry annotation.
+	// +optional
+	Retries int32 `json:"retries,omitempty"`
+
+	// Last time the control plane failed to be provisioned, the next attempt is
+	// delayed by an exponential backoff from it.
+	// +optional
+	LastRetryTime *metav1.Time `json:"lastRetryTime,omitempty"`
 }
 
 type ClusterPhase string
@@ -90,12 +174,55 @@ const (
 	// ClusterUpdating when update cluster spec, phase will be updating
 	ClusterUpdating ClusterPhase = "Updating"
 
-	// ClusterError happens when Cluster can not be initiated, or occur the error that Operator
-	// can not recover
+	// ClusterError happens when Cluster can not be initiated after all the retries, the
+	// provisioning is retried again if the retry annotation is set
 	ClusterError ClusterPhase = "Error"
 )
 
+type ClusterConditionType string
+
+const (
+	// ControlPlaneProvisioned is true when the provisioner has set up the tenant control plane
+	ControlPlaneProvisioned ClusterConditionType = "ControlPlaneProvisioned"
+
+	// PKIReady is true when the certificates and kubeconfigs of the tenant control plane are created
+	PKIReady ClusterConditionType = "PKIReady"
+
+	// EtcdReady is true when the etcd of the tenant control plane is ready
+	EtcdReady ClusterConditionType = "EtcdReady"
+
+	// APIServerReady is true when the apiserver of the tenant control plane is ready
+	APIServerReady ClusterConditionType = "APIServerReady"
+
+	// ControllerManagerReady is true when the controller-manager of the tenant control plane is ready
+	ControllerManagerReady ClusterConditionType = "ControllerManagerReady"
+
+	// SyncerConnected is true when the syncer has connected to the tenant control plane
+	SyncerConnected ClusterConditionType = "SyncerConnected"
+
+	// UpgradeInProgress is true when the tenant control plane is being upgraded to the ClusterVersion
+	UpgradeInProgress ClusterConditionType = "UpgradeInProgress"
+
+	// PKIValid is false when a certificate of the tenant control plane is expired, or is about
+	// to expire and fails to be renewed
+	PKIValid ClusterConditionType = "PKIValid"
+
+	// ParametersValid is false when the parameters set on the VirtualCluster don't match the
+	// parameters declared by the ClusterVersion, or the bundles fail to be rendered with them
+	ParametersValid ClusterConditionType = "ParametersValid"
+
+	// SyncPaused is true when syncing the tenant control plane is paused by the syncer, the
+	// tenant objects are not synced to the super cluster while the super cluster status is
+	// still synced back to the tenant objects
+	SyncPaused ClusterConditionType = "SyncPaused"
+)
+
 type ClusterCondition struct {
+	// Type of cluster condition, the conditions without type are kept for
+	// backward compatibility.
+	// +optional
+	Type ClusterConditionType `json:"type,omitempty"`
+
 	// Cluster Condition Status
 	// Can be True, False, Unknown.
 	Status corev1.ConditionStatus `json:"status"`
@@ -17,33 +17,62 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/clientcmd"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
 var vclog = logf.Log.WithName("virtualcluster-webhook")
 
+// vcWebhookReader reads the ClusterVersions and the kubeconfig Secrets referred by the
+// VirtualClusters, the references are not checked if the webhook is not set up with a manager
+var vcWebhookReader client.Reader
+
 func (vc *VirtualCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	vclog.Info("setup virtualcluster validation webhook")
+	vcWebhookReader = mgr.GetAPIReader()
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(vc).
 		Complete()
 }
 
+var _ webhook.Defaulter = &VirtualCluster{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (vc *VirtualCluster) Default() {
+	vclog.Info("default", "vc-name", vc.Name)
+	if vc.Spec.ClusterDomain == "" {
+		vc.Spec.ClusterDomain = DefaultClusterDomain
+	}
+}
+
 var _ webhook.Validator = &VirtualCluster{}
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
 func (vc *VirtualCluster) ValidateCreate() error {
 	vclog.Info("validate create", "vc-name", vc.Name)
-	// do nothing for delete request
-	return nil
+	allErrs := vc.ValidateSpec()
+	allErrs = append(allErrs, vc.validateClusterVersionRef()...)
+	allErrs = append(allErrs, vc.validateExternalKubeconfigRef()...)
+	return vc.toInvalidError(allErrs)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
@@ -70,9 +99,218 @@ func (vc *VirtualCluster) validateVirtualClusterUpdate(old runtime.Object) error
 		allErrs = append(allErrs,
 			field.Invalid(field.NewPath("status").Child("phase"),
 				vc.Name, "cannot set virtualcluster.Status.Phase to empty"))
-		return apierrors.NewInvalid(
-			schema.GroupKind{Group: "tenancy.x-k8s.io", Kind: "VirtualCluster"},
-			vc.Name, allErrs)
+		return vc.toInvalidError(allErrs)
 	}
-	return nil
+	// the spec is only validated when it changes, so that the status of the VirtualClusters
+	// created before a validation is added can still be updated
+	if reflect.DeepEqual(oldVC.Spec, vc.Spec) {
+		return nil
+	}
+
+	specPath := field.NewPath("spec")
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(
+		vc.GetClusterDomain(), oldVC.GetClusterDomain(), specPath.Child("clusterDomain"))...)
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(
+		vc.Spec.ServiceCidr, oldVC.Spec.ServiceCidr, specPath.Child("serviceCidr"))...)
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(
+		vc.Spec.ExternalControlPlane, oldVC.Spec.ExternalControlPlane, specPath.Child("externalControlPlane"))...)
+	allErrs = append(allErrs, vc.ValidateSpec()...)
+	// changing the ClusterVersion upgrades the control plane, the new one must exist
+	if vc.Spec.ClusterVersionName != oldVC.Spec.ClusterVersionName ||
+		!reflect.DeepEqual(vc.Spec.Parameters, oldVC.Spec.Parameters) {
+		allErrs = append(allErrs, vc.validateClusterVersionRef()...)
+	}
+	return vc.toInvalidError(allErrs)
+}
+
+// ValidateSpec checks the fields of the spec that don't refer to other objects
+func (vc *VirtualCluster) ValidateSpec() field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if vc.Spec.ClusterDomain != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(vc.Spec.ClusterDomain) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("clusterDomain"), vc.Spec.ClusterDomain, msg))
+		}
+	}
+	if vc.Spec.ServiceCidr != "" {
+		if _, _, err := net.ParseCIDR(vc.Spec.ServiceCidr); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("serviceCidr"), vc.Spec.ServiceCidr, err.Error()))
+		}
+	}
+	if vc.Spec.PKIExpireDays < 0 {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("pkiExpireDays"), vc.Spec.PKIExpireDays, "must be greater than or equal to 0"))
+	}
+
+	transparentPath := specPath.Child("transparentMetaPrefixes")
+	allErrs = append(allErrs, validateMetaPrefixes(vc.Spec.TransparentMetaPrefixes, transparentPath)...)
+	allErrs = append(allErrs, validateMetaPrefixes(vc.Spec.OpaqueMetaPrefixes, specPath.Child("opaqueMetaPrefixes"))...)
+	// a key is either back populated to the tenant or hidden from it
+	for i, prefix := range vc.Spec.TransparentMetaPrefixes {
+		for _, opaque := range vc.Spec.OpaqueMetaPrefixes {
+			if prefix == opaque {
+				allErrs = append(allErrs, field.Invalid(transparentPath.Index(i), prefix, "cannot be both transparent and opaque"))
+			}
+		}
+	}
+
+	if ref := vc.Spec.ExternalControlPlane; ref != nil {
+		allErrs = append(allErrs, validateObjectRef(ref.KubeconfigSecretName, specPath.Child("externalControlPlane", "kubeconfigSecretName"))...)
+	}
+	if vc.Spec.Audit != nil {
+		allErrs = append(allErrs, ValidateAuditConfig(vc.Spec.Audit, specPath.Child("audit"))...)
+	}
+	return allErrs
+}
+
+// ValidateAuditConfig checks the audit policy and the backends are referred by valid names,
+// at least one backend is required for the apiserver to record the audit events
+func ValidateAuditConfig(audit *AuditConfig, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	allErrs = append(allErrs, validateObjectRef(audit.PolicyConfigMapName, fldPath.Child("policyConfigMapName"))...)
+	if audit.Log == nil && audit.Webhook == nil {
+		allErrs = append(allErrs, field.Required(fldPath, "at least one of log and webhook must be set"))
+	}
+	if log := audit.Log; log != nil {
+		logPath := fldPath.Child("log")
+		for name, value := range map[string]int32{"maxAge": log.MaxAge, "maxBackup": log.MaxBackup, "maxSize": log.MaxSize} {
+			if value < 0 {
+				allErrs = append(allErrs, field.Invalid(logPath.Child(name), value, "must be greater than or equal to 0"))
+			}
+		}
+	}
+	if wh := audit.Webhook; wh != nil {
+		whPath := fldPath.Child("webhook")
+		allErrs = append(allErrs, validateObjectRef(wh.KubeconfigSecretName, whPath.Child("kubeconfigSecretName"))...)
+		switch wh.Mode {
+		case "", AuditWebhookModeBatch, AuditWebhookModeBlocking, AuditWebhookModeBlockingStrict:
+		default:
+			allErrs = append(allErrs, field.NotSupported(whPath.Child("mode"), wh.Mode,
+				[]string{AuditWebhookModeBatch, AuditWebhookModeBlocking, AuditWebhookModeBlockingStrict}))
+		}
+	}
+	return allErrs
+}
+
+// validateObjectRef checks the name of an object in the namespace of the VirtualCluster
+func validateObjectRef(name string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if name == "" {
+		return append(allErrs, field.Required(fldPath, ""))
+	}
+	for _, msg := range validation.IsDNS1123Subdomain(name) {
+		allErrs = append(allErrs, field.Invalid(fldPath, name, msg))
+	}
+	return allErrs
+}
+
+// validateMetaPrefixes checks the prefixes are unique and start with a valid domain, the
+// prefixes are matched against the keys of the labels and annotations
+func validateMetaPrefixes(prefixes []string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	seen := make(map[string]bool, len(prefixes))
+	for i, prefix := range prefixes {
+		if seen[prefix] {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i), prefix))
+			continue
+		}
+		seen[prefix] = true
+		domain := strings.SplitN(prefix, "/", 2)[0]
+		for _, msg := range validation.IsDNS1123Subdomain(domain) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), prefix, msg))
+		}
+	}
+	return allErrs
+}
+
+// validateClusterVersionRef checks the ClusterVersion exists and declares the parameters
+// set on the VirtualCluster
+func (vc *VirtualCluster) validateClusterVersionRef() field.ErrorList {
+	var allErrs field.ErrorList
+	if vcWebhookReader == nil || vc.Spec.ClusterVersionName == "" {
+		// the external provisioner doesn't require a ClusterVersion
+		return allErrs
+	}
+	cvPath := field.NewPath("spec", "clusterVersionName")
+	cv := &ClusterVersion{}
+	if err := vcWebhookReader.Get(context.TODO(), client.ObjectKey{Name: vc.Spec.ClusterVersionName}, cv); err != nil {
+		if apierrors.IsNotFound(err) {
+			return append(allErrs, field.NotFound(cvPath, vc.Spec.ClusterVersionName))
+		}
+		return append(allErrs, field.InternalError(cvPath, err))
+	}
+	if _, err := cv.ResolveParameters(vc.Spec.Parameters); err != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "parameters"), vc.Spec.Parameters, err.Error()))
+	}
+	return allErrs
+}
+
+// validateExternalKubeconfigRef checks the kubeconfig Secret of the external control plane
+// exists and the kubeconfig only carries inline credentials
+func (vc *VirtualCluster) validateExternalKubeconfigRef() field.ErrorList {
+	var allErrs field.ErrorList
+	ref := vc.Spec.ExternalControlPlane
+	if vcWebhookReader == nil || ref == nil || ref.KubeconfigSecretName == "" {
+		return allErrs
+	}
+	refPath := field.NewPath("spec", "externalControlPlane", "kubeconfigSecretName")
+	srt := &corev1.Secret{}
+	if err := vcWebhookReader.Get(context.TODO(), client.ObjectKey{Name: ref.KubeconfigSecretName, Namespace: vc.Namespace}, srt); err != nil {
+		if apierrors.IsNotFound(err) {
+			return append(allErrs, field.NotFound(refPath, ref.KubeconfigSecretName))
+		}
+		return append(allErrs, field.InternalError(refPath, err))
+	}
+	if err := ValidateExternalKubeconfig(srt.Data[ref.GetKubeconfigSecretKey()]); err != nil {
+		allErrs = append(allErrs, field.Invalid(refPath, ref.KubeconfigSecretName, err.Error()))
+	}
+	return allErrs
+}
+
+// ValidateExternalKubeconfig checks the kubeconfig of an external control plane only carries
+// inline credentials. The kubeconfig is supplied by the tenant and loaded in the pods of
+// vc-manager and the syncer, so the exec plugins, the auth providers and the credentials
+// read from files, which would run or read in those pods, are rejected.
+func ValidateExternalKubeconfig(kubeconfig []byte) error {
+	if len(kubeconfig) == 0 {
+		return errors.New("kubeconfig is empty")
+	}
+	cfg, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return fmt.Errorf("fail to parse kubeconfig: %v", err)
+	}
+	var errs []error
+	for _, name := range sets.StringKeySet(cfg.Clusters).List() {
+		if cfg.Clusters[name].CertificateAuthority != "" {
+			errs = append(errs, fmt.Errorf("cluster %s: certificate-authority is not allowed, use certificate-authority-data", name))
+		}
+	}
+	for _, name := range sets.StringKeySet(cfg.AuthInfos).List() {
+		authInfo := cfg.AuthInfos[name]
+		for _, check := range []struct {
+			set bool
+			msg string
+		}{
+			{authInfo.ClientCertificate != "", "client-certificate is not allowed, use client-certificate-data"},
+			{authInfo.ClientKey != "", "client-key is not allowed, use client-key-data"},
+			{authInfo.TokenFile != "", "tokenFile is not allowed, use token"},
+			{authInfo.Username != "" || authInfo.Password != "", "username and password are not allowed"},
+			{authInfo.AuthProvider != nil, "auth-provider is not allowed"},
+			{authInfo.Exec != nil, "exec is not allowed"},
+		} {
+			if check.set {
+				errs = append(errs, fmt.Errorf("user %s: %s", name, check.msg))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (vc *VirtualCluster) toInvalidError(allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(
+		schema.GroupKind{Group: "tenancy.x-k8s.io", Kind: "VirtualCluster"},
+		vc.Name, allErrs)
 }
@@ -0,0 +1,236 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+)
+
+func loadSampleVirtualCluster(t *testing.T, name string) *VirtualCluster {
+	data, err := ioutil.ReadFile("../../../../config/sampleswithspec/" + name)
+	if err != nil {
+		t.Fatalf("fail to read sample %s: %v", name, err)
+	}
+	vc := &VirtualCluster{}
+	if err := yaml.Unmarshal(data, vc); err != nil {
+		t.Fatalf("fail to decode sample %s: %v", name, err)
+	}
+	return vc
+}
+
+func expectFieldErrors(t *testing.T, err error, expected []string) {
+	t.Helper()
+	if len(expected) == 0 {
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		return
+	}
+	if err == nil {
+		t.Fatalf("expected errors %v, got none", expected)
+	}
+	for _, msg := range expected {
+		if !strings.Contains(err.Error(), msg) {
+			t.Errorf("expected error %q in %q", msg, err.Error())
+		}
+	}
+}
+
+func TestValidateVirtualClusterCreate(t *testing.T) {
+	tests := map[string]struct {
+		mutate func(vc *VirtualCluster)
+		errs   []string
+	}{
+		"sample": {
+			mutate: func(vc *VirtualCluster) {},
+		},
+		"invalid cluster domain": {
+			mutate: func(vc *VirtualCluster) { vc.Spec.ClusterDomain = "Cluster_Local" },
+			errs:   []string{"spec.clusterDomain: Invalid value"},
+		},
+		"invalid service cidr": {
+			mutate: func(vc *VirtualCluster) { vc.Spec.ServiceCidr = "10.32.0.0/33" },
+			errs:   []string{"spec.serviceCidr: Invalid value"},
+		},
+		"negative pki expire days": {
+			mutate: func(vc *VirtualCluster) { vc.Spec.PKIExpireDays = -1 },
+			errs:   []string{"spec.pkiExpireDays: Invalid value"},
+		},
+		"invalid meta prefix": {
+			mutate: func(vc *VirtualCluster) { vc.Spec.TransparentMetaPrefixes = []string{"k8s net"} },
+			errs:   []string{"spec.transparentMetaPrefixes[0]: Invalid value"},
+		},
+		"duplicated meta prefix": {
+			mutate: func(vc *VirtualCluster) {
+				vc.Spec.OpaqueMetaPrefixes = []string{"tenancy.x-k8s.io", "tenancy.x-k8s.io"}
+			},
+			errs: []string{"spec.opaqueMetaPrefixes[1]: Duplicate value"},
+		},
+		"transparent and opaque prefix": {
+			mutate: func(vc *VirtualCluster) { vc.Spec.TransparentMetaPrefixes = []string{"tenancy.x-k8s.io"} },
+			errs:   []string{"spec.transparentMetaPrefixes[0]: Invalid value"},
+		},
+		"external control plane without secret": {
+			mutate: func(vc *VirtualCluster) { vc.Spec.ExternalControlPlane = &ExternalControlPlane{} },
+			errs:   []string{"spec.externalControlPlane.kubeconfigSecretName: Required value"},
+		},
+		"missing external kubeconfig secret": {
+			mutate: func(vc *VirtualCluster) {
+				vc.Spec.ExternalControlPlane = &ExternalControlPlane{KubeconfigSecretName: "missing-kubeconfig"}
+			},
+			errs: []string{"spec.externalControlPlane.kubeconfigSecretName: Not found"},
+		},
+		"external kubeconfig with exec plugin": {
+			mutate: func(vc *VirtualCluster) {
+				vc.Spec.ExternalControlPlane = &ExternalControlPlane{KubeconfigSecretName: "exec-kubeconfig"}
+			},
+			errs: []string{"spec.externalControlPlane.kubeconfigSecretName: Invalid value", "user admin: exec is not allowed"},
+		},
+		"external kubeconfig with inline credentials": {
+			mutate: func(vc *VirtualCluster) {
+				vc.Spec.ExternalControlPlane = &ExternalControlPlane{KubeconfigSecretName: "inline-kubeconfig", KubeconfigSecretKey: "admin.conf"}
+			},
+		},
+		"audit without backend": {
+			mutate: func(vc *VirtualCluster) { vc.Spec.Audit = &AuditConfig{PolicyConfigMapName: "audit-policy"} },
+			errs:   []string{"spec.audit: Required value"},
+		},
+		"audit without policy": {
+			mutate: func(vc *VirtualCluster) { vc.Spec.Audit = &AuditConfig{Log: &AuditLogBackend{}} },
+			errs:   []string{"spec.audit.policyConfigMapName: Required value"},
+		},
+		"audit with invalid backends": {
+			mutate: func(vc *VirtualCluster) {
+				vc.Spec.Audit = &AuditConfig{
+					PolicyConfigMapName: "audit-policy",
+					Log:                 &AuditLogBackend{MaxSize: -1},
+					Webhook:             &AuditWebhookBackend{KubeconfigSecretName: "audit-sink", Mode: "async"},
+				}
+			},
+			errs: []string{"spec.audit.log.maxSize: Invalid value", "spec.audit.webhook.mode: Unsupported value"},
+		},
+		"audit": {
+			mutate: func(vc *VirtualCluster) {
+				vc.Spec.Audit = &AuditConfig{
+					PolicyConfigMapName: "audit-policy",
+					Log:                 &AuditLogBackend{MaxAge: 7, Ship: true},
+					Webhook:             &AuditWebhookBackend{KubeconfigSecretName: "audit-sink", Mode: AuditWebhookModeBlocking},
+				}
+			},
+		},
+		"missing clusterversion": {
+			mutate: func(vc *VirtualCluster) { vc.Spec.ClusterVersionName = "cv-missing" },
+			errs:   []string{"spec.clusterVersionName: Not found"},
+		},
+		"undeclared parameter": {
+			mutate: func(vc *VirtualCluster) { vc.Spec.Parameters = map[string]string{"cpu": "2"} },
+			errs:   []string{"spec.parameters: Invalid value", "parameter cpu is not declared"},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	if err := AddToScheme(scheme); err != nil {
+		t.Fatalf("fail to add tenancy scheme: %v", err)
+	}
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("fail to add client-go scheme: %v", err)
+	}
+	cv := loadSampleClusterVersion(t, "clusterversion_v1_nodeport.yaml")
+	execSrt := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "exec-kubeconfig", Namespace: "default"},
+		Data: map[string][]byte{DefaultExternalKubeconfigSecretKey: []byte(
+			"apiVersion: v1\nkind: Config\nusers:\n- name: admin\n  user:\n    exec:\n      command: sh\n")},
+	}
+	inlineSrt := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "inline-kubeconfig", Namespace: "default"},
+		Data: map[string][]byte{"admin.conf": []byte(
+			"apiVersion: v1\nkind: Config\nusers:\n- name: admin\n  user:\n    token: abc\n")},
+	}
+	vcWebhookReader = fake.NewClientBuilder().WithScheme(scheme).WithObjects(cv, execSrt, inlineSrt).Build()
+	defer func() { vcWebhookReader = nil }()
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			vc := loadSampleVirtualCluster(t, "virtualcluster_1_nodeport.yaml")
+			tc.mutate(vc)
+			expectFieldErrors(t, vc.ValidateCreate(), tc.errs)
+		})
+	}
+}
+
+func TestValidateVirtualClusterUpdate(t *testing.T) {
+	tests := map[string]struct {
+		mutate func(vc *VirtualCluster)
+		errs   []string
+	}{
+		"status": {
+			mutate: func(vc *VirtualCluster) { vc.Status.Phase = ClusterRunning },
+		},
+		"empty phase": {
+			mutate: func(vc *VirtualCluster) { vc.Status.Phase = "" },
+			errs:   []string{"status.phase: Invalid value"},
+		},
+		"defaulted cluster domain": {
+			mutate: func(vc *VirtualCluster) { vc.Spec.ClusterDomain = "" },
+		},
+		"cluster domain": {
+			mutate: func(vc *VirtualCluster) { vc.Spec.ClusterDomain = "tenant.local" },
+			errs:   []string{"spec.clusterDomain: Invalid value", "field is immutable"},
+		},
+		"service cidr": {
+			mutate: func(vc *VirtualCluster) { vc.Spec.ServiceCidr = "10.96.0.0/12" },
+			errs:   []string{"spec.serviceCidr: Invalid value", "field is immutable"},
+		},
+		"meta prefixes": {
+			mutate: func(vc *VirtualCluster) { vc.Spec.TransparentMetaPrefixes = []string{"k8s.net.status"} },
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			old := loadSampleVirtualCluster(t, "virtualcluster_1_nodeport.yaml")
+			old.Status.Phase = ClusterPending
+			vc := old.DeepCopy()
+			tc.mutate(vc)
+			expectFieldErrors(t, vc.ValidateUpdate(old), tc.errs)
+		})
+	}
+}
+
+func TestDefaultVirtualCluster(t *testing.T) {
+	vc := &VirtualCluster{}
+	vc.Default()
+	if vc.Spec.ClusterDomain != DefaultClusterDomain {
+		t.Errorf("expected cluster domain %s, got %q", DefaultClusterDomain, vc.Spec.ClusterDomain)
+	}
+
+	cv := &ClusterVersion{}
+	cv.Spec.Parameters = []ClusterVersionParameter{{Name: "cpu", Type: ParameterTypeQuantity}, {Name: "storageClass"}}
+	cv.Default()
+	if cv.Spec.Parameters[0].Type != ParameterTypeQuantity || cv.Spec.Parameters[1].Type != ParameterTypeString {
+		t.Errorf("expected the parameters to be of type quantity and string, got %+v", cv.Spec.Parameters)
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package shipper forwards the audit events logged by a tenant apiserver to the audit sink
+// of the super cluster.
+package shipper
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+// DefaultBatchSize is the max number of audit events sent to the sink in one request
+const DefaultBatchSize = 100
+
+// Shipper tails the JSON audit log of a tenant apiserver and posts the events to the sink as
+// audit.k8s.io/v1 EventLists, the events are annotated with the tenant they come from.
+// The events left in a rotated log file are not shipped.
+type Shipper struct {
+	// LogPath is the path of the audit log written by the apiserver
+	LogPath string
+	// SinkURL is the URL the EventLists are posted to
+	SinkURL string
+	// Cluster is the root namespace of the tenant control plane
+	Cluster     string
+	VCNamespace string
+	VCName      string
+	BatchSize   int
+	Client      *http.Client
+	Log         logr.Logger
+
+	// file is the log file read last time, to detect the rotation of the log
+	file    os.FileInfo
+	offset  int64
+	pending []auditv1.Event
+}
+
+// Run ships the audit events every period until the context is done.
+func (s *Shipper) Run(ctx context.Context, period time.Duration) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := s.Ship(ctx); err != nil {
+			s.Log.Error(err, "fail to ship audit events")
+		}
+	}, period)
+}
+
+// Ship sends the audit events appended to the log since the last call. A batch failing to be
+// sent is kept and sent again before reading more events.
+func (s *Shipper) Ship(ctx context.Context) error {
+	for {
+		if len(s.pending) == 0 {
+			if err := s.read(); err != nil {
+				return err
+			}
+			if len(s.pending) == 0 {
+				return nil
+			}
+		}
+		if err := s.send(ctx, s.pending); err != nil {
+			return err
+		}
+		s.pending = nil
+	}
+}
+
+// read reads at most BatchSize complete lines of the log into pending.
+func (s *Shipper) read() error {
+	f, err := os.Open(s.LogPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// the apiserver hasn't logged any event yet
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if s.file != nil && (!os.SameFile(s.file, info) || info.Size() < s.offset) {
+		s.Log.Info("audit log is rotated", "path", s.LogPath)
+		s.offset = 0
+	}
+	s.file = info
+	if _, err := f.Seek(s.offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	batchSize := s.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	r := bufio.NewReader(f)
+	for len(s.pending) < batchSize {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			// an incomplete line is read again once the apiserver finishes writing it
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		s.offset += int64(len(line))
+		event := auditv1.Event{}
+		if err := json.Unmarshal(line, &event); err != nil {
+			s.Log.Error(err, "skip malformed audit event", "offset", s.offset)
+			continue
+		}
+		s.annotate(&event)
+		s.pending = append(s.pending, event)
+	}
+	return nil
+}
+
+// annotate identifies the tenant the event comes from.
+func (s *Shipper) annotate(event *auditv1.Event) {
+	if event.Annotations == nil {
+		event.Annotations = map[string]string{}
+	}
+	event.Annotations[constants.LabelCluster] = s.Cluster
+	event.Annotations[constants.LabelVCNamespace] = s.VCNamespace
+	event.Annotations[constants.LabelVCName] = s.VCName
+}
+
+func (s *Shipper) send(ctx context.Context, events []auditv1.Event) error {
+	list := &auditv1.EventList{Items: events}
+	list.APIVersion = auditv1.SchemeGroupVersion.String()
+	list.Kind = "EventList"
+	body, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.SinkURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	cli := s.Client
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+	resp, err := cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("audit sink %s responded %s", s.SinkURL, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shipper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-logr/logr"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+type testSink struct {
+	fail   bool
+	events []auditv1.Event
+}
+
+func (ts *testSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if ts.fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	list := &auditv1.EventList{}
+	if err := json.NewDecoder(r.Body).Decode(list); err != nil || list.Kind != "EventList" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	ts.events = append(ts.events, list.Items...)
+}
+
+func appendEvents(t *testing.T, path string, ids ...string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatalf("fail to open audit log: %v", err)
+	}
+	defer f.Close()
+	for _, id := range ids {
+		fmt.Fprintf(f, `{"kind":"Event","apiVersion":"audit.k8s.io/v1","auditID":%q,"verb":"get"}`+"\n", id)
+	}
+}
+
+func TestShip(t *testing.T) {
+	sink := &testSink{}
+	srv := httptest.NewServer(sink)
+	defer srv.Close()
+	logPath := filepath.Join(t.TempDir(), "audit.log")
+	s := &Shipper{
+		LogPath:     logPath,
+		SinkURL:     srv.URL,
+		Cluster:     "default-abcdef-vc",
+		VCNamespace: "default",
+		VCName:      "vc",
+		BatchSize:   2,
+		Log:         logr.Discard(),
+	}
+
+	// the log is not written yet
+	if err := s.Ship(context.TODO()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	appendEvents(t, logPath, "1", "2", "3")
+	if err := s.Ship(context.TODO()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sink.events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(sink.events))
+	}
+	event := sink.events[0]
+	if event.Annotations[constants.LabelCluster] != "default-abcdef-vc" ||
+		event.Annotations[constants.LabelVCNamespace] != "default" ||
+		event.Annotations[constants.LabelVCName] != "vc" {
+		t.Errorf("expected the event to identify the tenant, got %v", event.Annotations)
+	}
+
+	// the events are sent again once the sink is back
+	sink.fail = true
+	appendEvents(t, logPath, "4")
+	if err := s.Ship(context.TODO()); err == nil {
+		t.Fatalf("expected an error from the sink")
+	}
+	sink.fail = false
+	if err := s.Ship(context.TODO()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sink.events) != 4 || sink.events[3].AuditID != "4" {
+		t.Fatalf("expected event 4 to be shipped once, got %d events", len(sink.events))
+	}
+
+	// the log is read from the start once it is rotated
+	if err := os.Remove(logPath); err != nil {
+		t.Fatalf("fail to remove audit log: %v", err)
+	}
+	appendEvents(t, logPath, "5")
+	if err := s.Ship(context.TODO()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sink.events) != 5 || sink.events[4].AuditID != "5" {
+		t.Errorf("expected event 5 to be shipped after the rotation, got %d events", len(sink.events))
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers/provisioner"
 )
 
 // Controllers defines all the shared information between all
@@ -34,6 +35,12 @@ type Controllers struct {
 	MaxConcurrentReconciles int
 	ProvisionerName         string
 	ProvisionerTimeout      time.Duration
+	// PKIRenewBefore is how long before expiry the certificates of the tenant control planes are renewed
+	PKIRenewBefore time.Duration
+	// PKICAOverlap is how long the previous root CA of a tenant control plane is trusted after the rotation
+	PKICAOverlap time.Duration
+	// AuditShipper configures the sidecar shipping the audit log of the tenant apiservers
+	AuditShipper provisioner.AuditShipperConfig
 }
 
 // SetupWithManager adds all Controllers to the Manager
@@ -62,6 +69,15 @@ func (c *Controllers) SetupWithManager(mgr ctrl.Manager) error {
 		}).SetupWithManager(mgr, opts); err != nil {
 			return err
 		}
+		if err := (&controllers.ReconcilePKI{
+			Client:       mgr.GetClient(),
+			Log:          c.Log.WithName("pki"),
+			RenewBefore:  c.PKIRenewBefore,
+			CAOverlap:    c.PKICAOverlap,
+			ResyncPeriod: controllers.DefaultPKIResyncPeriod,
+		}).SetupWithManager(mgr, opts); err != nil {
+			return err
+		}
 	}
 
 	if err := (&controllers.ReconcileVirtualCluster{
@@ -69,6 +85,7 @@ func (c *Controllers) SetupWithManager(mgr ctrl.Manager) error {
 		Log:                c.Log.WithName("virtualcluster"),
 		ProvisionerName:    c.ProvisionerName,
 		ProvisionerTimeout: c.ProvisionerTimeout,
+		AuditShipper:       c.AuditShipper,
 	}).SetupWithManager(mgr, opts); err != nil {
 		return err
 	}
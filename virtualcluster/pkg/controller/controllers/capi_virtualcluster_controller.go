@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -135,9 +136,10 @@ func (r *ReconcileCAPIVirtualCluster) Reconcile(ctx context.Context, request ctr
 	case "":
 		// set vc status as ClusterPending if no status is set
 		r.Log.Info("will create a VirtualCluster", "vc", vc.Name)
-		// will retry three times
 		kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterPending,
-			"retry: 3", "ClusterCreating")
+			"tenant control plane is being provisioned", "ClusterCreating")
+		vc.SetCondition(tenancyv1alpha1.ControlPlaneProvisioned, corev1.ConditionFalse,
+			"Provisioning", "tenant control plane is being provisioned")
 		if err := kubeutil.RetryUpdateVCStatusOnConflict(ctx, r, vc, r.Log); err != nil {
 			return ctrl.Result{}, err
 		}
@@ -148,6 +150,8 @@ func (r *ReconcileCAPIVirtualCluster) Reconcile(ctx context.Context, request ctr
 		if clusterv1.ClusterPhase(cluster.Status.Phase) == clusterv1.ClusterPhaseProvisioned {
 			kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterRunning,
 				"tenant cluster provisioned", "ClusterRunning")
+			vc.SetCondition(tenancyv1alpha1.ControlPlaneProvisioned, corev1.ConditionTrue,
+				"Provisioned", "tenant cluster provisioned")
 			if err := kubeutil.RetryUpdateVCStatusOnConflict(ctx, r, vc, r.Log); err != nil {
 				return ctrl.Result{}, err
 			}
@@ -18,16 +18,24 @@ package controllers
 
 import (
 	"context"
+	"sort"
 
 	"github.com/go-logr/logr"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
 	strutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/strings"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 )
 
 var _ reconcile.Reconciler = &ReconcileClusterVersion{}
@@ -43,11 +51,34 @@ func (r *ReconcileClusterVersion) SetupWithManager(mgr ctrl.Manager, opts contro
 	return ctrl.NewControllerManagedBy(mgr).
 		WithOptions(opts).
 		For(&tenancyv1alpha1.ClusterVersion{}).
+		Watches(&source.Kind{Type: &tenancyv1alpha1.VirtualCluster{}}, handler.Funcs{
+			CreateFunc: func(e event.CreateEvent, q workqueue.RateLimitingInterface) {
+				enqueueClusterVersionOf(e.Object, q)
+			},
+			UpdateFunc: func(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+				// the VirtualCluster may move to another ClusterVersion
+				enqueueClusterVersionOf(e.ObjectOld, q)
+				enqueueClusterVersionOf(e.ObjectNew, q)
+			},
+			DeleteFunc: func(e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+				enqueueClusterVersionOf(e.Object, q)
+			},
+		}).
 		Complete(r)
 }
 
+// enqueueClusterVersionOf enqueues the ClusterVersion referenced by the VirtualCluster obj
+func enqueueClusterVersionOf(obj client.Object, q workqueue.RateLimitingInterface) {
+	vc, ok := obj.(*tenancyv1alpha1.VirtualCluster)
+	if !ok || vc.Spec.ClusterVersionName == "" {
+		return
+	}
+	q.Add(reconcile.Request{NamespacedName: types.NamespacedName{Name: vc.Spec.ClusterVersionName}})
+}
+
 // +kubebuilder:rbac:groups=tenancy.x-k8s.io,resources=clusterversions,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=tenancy.x-k8s.io,resources=clusterversions/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=tenancy.x-k8s.io,resources=virtualclusters,verbs=get;list;watch
 
 // Reconcile reads that state of the cluster for a ClusterVersion object and makes changes based on the state read
 // and what is in the ClusterVersion.Spec
@@ -77,6 +108,9 @@ func (r *ReconcileClusterVersion) Reconcile(ctx context.Context, request reconci
 				return reconcile.Result{}, err
 			}
 		}
+		if err := r.updateStatus(ctx, cv); err != nil {
+			return reconcile.Result{}, err
+		}
 	} else {
 		// the object is being deleted, star the finalizer
 		if strutil.ContainString(cv.ObjectMeta.Finalizers, cvf) {
@@ -93,3 +127,54 @@ func (r *ReconcileClusterVersion) Reconcile(ctx context.Context, request reconci
 
 	return reconcile.Result{}, nil
 }
+
+// updateStatus updates the status of cv with the VirtualClusters referencing it
+func (r *ReconcileClusterVersion) updateStatus(ctx context.Context, cv *tenancyv1alpha1.ClusterVersion) error {
+	vcList := &tenancyv1alpha1.VirtualClusterList{}
+	if err := r.List(ctx, vcList); err != nil {
+		return err
+	}
+	status := clusterVersionStatus(cv, vcList.Items)
+	if apiequality.Semantic.DeepEqual(cv.Status, status) {
+		return nil
+	}
+	cv.Status = status
+	r.Log.Info("update ClusterVersion status", "ClusterVersion", cv.Name,
+		"virtualclusters", len(status.VirtualClusters), "up-to-date", status.UpToDateVirtualClusters)
+	return r.Status().Update(ctx, cv)
+}
+
+// clusterVersionStatus returns the status of cv referenced by the given VirtualClusters,
+// a VirtualCluster is up to date if it is labeled with the hash of the current spec
+func clusterVersionStatus(cv *tenancyv1alpha1.ClusterVersion, vcs []tenancyv1alpha1.VirtualCluster) tenancyv1alpha1.ClusterVersionStatus {
+	status := tenancyv1alpha1.ClusterVersionStatus{SpecHash: cv.SpecHash()}
+	if message, deprecated := cv.Annotations[constants.LabelClusterVersionDeprecated]; deprecated {
+		status.Deprecated = true
+		status.DeprecationMessage = message
+	}
+	for _, err := range cv.ValidateBundles() {
+		status.ValidationErrors = append(status.ValidationErrors, err.Error())
+	}
+
+	for _, vc := range vcs {
+		if vc.Spec.ClusterVersionName != cv.Name {
+			continue
+		}
+		upToDate := vc.Labels[constants.LabelClusterVersionSpecHash] == status.SpecHash
+		if upToDate {
+			status.UpToDateVirtualClusters++
+		}
+		status.VirtualClusters = append(status.VirtualClusters, tenancyv1alpha1.ClusterVersionReference{
+			Namespace: vc.Namespace,
+			Name:      vc.Name,
+			UpToDate:  upToDate,
+		})
+	}
+	sort.Slice(status.VirtualClusters, func(i, j int) bool {
+		if status.VirtualClusters[i].Namespace != status.VirtualClusters[j].Namespace {
+			return status.VirtualClusters[i].Namespace < status.VirtualClusters[j].Namespace
+		}
+		return status.VirtualClusters[i].Name < status.VirtualClusters[j].Name
+	})
+	return status
+}
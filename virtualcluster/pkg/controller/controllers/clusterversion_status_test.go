@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+func newStatusTestVC(namespace, name, cvName, specHash string) tenancyv1alpha1.VirtualCluster {
+	vc := tenancyv1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       tenancyv1alpha1.VirtualClusterSpec{ClusterVersionName: cvName},
+	}
+	if specHash != "" {
+		vc.Labels = map[string]string{constants.LabelClusterVersionSpecHash: specHash}
+	}
+	return vc
+}
+
+func TestClusterVersionStatus(t *testing.T) {
+	cv := &tenancyv1alpha1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "cv-1.20",
+			Annotations: map[string]string{constants.LabelClusterVersionDeprecated: "use cv-1.21 instead"},
+		},
+	}
+	specHash := cv.SpecHash()
+
+	status := clusterVersionStatus(cv, []tenancyv1alpha1.VirtualCluster{
+		newStatusTestVC("tenant-b", "vc", "cv-1.20", specHash),
+		newStatusTestVC("tenant-a", "vc-2", "cv-1.20", "outdated"),
+		newStatusTestVC("tenant-a", "vc-1", "cv-1.20", specHash),
+		newStatusTestVC("tenant-a", "vc-3", "cv-1.21", specHash),
+		newStatusTestVC("tenant-c", "vc", "cv-1.20", ""),
+	})
+
+	if status.SpecHash != specHash {
+		t.Errorf("expected spec hash %s, got %s", specHash, status.SpecHash)
+	}
+	if !status.Deprecated || status.DeprecationMessage != "use cv-1.21 instead" {
+		t.Errorf("expected the ClusterVersion to be deprecated, got %v %q", status.Deprecated, status.DeprecationMessage)
+	}
+	if len(status.ValidationErrors) == 0 {
+		t.Errorf("expected the empty bundles to be reported")
+	}
+	if status.UpToDateVirtualClusters != 2 {
+		t.Errorf("expected 2 up-to-date virtualclusters, got %d", status.UpToDateVirtualClusters)
+	}
+	expected := []tenancyv1alpha1.ClusterVersionReference{
+		{Namespace: "tenant-a", Name: "vc-1", UpToDate: true},
+		{Namespace: "tenant-a", Name: "vc-2"},
+		{Namespace: "tenant-b", Name: "vc", UpToDate: true},
+		{Namespace: "tenant-c", Name: "vc"},
+	}
+	if len(status.VirtualClusters) != len(expected) {
+		t.Fatalf("expected virtualclusters %v, got %v", expected, status.VirtualClusters)
+	}
+	for i := range expected {
+		if status.VirtualClusters[i] != expected[i] {
+			t.Errorf("expected virtualcluster %v, got %v", expected[i], status.VirtualClusters[i])
+		}
+	}
+}
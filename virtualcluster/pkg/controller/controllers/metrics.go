@@ -43,4 +43,11 @@ var (
 		},
 		[]string{"cluster_version", "resource_version"},
 	)
+	pkiCertificateExpiryDays = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "pki_certificate_expiry_days",
+			Help: "Days until the certificates of the tenant control planes expire",
+		},
+		[]string{"virtualcluster", "certificate"},
+	)
 )
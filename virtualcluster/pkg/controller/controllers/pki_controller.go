@@ -0,0 +1,473 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	certutil "k8s.io/client-go/util/cert"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/kubeconfig"
+	vcpki "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/pki"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	kubeutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/kube"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	pkiutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/pki"
+)
+
+const (
+	// DefaultPKIRenewBefore is how long before expiry the certificates are renewed by default.
+	DefaultPKIRenewBefore = 30 * 24 * time.Hour
+	// DefaultPKICAOverlap is how long the previous root CA is trusted after the root CA is rotated by default.
+	DefaultPKICAOverlap = 24 * time.Hour
+	// DefaultPKIResyncPeriod is how often the expiry of the certificates is checked by default.
+	DefaultPKIResyncPeriod = time.Hour
+)
+
+var (
+	// pkiLeafSecretNames are the secrets of the crt/key pairs signed by the root CA.
+	pkiLeafSecretNames = []string{
+		secret.APIServerCASecretName,
+		secret.ETCDCASecretName,
+		secret.FrontProxyCASecretName,
+	}
+
+	// pkiKubeconfigSecretNames are the secrets of the kubeconfigs holding client certificates
+	// signed by the root CA.
+	pkiKubeconfigSecretNames = []string{
+		secret.ControllerManagerSecretName,
+		secret.AdminSecretName,
+	}
+
+	// pkiSecretComponents are the control plane components restarted when the certificates in
+	// the secrets are renewed, the admin kubeconfig is only used out of the control plane.
+	pkiSecretComponents = map[string][]string{
+		secret.RootCASecretName:            {"etcd", "apiserver", "controller-manager"},
+		secret.APIServerCASecretName:       {"apiserver"},
+		secret.ETCDCASecretName:            {"etcd"},
+		secret.FrontProxyCASecretName:      {"apiserver"},
+		secret.ControllerManagerSecretName: {"controller-manager"},
+	}
+)
+
+// pkiCertificateNames are the secrets holding the certificates watched for expiry.
+func pkiCertificateNames() []string {
+	names := []string{secret.RootCASecretName}
+	names = append(names, pkiLeafSecretNames...)
+	return append(names, pkiKubeconfigSecretNames...)
+}
+
+var _ reconcile.Reconciler = &ReconcilePKI{}
+
+// ReconcilePKI watches the expiry of the certificates of the tenant control planes set up by the
+// native provisioner. The days to expiry are exposed in the pki_certificate_expiry_days metric and
+// in the PKIValid condition of the VirtualClusters. If featuregate.PKIRotation is enabled, the
+// certificates are renewed ahead of expiry and the root CA is rotated when it is about to expire,
+// or when requested with the rotate-root-ca annotation. The previous root CA is trusted by the
+// control plane for CAOverlap after the rotation, and the apiserver keeps serving with the
+// certificate signed by the previous root CA until then so that the clients only trusting the
+// previous root CA can connect while they pick up the new one. The components are restarted to
+// load the renewed certificates.
+type ReconcilePKI struct {
+	client.Client
+	Log logr.Logger
+	// RenewBefore is how long before expiry the certificates are renewed, it is capped to the
+	// third of the validity of the certificates
+	RenewBefore time.Duration
+	// CAOverlap is how long the previous root CA is trusted after the root CA is rotated
+	CAOverlap time.Duration
+	// ResyncPeriod is how often the expiry of the certificates is checked
+	ResyncPeriod time.Duration
+
+	now func() time.Time
+}
+
+// SetupWithManager will configure the PKI reconciler
+func (r *ReconcilePKI) SetupWithManager(mgr ctrl.Manager, opts controller.Options) error {
+	if r.now == nil {
+		r.now = time.Now
+	}
+	metrics.Registry.MustRegister(pkiCertificateExpiryDays)
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("virtualcluster-pki").
+		WithOptions(opts).
+		For(&tenancyv1alpha1.VirtualCluster{}).
+		Complete(r)
+}
+
+// tenantPKI is the PKI of a tenant control plane stored in the secrets of its root namespace.
+type tenantPKI struct {
+	secrets map[string]*corev1.Secret
+	// certs are the certificates by the name of the secret holding them
+	certs  map[string]*x509.Certificate
+	rootCA *vcpki.CrtKeyPair
+	// previousRootCA is the root CA replaced by the rotation of the root CA, it is nil once the
+	// overlap is over
+	previousRootCA *x509.Certificate
+}
+
+// rootCABundle is the PEM of the root CAs trusted by the control plane.
+func (p *tenantPKI) rootCABundle() []byte {
+	return p.secrets[secret.RootCASecretName].Data[corev1.TLSCertKey]
+}
+
+// previousCATrustedUntil returns the time the previous root CA is trusted until, if the root CA is being rotated.
+func (p *tenantPKI) previousCATrustedUntil() (time.Time, bool) {
+	until, exists := p.secrets[secret.RootCASecretName].Annotations[constants.LabelPKIPreviousCATrustedUntil]
+	if !exists {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, until)
+	if err != nil {
+		// the previous root CA is not trusted any longer
+		return time.Time{}, true
+	}
+	return t, true
+}
+
+// earliestExpiry returns the name of the secret holding the certificate expiring first.
+func (p *tenantPKI) earliestExpiry() string {
+	var earliest string
+	for _, name := range pkiCertificateNames() {
+		if earliest == "" || p.certs[name].NotAfter.Before(p.certs[earliest].NotAfter) {
+			earliest = name
+		}
+	}
+	return earliest
+}
+
+// loadPKI reads the certificates of the tenant control plane from the secrets in the root namespace ns.
+func (r *ReconcilePKI) loadPKI(ctx context.Context, ns string) (*tenantPKI, error) {
+	p := &tenantPKI{
+		secrets: map[string]*corev1.Secret{},
+		certs:   map[string]*x509.Certificate{},
+	}
+	for _, name := range pkiCertificateNames() {
+		srt := &corev1.Secret{}
+		if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: ns}, srt); err != nil {
+			return nil, fmt.Errorf("fail to get PKI secret %s: %v", name, err)
+		}
+		p.secrets[name] = srt
+	}
+
+	rootSrt := p.secrets[secret.RootCASecretName]
+	rootCerts, err := certutil.ParseCertsPEM(rootSrt.Data[corev1.TLSCertKey])
+	if err != nil {
+		return nil, fmt.Errorf("fail to parse root CA: %v", err)
+	}
+	rootKey, err := vcpki.DecodePrivateKeyPEM(rootSrt.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return nil, fmt.Errorf("fail to parse root CA key: %v", err)
+	}
+	// the root CA is followed by the previous root CA during the rotation of the root CA
+	p.rootCA = &vcpki.CrtKeyPair{Crt: rootCerts[0], Key: rootKey}
+	p.certs[secret.RootCASecretName] = rootCerts[0]
+	if len(rootCerts) > 1 {
+		p.previousRootCA = rootCerts[1]
+	}
+
+	for _, name := range pkiLeafSecretNames {
+		crt, err := pkiutil.DecodeCertPEM(p.secrets[name].Data[corev1.TLSCertKey])
+		if err != nil {
+			return nil, fmt.Errorf("fail to parse certificate of %s: %v", name, err)
+		}
+		p.certs[name] = crt
+	}
+	for _, name := range pkiKubeconfigSecretNames {
+		crt, err := kubeconfig.ClientCertificate(p.secrets[name].Data[name])
+		if err != nil {
+			return nil, fmt.Errorf("fail to parse client certificate of %s: %v", name, err)
+		}
+		p.certs[name] = crt
+	}
+	return p, nil
+}
+
+// pkiValidity is the validity of the certificates renewed for vc.
+func pkiValidity(vc *tenancyv1alpha1.VirtualCluster) time.Duration {
+	if vc.Spec.PKIExpireDays > 0 {
+		return time.Duration(vc.Spec.PKIExpireDays) * 24 * time.Hour
+	}
+	return pkiutil.CertificateValidity
+}
+
+// renewBefore is how long before expiry the certificates of the given validity are renewed.
+func (r *ReconcilePKI) renewBefore(validity time.Duration) time.Duration {
+	if r.RenewBefore > validity/3 {
+		return validity / 3
+	}
+	return r.RenewBefore
+}
+
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;patch
+// +kubebuilder:rbac:groups=tenancy.x-k8s.io,resources=virtualclusters,verbs=get;list;watch;update;patch
+
+// Reconcile checks the expiry of the certificates of a running VirtualCluster, and renews them
+// if featuregate.PKIRotation is enabled
+func (r *ReconcilePKI) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	vc := &tenancyv1alpha1.VirtualCluster{}
+	if err := r.Get(ctx, request.NamespacedName, vc); err != nil {
+		if apierrors.IsNotFound(err) {
+			deletePKICertificateExpiryDays(request.NamespacedName)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if !vc.DeletionTimestamp.IsZero() || vc.Status.Phase != tenancyv1alpha1.ClusterRunning {
+		return reconcile.Result{}, nil
+	}
+
+	p, err := r.loadPKI(ctx, vc.Status.ClusterNamespace)
+	if err != nil {
+		r.Log.Error(err, "fail to load PKI", "vc", vc.GetName())
+		return reconcile.Result{}, err
+	}
+
+	now := r.now()
+	validity := pkiValidity(vc)
+	renewBefore := r.renewBefore(validity)
+	vcChanged := false
+	var renewErr error
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.PKIRotation) {
+		_, requested := vc.Annotations[constants.LabelVCRotateRootCA]
+		if _, rotating := p.previousCATrustedUntil(); !rotating && (requested || p.rootCA.Crt.NotAfter.Sub(now) < renewBefore) {
+			renewErr = r.rotateRootCA(ctx, vc, p, now)
+		}
+		if requested && renewErr == nil {
+			if err := r.removeRotateRootCAAnnotation(ctx, vc); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+		if renewErr == nil {
+			renewErr = r.renewPKI(ctx, vc, p, validity, renewBefore, now)
+		}
+	}
+
+	recordPKICertificateExpiryDays(request.NamespacedName, p, now)
+	if setPKICondition(vc, p, renewBefore, now, renewErr) {
+		vcChanged = true
+	}
+	if vcChanged {
+		if err := kubeutil.RetryUpdateVCStatusOnConflict(ctx, r, vc, r.Log); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	if renewErr != nil {
+		return reconcile.Result{}, renewErr
+	}
+
+	result := reconcile.Result{RequeueAfter: r.ResyncPeriod}
+	if until, rotating := p.previousCATrustedUntil(); rotating && until.Sub(now) < result.RequeueAfter {
+		result.RequeueAfter = until.Sub(now)
+	}
+	return result, nil
+}
+
+// removeRotateRootCAAnnotation removes the rotate-root-ca annotation from vc once the rotation
+// of the root CA is started. The annotation is removed with a patch so that it does not
+// depend on the status update, which only retries the status on conflicts.
+func (r *ReconcilePKI) removeRotateRootCAAnnotation(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) error {
+	patch := client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf(
+		`{"metadata":{"annotations":{%q:null}}}`, constants.LabelVCRotateRootCA)))
+	if err := r.Patch(ctx, vc, patch); err != nil {
+		return fmt.Errorf("fail to remove annotation %s: %v", constants.LabelVCRotateRootCA, err)
+	}
+	// the patched object is decoded into vc, which keeps the keys missing from the response
+	delete(vc.Annotations, constants.LabelVCRotateRootCA)
+	return nil
+}
+
+// rotateRootCA replaces the root CA of vc with a new one, the previous root CA is still trusted
+// for CAOverlap. The certificates signed by the previous root CA are renewed by renewPKI.
+func (r *ReconcilePKI) rotateRootCA(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, p *tenantPKI, now time.Time) error {
+	rootCA, err := vcpki.NewRootCrtAndKey()
+	if err != nil {
+		return err
+	}
+	rootSrt := p.secrets[secret.RootCASecretName]
+	rootSrt.Data[corev1.TLSCertKey] = vcpki.EncodeCertBundlePEM(rootCA.Crt, p.rootCA.Crt)
+	rootSrt.Data[corev1.TLSPrivateKeyKey] = vcpki.EncodePrivateKeyPEM(rootCA.Key)
+	if rootSrt.Annotations == nil {
+		rootSrt.Annotations = map[string]string{}
+	}
+	rootSrt.Annotations[constants.LabelPKIPreviousCATrustedUntil] = now.Add(r.CAOverlap).UTC().Format(time.RFC3339)
+	if err := r.Update(ctx, rootSrt); err != nil {
+		return err
+	}
+	r.Log.Info("root CA is rotated", "vc", vc.GetName(), "previous-ca-trusted-until", rootSrt.Annotations[constants.LabelPKIPreviousCATrustedUntil])
+
+	p.previousRootCA = p.rootCA.Crt
+	p.rootCA = rootCA
+	p.certs[secret.RootCASecretName] = rootCA.Crt
+	return r.restartComponents(ctx, vc, pkiSecretComponents[secret.RootCASecretName], now)
+}
+
+// renewPKI stops trusting the previous root CA once the overlap of a root CA rotation is over,
+// and renews the certificates expiring within renewBefore or not signed by the root CA. The
+// serving certificate of the apiserver signed by the previous root CA is kept during the
+// overlap. The components using the renewed certificates are restarted.
+func (r *ReconcilePKI) renewPKI(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, p *tenantPKI, validity, renewBefore time.Duration, now time.Time) error {
+	var renewed []string
+	if until, rotating := p.previousCATrustedUntil(); rotating && !now.Before(until) {
+		rootSrt := p.secrets[secret.RootCASecretName]
+		rootSrt.Data[corev1.TLSCertKey] = pkiutil.EncodeCertPEM(p.rootCA.Crt)
+		delete(rootSrt.Annotations, constants.LabelPKIPreviousCATrustedUntil)
+		if err := r.Update(ctx, rootSrt); err != nil {
+			return err
+		}
+		r.Log.Info("previous root CA is not trusted any longer", "vc", vc.GetName())
+		p.previousRootCA = nil
+		renewed = append(renewed, secret.RootCASecretName)
+	}
+
+	needsRenewal := func(name string, crt *x509.Certificate) bool {
+		if crt.NotAfter.Sub(now) < renewBefore {
+			return true
+		}
+		if crt.CheckSignatureFrom(p.rootCA.Crt) == nil {
+			return false
+		}
+		// the tenant kubeconfigs may only trust the previous root CA until the overlap is over
+		return name != secret.APIServerCASecretName || p.previousRootCA == nil || crt.CheckSignatureFrom(p.previousRootCA) != nil
+	}
+	for _, name := range pkiLeafSecretNames {
+		if !needsRenewal(name, p.certs[name]) {
+			continue
+		}
+		pair, err := vcpki.RenewCrtKeyPair(p.rootCA, p.certs[name], validity)
+		if err != nil {
+			return err
+		}
+		srt := p.secrets[name]
+		srt.Data[corev1.TLSCertKey] = pkiutil.EncodeCertPEM(pair.Crt)
+		srt.Data[corev1.TLSPrivateKeyKey] = vcpki.EncodePrivateKeyPEM(pair.Key)
+		if err := r.Update(ctx, srt); err != nil {
+			return err
+		}
+		p.certs[name] = pair.Crt
+		renewed = append(renewed, name)
+	}
+	for _, name := range pkiKubeconfigSecretNames {
+		if !needsRenewal(name, p.certs[name]) {
+			continue
+		}
+		srt := p.secrets[name]
+		kbCfg, err := kubeconfig.RenewKubeconfig(srt.Data[name], p.rootCA, p.rootCABundle(), validity)
+		if err != nil {
+			return fmt.Errorf("fail to renew %s: %v", name, err)
+		}
+		crt, err := kubeconfig.ClientCertificate(kbCfg)
+		if err != nil {
+			return err
+		}
+		srt.Data[name] = kbCfg
+		if err := r.Update(ctx, srt); err != nil {
+			return err
+		}
+		p.certs[name] = crt
+		renewed = append(renewed, name)
+	}
+	if len(renewed) == 0 {
+		return nil
+	}
+	r.Log.Info("certificates are renewed", "vc", vc.GetName(), "secrets", renewed)
+
+	var components []string
+	for _, name := range renewed {
+		components = append(components, pkiSecretComponents[name]...)
+	}
+	return r.restartComponents(ctx, vc, components, now)
+}
+
+// restartComponents rolls the statefulsets of the components to load the renewed certificates.
+func (r *ReconcilePKI) restartComponents(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, components []string, now time.Time) error {
+	restarted := map[string]bool{}
+	patch := client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf(
+		`{"spec":{"template":{"metadata":{"annotations":{%q:%q}}}}}`,
+		constants.LabelPKIRenewedAt, now.UTC().Format(time.RFC3339))))
+	for _, name := range components {
+		if restarted[name] {
+			continue
+		}
+		restarted[name] = true
+		sts := &appsv1.StatefulSet{}
+		sts.Name, sts.Namespace = name, vc.Status.ClusterNamespace
+		if err := r.Patch(ctx, sts, patch); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("fail to restart %s: %v", name, err)
+		}
+		r.Log.Info("restarted control plane component to load the renewed certificates", "vc", vc.GetName(), "component", name)
+	}
+	return nil
+}
+
+// setPKICondition reports the certificate of vc expiring first in the PKIValid condition, it
+// returns true if the condition is changed.
+func setPKICondition(vc *tenancyv1alpha1.VirtualCluster, p *tenantPKI, renewBefore time.Duration, now time.Time, renewErr error) bool {
+	earliest := p.earliestExpiry()
+	notAfter := p.certs[earliest].NotAfter
+	days := int64(notAfter.Sub(now) / (24 * time.Hour))
+	switch {
+	case !now.Before(notAfter):
+		return vc.SetCondition(tenancyv1alpha1.PKIValid, corev1.ConditionFalse, "Expired",
+			fmt.Sprintf("certificate %s expired at %s", earliest, notAfter.UTC().Format(time.RFC3339)))
+	case renewErr != nil:
+		return vc.SetCondition(tenancyv1alpha1.PKIValid, corev1.ConditionFalse, "RenewalFailed",
+			fmt.Sprintf("certificate %s expires in %d days, fail to renew the certificates: %v", earliest, days, renewErr))
+	case notAfter.Sub(now) < renewBefore:
+		return vc.SetCondition(tenancyv1alpha1.PKIValid, corev1.ConditionFalse, "Expiring",
+			fmt.Sprintf("certificate %s expires in %d days", earliest, days))
+	}
+	if until, rotating := p.previousCATrustedUntil(); rotating {
+		return vc.SetCondition(tenancyv1alpha1.PKIValid, corev1.ConditionTrue, "RootCARotating",
+			fmt.Sprintf("certificate %s expires in %d days, the previous root CA is trusted until %s",
+				earliest, days, until.UTC().Format(time.RFC3339)))
+	}
+	return vc.SetCondition(tenancyv1alpha1.PKIValid, corev1.ConditionTrue, "Valid",
+		fmt.Sprintf("certificate %s expires in %d days", earliest, days))
+}
+
+func recordPKICertificateExpiryDays(key types.NamespacedName, p *tenantPKI, now time.Time) {
+	for _, name := range pkiCertificateNames() {
+		days := p.certs[name].NotAfter.Sub(now).Hours() / 24
+		pkiCertificateExpiryDays.WithLabelValues(key.String(), name).Set(days)
+	}
+}
+
+func deletePKICertificateExpiryDays(key types.NamespacedName) {
+	for _, name := range pkiCertificateNames() {
+		pkiCertificateExpiryDays.DeleteLabelValues(key.String(), name)
+	}
+}
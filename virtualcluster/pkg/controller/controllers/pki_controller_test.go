@@ -0,0 +1,286 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	certutil "k8s.io/client-go/util/cert"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/kubeconfig"
+	vcpki "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/pki"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	utiltest "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/test"
+	pkiutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/pki"
+)
+
+const testPKINamespace = "default-abcdef-vc"
+
+// newTestPKIObjects returns a running VirtualCluster and the PKI secrets and statefulsets of its
+// control plane, the apiserver certificate is valid for apiserverValidity.
+func newTestPKIObjects(t *testing.T, apiserverValidity time.Duration) []client.Object {
+	vc := &tenancyv1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "vc", Namespace: "default"},
+		Status: tenancyv1alpha1.VirtualClusterStatus{
+			Phase:            tenancyv1alpha1.ClusterRunning,
+			ClusterNamespace: testPKINamespace,
+		},
+	}
+	rootCA, err := vcpki.NewRootCrtAndKey()
+	if err != nil {
+		t.Fatalf("fail to create root CA: %v", err)
+	}
+	apiserver, err := vcpki.NewAPIServerCrtAndKey(rootCA, vc, "apiserver-svc")
+	if err != nil {
+		t.Fatalf("fail to create apiserver crt: %v", err)
+	}
+	apiserver, err = vcpki.RenewCrtKeyPair(rootCA, apiserver.Crt, apiserverValidity)
+	if err != nil {
+		t.Fatalf("fail to create apiserver crt: %v", err)
+	}
+	etcd, err := vcpki.NewEtcdServerCertAndKey(rootCA, []string{"etcd-0.etcd"})
+	if err != nil {
+		t.Fatalf("fail to create etcd crt: %v", err)
+	}
+	frontProxy, err := vcpki.NewFrontProxyClientCertAndKey(rootCA)
+	if err != nil {
+		t.Fatalf("fail to create front-proxy crt: %v", err)
+	}
+	ctrlMgrKbCfg, err := kubeconfig.GenerateKubeconfig("system:kube-controller-manager", vc.Name, "apiserver-svc", []string{}, rootCA)
+	if err != nil {
+		t.Fatalf("fail to create controller-manager kubeconfig: %v", err)
+	}
+	adminKbCfg, err := kubeconfig.GenerateKubeconfig("admin", vc.Name, "apiserver-svc", []string{"system:masters"}, rootCA)
+	if err != nil {
+		t.Fatalf("fail to create admin kubeconfig: %v", err)
+	}
+
+	objs := []client.Object{
+		vc,
+		secret.CrtKeyPairToSecret(secret.RootCASecretName, testPKINamespace, rootCA),
+		secret.CrtKeyPairToSecret(secret.APIServerCASecretName, testPKINamespace, apiserver),
+		secret.CrtKeyPairToSecret(secret.ETCDCASecretName, testPKINamespace, etcd),
+		secret.CrtKeyPairToSecret(secret.FrontProxyCASecretName, testPKINamespace, frontProxy),
+		secret.KubeconfigToSecret(secret.ControllerManagerSecretName, testPKINamespace, ctrlMgrKbCfg),
+		secret.KubeconfigToSecret(secret.AdminSecretName, testPKINamespace, adminKbCfg),
+	}
+	for _, name := range []string{"etcd", "apiserver", "controller-manager"} {
+		objs = append(objs, &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testPKINamespace}})
+	}
+	return objs
+}
+
+func newTestReconcilePKI(t *testing.T, objs ...client.Object) *ReconcilePKI {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("fail to add client-go scheme: %v", err)
+	}
+	if err := tenancyv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("fail to add tenancy scheme: %v", err)
+	}
+	return &ReconcilePKI{
+		Client:       fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+		Log:          logr.Discard(),
+		RenewBefore:  DefaultPKIRenewBefore,
+		CAOverlap:    DefaultPKICAOverlap,
+		ResyncPeriod: DefaultPKIResyncPeriod,
+		now:          time.Now,
+	}
+}
+
+var testPKIRequest = reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "vc"}}
+
+func (r *ReconcilePKI) reconcileTest(t *testing.T) *tenancyv1alpha1.VirtualCluster {
+	t.Helper()
+	if _, err := r.Reconcile(context.TODO(), testPKIRequest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	vc := &tenancyv1alpha1.VirtualCluster{}
+	if err := r.Get(context.TODO(), testPKIRequest.NamespacedName, vc); err != nil {
+		t.Fatalf("fail to get virtualcluster: %v", err)
+	}
+	return vc
+}
+
+func (r *ReconcilePKI) getTestSecret(t *testing.T, name string) *corev1.Secret {
+	t.Helper()
+	srt := &corev1.Secret{}
+	if err := r.Get(context.TODO(), client.ObjectKey{Name: name, Namespace: testPKINamespace}, srt); err != nil {
+		t.Fatalf("fail to get secret %s: %v", name, err)
+	}
+	return srt
+}
+
+// expectRestarted checks whether the statefulsets of the components are restarted.
+func (r *ReconcilePKI) expectRestarted(t *testing.T, expected map[string]bool) {
+	t.Helper()
+	for name, restarted := range expected {
+		sts := &appsv1.StatefulSet{}
+		if err := r.Get(context.TODO(), client.ObjectKey{Name: name, Namespace: testPKINamespace}, sts); err != nil {
+			t.Fatalf("fail to get statefulset %s: %v", name, err)
+		}
+		_, annotated := sts.Spec.Template.Annotations[constants.LabelPKIRenewedAt]
+		if annotated != restarted {
+			t.Errorf("expected statefulset %s restarted %v, got %v", name, restarted, annotated)
+		}
+	}
+}
+
+func expectPKICondition(t *testing.T, vc *tenancyv1alpha1.VirtualCluster, status corev1.ConditionStatus, reason string) {
+	t.Helper()
+	cond := vc.GetCondition(tenancyv1alpha1.PKIValid)
+	if cond == nil || cond.Status != status || cond.Reason != reason {
+		t.Errorf("expected PKIValid condition %s %s, got %+v", status, reason, cond)
+	}
+}
+
+func TestReconcilePKIExpiry(t *testing.T) {
+	r := newTestReconcilePKI(t, newTestPKIObjects(t, 10*24*time.Hour)...)
+	vc := r.reconcileTest(t)
+
+	// the certificates are not renewed without featuregate.PKIRotation
+	expectPKICondition(t, vc, corev1.ConditionFalse, "Expiring")
+	r.expectRestarted(t, map[string]bool{"etcd": false, "apiserver": false, "controller-manager": false})
+	days := testutil.ToFloat64(pkiCertificateExpiryDays.WithLabelValues("default/vc", secret.APIServerCASecretName))
+	if days < 9 || days > 10 {
+		t.Errorf("expected apiserver-ca to expire in 10 days, got %v", days)
+	}
+
+	if err := r.Delete(context.TODO(), vc); err != nil {
+		t.Fatalf("fail to delete virtualcluster: %v", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), testPKIRequest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := testutil.CollectAndCount(pkiCertificateExpiryDays); n != 0 {
+		t.Errorf("expected the metrics of the deleted virtualcluster to be removed, got %d", n)
+	}
+}
+
+func TestReconcilePKIRenew(t *testing.T) {
+	defer utiltest.SetFeatureGateDuringTest(t, featuregate.DefaultFeatureGate, featuregate.PKIRotation, true)()
+	r := newTestReconcilePKI(t, newTestPKIObjects(t, 10*24*time.Hour)...)
+	etcdCrt := r.getTestSecret(t, secret.ETCDCASecretName).Data[corev1.TLSCertKey]
+
+	vc := r.reconcileTest(t)
+	expectPKICondition(t, vc, corev1.ConditionTrue, "Valid")
+	crt, err := pkiutil.DecodeCertPEM(r.getTestSecret(t, secret.APIServerCASecretName).Data[corev1.TLSCertKey])
+	if err != nil {
+		t.Fatalf("fail to decode apiserver crt: %v", err)
+	}
+	if crt.NotAfter.Sub(time.Now()) < pkiutil.CertificateValidity-time.Hour {
+		t.Errorf("expected apiserver-ca to be renewed, expires at %v", crt.NotAfter)
+	}
+	if crt.Subject.CommonName != testPKINamespace {
+		t.Errorf("expected the subject of apiserver-ca to be kept, got %v", crt.Subject)
+	}
+	if string(r.getTestSecret(t, secret.ETCDCASecretName).Data[corev1.TLSCertKey]) != string(etcdCrt) {
+		t.Errorf("expected etcd-ca not to be renewed")
+	}
+	r.expectRestarted(t, map[string]bool{"etcd": false, "apiserver": true, "controller-manager": false})
+}
+
+func TestReconcilePKIRotateRootCA(t *testing.T) {
+	defer utiltest.SetFeatureGateDuringTest(t, featuregate.DefaultFeatureGate, featuregate.PKIRotation, true)()
+	objs := newTestPKIObjects(t, pkiutil.CertificateValidity)
+	objs[0].SetAnnotations(map[string]string{constants.LabelVCRotateRootCA: "true"})
+	r := newTestReconcilePKI(t, objs...)
+	previousCA, err := pkiutil.DecodeCertPEM(r.getTestSecret(t, secret.RootCASecretName).Data[corev1.TLSCertKey])
+	if err != nil {
+		t.Fatalf("fail to decode root CA: %v", err)
+	}
+
+	vc := r.reconcileTest(t)
+	expectPKICondition(t, vc, corev1.ConditionTrue, "RootCARotating")
+	if _, exists := vc.Annotations[constants.LabelVCRotateRootCA]; exists {
+		t.Errorf("expected the rotate-root-ca annotation to be removed")
+	}
+	rootSrt := r.getTestSecret(t, secret.RootCASecretName)
+	bundle, err := certutil.ParseCertsPEM(rootSrt.Data[corev1.TLSCertKey])
+	if err != nil {
+		t.Fatalf("fail to parse root CA bundle: %v", err)
+	}
+	if len(bundle) != 2 || !bundle[1].Equal(previousCA) || bundle[0].Equal(previousCA) {
+		t.Fatalf("expected the new root CA followed by the previous one, got %d certificates", len(bundle))
+	}
+	if _, exists := rootSrt.Annotations[constants.LabelPKIPreviousCATrustedUntil]; !exists {
+		t.Errorf("expected the previous root CA to be trusted until the end of the overlap")
+	}
+	for _, name := range pkiLeafSecretNames {
+		crt, err := pkiutil.DecodeCertPEM(r.getTestSecret(t, name).Data[corev1.TLSCertKey])
+		if err != nil {
+			t.Fatalf("fail to decode %s: %v", name, err)
+		}
+		signer := bundle[0]
+		if name == secret.APIServerCASecretName {
+			// the apiserver keeps serving with the previous root CA during the overlap
+			signer = previousCA
+		}
+		if err := crt.CheckSignatureFrom(signer); err != nil {
+			t.Errorf("expected %s to be signed by %s: %v", name, signer.Subject.CommonName, err)
+		}
+	}
+	for _, name := range pkiKubeconfigSecretNames {
+		crt, err := kubeconfig.ClientCertificate(r.getTestSecret(t, name).Data[name])
+		if err != nil {
+			t.Fatalf("fail to decode %s: %v", name, err)
+		}
+		if err := crt.CheckSignatureFrom(bundle[0]); err != nil {
+			t.Errorf("expected %s to be signed by the new root CA: %v", name, err)
+		}
+	}
+	r.expectRestarted(t, map[string]bool{"etcd": true, "apiserver": true, "controller-manager": true})
+
+	// the previous root CA is dropped once the overlap is over
+	r.now = func() time.Time { return time.Now().Add(DefaultPKICAOverlap + time.Minute) }
+	vc = r.reconcileTest(t)
+	expectPKICondition(t, vc, corev1.ConditionTrue, "Valid")
+	rootSrt = r.getTestSecret(t, secret.RootCASecretName)
+	bundle, err = certutil.ParseCertsPEM(rootSrt.Data[corev1.TLSCertKey])
+	if err != nil {
+		t.Fatalf("fail to parse root CA bundle: %v", err)
+	}
+	if len(bundle) != 1 || bundle[0].Equal(previousCA) {
+		t.Errorf("expected only the new root CA to be trusted, got %d certificates", len(bundle))
+	}
+	if _, exists := rootSrt.Annotations[constants.LabelPKIPreviousCATrustedUntil]; exists {
+		t.Errorf("expected the rotation to be completed")
+	}
+	crt, err := pkiutil.DecodeCertPEM(r.getTestSecret(t, secret.APIServerCASecretName).Data[corev1.TLSCertKey])
+	if err != nil {
+		t.Fatalf("fail to decode apiserver crt: %v", err)
+	}
+	if err := crt.CheckSignatureFrom(bundle[0]); err != nil {
+		t.Errorf("expected apiserver-ca to be signed by the new root CA once the overlap is over: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestProvisionRetryDelay(t *testing.T) {
+	for retries, expected := range map[int32]time.Duration{
+		0:  0,
+		1:  10 * time.Second,
+		2:  20 * time.Second,
+		3:  40 * time.Second,
+		6:  5 * time.Minute,
+		40: 5 * time.Minute,
+	} {
+		if delay := provisionRetryDelay(retries); delay != expected {
+			t.Errorf("expected delay %v after %d retries, got %v", expected, retries, delay)
+		}
+	}
+}
+
+func TestProvisionRetryRemaining(t *testing.T) {
+	now := time.Now()
+	lastRetry := metav1.NewTime(now.Add(-15 * time.Second))
+	for name, tc := range map[string]struct {
+		status   tenancyv1alpha1.VirtualClusterStatus
+		expected time.Duration
+	}{
+		"never failed": {
+			expected: 0,
+		},
+		"backing off": {
+			status:   tenancyv1alpha1.VirtualClusterStatus{Retries: 2, LastRetryTime: &lastRetry},
+			expected: 5 * time.Second,
+		},
+		"backoff elapsed": {
+			status:   tenancyv1alpha1.VirtualClusterStatus{Retries: 1, LastRetryTime: &lastRetry},
+			expected: 0,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			vc := &tenancyv1alpha1.VirtualCluster{Status: tc.status}
+			if remaining := provisionRetryRemaining(vc, now); remaining != tc.expected {
+				t.Errorf("expected remaining %v, got %v", tc.expected, remaining)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conformance holds the test suite any provisioner.Provisioner has to pass.
+package conformance
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers/provisioner"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+// Suite is the conformance test suite of the provisioners. It runs the provisioner against a
+// fake super cluster the way the VirtualCluster controller does, e.g.
+//
+//	func TestConformance(t *testing.T) {
+//		conformance.Suite{NewProvisioner: newTestProvisioner}.Run(t)
+//	}
+type Suite struct {
+	// NewProvisioner creates the provisioner under test with the client of the fake super cluster.
+	NewProvisioner func(t *testing.T, cli client.Client) provisioner.Provisioner
+
+	// ClusterVersion the VirtualClusters are provisioned with, DefaultClusterVersion() if not set.
+	ClusterVersion *tenancyv1alpha1.ClusterVersion
+
+	// PrepareVirtualCluster, if set, is called before the VirtualCluster is created in the fake super
+	// cluster, e.g. to create the Secrets the VirtualCluster refers to.
+	PrepareVirtualCluster func(t *testing.T, cli client.Client, vc *tenancyv1alpha1.VirtualCluster)
+}
+
+// Run runs the suite, each test provisions a VirtualCluster in a new fake super cluster.
+func (s Suite) Run(t *testing.T) {
+	t.Run("GetProvisioner", s.testGetProvisioner)
+	t.Run("CreateVirtualCluster", s.testCreate)
+	t.Run("UpgradeVirtualCluster", s.testUpgrade)
+	t.Run("DeleteVirtualCluster", s.testDelete)
+}
+
+// env is a VirtualCluster of the ClusterVersion in a fake super cluster.
+type env struct {
+	cli         client.Client
+	provisioner provisioner.Provisioner
+	cv          *tenancyv1alpha1.ClusterVersion
+	vc          *tenancyv1alpha1.VirtualCluster
+}
+
+func (s Suite) newEnv(t *testing.T) *env {
+	cv := s.ClusterVersion
+	if cv == nil {
+		cv = DefaultClusterVersion()
+	}
+	cv = cv.DeepCopy()
+	vc := &tenancyv1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "conformance", Namespace: "default", UID: "5f3a6e4c-8d3b-4a57-9a4e-2c1f0b7d6e91"},
+		Spec:       tenancyv1alpha1.VirtualClusterSpec{ClusterVersionName: cv.Name},
+	}
+	// the VirtualCluster controller sets the root namespace before provisioning
+	vc.Status.Phase = tenancyv1alpha1.ClusterPending
+	vc.Status.ClusterNamespace = conversion.ToClusterKey(vc)
+
+	cli := NewFakeCluster(cv)
+	if s.PrepareVirtualCluster != nil {
+		s.PrepareVirtualCluster(t, cli, vc)
+	}
+	if err := cli.Create(context.TODO(), vc); err != nil {
+		t.Fatalf("fail to create virtualcluster: %v", err)
+	}
+	return &env{cli: cli, provisioner: s.NewProvisioner(t, cli), cv: cv, vc: vc}
+}
+
+func (e *env) create(t *testing.T) {
+	t.Helper()
+	if err := e.provisioner.CreateVirtualCluster(context.TODO(), e.vc); err != nil {
+		t.Fatalf("fail to create virtualcluster: %v", err)
+	}
+}
+
+// expectRunning checks the root namespace holds the admin kubeconfig the syncer connects with,
+// and the VirtualCluster is labeled with the ClusterVersion spec it runs.
+func (e *env) expectRunning(t *testing.T) {
+	t.Helper()
+	ns := conversion.ToClusterKey(e.vc)
+	if err := e.cli.Get(context.TODO(), client.ObjectKey{Name: ns}, &corev1.Namespace{}); err != nil {
+		t.Errorf("expected root namespace %s: %v", ns, err)
+	}
+	adminSrt := &corev1.Secret{}
+	if err := e.cli.Get(context.TODO(), client.ObjectKey{Name: constants.KubeconfigAdminSecretName, Namespace: ns}, adminSrt); err != nil {
+		t.Errorf("expected the admin kubeconfig in root namespace %s: %v", ns, err)
+	} else if len(adminSrt.Data[constants.KubeconfigAdminSecretName]) == 0 {
+		t.Errorf("expected the admin kubeconfig secret to hold %s", constants.KubeconfigAdminSecretName)
+	}
+
+	cv := &tenancyv1alpha1.ClusterVersion{}
+	if err := e.cli.Get(context.TODO(), client.ObjectKey{Name: e.vc.Spec.ClusterVersionName}, cv); err != nil {
+		t.Fatalf("fail to get ClusterVersion %s: %v", e.vc.Spec.ClusterVersionName, err)
+	}
+	if hash := e.vc.Labels[constants.LabelClusterVersionSpecHash]; hash != cv.SpecHash() {
+		t.Errorf("expected the virtualcluster to be labeled with the spec hash %s of ClusterVersion %s, got %q", cv.SpecHash(), cv.Name, hash)
+	}
+}
+
+func (s Suite) testGetProvisioner(t *testing.T) {
+	e := s.newEnv(t)
+	name := e.provisioner.GetProvisioner()
+	if name == "" {
+		t.Fatalf("expected the provisioner to be named")
+	}
+	// the name is part of the finalizer of the VirtualClusters
+	e.create(t)
+	if e.provisioner.GetProvisioner() != name {
+		t.Errorf("expected the provisioner name to be stable, got %s and %s", name, e.provisioner.GetProvisioner())
+	}
+}
+
+func (s Suite) testCreate(t *testing.T) {
+	e := s.newEnv(t)
+	e.create(t)
+	e.expectRunning(t)
+
+	// the creation is retried when the status of the VirtualCluster fails to be updated
+	e.create(t)
+	e.expectRunning(t)
+}
+
+func (s Suite) testUpgrade(t *testing.T) {
+	e := s.newEnv(t)
+	e.create(t)
+
+	if err := e.provisioner.UpgradeVirtualCluster(context.TODO(), e.vc); err != nil {
+		t.Fatalf("expected the upgrade to the applied ClusterVersion to succeed: %v", err)
+	}
+	e.expectRunning(t)
+
+	cv := &tenancyv1alpha1.ClusterVersion{}
+	if err := e.cli.Get(context.TODO(), client.ObjectKey{Name: e.cv.Name}, cv); err != nil {
+		t.Fatalf("fail to get ClusterVersion %s: %v", e.cv.Name, err)
+	}
+	apiserver := &cv.Spec.APIServer.StatefulSet.Spec.Template.Spec.Containers[0]
+	apiserver.Args = append(apiserver.Args, "--v=4")
+	if err := e.cli.Update(context.TODO(), cv); err != nil {
+		t.Fatalf("fail to update ClusterVersion %s: %v", e.cv.Name, err)
+	}
+	for i := 0; i < 2; i++ {
+		// the upgrade is retried when the VirtualCluster fails to be updated
+		if err := e.provisioner.UpgradeVirtualCluster(context.TODO(), e.vc); err != nil {
+			t.Fatalf("fail to upgrade virtualcluster, attempt %d: %v", i+1, err)
+		}
+		e.expectRunning(t)
+	}
+}
+
+func (s Suite) testDelete(t *testing.T) {
+	e := s.newEnv(t)
+	// a VirtualCluster failed to be provisioned can be deleted
+	if err := e.provisioner.DeleteVirtualCluster(context.TODO(), e.vc.DeepCopy()); err != nil {
+		t.Fatalf("fail to delete virtualcluster not provisioned: %v", err)
+	}
+
+	e.create(t)
+	for i := 0; i < 2; i++ {
+		// the deletion is retried until the finalizer is removed
+		if err := e.provisioner.DeleteVirtualCluster(context.TODO(), e.vc); err != nil {
+			t.Fatalf("fail to delete virtualcluster, attempt %d: %v", i+1, err)
+		}
+	}
+	ns := conversion.ToClusterKey(e.vc)
+	err := e.cli.Get(context.TODO(), client.ObjectKey{Name: ns}, &corev1.Namespace{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected root namespace %s to be deleted, got %v", ns, err)
+	}
+}
+
+// DefaultClusterVersion returns a ClusterVersion of the etcd, apiserver and controller-manager
+// bundles the native provisioner requires.
+func DefaultClusterVersion() *tenancyv1alpha1.ClusterVersion {
+	replicas := int32(1)
+	bundle := func(name, image string, args ...string) *tenancyv1alpha1.StatefulSetSvcBundle {
+		labels := map[string]string{"component-name": name}
+		return &tenancyv1alpha1.StatefulSetSvcBundle{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			StatefulSet: &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec: appsv1.StatefulSetSpec{
+					Replicas:    &replicas,
+					ServiceName: name,
+					Selector:    &metav1.LabelSelector{MatchLabels: labels},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: labels},
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: name, Image: image, Args: args}},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	etcd := bundle("etcd", "virtualcluster/etcd-v3.4.0", "--data-dir=/var/lib/etcd/data")
+	etcd.Service = &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "etcd"},
+		Spec:       corev1.ServiceSpec{ClusterIP: corev1.ClusterIPNone, Selector: map[string]string{"component-name": "etcd"}},
+	}
+	apiserver := bundle("apiserver", "k8s.gcr.io/kube-apiserver:v1.22.13", "--secure-port=6443")
+	apiserver.Service = &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "apiserver-svc"},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeNodePort,
+			Selector: map[string]string{"component-name": "apiserver"},
+			Ports:    []corev1.ServicePort{{Name: "api", Port: 6443, TargetPort: intstr.FromString("api")}},
+		},
+	}
+	controllerManager := bundle("controller-manager", "k8s.gcr.io/kube-controller-manager:v1.22.13", "--leader-elect=false")
+
+	return &tenancyv1alpha1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "cv-conformance"},
+		Spec: tenancyv1alpha1.ClusterVersionSpec{
+			ETCD:              etcd,
+			APIServer:         apiserver,
+			ControllerManager: controllerManager,
+		},
+	}
+}
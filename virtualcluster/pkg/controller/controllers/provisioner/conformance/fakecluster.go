@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+// NewFakeCluster returns a client of a fake super cluster holding the objects. The applied
+// objects replace the existing ones, as the fake client doesn't support server-side apply,
+// and the StatefulSets are ready as soon as they are written.
+func NewFakeCluster(objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = tenancyv1alpha1.AddToScheme(scheme)
+	return &fakeCluster{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()}
+}
+
+type fakeCluster struct {
+	client.Client
+}
+
+func (f *fakeCluster) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	markReady(obj)
+	return f.Client.Create(ctx, obj, opts...)
+}
+
+func (f *fakeCluster) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	markReady(obj)
+	return f.Client.Update(ctx, obj, opts...)
+}
+
+func (f *fakeCluster) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return f.Client.Patch(ctx, obj, patch, opts...)
+	}
+	existing := obj.DeepCopyObject().(client.Object)
+	err := f.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	switch {
+	case apierrors.IsNotFound(err):
+		obj.SetResourceVersion("")
+		return f.Create(ctx, obj)
+	case err != nil:
+		return err
+	}
+	obj.SetResourceVersion(existing.GetResourceVersion())
+	return f.Update(ctx, obj)
+}
+
+// markReady sets the status of a StatefulSet as if all its replicas are rolled out and ready.
+func markReady(obj client.Object) {
+	sts, ok := obj.(*appsv1.StatefulSet)
+	if !ok {
+		return
+	}
+	replicas := int32(1)
+	if sts.Spec.Replicas == nil {
+		sts.Spec.Replicas = &replicas
+	}
+	sts.Status.ObservedGeneration = sts.Generation
+	sts.Status.Replicas = *sts.Spec.Replicas
+	sts.Status.ReadyReplicas = *sts.Spec.Replicas
+	sts.Status.UpdatedReplicas = *sts.Spec.Replicas
+	sts.Status.CurrentRevision = sts.Status.UpdateRevision
+}
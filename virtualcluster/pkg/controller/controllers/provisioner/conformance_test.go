@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner_test
+
+import (
+	"context"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers/provisioner"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers/provisioner/conformance"
+)
+
+func TestNativeConformance(t *testing.T) {
+	conformance.Suite{
+		NewProvisioner: func(t *testing.T, cli client.Client) provisioner.Provisioner {
+			return provisioner.NewTestNative(cli)
+		},
+	}.Run(t)
+}
+
+func TestExternalConformance(t *testing.T) {
+	conformance.Suite{
+		NewProvisioner: func(t *testing.T, cli client.Client) provisioner.Provisioner {
+			tenantClient := fake.NewSimpleClientset()
+			tenantClient.PrependReactor("create", "selfsubjectaccessreviews", func(action core.Action) (bool, runtime.Object, error) {
+				review := action.(core.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+				review.Status.Allowed = true
+				return true, review, nil
+			})
+			return provisioner.NewTestExternal(cli, tenantClient)
+		},
+		PrepareVirtualCluster: func(t *testing.T, cli client.Client, vc *tenancyv1alpha1.VirtualCluster) {
+			srt := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "external-kubeconfig", Namespace: vc.Namespace},
+				Data:       map[string][]byte{provisioner.DefaultExternalKubeconfigSecretKey: []byte("apiVersion: v1\nkind: Config\n")},
+			}
+			if err := cli.Create(context.TODO(), srt); err != nil {
+				t.Fatalf("fail to create kubeconfig secret: %v", err)
+			}
+			vc.Spec.ExternalControlPlane = &tenancyv1alpha1.ExternalControlPlane{KubeconfigSecretName: srt.Name}
+		},
+	}.Run(t)
+}
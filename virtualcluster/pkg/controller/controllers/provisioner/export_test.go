@@ -0,0 +1,52 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NewTestNative creates a Native provisioner with the client for the external tests, the
+// tenant apiserver is always ready.
+func NewTestNative(cli client.Client) *Native {
+	return &Native{
+		Client:             cli,
+		Log:                logr.Discard(),
+		ProvisionerTimeout: time.Second,
+		tenantReadyz: func(_ context.Context, _ string) error {
+			return nil
+		},
+	}
+}
+
+// NewTestExternal creates an External provisioner with the client for the external tests, the
+// external control plane is accessed with the tenant client.
+func NewTestExternal(cli client.Client, tenantClient kubernetes.Interface) *External {
+	return &External{
+		Client:             cli,
+		Log:                logr.Discard(),
+		ProvisionerTimeout: time.Second,
+		newTenantClient: func(_ []byte) (kubernetes.Interface, error) {
+			return tenantClient, nil
+		},
+	}
+}
@@ -18,6 +18,8 @@ package provisioner
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
 )
@@ -28,4 +30,32 @@ type Provisioner interface {
 	GetProvisioner() string
 	// UpgradeVirtualCluster is used to apply current clusterversion if featuregate.VirtualClusterApplyUpdate enabled
 	UpgradeVirtualCluster(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) error
+	// RollbackVirtualCluster is used to re-apply the clusterversion applied before the last upgrade
+	RollbackVirtualCluster(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) error
+}
+
+// UpgradeRolledBackError is returned by UpgradeVirtualCluster when the upgrade failed and
+// the control plane is rolled back to the previously applied clusterversion.
+type UpgradeRolledBackError struct {
+	ClusterVersion string
+	Err            error
+}
+
+func (e *UpgradeRolledBackError) Error() string {
+	return fmt.Sprintf("%v, rolled back to ClusterVersion %s", e.Err, e.ClusterVersion)
+}
+
+func (e *UpgradeRolledBackError) Unwrap() error {
+	return e.Err
+}
+
+// DeletionInProgressError is returned by DeleteVirtualCluster when the deletion of the control
+// plane is issued but not finished yet, the deletion is expected to be retried after RequeueAfter.
+type DeletionInProgressError struct {
+	Namespace    string
+	RequeueAfter time.Duration
+}
+
+func (e *DeletionInProgressError) Error() string {
+	return fmt.Sprintf("root namespace %s is being deleted", e.Namespace)
 }
@@ -0,0 +1,306 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	kubeutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/kube"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+// DefaultExternalKubeconfigSecretKey is the key of the kubeconfig in the Secret referred by
+// ExternalControlPlane if KubeconfigSecretKey is not set.
+const DefaultExternalKubeconfigSecretKey = tenancyv1alpha1.DefaultExternalKubeconfigSecretKey
+
+// externalRequiredPermissions are the permissions the syncer needs on the tenant control plane.
+var externalRequiredPermissions = []struct {
+	group     string
+	resources []string
+	verbs     []string
+}{
+	{"", []string{"namespaces", "pods", "services", "endpoints", "configmaps", "secrets", "serviceaccounts", "persistentvolumeclaims"},
+		[]string{"get", "list", "watch", "create", "update", "delete"}},
+	{"", []string{"nodes", "persistentvolumes"}, []string{"get", "list", "watch", "create", "update", "delete"}},
+	{"", []string{"pods/status", "nodes/status", "services/status", "persistentvolumeclaims/status", "persistentvolumes/status"},
+		[]string{"update"}},
+	{"", []string{"events"}, []string{"create", "update"}},
+	{"storage.k8s.io", []string{"storageclasses"}, []string{"get", "list", "watch", "create", "update", "delete"}},
+	{"scheduling.k8s.io", []string{"priorityclasses"}, []string{"get", "list", "watch", "create", "update", "delete"}},
+	{"apiextensions.k8s.io", []string{"customresourcedefinitions"}, []string{"get", "list", "watch"}},
+}
+
+// External attaches an existing apiserver, e.g. EKS, GKE or kind, as the tenant control plane
+// of the VirtualClusters. The kubeconfig of the apiserver is read from the Secret referred by
+// vc.Spec.ExternalControlPlane, and is copied to the admin kubeconfig Secret the syncer
+// connects with once the apiserver is reachable and the kubeconfig grants the permissions the
+// syncer needs. The control plane is never modified by the provisioner.
+type External struct {
+	client.Client
+	Log                logr.Logger
+	ProvisionerTimeout time.Duration
+	// newTenantClient creates the client of the tenant control plane from the kubeconfig
+	newTenantClient func(kubeconfig []byte) (kubernetes.Interface, error)
+}
+
+func NewProvisionerExternal(mgr manager.Manager, log logr.Logger, provisionerTimeout time.Duration) (*External, error) {
+	mpe := &External{
+		Client:             mgr.GetClient(),
+		Log:                log.WithName("External"),
+		ProvisionerTimeout: provisionerTimeout,
+	}
+	mpe.newTenantClient = mpe.newTenantClientFromKubeconfig
+	return mpe, nil
+}
+
+// newTenantClientFromKubeconfig creates the client of the tenant control plane, each request
+// times out after the provisioner timeout so that an unresponsive apiserver doesn't block
+// the reconciling.
+func (mpe *External) newTenantClientFromKubeconfig(kubeconfig []byte) (kubernetes.Interface, error) {
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	restConfig.Timeout = mpe.ProvisionerTimeout
+	return kubernetes.NewForConfig(restConfig)
+}
+
+// CreateVirtualCluster validates the external control plane of vc and stores its kubeconfig
+// as the admin kubeconfig in the root namespace.
+func (mpe *External) CreateVirtualCluster(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) error {
+	kubeconfig, err := mpe.validateControlPlane(ctx, vc)
+	if err != nil {
+		return err
+	}
+	ns, err := kubeutil.CreateRootNS(mpe, vc)
+	if err != nil {
+		return err
+	}
+	if err := mpe.createOrUpdateAdminKubeconfig(ctx, ns, kubeconfig); err != nil {
+		return err
+	}
+	return mpe.labelClusterVersion(ctx, vc)
+}
+
+// UpgradeVirtualCluster re-validates the external control plane of vc and refreshes the admin
+// kubeconfig, e.g. after the credentials in the referred Secret are rotated.
+func (mpe *External) UpgradeVirtualCluster(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) error {
+	kubeconfig, err := mpe.validateControlPlane(ctx, vc)
+	if err != nil {
+		return err
+	}
+	ns := vc.Status.ClusterNamespace
+	if ns == "" {
+		ns = conversion.ToClusterKey(vc)
+	}
+	if err := mpe.createOrUpdateAdminKubeconfig(ctx, ns, kubeconfig); err != nil {
+		return err
+	}
+	return mpe.labelClusterVersion(ctx, vc)
+}
+
+// RollbackVirtualCluster is not supported as the external control plane is not managed by vc-manager.
+func (mpe *External) RollbackVirtualCluster(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) error {
+	return errors.New("the external control plane is not managed by vc-manager and can not be rolled back")
+}
+
+// DeleteVirtualCluster deletes the root namespace of vc, the external control plane is left untouched.
+func (mpe *External) DeleteVirtualCluster(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) error {
+	ns := vc.Status.ClusterNamespace
+	if ns == "" {
+		ns = conversion.ToClusterKey(vc)
+	}
+	rootNS := &corev1.Namespace{}
+	if err := mpe.Get(ctx, client.ObjectKey{Name: ns}, rootNS); err != nil {
+		if apierrors.IsNotFound(err) {
+			mpe.Log.Info("root namespace is already deleted", "namespace", ns)
+			return nil
+		}
+		return err
+	}
+	// never delete a namespace that is not created for vc
+	if rootNS.Annotations[constants.LabelVCRootNS] != "true" || rootNS.Annotations[constants.LabelVCUID] != string(vc.UID) {
+		mpe.Log.Info("namespace is not the root namespace of the virtualcluster, skip deleting it", "namespace", ns, "vc", vc.GetName())
+		return nil
+	}
+	mpe.Log.Info("deleting root namespace", "namespace", ns)
+	if err := mpe.Delete(ctx, rootNS); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (mpe *External) GetProvisioner() string {
+	return "external"
+}
+
+// validateControlPlane reads the kubeconfig of the external control plane of vc and checks the
+// apiserver is reachable with the permissions the syncer needs. The result is reported in the
+// APIServerReady condition of vc.
+func (mpe *External) validateControlPlane(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) ([]byte, error) {
+	kubeconfig, err := mpe.readKubeconfig(ctx, vc)
+	if err != nil {
+		vc.SetCondition(tenancyv1alpha1.APIServerReady, corev1.ConditionFalse, "InvalidKubeconfig", err.Error())
+		return nil, err
+	}
+	tenantClient, err := mpe.newTenantClient(kubeconfig)
+	if err != nil {
+		err = fmt.Errorf("invalid kubeconfig of the external control plane: %v", err)
+		vc.SetCondition(tenancyv1alpha1.APIServerReady, corev1.ConditionFalse, "InvalidKubeconfig", err.Error())
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, mpe.ProvisionerTimeout)
+	defer cancel()
+	version, err := tenantClient.Discovery().ServerVersion()
+	if err != nil {
+		err = fmt.Errorf("external control plane is unreachable: %v", err)
+		vc.SetCondition(tenancyv1alpha1.APIServerReady, corev1.ConditionFalse, "Unreachable", err.Error())
+		return nil, err
+	}
+	denied, err := deniedPermissions(ctx, tenantClient)
+	if err != nil {
+		err = fmt.Errorf("fail to review the permissions on the external control plane: %v", err)
+		vc.SetCondition(tenancyv1alpha1.APIServerReady, corev1.ConditionFalse, "Unreachable", err.Error())
+		return nil, err
+	}
+	if len(denied) > 0 {
+		err = fmt.Errorf("the kubeconfig of the external control plane is not allowed to %s", strings.Join(denied, ", "))
+		vc.SetCondition(tenancyv1alpha1.APIServerReady, corev1.ConditionFalse, "Forbidden", err.Error())
+		return nil, err
+	}
+	vc.SetCondition(tenancyv1alpha1.APIServerReady, corev1.ConditionTrue, "Connected",
+		fmt.Sprintf("external control plane %s is reachable", version.GitVersion))
+	return kubeconfig, nil
+}
+
+// readKubeconfig reads the kubeconfig from the Secret referred by the external control plane
+// of vc, the Secret is in the namespace of vc.
+func (mpe *External) readKubeconfig(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) ([]byte, error) {
+	ref := vc.Spec.ExternalControlPlane
+	if ref == nil || ref.KubeconfigSecretName == "" {
+		return nil, errors.New("spec.externalControlPlane.kubeconfigSecretName is required by the external provisioner")
+	}
+	key := ref.GetKubeconfigSecretKey()
+	srt := &corev1.Secret{}
+	if err := mpe.Get(ctx, client.ObjectKey{Name: ref.KubeconfigSecretName, Namespace: vc.Namespace}, srt); err != nil {
+		return nil, fmt.Errorf("fail to get the kubeconfig Secret %s/%s: %v", vc.Namespace, ref.KubeconfigSecretName, err)
+	}
+	kubeconfig, exists := srt.Data[key]
+	if !exists || len(kubeconfig) == 0 {
+		return nil, fmt.Errorf("kubeconfig Secret %s/%s has no key %s", vc.Namespace, ref.KubeconfigSecretName, key)
+	}
+	// the Secret may be changed after the VirtualCluster is admitted
+	if err := tenancyv1alpha1.ValidateExternalKubeconfig(kubeconfig); err != nil {
+		return nil, fmt.Errorf("kubeconfig Secret %s/%s is rejected: %v", vc.Namespace, ref.KubeconfigSecretName, err)
+	}
+	return kubeconfig, nil
+}
+
+// deniedPermissions returns the permissions the syncer needs that are not granted to the tenant client.
+func deniedPermissions(ctx context.Context, tenantClient kubernetes.Interface) ([]string, error) {
+	var denied []string
+	for _, p := range externalRequiredPermissions {
+		for _, resource := range p.resources {
+			resource, subresource := resource, ""
+			if i := strings.Index(resource, "/"); i >= 0 {
+				resource, subresource = resource[:i], resource[i+1:]
+			}
+			for _, verb := range p.verbs {
+				review := &authorizationv1.SelfSubjectAccessReview{
+					Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+						ResourceAttributes: &authorizationv1.ResourceAttributes{
+							Verb:        verb,
+							Group:       p.group,
+							Resource:    resource,
+							Subresource: subresource,
+						},
+					},
+				}
+				review, err := tenantClient.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+				if err != nil {
+					return nil, err
+				}
+				if !review.Status.Allowed {
+					denied = append(denied, fmt.Sprintf("%s %s", verb, qualifiedResource(p.group, resource, subresource)))
+				}
+			}
+		}
+	}
+	return denied, nil
+}
+
+func qualifiedResource(group, resource, subresource string) string {
+	if subresource != "" {
+		resource = resource + "/" + subresource
+	}
+	if group == "" {
+		return resource
+	}
+	return resource + "." + group
+}
+
+// createOrUpdateAdminKubeconfig stores the kubeconfig as the admin kubeconfig in the root namespace ns.
+func (mpe *External) createOrUpdateAdminKubeconfig(ctx context.Context, ns string, kubeconfig []byte) error {
+	adminSrt := secret.KubeconfigToSecret(secret.AdminSecretName, ns, string(kubeconfig))
+	err := mpe.Create(ctx, adminSrt)
+	if apierrors.IsAlreadyExists(err) {
+		existing := &corev1.Secret{}
+		if err := mpe.Get(ctx, client.ObjectKey{Name: secret.AdminSecretName, Namespace: ns}, existing); err != nil {
+			return err
+		}
+		existing.Data = adminSrt.Data
+		return mpe.Update(ctx, existing)
+	}
+	return err
+}
+
+// labelClusterVersion labels vc with the spec hash of its ClusterVersion if it exists. The
+// ClusterVersion is not deployed to the external control plane, the label only records
+// that the changes of the ClusterVersion are handled.
+func (mpe *External) labelClusterVersion(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) error {
+	if vc.Spec.ClusterVersionName == "" {
+		return nil
+	}
+	cv := &tenancyv1alpha1.ClusterVersion{}
+	if err := mpe.Get(ctx, client.ObjectKey{Name: vc.Spec.ClusterVersionName}, cv); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	updateLabelClusterVersionApplied(vc, cv)
+	labelClusterVersionSpecHash(vc, cv.SpecHash())
+	return nil
+}
@@ -0,0 +1,330 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	core "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+)
+
+const testKubeconfig = "apiVersion: v1\nkind: Config\n"
+
+// newTestTenantClient creates a tenant client denying the reviewed "verb resource" in denied.
+func newTestTenantClient(denied ...string) *kubefake.Clientset {
+	tenantClient := kubefake.NewSimpleClientset()
+	tenantClient.PrependReactor("create", "selfsubjectaccessreviews", func(action core.Action) (bool, runtime.Object, error) {
+		review := action.(core.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attrs := review.Spec.ResourceAttributes
+		review.Status.Allowed = true
+		for _, d := range denied {
+			if d == attrs.Verb+" "+qualifiedResource(attrs.Group, attrs.Resource, attrs.Subresource) {
+				review.Status.Allowed = false
+			}
+		}
+		return true, review, nil
+	})
+	return tenantClient
+}
+
+func newTestExternal(tenantClient kubernetes.Interface, objs ...client.Object) *External {
+	return &External{
+		Client:             fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs...).Build(),
+		Log:                logr.Discard(),
+		ProvisionerTimeout: time.Second,
+		newTenantClient: func(_ []byte) (kubernetes.Interface, error) {
+			return tenantClient, nil
+		},
+	}
+}
+
+func newTestExternalVC(secretKey string) *tenancyv1alpha1.VirtualCluster {
+	vc := newTestVC()
+	vc.Spec.ExternalControlPlane = &tenancyv1alpha1.ExternalControlPlane{
+		KubeconfigSecretName: "eks-kubeconfig",
+		KubeconfigSecretKey:  secretKey,
+	}
+	return vc
+}
+
+func newTestKubeconfigSecret(key string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "eks-kubeconfig", Namespace: "default"},
+		Data:       map[string][]byte{key: []byte(testKubeconfig)},
+	}
+}
+
+func TestExternalCreateVirtualCluster(t *testing.T) {
+	tests := []struct {
+		name         string
+		vc           *tenancyv1alpha1.VirtualCluster
+		objs         []client.Object
+		denied       []string
+		expectReason string
+		expectErr    string
+	}{
+		{
+			name:         "no external control plane",
+			vc:           newTestVC(),
+			expectReason: "InvalidKubeconfig",
+			expectErr:    "kubeconfigSecretName is required",
+		},
+		{
+			name:         "missing secret",
+			vc:           newTestExternalVC(""),
+			expectReason: "InvalidKubeconfig",
+			expectErr:    "fail to get the kubeconfig Secret default/eks-kubeconfig",
+		},
+		{
+			name:         "missing key",
+			vc:           newTestExternalVC("admin.conf"),
+			objs:         []client.Object{newTestKubeconfigSecret(DefaultExternalKubeconfigSecretKey)},
+			expectReason: "InvalidKubeconfig",
+			expectErr:    "has no key admin.conf",
+		},
+		{
+			name:         "forbidden",
+			vc:           newTestExternalVC(""),
+			objs:         []client.Object{newTestKubeconfigSecret(DefaultExternalKubeconfigSecretKey)},
+			denied:       []string{"update pods/status", "watch storageclasses.storage.k8s.io"},
+			expectReason: "Forbidden",
+			expectErr:    "not allowed to update pods/status, watch storageclasses.storage.k8s.io",
+		},
+		{
+			name:         "connected",
+			vc:           newTestExternalVC(""),
+			objs:         []client.Object{newTestKubeconfigSecret(DefaultExternalKubeconfigSecretKey)},
+			expectReason: "Connected",
+		},
+		{
+			name:         "connected with secret key",
+			vc:           newTestExternalVC("admin.conf"),
+			objs:         []client.Object{newTestKubeconfigSecret("admin.conf")},
+			expectReason: "Connected",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mpe := newTestExternal(newTestTenantClient(tt.denied...), tt.objs...)
+			err := mpe.CreateVirtualCluster(context.TODO(), tt.vc)
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Errorf("expected error %q, got %v", tt.expectErr, err)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			cond := tt.vc.GetCondition(tenancyv1alpha1.APIServerReady)
+			if cond == nil || cond.Reason != tt.expectReason {
+				t.Errorf("expected APIServerReady condition with reason %s, got %+v", tt.expectReason, cond)
+			}
+
+			adminSrt := &corev1.Secret{}
+			err = mpe.Get(context.TODO(), client.ObjectKey{Name: secret.AdminSecretName, Namespace: "default-abcdef-vc"}, adminSrt)
+			if tt.expectErr != "" {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("expected no admin kubeconfig, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected the admin kubeconfig: %v", err)
+			}
+			if string(adminSrt.Data[secret.AdminSecretName]) != testKubeconfig {
+				t.Errorf("expected the admin kubeconfig to be copied, got %q", adminSrt.Data[secret.AdminSecretName])
+			}
+		})
+	}
+}
+
+func TestExternalRejectKubeconfigCredentials(t *testing.T) {
+	const cluster = `apiVersion: v1
+kind: Config
+clusters:
+- name: eks
+  cluster:
+    server: https://eks.example.com
+%s
+users:
+- name: admin
+  user:
+%s
+`
+	tests := map[string]struct {
+		cluster string
+		user    string
+		errs    string
+	}{
+		"certificate-authority": {
+			cluster: "    certificate-authority: /etc/kubernetes/pki/ca.crt",
+			errs:    "cluster eks: certificate-authority is not allowed",
+		},
+		"client-certificate": {
+			user: "    client-certificate: /var/run/secrets/tls.crt",
+			errs: "user admin: client-certificate is not allowed",
+		},
+		"client-key": {
+			user: "    client-key: /var/run/secrets/tls.key",
+			errs: "user admin: client-key is not allowed",
+		},
+		"tokenFile": {
+			user: "    tokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token",
+			errs: "user admin: tokenFile is not allowed",
+		},
+		"username and password": {
+			user: "    username: admin\n    password: secret",
+			errs: "user admin: username and password are not allowed",
+		},
+		"auth-provider": {
+			user: "    auth-provider:\n      name: gcp",
+			errs: "user admin: auth-provider is not allowed",
+		},
+		"exec": {
+			user: "    exec:\n      apiVersion: client.authentication.k8s.io/v1beta1\n      command: sh",
+			errs: "user admin: exec is not allowed",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			kubeconfigSrt := newTestKubeconfigSecret(DefaultExternalKubeconfigSecretKey)
+			kubeconfigSrt.Data[DefaultExternalKubeconfigSecretKey] = []byte(fmt.Sprintf(cluster, tc.cluster, tc.user))
+			vc := newTestExternalVC("")
+			mpe := newTestExternal(newTestTenantClient(), kubeconfigSrt)
+			mpe.newTenantClient = func(_ []byte) (kubernetes.Interface, error) {
+				t.Fatalf("expected the kubeconfig to be rejected before it is loaded")
+				return nil, nil
+			}
+			err := mpe.CreateVirtualCluster(context.TODO(), vc)
+			if err == nil || !strings.Contains(err.Error(), tc.errs) {
+				t.Errorf("expected error %q, got %v", tc.errs, err)
+			}
+			cond := vc.GetCondition(tenancyv1alpha1.APIServerReady)
+			if cond == nil || cond.Reason != "InvalidKubeconfig" {
+				t.Errorf("expected APIServerReady condition with reason InvalidKubeconfig, got %+v", cond)
+			}
+		})
+	}
+
+	// inline credentials are accepted
+	kubeconfigSrt := newTestKubeconfigSecret(DefaultExternalKubeconfigSecretKey)
+	kubeconfigSrt.Data[DefaultExternalKubeconfigSecretKey] = []byte(fmt.Sprintf(cluster,
+		"    certificate-authority-data: Y2E=", "    client-certificate-data: Y3J0\n    client-key-data: a2V5\n    token: abc"))
+	if err := newTestExternal(newTestTenantClient(), kubeconfigSrt).CreateVirtualCluster(context.TODO(), newTestExternalVC("")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestExternalUnresponsiveControlPlane(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	// unblock the handlers before the server is closed
+	defer close(done)
+
+	kubeconfigSrt := newTestKubeconfigSecret(DefaultExternalKubeconfigSecretKey)
+	kubeconfigSrt.Data[DefaultExternalKubeconfigSecretKey] = []byte(fmt.Sprintf(
+		"apiVersion: v1\nkind: Config\nclusters:\n- name: eks\n  cluster:\n    server: %s\n"+
+			"contexts:\n- name: eks\n  context:\n    cluster: eks\ncurrent-context: eks\n", server.URL))
+	mpe := newTestExternal(nil, kubeconfigSrt)
+	mpe.ProvisionerTimeout = 100 * time.Millisecond
+	mpe.newTenantClient = mpe.newTenantClientFromKubeconfig
+
+	vc := newTestExternalVC("")
+	errCh := make(chan error, 1)
+	go func() { errCh <- mpe.CreateVirtualCluster(context.TODO(), vc) }()
+	select {
+	case err := <-errCh:
+		if err == nil || !strings.Contains(err.Error(), "external control plane is unreachable") {
+			t.Errorf("expected the control plane to be unreachable, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("expected the validation to time out")
+	}
+	if cond := vc.GetCondition(tenancyv1alpha1.APIServerReady); cond == nil || cond.Reason != "Unreachable" {
+		t.Errorf("expected APIServerReady condition with reason Unreachable, got %+v", cond)
+	}
+}
+
+func TestExternalUpgradeVirtualCluster(t *testing.T) {
+	vc := newTestExternalVC("")
+	kubeconfigSrt := newTestKubeconfigSecret(DefaultExternalKubeconfigSecretKey)
+	mpe := newTestExternal(newTestTenantClient(), kubeconfigSrt)
+	if err := mpe.CreateVirtualCluster(context.TODO(), vc); err != nil {
+		t.Fatalf("fail to create virtualcluster: %v", err)
+	}
+
+	// the rotated credentials are copied to the admin kubeconfig
+	rotated := "apiVersion: v1\nkind: Config\ncurrent-context: rotated\n"
+	kubeconfigSrt.Data[DefaultExternalKubeconfigSecretKey] = []byte(rotated)
+	if err := mpe.Update(context.TODO(), kubeconfigSrt); err != nil {
+		t.Fatalf("fail to update kubeconfig secret: %v", err)
+	}
+	if err := mpe.UpgradeVirtualCluster(context.TODO(), vc); err != nil {
+		t.Fatalf("fail to upgrade virtualcluster: %v", err)
+	}
+	adminSrt := &corev1.Secret{}
+	if err := mpe.Get(context.TODO(), client.ObjectKey{Name: secret.AdminSecretName, Namespace: vc.Status.ClusterNamespace}, adminSrt); err != nil {
+		t.Fatalf("expected the admin kubeconfig: %v", err)
+	}
+	if string(adminSrt.Data[secret.AdminSecretName]) != rotated {
+		t.Errorf("expected the admin kubeconfig to be refreshed, got %q", adminSrt.Data[secret.AdminSecretName])
+	}
+
+	if err := mpe.RollbackVirtualCluster(context.TODO(), vc); err == nil {
+		t.Errorf("expected the rollback not to be supported")
+	}
+}
+
+func TestExternalDeleteVirtualCluster(t *testing.T) {
+	vc := newTestExternalVC("")
+	kubeconfigSrt := newTestKubeconfigSecret(DefaultExternalKubeconfigSecretKey)
+	mpe := newTestExternal(newTestTenantClient(), newTestRootNS(vc), kubeconfigSrt)
+
+	if err := mpe.DeleteVirtualCluster(context.TODO(), vc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := mpe.Get(context.TODO(), client.ObjectKey{Name: vc.Status.ClusterNamespace}, &corev1.Namespace{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected root namespace to be deleted, got %v", err)
+	}
+	// the kubeconfig of the external control plane is owned by the user
+	if err := mpe.Get(context.TODO(), client.ObjectKeyFromObject(kubeconfigSrt), &corev1.Secret{}); err != nil {
+		t.Errorf("expected the kubeconfig secret to be kept: %v", err)
+	}
+}
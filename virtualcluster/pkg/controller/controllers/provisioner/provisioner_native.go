@@ -18,16 +18,17 @@ package provisioner
 
 import (
 	"context"
-	"crypto/rsa"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/client-go/util/cert"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
@@ -48,6 +49,24 @@ const (
 )
 
 var (
+	// componentConditions are the conditions reporting the readiness of the control plane components.
+	componentConditions = map[string]tenancyv1alpha1.ClusterConditionType{
+		"etcd":               tenancyv1alpha1.EtcdReady,
+		"apiserver":          tenancyv1alpha1.APIServerReady,
+		"controller-manager": tenancyv1alpha1.ControllerManagerReady,
+	}
+
+	// pkiSecretNames are the secrets created by createOrUpdatePKISecrets.
+	pkiSecretNames = []string{
+		secret.RootCASecretName,
+		secret.APIServerCASecretName,
+		secret.ETCDCASecretName,
+		secret.FrontProxyCASecretName,
+		secret.ControllerManagerSecretName,
+		secret.AdminSecretName,
+		secret.ServiceAccountSecretName,
+	}
+
 	definitelyTrue = true
 	patchOptions   = &client.PatchOptions{Force: &definitelyTrue, FieldManager: "virtualcluster/provisioner/native"}
 )
@@ -57,15 +76,21 @@ type Native struct {
 	scheme             *runtime.Scheme
 	Log                logr.Logger
 	ProvisionerTimeout time.Duration
+	// AuditShipper is the sidecar added to the apiservers shipping their audit log
+	AuditShipper AuditShipperConfig
+	// tenantReadyz checks the readiness of the tenant apiserver in the given root namespace
+	tenantReadyz func(ctx context.Context, namespace string) error
 }
 
 func NewProvisionerNative(mgr manager.Manager, log logr.Logger, provisionerTimeout time.Duration) (*Native, error) {
-	return &Native{
+	mpn := &Native{
 		Client:             mgr.GetClient(),
 		scheme:             mgr.GetScheme(),
 		Log:                log.WithName("Native"),
 		ProvisionerTimeout: provisionerTimeout,
-	}, nil
+	}
+	mpn.tenantReadyz = mpn.checkTenantReadyz
+	return mpn, nil
 }
 
 func updateLabelClusterVersionApplied(vc *tenancyv1alpha1.VirtualCluster, cv *tenancyv1alpha1.ClusterVersion) {
@@ -73,8 +98,16 @@ func updateLabelClusterVersionApplied(vc *tenancyv1alpha1.VirtualCluster, cv *te
 		if vc.Labels == nil {
 			vc.Labels = map[string]string{}
 		}
-		vc.Labels[constants.LabelClusterVersionApplied] = cv.ObjectMeta.ResourceVersion
+		vc.Labels[constants.LabelClusterVersionApplied] = cv.AppliedVersion()
+	}
+}
+
+// labelClusterVersionSpecHash labels vc with the hash of the ClusterVersion spec applied to its control plane
+func labelClusterVersionSpecHash(vc *tenancyv1alpha1.VirtualCluster, specHash string) {
+	if vc.Labels == nil {
+		vc.Labels = map[string]string{}
 	}
+	vc.Labels[constants.LabelClusterVersionSpecHash] = specHash
 }
 
 // CreateVirtualCluster sets up the control plane for vc on meta k8s
@@ -83,15 +116,29 @@ func (mpn *Native) CreateVirtualCluster(ctx context.Context, vc *tenancyv1alpha1
 	if err != nil {
 		return err
 	}
+	rendered, err := renderClusterVersion(cv, vc)
+	if err != nil {
+		return err
+	}
 
 	updateLabelClusterVersionApplied(vc, cv)
 
 	// 1. create the root ns
-	_, err = kubeutil.CreateRootNS(mpn, vc)
+	ns, err := kubeutil.CreateRootNS(mpn, vc)
 	if err != nil {
 		return err
 	}
-	return mpn.applyVirtualCluster(ctx, cv, vc, true)
+	// the templates of the rendered cv are complemented while being applied
+	applied := newAppliedClusterVersion(cv, vc.Spec.Parameters)
+	specHash := cv.SpecHash()
+	if err := mpn.applyVirtualCluster(ctx, rendered, vc, true); err != nil {
+		return err
+	}
+	labelClusterVersionSpecHash(vc, specHash)
+	if err := mpn.saveClusterVersionHistory(ctx, ns, &clusterVersionHistory{Current: applied}); err != nil {
+		mpn.Log.Error(err, "fail to record the applied ClusterVersion", "vc", vc.GetName())
+	}
+	return nil
 }
 
 func (mpn *Native) fetchClusterVersion(vc *tenancyv1alpha1.VirtualCluster) (*tenancyv1alpha1.ClusterVersion, error) {
@@ -105,38 +152,8 @@ func (mpn *Native) fetchClusterVersion(vc *tenancyv1alpha1.VirtualCluster) (*ten
 	return cv, nil
 }
 
-func (mpn *Native) UpgradeVirtualCluster(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) error {
-	cv, err := mpn.fetchClusterVersion(vc)
-	if err != nil {
-		return err
-	}
-	if cvVersion, ok := vc.Labels[constants.LabelClusterVersionApplied]; ok && cvVersion == cv.ObjectMeta.ResourceVersion {
-		mpn.Log.Info("cluster is already in desired version")
-		return nil
-	}
-	updateLabelClusterVersionApplied(vc, cv)
-
-	// We currently do not support ETCD upgrades because of amount of manual actions required
-	// The easiest way to achieve it - pass empty ETCD definition to the ClusterVersion
-	return mpn.applyVirtualCluster(ctx, cv, vc, false)
-}
-
 func (mpn *Native) applyVirtualCluster(ctx context.Context, cv *tenancyv1alpha1.ClusterVersion, vc *tenancyv1alpha1.VirtualCluster, applyETCD bool) error {
-	var err error
-	isClusterIP := cv.Spec.APIServer.Service != nil && cv.Spec.APIServer.Service.Spec.Type == corev1.ServiceTypeClusterIP
-	// if ClusterIP, have to update API Server ahead of time to lay it down in the PKI
-	if isClusterIP {
-		mpn.Log.Info("applying ClusterIP Service for API component", "component", cv.Spec.APIServer.Name)
-		complementAPIServerTemplate(conversion.ToClusterKey(vc), cv.Spec.APIServer, nil)
-		err := mpn.Patch(ctx, cv.Spec.APIServer.Service, client.Apply, patchOptions)
-		if err != nil {
-			mpn.Log.Error(err, "failed to update service", "service", cv.Spec.APIServer.Service.GetName())
-			return err
-		}
-	}
-
-	// 2. apply PKI
-	clusterCAGroup, err := mpn.createAndApplyPKI(ctx, vc, cv, isClusterIP)
+	clusterCAGroup, err := mpn.applyPKI(ctx, cv, vc)
 	if err != nil {
 		return err
 	}
@@ -165,6 +182,31 @@ func (mpn *Native) applyVirtualCluster(ctx context.Context, cv *tenancyv1alpha1.
 	return nil
 }
 
+// applyPKI applies the PKI of the control plane, the ClusterIP Service of the apiserver
+// is applied ahead if any.
+func (mpn *Native) applyPKI(ctx context.Context, cv *tenancyv1alpha1.ClusterVersion, vc *tenancyv1alpha1.VirtualCluster) (*vcpki.ClusterCAGroup, error) {
+	isClusterIP := cv.Spec.APIServer.Service != nil && cv.Spec.APIServer.Service.Spec.Type == corev1.ServiceTypeClusterIP
+	// if ClusterIP, have to update API Server ahead of time to lay it down in the PKI
+	if isClusterIP {
+		mpn.Log.Info("applying ClusterIP Service for API component", "component", cv.Spec.APIServer.Name)
+		complementAPIServerTemplate(conversion.ToClusterKey(vc), cv.Spec.APIServer, nil)
+		err := mpn.Patch(ctx, cv.Spec.APIServer.Service, client.Apply, patchOptions)
+		if err != nil {
+			mpn.Log.Error(err, "failed to update service", "service", cv.Spec.APIServer.Service.GetName())
+			return nil, err
+		}
+	}
+
+	// 2. apply PKI
+	clusterCAGroup, err := mpn.createAndApplyPKI(ctx, vc, cv, isClusterIP)
+	if err != nil {
+		vc.SetCondition(tenancyv1alpha1.PKIReady, corev1.ConditionFalse, "PKIFailed", err.Error())
+		return nil, err
+	}
+	vc.SetCondition(tenancyv1alpha1.PKIReady, corev1.ConditionTrue, "PKICreated", "certificates and kubeconfigs are created")
+	return clusterCAGroup, nil
+}
+
 // genInitialClusterArgs generates the values for `--initial-cluster` option of etcd based on the number of
 // replicas specified in etcd StatefulSet
 func genInitialClusterArgs(replicas int32, stsName, svcName string) (argsVal string) {
@@ -255,6 +297,18 @@ func complementCtrlMgrTemplate(vcns string, ctrlMgrBdl *tenancyv1alpha1.Stateful
 // and Service Bundle ssBdl
 // the method also adds annotations with certificates hashes to trigger pod recreation if certificates were changed
 func (mpn *Native) deployComponent(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, ssBdl *tenancyv1alpha1.StatefulSetSvcBundle, clusterCAGroup *vcpki.ClusterCAGroup) error {
+	err := mpn.applyComponent(ctx, vc, ssBdl, clusterCAGroup)
+	if conditionType, ok := componentConditions[ssBdl.Name]; ok {
+		if err != nil {
+			vc.SetCondition(conditionType, corev1.ConditionFalse, "ComponentNotReady", err.Error())
+		} else {
+			vc.SetCondition(conditionType, corev1.ConditionTrue, "ComponentReady", fmt.Sprintf("statefulset %s is ready", ssBdl.Name))
+		}
+	}
+	return err
+}
+
+func (mpn *Native) applyComponent(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, ssBdl *tenancyv1alpha1.StatefulSetSvcBundle, clusterCAGroup *vcpki.ClusterCAGroup) error {
 	mpn.Log.Info("deploying StatefulSet for control plane component", "component", ssBdl.Name)
 
 	ns := conversion.ToClusterKey(vc)
@@ -264,6 +318,9 @@ func (mpn *Native) deployComponent(ctx context.Context, vc *tenancyv1alpha1.Virt
 		complementETCDTemplate(ns, ssBdl)
 	case "apiserver":
 		complementAPIServerTemplate(ns, ssBdl, clusterCAGroup)
+		if err := mpn.applyAudit(ctx, vc, ssBdl); err != nil {
+			return err
+		}
 	case "controller-manager":
 		complementCtrlMgrTemplate(ns, ssBdl, clusterCAGroup)
 	default:
@@ -297,6 +354,10 @@ func (mpn *Native) deployComponent(ctx context.Context, vc *tenancyv1alpha1.Virt
 func (mpn *Native) createOrUpdatePKISecrets(ctx context.Context, caGroup *vcpki.ClusterCAGroup, namespace string) error {
 	// create secret for root crt/key pair
 	rootSrt := secret.CrtKeyPairToSecret(secret.RootCASecretName, namespace, caGroup.RootCA)
+	if len(caGroup.RootCABundle) > 0 {
+		// keep trusting the previous root CA until the rotation of the root CA completes
+		rootSrt.Data[corev1.TLSCertKey] = caGroup.RootCABundle
+	}
 	// create secret for apiserver crt/key pair
 	apiserverSrt := secret.CrtKeyPairToSecret(secret.APIServerCASecretName,
 		namespace, caGroup.APIServer)
@@ -361,27 +422,15 @@ func (mpn *Native) createAndApplyPKI(ctx context.Context, vc *tenancyv1alpha1.Vi
 			Crt: rootCACrt,
 			Key: rootCAKey,
 		}
+		caGroup.RootCABundle = rootCaSecret.Data[corev1.TLSCertKey]
 		mpn.Log.Info("rootCA pair is reused from the secret")
 	case apierrors.IsNotFound(err):
 		mpn.Log.Info("rootCA secret is not found. Creating")
-		rootCACrt, rootKey, rootCAErr := pkiutil.NewCertificateAuthority(
-			&pkiutil.CertConfig{
-				Config: cert.Config{
-					CommonName:   "kubernetes",
-					Organization: []string{"kubernetes-sig.kubernetes-sigs/multi-tenancy.virtualcluster"},
-				},
-			})
+		var rootCAErr error
+		rootCAPair, rootCAErr = vcpki.NewRootCrtAndKey()
 		if rootCAErr != nil {
 			return nil, rootCAErr
 		}
-		rootRsaKey, ok := rootKey.(*rsa.PrivateKey)
-		if !ok {
-			return nil, errors.New("fail to assert rsa PrivateKey")
-		}
-		rootCAPair = &vcpki.CrtKeyPair{
-			Crt: rootCACrt,
-			Key: rootRsaKey,
-		}
 		mpn.Log.Info("rootCA pair generated")
 	default:
 		mpn.Log.Error(err, "failed to check rootCA secret existence")
@@ -464,10 +513,135 @@ func (mpn *Native) createAndApplyPKI(ctx context.Context, vc *tenancyv1alpha1.Vi
 	return caGroup, nil
 }
 
-func (mpn *Native) DeleteVirtualCluster(_ context.Context, _ *tenancyv1alpha1.VirtualCluster) error {
+// DeleteVirtualCluster tears down the control plane of vc on meta k8s. The components,
+// the PKI secrets and the root namespace are deleted without waiting for them to be gone.
+// While the root namespace is terminating a DeletionInProgressError is returned so that
+// the deletion is retried later. Once the root namespace has been terminating longer than
+// the provisioner timeout, what blocks the deletion is reported in the
+// ControlPlaneProvisioned condition of vc and an error is returned.
+func (mpn *Native) DeleteVirtualCluster(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) error {
+	ns := vc.Status.ClusterNamespace
+	if ns == "" {
+		ns = conversion.ToClusterKey(vc)
+	}
+
+	rootNS := &corev1.Namespace{}
+	if err := mpn.Get(ctx, client.ObjectKey{Name: ns}, rootNS); err != nil {
+		if apierrors.IsNotFound(err) {
+			mpn.Log.Info("root namespace is already deleted", "namespace", ns)
+			return nil
+		}
+		return err
+	}
+	// never delete a namespace that is not created for vc
+	if rootNS.Annotations[constants.LabelVCRootNS] != "true" || rootNS.Annotations[constants.LabelVCUID] != string(vc.UID) {
+		mpn.Log.Info("namespace is not the root namespace of the virtualcluster, skip deleting it", "namespace", ns, "vc", vc.GetName())
+		return nil
+	}
+	vc.SetCondition(tenancyv1alpha1.ControlPlaneProvisioned, corev1.ConditionFalse, "Deleting", "tenant control plane is being deleted")
+
+	if rootNS.DeletionTimestamp.IsZero() {
+		if err := mpn.deleteControlPlane(ctx, ns); err != nil {
+			return err
+		}
+		mpn.Log.Info("deleting root namespace", "namespace", ns)
+		if err := mpn.Delete(ctx, rootNS); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	if err := mpn.Get(ctx, client.ObjectKey{Name: ns}, rootNS); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if rootNS.DeletionTimestamp.IsZero() || time.Since(rootNS.DeletionTimestamp.Time) < mpn.ProvisionerTimeout {
+		return &DeletionInProgressError{Namespace: ns, RequeueAfter: ComponentPollPeriodSec * time.Second}
+	}
+	blockers := mpn.deletionBlockers(ctx, rootNS)
+	msg := fmt.Sprintf("root namespace %s is not deleted in %v", ns, mpn.ProvisionerTimeout)
+	if len(blockers) > 0 {
+		msg = fmt.Sprintf("%s, blocked by: %s", msg, strings.Join(blockers, "; "))
+	}
+	vc.SetCondition(tenancyv1alpha1.ControlPlaneProvisioned, corev1.ConditionFalse, "DeletionBlocked", msg)
+	return errors.New(msg)
+}
+
+// deleteControlPlane deletes the statefulsets, the services and the PKI secrets of the
+// control plane in namespace ns. The components are deleted in the reverse order of
+// their deployment so that etcd goes last.
+func (mpn *Native) deleteControlPlane(ctx context.Context, ns string) error {
+	for _, name := range []string{"controller-manager", "apiserver", "etcd"} {
+		sts := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns}}
+		if err := mpn.Delete(ctx, sts); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		mpn.Log.Info("deleted StatefulSet for control plane component", "component", name)
+	}
+
+	// the services are named by the ClusterVersion which may be gone, the root
+	// namespace only holds the services of the control plane.
+	svcs := &corev1.ServiceList{}
+	if err := mpn.List(ctx, svcs, client.InNamespace(ns)); err != nil {
+		return err
+	}
+	for i := range svcs.Items {
+		if err := mpn.Delete(ctx, &svcs.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		mpn.Log.Info("deleted Service for control plane component", "service", svcs.Items[i].GetName())
+	}
+
+	for _, name := range pkiSecretNames {
+		srt := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns}}
+		if err := mpn.Delete(ctx, srt); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	mpn.Log.Info("deleted PKI secrets", "namespace", ns)
 	return nil
 }
 
+// deletionBlockers describes what keeps the root namespace rootNS from being deleted, i.e.
+// the finalizers and the deletion conditions of the namespace, and the pods and the
+// persistentvolumeclaims left in it.
+func (mpn *Native) deletionBlockers(ctx context.Context, rootNS *corev1.Namespace) []string {
+	var blockers []string
+	ns := rootNS.GetName()
+	if len(rootNS.Finalizers) > 0 {
+		blockers = append(blockers, fmt.Sprintf("namespace %s has finalizers %v", ns, rootNS.Finalizers))
+	}
+	for _, c := range rootNS.Status.Conditions {
+		if c.Status == corev1.ConditionTrue {
+			blockers = append(blockers, fmt.Sprintf("%s: %s", c.Type, c.Message))
+		}
+	}
+
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	if err := mpn.List(ctx, pvcs, client.InNamespace(ns)); err == nil {
+		for _, pvc := range pvcs.Items {
+			if len(pvc.Finalizers) > 0 {
+				blockers = append(blockers, fmt.Sprintf("persistentvolumeclaim %s has finalizers %v", pvc.Name, pvc.Finalizers))
+			} else {
+				blockers = append(blockers, fmt.Sprintf("persistentvolumeclaim %s is still %s", pvc.Name, pvc.Status.Phase))
+			}
+		}
+	}
+
+	pods := &corev1.PodList{}
+	if err := mpn.List(ctx, pods, client.InNamespace(ns)); err == nil {
+		for _, pod := range pods.Items {
+			if len(pod.Finalizers) > 0 {
+				blockers = append(blockers, fmt.Sprintf("pod %s has finalizers %v", pod.Name, pod.Finalizers))
+			} else {
+				blockers = append(blockers, fmt.Sprintf("pod %s is still %s", pod.Name, pod.Status.Phase))
+			}
+		}
+	}
+	return blockers
+}
+
 func (mpn *Native) GetProvisioner() string {
 	return "native"
 }
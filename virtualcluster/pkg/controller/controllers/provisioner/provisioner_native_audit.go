@@ -0,0 +1,204 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+)
+
+const (
+	// AuditPolicyConfigMapName is the ConfigMap the audit policy is copied to in the root namespace
+	AuditPolicyConfigMapName = "audit-policy"
+	// AuditWebhookSecretName is the Secret the audit webhook kubeconfig is copied to in the root namespace
+	AuditWebhookSecretName = "audit-webhook-kubeconfig" // #nosec G101 -- This is the name of a secret
+
+	auditPolicyDir            = "/etc/kubernetes/audit"
+	auditWebhookDir           = "/etc/kubernetes/audit-webhook"
+	auditLogDir               = "/var/log/kubernetes/audit"
+	auditLogFileName          = "audit.log"
+	auditLogVolumeName        = "audit-log"
+	auditShipperContainerName = "audit-shipper"
+)
+
+// AuditShipperConfig configures the sidecar forwarding the audit log of the tenant apiservers
+// to the audit sink of the super cluster.
+type AuditShipperConfig struct {
+	// Image is the image of the audit-shipper, the sidecar is not added if it is empty
+	Image string
+	// SinkURL is the URL the audit events are posted to
+	SinkURL string
+}
+
+func (c AuditShipperConfig) enabled() bool {
+	return c.Image != "" && c.SinkURL != ""
+}
+
+// applyAudit copies the audit policy and the webhook kubeconfig of the VirtualCluster to the
+// root namespace, and complements the apiserver template to mount them.
+func (mpn *Native) applyAudit(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, apiserverBdl *tenancyv1alpha1.StatefulSetSvcBundle) error {
+	audit := vc.Spec.Audit
+	if audit == nil {
+		return nil
+	}
+	ns := apiserverBdl.StatefulSet.Namespace
+
+	policy := &corev1.ConfigMap{}
+	if err := mpn.Get(ctx, client.ObjectKey{Namespace: vc.Namespace, Name: audit.PolicyConfigMapName}, policy); err != nil {
+		return fmt.Errorf("fail to get audit policy %s/%s: %v", vc.Namespace, audit.PolicyConfigMapName, err)
+	}
+	policyData, ok := policy.Data[tenancyv1alpha1.AuditPolicyKey]
+	if !ok {
+		return fmt.Errorf("audit policy %s/%s has no %s", vc.Namespace, audit.PolicyConfigMapName, tenancyv1alpha1.AuditPolicyKey)
+	}
+	if err := mpn.Patch(ctx, &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: corev1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: AuditPolicyConfigMapName, Namespace: ns},
+		Data:       map[string]string{tenancyv1alpha1.AuditPolicyKey: policyData},
+	}, client.Apply, patchOptions); err != nil {
+		return err
+	}
+	hashes := map[string]string{AuditPolicyConfigMapName + "-hash": secret.GetHash(policyData)}
+
+	if wh := audit.Webhook; wh != nil {
+		whSrt := &corev1.Secret{}
+		if err := mpn.Get(ctx, client.ObjectKey{Namespace: vc.Namespace, Name: wh.KubeconfigSecretName}, whSrt); err != nil {
+			return fmt.Errorf("fail to get audit webhook kubeconfig %s/%s: %v", vc.Namespace, wh.KubeconfigSecretName, err)
+		}
+		kubeconfig, ok := whSrt.Data[tenancyv1alpha1.AuditWebhookKubeconfigKey]
+		if !ok {
+			return fmt.Errorf("audit webhook secret %s/%s has no %s", vc.Namespace, wh.KubeconfigSecretName, tenancyv1alpha1.AuditWebhookKubeconfigKey)
+		}
+		if err := mpn.Patch(ctx, &corev1.Secret{
+			TypeMeta:   metav1.TypeMeta{Kind: "Secret", APIVersion: corev1.SchemeGroupVersion.String()},
+			ObjectMeta: metav1.ObjectMeta{Name: AuditWebhookSecretName, Namespace: ns},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{tenancyv1alpha1.AuditWebhookKubeconfigKey: kubeconfig},
+		}, client.Apply, patchOptions); err != nil {
+			return err
+		}
+		hashes[AuditWebhookSecretName+"-hash"] = secret.GetHash(kubeconfig)
+	}
+
+	complementAPIServerAuditTemplate(vc, apiserverBdl, hashes, mpn.AuditShipper)
+	return nil
+}
+
+// complementAPIServerAuditTemplate mounts the audit policy and backends into the apiserver,
+// the hashes of the copied policy and kubeconfig restart the apiserver once they change.
+func complementAPIServerAuditTemplate(vc *tenancyv1alpha1.VirtualCluster, apiserverBdl *tenancyv1alpha1.StatefulSetSvcBundle, hashes map[string]string, shipper AuditShipperConfig) {
+	audit := vc.Spec.Audit
+	podSpec := &apiserverBdl.StatefulSet.Spec.Template.Spec
+	if len(podSpec.Containers) == 0 {
+		return
+	}
+	apiserver := &podSpec.Containers[0]
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == apiserverBdl.Name {
+			apiserver = &podSpec.Containers[i]
+		}
+	}
+
+	annotations := apiserverBdl.StatefulSet.Spec.Template.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	for k, v := range hashes {
+		annotations[k] = v
+	}
+	apiserverBdl.StatefulSet.Spec.Template.SetAnnotations(annotations)
+
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: AuditPolicyConfigMapName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: AuditPolicyConfigMapName},
+			},
+		},
+	})
+	apiserver.VolumeMounts = append(apiserver.VolumeMounts, corev1.VolumeMount{
+		Name:      AuditPolicyConfigMapName,
+		MountPath: auditPolicyDir,
+		ReadOnly:  true,
+	})
+	apiserver.Args = append(apiserver.Args,
+		"--audit-policy-file="+filepath.Join(auditPolicyDir, tenancyv1alpha1.AuditPolicyKey))
+
+	if log := audit.Log; log != nil {
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name:         auditLogVolumeName,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		})
+		apiserver.VolumeMounts = append(apiserver.VolumeMounts, corev1.VolumeMount{
+			Name:      auditLogVolumeName,
+			MountPath: auditLogDir,
+		})
+		apiserver.Args = append(apiserver.Args,
+			"--audit-log-path="+filepath.Join(auditLogDir, auditLogFileName), "--audit-log-format=json")
+		if log.MaxAge > 0 {
+			apiserver.Args = append(apiserver.Args, fmt.Sprintf("--audit-log-maxage=%d", log.MaxAge))
+		}
+		if log.MaxBackup > 0 {
+			apiserver.Args = append(apiserver.Args, fmt.Sprintf("--audit-log-maxbackup=%d", log.MaxBackup))
+		}
+		if log.MaxSize > 0 {
+			apiserver.Args = append(apiserver.Args, fmt.Sprintf("--audit-log-maxsize=%d", log.MaxSize))
+		}
+	}
+
+	if wh := audit.Webhook; wh != nil {
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: AuditWebhookSecretName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: AuditWebhookSecretName},
+			},
+		})
+		apiserver.VolumeMounts = append(apiserver.VolumeMounts, corev1.VolumeMount{
+			Name:      AuditWebhookSecretName,
+			MountPath: auditWebhookDir,
+			ReadOnly:  true,
+		})
+		apiserver.Args = append(apiserver.Args,
+			"--audit-webhook-config-file="+filepath.Join(auditWebhookDir, tenancyv1alpha1.AuditWebhookKubeconfigKey),
+			"--audit-webhook-mode="+wh.GetMode())
+	}
+
+	// the shipper is appended last as appending a container moves the apiserver container
+	if log := audit.Log; log != nil && log.Ship && shipper.enabled() {
+		podSpec.Containers = append(podSpec.Containers, corev1.Container{
+			Name:    auditShipperContainerName,
+			Image:   shipper.Image,
+			Command: []string{"audit-shipper"},
+			Args: []string{
+				"--audit-log-path=" + filepath.Join(auditLogDir, auditLogFileName),
+				"--sink-url=" + shipper.SinkURL,
+				"--cluster=" + apiserverBdl.StatefulSet.Namespace,
+				"--vc-namespace=" + vc.Namespace,
+				"--vc-name=" + vc.Name,
+			},
+			VolumeMounts: []corev1.VolumeMount{{Name: auditLogVolumeName, MountPath: auditLogDir, ReadOnly: true}},
+		})
+	}
+}
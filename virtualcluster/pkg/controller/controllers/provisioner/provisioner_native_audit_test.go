@@ -0,0 +1,168 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+func newTestAuditAPIServerBundle(ns string) *tenancyv1alpha1.StatefulSetSvcBundle {
+	apiserverBdl := newTestClusterVersion("cv", "1").Spec.APIServer
+	apiserverBdl.StatefulSet.Namespace = ns
+	apiserverBdl.StatefulSet.Spec.Template.Spec.Containers = []corev1.Container{{
+		Name:    "apiserver",
+		Command: []string{"kube-apiserver"},
+		Args:    []string{"--bind-address=0.0.0.0"},
+	}}
+	return apiserverBdl
+}
+
+func hasVolume(podSpec *corev1.PodSpec, name string) bool {
+	for _, v := range podSpec.Volumes {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestComplementAPIServerAuditTemplate(t *testing.T) {
+	shipper := AuditShipperConfig{Image: "audit-shipper:latest", SinkURL: "http://audit-sink.vc-manager"}
+	tests := map[string]struct {
+		audit      *tenancyv1alpha1.AuditConfig
+		shipper    AuditShipperConfig
+		args       []string
+		volumes    []string
+		containers int
+	}{
+		"log": {
+			audit: &tenancyv1alpha1.AuditConfig{
+				PolicyConfigMapName: "audit-policy",
+				Log:                 &tenancyv1alpha1.AuditLogBackend{MaxAge: 7, MaxSize: 100},
+			},
+			args: []string{
+				"--audit-policy-file=/etc/kubernetes/audit/policy.yaml",
+				"--audit-log-path=/var/log/kubernetes/audit/audit.log",
+				"--audit-log-maxage=7",
+				"--audit-log-maxsize=100",
+			},
+			volumes:    []string{AuditPolicyConfigMapName, auditLogVolumeName},
+			containers: 1,
+		},
+		"webhook": {
+			audit: &tenancyv1alpha1.AuditConfig{
+				PolicyConfigMapName: "audit-policy",
+				Webhook:             &tenancyv1alpha1.AuditWebhookBackend{KubeconfigSecretName: "audit-sink"},
+			},
+			args: []string{
+				"--audit-webhook-config-file=/etc/kubernetes/audit-webhook/kubeconfig",
+				"--audit-webhook-mode=batch",
+			},
+			volumes:    []string{AuditPolicyConfigMapName, AuditWebhookSecretName},
+			containers: 1,
+		},
+		"shipped log": {
+			audit: &tenancyv1alpha1.AuditConfig{
+				PolicyConfigMapName: "audit-policy",
+				Log:                 &tenancyv1alpha1.AuditLogBackend{Ship: true},
+				Webhook:             &tenancyv1alpha1.AuditWebhookBackend{KubeconfigSecretName: "audit-sink"},
+			},
+			shipper:    shipper,
+			args:       []string{"--audit-log-path=/var/log/kubernetes/audit/audit.log", "--audit-webhook-mode=batch"},
+			volumes:    []string{auditLogVolumeName, AuditWebhookSecretName},
+			containers: 2,
+		},
+		"shipper not configured": {
+			audit: &tenancyv1alpha1.AuditConfig{
+				PolicyConfigMapName: "audit-policy",
+				Log:                 &tenancyv1alpha1.AuditLogBackend{Ship: true},
+			},
+			containers: 1,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			vc := newTestVC()
+			vc.Spec.Audit = tc.audit
+			apiserverBdl := newTestAuditAPIServerBundle(vc.Status.ClusterNamespace)
+			complementAPIServerAuditTemplate(vc, apiserverBdl, map[string]string{"audit-policy-hash": "1234"}, tc.shipper)
+
+			template := apiserverBdl.StatefulSet.Spec.Template
+			if template.Annotations["audit-policy-hash"] != "1234" {
+				t.Errorf("expected the audit policy hash to be annotated, got %v", template.Annotations)
+			}
+			if len(template.Spec.Containers) != tc.containers {
+				t.Fatalf("expected %d containers, got %d", tc.containers, len(template.Spec.Containers))
+			}
+			apiserver := template.Spec.Containers[0]
+			args := strings.Join(apiserver.Args, " ")
+			for _, arg := range tc.args {
+				if !strings.Contains(args, arg) {
+					t.Errorf("expected arg %s in %s", arg, args)
+				}
+			}
+			for _, volume := range tc.volumes {
+				if !hasVolume(&template.Spec, volume) {
+					t.Errorf("expected volume %s", volume)
+				}
+			}
+			if len(apiserver.VolumeMounts) != len(template.Spec.Volumes) {
+				t.Errorf("expected all the audit volumes to be mounted into the apiserver, got %+v", apiserver.VolumeMounts)
+			}
+
+			if tc.containers > 1 {
+				sidecar := template.Spec.Containers[1]
+				if sidecar.Name != auditShipperContainerName || sidecar.Image != shipper.Image {
+					t.Errorf("unexpected shipper container %+v", sidecar)
+				}
+				sidecarArgs := strings.Join(sidecar.Args, " ")
+				for _, arg := range []string{"--sink-url=" + shipper.SinkURL, "--cluster=" + vc.Status.ClusterNamespace, "--vc-name=" + vc.Name} {
+					if !strings.Contains(sidecarArgs, arg) {
+						t.Errorf("expected arg %s in %s", arg, sidecarArgs)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestApplyAuditMissingPolicy(t *testing.T) {
+	vc := newTestVC()
+	apiserverBdl := newTestAuditAPIServerBundle(vc.Status.ClusterNamespace)
+	mpn := newTestNative()
+	if err := mpn.applyAudit(context.TODO(), vc, apiserverBdl); err != nil {
+		t.Fatalf("expected no error without audit, got %v", err)
+	}
+
+	vc.Spec.Audit = &tenancyv1alpha1.AuditConfig{
+		PolicyConfigMapName: "audit-policy",
+		Log:                 &tenancyv1alpha1.AuditLogBackend{},
+	}
+	if err := mpn.applyAudit(context.TODO(), vc, apiserverBdl); err == nil || !strings.Contains(err.Error(), "audit policy") {
+		t.Errorf("expected an error getting the audit policy, got %v", err)
+	}
+	if len(apiserverBdl.StatefulSet.Spec.Template.Spec.Volumes) != 0 {
+		t.Errorf("expected the apiserver template not to be changed")
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+func newTestVC() *tenancyv1alpha1.VirtualCluster {
+	return &tenancyv1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "vc", Namespace: "default", UID: "7374a172-c35d-45b1-9c8e-bf5c5b614937"},
+		Status:     tenancyv1alpha1.VirtualClusterStatus{ClusterNamespace: "default-abcdef-vc"},
+	}
+}
+
+func newTestRootNS(vc *tenancyv1alpha1.VirtualCluster, finalizers ...string) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: vc.Status.ClusterNamespace,
+			Annotations: map[string]string{
+				constants.LabelVCRootNS: "true",
+				constants.LabelVCUID:    string(vc.UID),
+			},
+			Finalizers: finalizers,
+		},
+	}
+}
+
+func newTestNative(objs ...client.Object) *Native {
+	return &Native{
+		Client:             fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs...).Build(),
+		Log:                logr.Discard(),
+		ProvisionerTimeout: 10 * time.Millisecond,
+	}
+}
+
+func TestDeleteVirtualCluster(t *testing.T) {
+	vc := newTestVC()
+	ns := vc.Status.ClusterNamespace
+	objs := []client.Object{newTestRootNS(vc)}
+	for _, name := range []string{"etcd", "apiserver", "controller-manager"} {
+		objs = append(objs,
+			&appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns}},
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name + "-svc", Namespace: ns}})
+	}
+	for _, name := range pkiSecretNames {
+		objs = append(objs, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns}})
+	}
+	mpn := newTestNative(objs...)
+
+	if err := mpn.DeleteVirtualCluster(context.TODO(), vc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, obj := range objs {
+		err := mpn.Get(context.TODO(), client.ObjectKeyFromObject(obj), obj)
+		if !apierrors.IsNotFound(err) {
+			t.Errorf("expected %T %s to be deleted, got %v", obj, obj.GetName(), err)
+		}
+	}
+}
+
+func TestDeleteVirtualClusterBlocked(t *testing.T) {
+	vc := newTestVC()
+	ns := vc.Status.ClusterNamespace
+	pvcs := []client.Object{
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+			Name:       "data-etcd-0",
+			Namespace:  ns,
+			Finalizers: []string{"kubernetes.io/pvc-protection"},
+		}},
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data-etcd-1", Namespace: ns},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+		},
+	}
+	mpn := newTestNative(append(pvcs, newTestRootNS(vc, "example.com/protect"))...)
+	mpn.ProvisionerTimeout = time.Hour
+
+	// the deletion is issued without waiting for the root namespace to be gone
+	err := mpn.DeleteVirtualCluster(context.TODO(), vc)
+	var inProgressErr *DeletionInProgressError
+	if !errors.As(err, &inProgressErr) {
+		t.Fatalf("expected a DeletionInProgressError, got %v", err)
+	}
+	if inProgressErr.RequeueAfter <= 0 {
+		t.Errorf("expected the deletion to be requeued, got %v", inProgressErr.RequeueAfter)
+	}
+	if cond := vc.GetCondition(tenancyv1alpha1.ControlPlaneProvisioned); cond == nil || cond.Reason != "Deleting" {
+		t.Fatalf("expected Deleting condition, got %+v", cond)
+	}
+
+	// the blockers are reported once the root namespace is terminating longer than the timeout
+	mpn.ProvisionerTimeout = 0
+	err = mpn.DeleteVirtualCluster(context.TODO(), vc)
+	if err == nil || errors.As(err, &inProgressErr) {
+		t.Fatalf("expected the deletion to be blocked, got %v", err)
+	}
+	cond := vc.GetCondition(tenancyv1alpha1.ControlPlaneProvisioned)
+	if cond == nil || cond.Reason != "DeletionBlocked" {
+		t.Fatalf("expected DeletionBlocked condition, got %+v", cond)
+	}
+	for _, blocker := range []string{
+		"example.com/protect",
+		"persistentvolumeclaim data-etcd-0 has finalizers [kubernetes.io/pvc-protection]",
+		"persistentvolumeclaim data-etcd-1 is still Pending",
+	} {
+		if !strings.Contains(cond.Message, blocker) {
+			t.Errorf("expected %q to be reported in %q", blocker, cond.Message)
+		}
+	}
+}
+
+func TestDeleteVirtualClusterForeignNamespace(t *testing.T) {
+	vc := newTestVC()
+	rootNS := newTestRootNS(vc)
+	rootNS.Annotations[constants.LabelVCUID] = "another-uid"
+	srt := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secret.RootCASecretName, Namespace: rootNS.Name}}
+	mpn := newTestNative(rootNS, srt)
+
+	if err := mpn.DeleteVirtualCluster(context.TODO(), vc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, obj := range []client.Object{rootNS, srt} {
+		if err := mpn.Get(context.TODO(), client.ObjectKeyFromObject(obj), obj); err != nil {
+			t.Errorf("expected %T %s to be kept, got %v", obj, obj.GetName(), err)
+		}
+	}
+}
+
+func TestDeleteVirtualClusterNamespaceGone(t *testing.T) {
+	mpn := newTestNative()
+	if err := mpn.DeleteVirtualCluster(context.TODO(), newTestVC()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
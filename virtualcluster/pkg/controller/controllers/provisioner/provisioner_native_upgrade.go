@@ -0,0 +1,324 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	kubeutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/kube"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	// clusterVersionHistoryName is the ConfigMap in the root namespace recording the
+	// ClusterVersions applied to the control plane.
+	clusterVersionHistoryName = "clusterversion-history"
+	currentClusterVersionKey  = "current"
+	previousClusterVersionKey = "previous"
+)
+
+// appliedClusterVersion is a ClusterVersion as it was applied to the control plane, with
+// the parameter values set on the VirtualCluster.
+type appliedClusterVersion struct {
+	Name            string                             `json:"name"`
+	ResourceVersion string                             `json:"resourceVersion"`
+	Spec            tenancyv1alpha1.ClusterVersionSpec `json:"spec"`
+	Parameters      map[string]string                  `json:"parameters,omitempty"`
+}
+
+func newAppliedClusterVersion(cv *tenancyv1alpha1.ClusterVersion, parameters map[string]string) *appliedClusterVersion {
+	applied := &appliedClusterVersion{
+		Name:            cv.Name,
+		ResourceVersion: cv.ResourceVersion,
+		Spec:            *cv.Spec.DeepCopy(),
+	}
+	if len(parameters) != 0 {
+		applied.Parameters = make(map[string]string, len(parameters))
+		for name, value := range parameters {
+			applied.Parameters[name] = value
+		}
+	}
+	return applied
+}
+
+// clusterVersion returns a ClusterVersion of the recorded spec, it can be complemented
+// without altering the record.
+func (a *appliedClusterVersion) clusterVersion() *tenancyv1alpha1.ClusterVersion {
+	return &tenancyv1alpha1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: a.Name, ResourceVersion: a.ResourceVersion},
+		Spec:       *a.Spec.DeepCopy(),
+	}
+}
+
+// rendered returns the recorded ClusterVersion rendered with the recorded parameter values.
+func (a *appliedClusterVersion) rendered() (*tenancyv1alpha1.ClusterVersion, error) {
+	return renderBundles(a.clusterVersion(), a.Parameters)
+}
+
+// parametersApplied returns true if the parameter values of vc are the ones applied with
+// the current ClusterVersion, they are assumed to be if the history isn't recorded.
+func (h *clusterVersionHistory) parametersApplied(vc *tenancyv1alpha1.VirtualCluster) bool {
+	return h.Current == nil || equality.Semantic.DeepEqual(h.Current.Parameters, vc.Spec.Parameters)
+}
+
+// clusterVersionHistory holds the ClusterVersion currently applied to the control plane
+// and the one applied before the last upgrade.
+type clusterVersionHistory struct {
+	Current  *appliedClusterVersion
+	Previous *appliedClusterVersion
+}
+
+// getClusterVersionHistory reads the history in the root namespace ns, the history is
+// empty if the control plane was provisioned before it is recorded.
+func (mpn *Native) getClusterVersionHistory(ctx context.Context, ns string) (*clusterVersionHistory, error) {
+	history := &clusterVersionHistory{}
+	cm := &corev1.ConfigMap{}
+	if err := mpn.Get(ctx, client.ObjectKey{Name: clusterVersionHistoryName, Namespace: ns}, cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return history, nil
+		}
+		return nil, err
+	}
+	for key, applied := range map[string]**appliedClusterVersion{
+		currentClusterVersionKey:  &history.Current,
+		previousClusterVersionKey: &history.Previous,
+	} {
+		data, exists := cm.Data[key]
+		if !exists {
+			continue
+		}
+		if err := json.Unmarshal([]byte(data), applied); err != nil {
+			return nil, fmt.Errorf("fail to decode %s ClusterVersion of %s/%s: %v", key, ns, clusterVersionHistoryName, err)
+		}
+	}
+	return history, nil
+}
+
+// saveClusterVersionHistory records the history in the root namespace ns.
+func (mpn *Native) saveClusterVersionHistory(ctx context.Context, ns string, history *clusterVersionHistory) error {
+	data := map[string]string{}
+	for key, applied := range map[string]*appliedClusterVersion{
+		currentClusterVersionKey:  history.Current,
+		previousClusterVersionKey: history.Previous,
+	} {
+		if applied == nil {
+			continue
+		}
+		raw, err := json.Marshal(applied)
+		if err != nil {
+			return err
+		}
+		data[key] = string(raw)
+	}
+
+	cm := &corev1.ConfigMap{}
+	err := mpn.Get(ctx, client.ObjectKey{Name: clusterVersionHistoryName, Namespace: ns}, cm)
+	switch {
+	case apierrors.IsNotFound(err):
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: clusterVersionHistoryName, Namespace: ns},
+			Data:       data,
+		}
+		return mpn.Create(ctx, cm)
+	case err != nil:
+		return err
+	}
+	cm.Data = data
+	return mpn.Update(ctx, cm)
+}
+
+// UpgradeVirtualCluster rolls the control plane of vc to its ClusterVersion. The apiserver and
+// then the controller-manager are rolled, the tenant apiserver has to be ready after each of
+// them. If the upgrade fails and vc is annotated with LabelVCUpgradeAutoRollback, the control
+// plane is rolled back to the ClusterVersion it was running and an UpgradeRolledBackError is returned.
+func (mpn *Native) UpgradeVirtualCluster(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) error {
+	cv, err := mpn.fetchClusterVersion(vc)
+	if err != nil {
+		return err
+	}
+	ns := conversion.ToClusterKey(vc)
+	history, err := mpn.getClusterVersionHistory(ctx, ns)
+	if err != nil {
+		return err
+	}
+	specHash := cv.SpecHash()
+	if history.parametersApplied(vc) {
+		if cvVersion, ok := vc.Labels[constants.LabelClusterVersionApplied]; ok && cvVersion == cv.AppliedVersion() {
+			mpn.Log.Info("cluster is already in desired version")
+			return nil
+		}
+		if vc.Labels[constants.LabelClusterVersionSpecHash] == specHash {
+			mpn.Log.Info("cluster is already in desired version", "specHash", specHash)
+			return nil
+		}
+	}
+	rendered, err := renderClusterVersion(cv, vc)
+	if err != nil {
+		return err
+	}
+	target := newAppliedClusterVersion(cv, vc.Spec.Parameters)
+
+	// We currently do not support ETCD upgrades because of amount of manual actions required
+	// The easiest way to achieve it - pass empty ETCD definition to the ClusterVersion
+	if err := mpn.rollVirtualCluster(ctx, rendered, vc, "Upgrading"); err != nil {
+		if vc.Annotations[constants.LabelVCUpgradeAutoRollback] != "true" || history.Current == nil {
+			return err
+		}
+		mpn.Log.Error(err, "fail to upgrade, rolling back", "vc", vc.GetName(), "clusterversion", history.Current.Name)
+		current, renderErr := history.Current.rendered()
+		if renderErr != nil {
+			return fmt.Errorf("%v, and fail to render ClusterVersion %s to roll back: %v", err, history.Current.Name, renderErr)
+		}
+		if rollbackErr := mpn.rollVirtualCluster(ctx, current, vc, "RollingBack"); rollbackErr != nil {
+			return fmt.Errorf("%v, and fail to roll back to ClusterVersion %s: %v", err, history.Current.Name, rollbackErr)
+		}
+		return &UpgradeRolledBackError{ClusterVersion: history.Current.Name, Err: err}
+	}
+	updateLabelClusterVersionApplied(vc, cv)
+	labelClusterVersionSpecHash(vc, specHash)
+
+	history.Previous, history.Current = history.Current, target
+	if err := mpn.saveClusterVersionHistory(ctx, ns, history); err != nil {
+		mpn.Log.Error(err, "fail to record the applied ClusterVersion", "vc", vc.GetName())
+	}
+	return nil
+}
+
+// RollbackVirtualCluster rolls the control plane of vc back to the ClusterVersion applied
+// before the last upgrade, vc is set to that ClusterVersion and the parameter values it was
+// applied with.
+func (mpn *Native) RollbackVirtualCluster(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) error {
+	ns := conversion.ToClusterKey(vc)
+	history, err := mpn.getClusterVersionHistory(ctx, ns)
+	if err != nil {
+		return err
+	}
+	if history.Previous == nil {
+		return fmt.Errorf("no ClusterVersion is recorded before the last upgrade of virtualcluster %s", vc.GetName())
+	}
+
+	previous := history.Previous.clusterVersion()
+	specHash := previous.SpecHash()
+	rendered, err := history.Previous.rendered()
+	if err != nil {
+		return err
+	}
+	if err := mpn.rollVirtualCluster(ctx, rendered, vc, "RollingBack"); err != nil {
+		return err
+	}
+	vc.Spec.ClusterVersionName = previous.Name
+	vc.Spec.Parameters = history.Previous.Parameters
+	updateLabelClusterVersionApplied(vc, previous)
+	labelClusterVersionSpecHash(vc, specHash)
+
+	history.Previous, history.Current = history.Current, history.Previous
+	if err := mpn.saveClusterVersionHistory(ctx, ns, history); err != nil {
+		mpn.Log.Error(err, "fail to record the applied ClusterVersion", "vc", vc.GetName())
+	}
+	return nil
+}
+
+// rollVirtualCluster re-applies the PKI, then rolls the apiserver and the controller-manager
+// of cv one after the other, waiting for each to be rolled out to its new revision. It stops
+// at the first component after which the tenant apiserver is not ready. The progress is
+// reported in the UpgradeInProgress condition of vc with the given reason.
+func (mpn *Native) rollVirtualCluster(ctx context.Context, cv *tenancyv1alpha1.ClusterVersion, vc *tenancyv1alpha1.VirtualCluster, reason string) error {
+	clusterCAGroup, err := mpn.applyPKI(ctx, cv, vc)
+	if err != nil {
+		return err
+	}
+
+	components := []*tenancyv1alpha1.StatefulSetSvcBundle{cv.Spec.APIServer}
+	if cv.Spec.ControllerManager != nil {
+		components = append(components, cv.Spec.ControllerManager)
+	}
+	for _, component := range components {
+		mpn.reportUpgradeProgress(ctx, vc, reason, fmt.Sprintf("rolling %s to ClusterVersion %s", component.Name, cv.Name))
+		if err := mpn.deployComponent(ctx, vc, component, clusterCAGroup); err != nil {
+			return fmt.Errorf("fail to roll %s: %v", component.Name, err)
+		}
+		// the ready replicas may still run the previous revision right after the update
+		if err := kubeutil.WaitStatefulSetRolledOut(mpn, conversion.ToClusterKey(vc), component.Name, int64(mpn.ProvisionerTimeout/time.Second), ComponentPollPeriodSec); err != nil {
+			return fmt.Errorf("fail to roll %s: %v", component.Name, err)
+		}
+		if err := mpn.waitTenantReady(ctx, conversion.ToClusterKey(vc)); err != nil {
+			return fmt.Errorf("tenant apiserver is not ready after rolling %s: %v", component.Name, err)
+		}
+	}
+	return nil
+}
+
+// reportUpgradeProgress sets the UpgradeInProgress condition of vc and updates vc, so that
+// the progress is visible while the control plane is rolled.
+func (mpn *Native) reportUpgradeProgress(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, reason, message string) {
+	vc.SetCondition(tenancyv1alpha1.UpgradeInProgress, corev1.ConditionTrue, reason, message)
+	if err := kubeutil.RetryUpdateVCStatusOnConflict(ctx, mpn, vc, mpn.Log); err != nil {
+		mpn.Log.Error(err, "fail to report upgrade progress", "vc", vc.GetName())
+	}
+}
+
+// waitTenantReady waits for the tenant apiserver in the root namespace ns to be ready
+// within the provisioner timeout.
+func (mpn *Native) waitTenantReady(ctx context.Context, ns string) error {
+	readyz := mpn.tenantReadyz
+	if readyz == nil {
+		readyz = mpn.checkTenantReadyz
+	}
+	var lastErr error
+	err := wait.PollImmediate(ComponentPollPeriodSec*time.Second, mpn.ProvisionerTimeout, func() (bool, error) {
+		lastErr = readyz(ctx, ns)
+		return lastErr == nil, nil
+	})
+	if err == wait.ErrWaitTimeout && lastErr != nil {
+		return lastErr
+	}
+	return err
+}
+
+// checkTenantReadyz queries /readyz of the tenant apiserver with the admin kubeconfig
+// stored in the root namespace ns.
+func (mpn *Native) checkTenantReadyz(ctx context.Context, ns string) error {
+	adminSrt := &corev1.Secret{}
+	if err := mpn.Get(ctx, client.ObjectKey{Name: secret.AdminSecretName, Namespace: ns}, adminSrt); err != nil {
+		return err
+	}
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(adminSrt.Data[secret.AdminSecretName])
+	if err != nil {
+		return err
+	}
+	tenantClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	_, err = tenantClient.Discovery().RESTClient().Get().AbsPath("/readyz").DoRaw(ctx)
+	return err
+}
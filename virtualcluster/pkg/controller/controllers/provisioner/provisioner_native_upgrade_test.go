@@ -0,0 +1,191 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	kubeutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/kube"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+)
+
+func newTestClusterVersion(name, resourceVersion string) *tenancyv1alpha1.ClusterVersion {
+	return &tenancyv1alpha1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: name, ResourceVersion: resourceVersion},
+		Spec: tenancyv1alpha1.ClusterVersionSpec{
+			APIServer: &tenancyv1alpha1.StatefulSetSvcBundle{
+				ObjectMeta:  metav1.ObjectMeta{Name: "apiserver"},
+				StatefulSet: &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "apiserver"}},
+				Service:     &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "apiserver-svc"}},
+			},
+		},
+	}
+}
+
+func TestClusterVersionHistory(t *testing.T) {
+	ns := newTestVC().Status.ClusterNamespace
+	mpn := newTestNative()
+
+	history, err := mpn.getClusterVersionHistory(context.TODO(), ns)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if history.Current != nil || history.Previous != nil {
+		t.Fatalf("expected an empty history, got %+v", history)
+	}
+
+	v1 := newAppliedClusterVersion(newTestClusterVersion("cv-v1", "1"), nil)
+	if err := mpn.saveClusterVersionHistory(context.TODO(), ns, &clusterVersionHistory{Current: v1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	v2 := newAppliedClusterVersion(newTestClusterVersion("cv-v2", "2"), nil)
+	if err := mpn.saveClusterVersionHistory(context.TODO(), ns, &clusterVersionHistory{Current: v2, Previous: v1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	history, err = mpn.getClusterVersionHistory(context.TODO(), ns)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if history.Current == nil || history.Current.Name != "cv-v2" || history.Current.ResourceVersion != "2" {
+		t.Errorf("unexpected current ClusterVersion %+v", history.Current)
+	}
+	if history.Previous == nil || history.Previous.Name != "cv-v1" || history.Previous.Spec.APIServer.Name != "apiserver" {
+		t.Errorf("unexpected previous ClusterVersion %+v", history.Previous)
+	}
+}
+
+func TestAppliedClusterVersionIsolated(t *testing.T) {
+	cv := newTestClusterVersion("cv", "1")
+	applied := newAppliedClusterVersion(cv, nil)
+	complementAPIServerTemplate("ns", cv.Spec.APIServer, nil)
+
+	restored := applied.clusterVersion()
+	if restored.Spec.APIServer.StatefulSet.Namespace != "" {
+		t.Errorf("expected the record not to be complemented, got namespace %q", restored.Spec.APIServer.StatefulSet.Namespace)
+	}
+	restored.Spec.APIServer.Name = "changed"
+	if applied.Spec.APIServer.Name != "apiserver" {
+		t.Errorf("expected the record not to be altered by the restored ClusterVersion")
+	}
+}
+
+func TestRollbackVirtualClusterWithoutPrevious(t *testing.T) {
+	vc := newTestVC()
+	mpn := newTestNative()
+	current := newAppliedClusterVersion(newTestClusterVersion("cv", "1"), nil)
+	if err := mpn.saveClusterVersionHistory(context.TODO(), vc.Status.ClusterNamespace, &clusterVersionHistory{Current: current}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := mpn.RollbackVirtualCluster(context.TODO(), vc)
+	if err == nil || !strings.Contains(err.Error(), "no ClusterVersion is recorded") {
+		t.Errorf("expected the rollback to be refused, got %v", err)
+	}
+}
+
+func TestWaitTenantReady(t *testing.T) {
+	mpn := newTestNative()
+	mpn.tenantReadyz = func(_ context.Context, _ string) error {
+		return errors.New("[-]etcd failed")
+	}
+	if err := mpn.waitTenantReady(context.TODO(), "ns"); err == nil || !strings.Contains(err.Error(), "etcd failed") {
+		t.Errorf("expected the readyz error to be returned, got %v", err)
+	}
+
+	mpn.tenantReadyz = func(_ context.Context, _ string) error {
+		return nil
+	}
+	if err := mpn.waitTenantReady(context.TODO(), "ns"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWaitStatefulSetRolledOut(t *testing.T) {
+	ns := newTestVC().Status.ClusterNamespace
+	replicas := int32(1)
+	newStatefulSet := func(name, currentRevision string) *appsv1.StatefulSet {
+		return &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, Generation: 2},
+			Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+			Status: appsv1.StatefulSetStatus{
+				ObservedGeneration: 2,
+				Replicas:           1,
+				ReadyReplicas:      1,
+				UpdatedReplicas:    1,
+				CurrentRevision:    currentRevision,
+				UpdateRevision:     "apiserver-v2",
+			},
+		}
+	}
+	// the replica of the old revision is still ready while the new one is rolled out
+	rolling := newStatefulSet("apiserver", "apiserver-v1")
+	rolling.Status.UpdatedReplicas = 0
+	mpn := newTestNative(rolling, newStatefulSet("controller-manager", "apiserver-v2"))
+	mpn.ProvisionerTimeout = time.Second
+
+	timeout := int64(mpn.ProvisionerTimeout / time.Second)
+	if err := kubeutil.WaitStatefulSetRolledOut(mpn, ns, "apiserver", timeout, ComponentPollPeriodSec); err == nil || !strings.Contains(err.Error(), "not rolled out") {
+		t.Errorf("expected the statefulset on the old revision not to be rolled out, got %v", err)
+	}
+	if err := kubeutil.WaitStatefulSetRolledOut(mpn, ns, "controller-manager", timeout, ComponentPollPeriodSec); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestUpgradeRolledBackError(t *testing.T) {
+	cause := errors.New("apiserver is not ready")
+	var err error = &UpgradeRolledBackError{ClusterVersion: "cv-v1", Err: cause}
+
+	var rolledBackErr *UpgradeRolledBackError
+	if !errors.As(err, &rolledBackErr) || rolledBackErr.ClusterVersion != "cv-v1" {
+		t.Errorf("expected an UpgradeRolledBackError, got %v", err)
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("expected the cause to be wrapped")
+	}
+}
+
+func TestLabelClusterVersionApplied(t *testing.T) {
+	if err := featuregate.DefaultFeatureGate.Set(featuregate.ClusterVersionPartialUpgrade, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() {
+		_ = featuregate.DefaultFeatureGate.Set(featuregate.ClusterVersionPartialUpgrade, false)
+	}()
+
+	cv := newTestClusterVersion("cv", "10")
+	cv.Generation = 2
+	vc := &tenancyv1alpha1.VirtualCluster{}
+	updateLabelClusterVersionApplied(vc, cv)
+
+	// the status updates change the resourceVersion only.
+	cv.ResourceVersion = "11"
+	if applied := vc.Labels[constants.LabelClusterVersionApplied]; applied != cv.AppliedVersion() {
+		t.Errorf("expected the applied version to be the generation 2, got %s", applied)
+	}
+}
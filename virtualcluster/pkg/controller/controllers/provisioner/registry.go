@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
+)
+
+// Registry holds the provisioners vc-manager can run with the --provisioner flag. An
+// out-of-tree provisioner registers itself in the init function of its package, and is
+// built into vc-manager by importing the package. The InitFn of a registration gets the
+// *Config in the Config of the plugin.InitContext and returns a Provisioner.
+var Registry plugin.ResourceRegister
+
+// Config is passed to the provisioners when they are initialized.
+type Config struct {
+	Manager            manager.Manager
+	Log                logr.Logger
+	ProvisionerTimeout time.Duration
+	// AuditShipper configures the audit-shipper sidecar of the native provisioner
+	AuditShipper AuditShipperConfig
+}
+
+func init() {
+	Registry.Register(&plugin.Registration{
+		ID: "native",
+		InitFn: func(ctx *plugin.InitContext) (interface{}, error) {
+			cfg := ctx.Config.(*Config)
+			mpn, err := NewProvisionerNative(cfg.Manager, cfg.Log, cfg.ProvisionerTimeout)
+			if err != nil {
+				return nil, err
+			}
+			mpn.AuditShipper = cfg.AuditShipper
+			return mpn, nil
+		},
+	})
+	Registry.Register(&plugin.Registration{
+		ID: "external",
+		InitFn: func(ctx *plugin.InitContext) (interface{}, error) {
+			cfg := ctx.Config.(*Config)
+			return NewProvisionerExternal(cfg.Manager, cfg.Log, cfg.ProvisionerTimeout)
+		},
+	})
+}
+
+// New initializes the provisioner registered with the name.
+func New(name string, cfg *Config) (Provisioner, error) {
+	r, exists := Registry.Get(name)
+	if !exists || r.Disable {
+		return nil, fmt.Errorf("virtualcluster provisioner %q is not registered, the registered provisioners are %s",
+			name, strings.Join(Registered(), ", "))
+	}
+	instance, err := r.Init(&plugin.InitContext{Context: context.Background(), Config: cfg}).Instance()
+	if err != nil {
+		return nil, fmt.Errorf("fail to initialize virtualcluster provisioner %s: %v", name, err)
+	}
+	p, ok := instance.(Provisioner)
+	if !ok {
+		return nil, fmt.Errorf("virtualcluster provisioner %s is a %T, not a Provisioner", name, instance)
+	}
+	return p, nil
+}
+
+// Registered returns the names of the enabled provisioners.
+func Registered() []string {
+	var names []string
+	for _, r := range Registry.List() {
+		if !r.Disable {
+			names = append(names, r.ID)
+		}
+	}
+	return names
+}
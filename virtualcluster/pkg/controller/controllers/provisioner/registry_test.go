@@ -0,0 +1,64 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
+)
+
+func TestRegistry(t *testing.T) {
+	registered := strings.Join(Registered(), ",")
+	if registered != "external,native" {
+		t.Errorf("expected the in-tree provisioners to be registered, got %s", registered)
+	}
+
+	Registry.Register(&plugin.Registration{
+		ID: "test",
+		InitFn: func(ctx *plugin.InitContext) (interface{}, error) {
+			return newTestNative(), nil
+		},
+	})
+	Registry.Register(&plugin.Registration{
+		ID: "test-invalid",
+		InitFn: func(ctx *plugin.InitContext) (interface{}, error) {
+			return "not a provisioner", nil
+		},
+	})
+	Registry.Register(&plugin.Registration{
+		ID:      "test-disabled",
+		Disable: true,
+		InitFn: func(ctx *plugin.InitContext) (interface{}, error) {
+			return newTestNative(), nil
+		},
+	})
+
+	if p, err := New("test", &Config{}); err != nil || p.GetProvisioner() != "native" {
+		t.Errorf("expected the registered provisioner, got %v, %v", p, err)
+	}
+	if _, err := New("test-invalid", &Config{}); err == nil || !strings.Contains(err.Error(), "not a Provisioner") {
+		t.Errorf("expected the invalid provisioner to be rejected, got %v", err)
+	}
+	if _, err := New("test-disabled", &Config{}); err == nil || !strings.Contains(err.Error(), "is not registered") {
+		t.Errorf("expected the disabled provisioner to be rejected, got %v", err)
+	}
+	if _, err := New("gke", &Config{}); err == nil || !strings.Contains(err.Error(), "the registered provisioners are external, native, test") {
+		t.Errorf("expected the registered provisioners to be listed, got %v", err)
+	}
+}
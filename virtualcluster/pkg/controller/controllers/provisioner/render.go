@@ -0,0 +1,185 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"text/template"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	corev1 "k8s.io/api/core/v1"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+// renderClusterVersion resolves the parameters of cv with the values set on vc and returns
+// a copy of cv whose bundles are rendered with them. The result is reported in the
+// ParametersValid condition of vc.
+func renderClusterVersion(cv *tenancyv1alpha1.ClusterVersion, vc *tenancyv1alpha1.VirtualCluster) (*tenancyv1alpha1.ClusterVersion, error) {
+	rendered, err := renderBundles(cv, vc.Spec.Parameters)
+	if err != nil {
+		vc.SetCondition(tenancyv1alpha1.ParametersValid, corev1.ConditionFalse, "InvalidParameters", err.Error())
+		return nil, err
+	}
+	vc.SetCondition(tenancyv1alpha1.ParametersValid, corev1.ConditionTrue, "ParametersResolved", "")
+	return rendered, nil
+}
+
+// renderBundles returns a copy of cv whose bundles are rendered with the parameter values.
+// The string fields of the StatefulSets and the Services are executed as Go templates,
+// then the patches of each bundle are rendered and applied.
+func renderBundles(cv *tenancyv1alpha1.ClusterVersion, values map[string]string) (*tenancyv1alpha1.ClusterVersion, error) {
+	params, err := cv.ResolveParameters(values)
+	if err != nil {
+		return nil, err
+	}
+	rendered := cv.DeepCopy()
+	for _, bdl := range []*tenancyv1alpha1.StatefulSetSvcBundle{rendered.Spec.ETCD, rendered.Spec.APIServer, rendered.Spec.ControllerManager} {
+		if bdl == nil {
+			continue
+		}
+		if err := renderBundle(bdl, params); err != nil {
+			return nil, fmt.Errorf("fail to render the %s bundle of ClusterVersion %s: %v", bdl.Name, cv.Name, err)
+		}
+	}
+	return rendered, nil
+}
+
+func renderBundle(bdl *tenancyv1alpha1.StatefulSetSvcBundle, params map[string]string) error {
+	if bdl.StatefulSet != nil {
+		if err := renderStrings(reflect.ValueOf(bdl.StatefulSet), "statefulset", params); err != nil {
+			return err
+		}
+	}
+	if bdl.Service != nil {
+		if err := renderStrings(reflect.ValueOf(bdl.Service), "service", params); err != nil {
+			return err
+		}
+	}
+
+	for i, patch := range bdl.Patches {
+		var target interface{}
+		switch patch.Target {
+		case tenancyv1alpha1.BundlePatchStatefulSet:
+			target = bdl.StatefulSet
+		case tenancyv1alpha1.BundlePatchService:
+			target = bdl.Service
+		default:
+			return fmt.Errorf("unknown target %q of patch %d", patch.Target, i)
+		}
+		if reflect.ValueOf(target).IsNil() {
+			return fmt.Errorf("the %s of patch %d is not defined", patch.Target, i)
+		}
+		// the patches are JSON documents, the values are inserted as they are
+		rawPatch, err := executeTemplate(fmt.Sprintf("patch %d", i), []byte(patch.Patch), params)
+		if err != nil {
+			return err
+		}
+		decoded, err := jsonpatch.DecodePatch(rawPatch)
+		if err != nil {
+			return fmt.Errorf("fail to decode patch %d: %v", i, err)
+		}
+		if err := renderJSON(target, func(raw []byte) ([]byte, error) {
+			return decoded.Apply(raw)
+		}); err != nil {
+			return fmt.Errorf("fail to apply patch %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// executeTemplate executes text as a Go template with params, referring to an undefined
+// parameter is an error.
+func executeTemplate(name string, text []byte, params map[string]string) ([]byte, error) {
+	if !bytes.Contains(text, []byte("{{")) {
+		return text, nil
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(text))
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, params); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// renderStrings executes the string fields reachable from v as Go templates with params.
+// The fields are rendered in place before any encoding, so the templates may contain
+// quoted literals and the values are inserted as they are.
+func renderStrings(v reflect.Value, name string, params map[string]string) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return renderStrings(v.Elem(), name, params)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Field(i); field.CanSet() {
+				if err := renderStrings(field, name, params); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := renderStrings(v.Index(i), name, params); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			if err := renderStrings(elem, name, params); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, elem)
+		}
+	case reflect.String:
+		out, err := executeTemplate(name, []byte(v.String()), params)
+		if err != nil {
+			return err
+		}
+		v.SetString(string(out))
+	}
+	return nil
+}
+
+// renderJSON replaces obj, a pointer to a struct, with the result of transforming its
+// JSON encoding.
+func renderJSON(obj interface{}, transform func([]byte) ([]byte, error)) error {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	out, err := transform(raw)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(raw, out) {
+		return nil
+	}
+	value := reflect.ValueOf(obj).Elem()
+	value.Set(reflect.Zero(value.Type()))
+	return json.Unmarshal(out, obj)
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+func newTestParameterizedClusterVersion() *tenancyv1alpha1.ClusterVersion {
+	cv := newTestClusterVersion("cv", "1")
+	version, replicas := "v1.22.13", "1"
+	cv.Spec.Parameters = []tenancyv1alpha1.ClusterVersionParameter{
+		{Name: "version", Default: &version},
+		{Name: "replicas", Type: tenancyv1alpha1.ParameterTypeInteger, Default: &replicas},
+		{Name: "cpu", Type: tenancyv1alpha1.ParameterTypeQuantity},
+		{Name: "flag"},
+	}
+	cv.Spec.APIServer.StatefulSet.Spec.Template.Spec.Containers = []corev1.Container{{
+		Name:    "apiserver",
+		Image:   "k8s.gcr.io/kube-apiserver:{{ .version }}",
+		Command: []string{"kube-apiserver", "--feature-gates={{ .flag }}"},
+		Args:    []string{`{{ printf "--version=%s" .version }}`},
+	}}
+	cv.Spec.APIServer.StatefulSet.Labels = map[string]string{"version": "{{ .version }}"}
+	cv.Spec.APIServer.Patches = []tenancyv1alpha1.BundlePatch{
+		{
+			Target: tenancyv1alpha1.BundlePatchStatefulSet,
+			Patch:  `[{"op": "add", "path": "/spec/replicas", "value": {{ .replicas }}}]`,
+		},
+		{
+			Target: tenancyv1alpha1.BundlePatchStatefulSet,
+			Patch:  `[{"op": "add", "path": "/spec/template/spec/containers/0/resources", "value": {"requests": {"cpu": "{{ .cpu }}"}}}]`,
+		},
+	}
+	return cv
+}
+
+func TestRenderBundles(t *testing.T) {
+	cv := newTestParameterizedClusterVersion()
+	rendered, err := renderBundles(cv, map[string]string{"cpu": "500m", "flag": `"quoted"=true`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sts := rendered.Spec.APIServer.StatefulSet
+	container := sts.Spec.Template.Spec.Containers[0]
+	if container.Image != "k8s.gcr.io/kube-apiserver:v1.22.13" {
+		t.Errorf("expected the default version to be rendered, got image %s", container.Image)
+	}
+	if container.Command[1] != `--feature-gates="quoted"=true` {
+		t.Errorf("expected the value to be rendered as it is, got %s", container.Command[1])
+	}
+	if container.Args[0] != "--version=v1.22.13" {
+		t.Errorf("expected the template with a quoted literal to be rendered, got %s", container.Args[0])
+	}
+	if sts.Labels["version"] != "v1.22.13" {
+		t.Errorf("expected the labels to be rendered, got %v", sts.Labels)
+	}
+	if sts.Spec.Replicas == nil || *sts.Spec.Replicas != 1 {
+		t.Errorf("expected the replicas to be patched, got %v", sts.Spec.Replicas)
+	}
+	if cpu := container.Resources.Requests[corev1.ResourceCPU]; cpu.Cmp(resource.MustParse("500m")) != 0 {
+		t.Errorf("expected the cpu request to be patched, got %s", cpu.String())
+	}
+	if sts.Name != "apiserver" {
+		t.Errorf("expected the other fields to be kept, got name %s", sts.Name)
+	}
+
+	if cv.Spec.APIServer.StatefulSet.Spec.Template.Spec.Containers[0].Image != "k8s.gcr.io/kube-apiserver:{{ .version }}" ||
+		cv.Spec.APIServer.StatefulSet.Labels["version"] != "{{ .version }}" {
+		t.Errorf("expected the ClusterVersion not to be altered")
+	}
+}
+
+func TestRenderBundlesErrors(t *testing.T) {
+	tests := map[string]struct {
+		mutate func(cv *tenancyv1alpha1.ClusterVersion)
+		values map[string]string
+		err    string
+	}{
+		"missing value": {
+			values: map[string]string{"flag": "a"},
+			err:    "parameter cpu is required",
+		},
+		"undeclared reference": {
+			mutate: func(cv *tenancyv1alpha1.ClusterVersion) {
+				cv.Spec.APIServer.Service.Spec.ExternalName = "{{ .domain }}"
+			},
+			values: map[string]string{"cpu": "1", "flag": "a"},
+			err:    `map has no entry for key "domain"`,
+		},
+		"patch of undefined service": {
+			mutate: func(cv *tenancyv1alpha1.ClusterVersion) {
+				cv.Spec.APIServer.Service = nil
+				cv.Spec.APIServer.Patches[0].Target = tenancyv1alpha1.BundlePatchService
+			},
+			values: map[string]string{"cpu": "1", "flag": "a"},
+			err:    "the service of patch 0 is not defined",
+		},
+		"patch failure": {
+			mutate: func(cv *tenancyv1alpha1.ClusterVersion) {
+				cv.Spec.APIServer.Patches[0].Patch = `[{"op": "test", "path": "/metadata/name", "value": "etcd"}]`
+			},
+			values: map[string]string{"cpu": "1", "flag": "a"},
+			err:    "fail to apply patch 0",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cv := newTestParameterizedClusterVersion()
+			if tc.mutate != nil {
+				tc.mutate(cv)
+			}
+			_, err := renderBundles(cv, tc.values)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected error %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestRenderClusterVersionCondition(t *testing.T) {
+	cv := newTestParameterizedClusterVersion()
+	vc := newTestVC()
+	vc.Spec.Parameters = map[string]string{"cpu": "1", "flag": "a", "memory": "1Gi"}
+
+	if _, err := renderClusterVersion(cv, vc); err == nil {
+		t.Fatalf("expected the undeclared parameter to be rejected")
+	}
+	condition := vc.GetCondition(tenancyv1alpha1.ParametersValid)
+	if condition == nil || condition.Status != corev1.ConditionFalse || !strings.Contains(condition.Message, "parameter memory is not declared") {
+		t.Errorf("expected the error to be reported on the virtualcluster, got %+v", condition)
+	}
+
+	delete(vc.Spec.Parameters, "memory")
+	if _, err := renderClusterVersion(cv, vc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if condition := vc.GetCondition(tenancyv1alpha1.ParametersValid); condition.Status != corev1.ConditionTrue {
+		t.Errorf("expected the parameters to be valid, got %+v", condition)
+	}
+}
+
+func TestParametersApplied(t *testing.T) {
+	vc := newTestVC()
+	history := &clusterVersionHistory{}
+	if !history.parametersApplied(vc) {
+		t.Errorf("expected the parameters to be assumed applied without history")
+	}
+
+	history.Current = newAppliedClusterVersion(newTestClusterVersion("cv", "1"), nil)
+	vc.Spec.Parameters = map[string]string{}
+	if !history.parametersApplied(vc) {
+		t.Errorf("expected no parameters to be applied")
+	}
+	vc.Spec.Parameters["cpu"] = "2"
+	if history.parametersApplied(vc) {
+		t.Errorf("expected the changed parameters not to be applied")
+	}
+}
@@ -18,13 +18,14 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -41,15 +42,107 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 )
 
-// GetProvisioner returns a new provisioner.Provisioner by ProvisionerName
-func (r *ReconcileVirtualCluster) GetProvisioner(mgr ctrl.Manager, log logr.Logger, provisionerTimeout time.Duration) (provisioner.Provisioner, error) {
-	switch r.ProvisionerName {
-	case "aliyun":
-		return provisioner.NewProvisionerAliyun(mgr, log, provisionerTimeout)
-	case "native":
-		return provisioner.NewProvisionerNative(mgr, log, provisionerTimeout)
+const (
+	// maxProvisionRetries is the number of failed attempts to provision the control plane
+	// before the VirtualCluster is in ClusterError
+	maxProvisionRetries = 3
+	// provisionRetryBaseDelay is the delay before the attempt following the first failure,
+	// it doubles on each failure up to provisionRetryMaxDelay
+	provisionRetryBaseDelay = 10 * time.Second
+	provisionRetryMaxDelay  = 5 * time.Minute
+)
+
+// provisionRetryDelay returns the backoff delay after the given number of failed attempts
+func provisionRetryDelay(retries int32) time.Duration {
+	if retries <= 0 {
+		return 0
 	}
-	return nil, fmt.Errorf("virtualcluster provisioner missing")
+	delay := provisionRetryBaseDelay
+	for i := int32(1); i < retries; i++ {
+		delay *= 2
+		if delay >= provisionRetryMaxDelay {
+			return provisionRetryMaxDelay
+		}
+	}
+	return delay
+}
+
+// provisionRetryRemaining returns how long the next attempt to provision the control plane
+// has still to wait since the last failure
+func provisionRetryRemaining(vc *tenancyv1alpha1.VirtualCluster, now time.Time) time.Duration {
+	if vc.Status.LastRetryTime == nil {
+		return 0
+	}
+	remaining := vc.Status.LastRetryTime.Add(provisionRetryDelay(vc.Status.Retries)).Sub(now)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+func resetProvisionRetries(vc *tenancyv1alpha1.VirtualCluster) {
+	vc.Status.Retries = 0
+	vc.Status.LastRetryTime = nil
+}
+
+// rollbackVirtualCluster rolls the control plane of vc back to the ClusterVersion applied before
+// the last upgrade, the rollback annotation is removed once the rollback is done.
+func (r *ReconcileVirtualCluster) rollbackVirtualCluster(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) error {
+	r.Log.Info("VirtualCluster is being rolled back", "vc", vc.GetName())
+	vc.SetCondition(tenancyv1alpha1.UpgradeInProgress, corev1.ConditionTrue, "RollingBack",
+		"tenant control plane is being rolled back to the previous ClusterVersion")
+	if err := kubeutil.RetryUpdateVCStatusOnConflict(ctx, r, vc, r.Log); err != nil {
+		return err
+	}
+	if err := r.Provisioner.RollbackVirtualCluster(ctx, vc); err != nil {
+		r.Log.Error(err, "fail to roll back virtualcluster", "vc", vc.GetName())
+		kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterRunning, fmt.Sprintf("fail to roll back: %s", err), "TenantControlPlaneRollbackFailed")
+		vc.SetCondition(tenancyv1alpha1.UpgradeInProgress, corev1.ConditionFalse, "RollbackFailed", err.Error())
+	} else {
+		r.Log.Info("rollback finished", "vc", vc.GetName(), "clusterversion", vc.Spec.ClusterVersionName)
+		kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterRunning, "tenant control plane is rolled back", "TenantControlPlaneRolledBack")
+		vc.SetCondition(tenancyv1alpha1.UpgradeInProgress, corev1.ConditionFalse, "RolledBack",
+			fmt.Sprintf("tenant control plane is rolled back to ClusterVersion %s", vc.Spec.ClusterVersionName))
+	}
+	delete(vc.Annotations, constants.LabelVCRollback)
+	return r.updateUpgradedVC(ctx, vc)
+}
+
+// updateUpgradedVC updates vc once its control plane is upgraded or rolled back, the status,
+// the labels, the annotations, the ClusterVersion and the parameters of vc are kept on conflicts.
+func (r *ReconcileVirtualCluster) updateUpgradedVC(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		vcStatus := vc.Status
+		vcLabels := vc.Labels
+		vcAnnotations := vc.Annotations
+		cvName := vc.Spec.ClusterVersionName
+		parameters := vc.Spec.Parameters
+		updateErr := r.Update(ctx, vc)
+		if updateErr != nil {
+			if err := r.Get(ctx, types.NamespacedName{
+				Namespace: vc.GetNamespace(),
+				Name:      vc.GetName(),
+			}, vc); err != nil {
+				r.Log.Info("fail to get obj on update failure", "object", vc.GetName(), "error", err.Error())
+			}
+			vc.Status = vcStatus
+			vc.Labels = vcLabels
+			vc.Annotations = vcAnnotations
+			vc.Spec.ClusterVersionName = cvName
+			vc.Spec.Parameters = parameters
+		}
+		return updateErr
+	})
+}
+
+// GetProvisioner returns a new provisioner.Provisioner registered as ProvisionerName
+func (r *ReconcileVirtualCluster) GetProvisioner(mgr ctrl.Manager, log logr.Logger, provisionerTimeout time.Duration) (provisioner.Provisioner, error) {
+	return provisioner.New(r.ProvisionerName, &provisioner.Config{
+		Manager:            mgr,
+		Log:                log,
+		ProvisionerTimeout: provisionerTimeout,
+		AuditShipper:       r.AuditShipper,
+	})
 }
 
 var _ reconcile.Reconciler = &ReconcileVirtualCluster{}
@@ -61,6 +154,8 @@ type ReconcileVirtualCluster struct {
 	ProvisionerName    string
 	ProvisionerTimeout time.Duration
 	Provisioner        provisioner.Provisioner
+	// AuditShipper configures the sidecar shipping the audit log of the tenant apiservers
+	AuditShipper provisioner.AuditShipperConfig
 }
 
 // SetupWithManager will configure the VirtualCluster reconciler
@@ -94,6 +189,8 @@ func (r *ReconcileVirtualCluster) SetupWithManager(mgr ctrl.Manager, opts contro
 // +kubebuilder:rbac:groups=core,resources=secrets/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=configmaps/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods;persistentvolumeclaims,verbs=get;list
 // +kubebuilder:rbac:groups=tenancy.x-k8s.io,resources=virtualclusters,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=tenancy.x-k8s.io,resources=virtualclusters/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=tenancy.x-k8s.io,resources=clusterversions,verbs=get;list;watch
@@ -130,7 +227,21 @@ func (r *ReconcileVirtualCluster) Reconcile(ctx context.Context, request reconci
 			r.Log.Info("VirtualCluster is being deleted, finalizer will be activated", "vc-name", vc.Name, "finalizer", vcFinalizerName)
 			// block if fail to delete VC
 			if err = r.Provisioner.DeleteVirtualCluster(ctx, vc); err != nil {
+				var inProgressErr *provisioner.DeletionInProgressError
+				if errors.As(err, &inProgressErr) {
+					// the deletion is issued, check it again later instead of blocking the worker
+					r.Log.Info("waiting for the tenant control plane to be deleted", "vc-name", vc.Name, "namespace", inProgressErr.Namespace)
+					rncilRslt.RequeueAfter = inProgressErr.RequeueAfter
+					err = kubeutil.RetryUpdateVCStatusOnConflict(ctx, r, vc, r.Log)
+					return
+				}
 				r.Log.Error(err, "fail to delete virtualcluster", "vc-name", vc.Name)
+				// report what blocks the deletion, the deletion is retried with backoff
+				kubeutil.SetVCStatus(vc, vc.Status.Phase,
+					fmt.Sprintf("fail to delete tenant control plane: %s", err), "TenantControlPlaneDeletionBlocked")
+				if updateErr := kubeutil.RetryUpdateVCStatusOnConflict(ctx, r, vc, r.Log); updateErr != nil {
+					r.Log.Error(updateErr, "fail to update virtualcluster status", "vc-name", vc.Name)
+				}
 				return
 			}
 			// remove finalizer from the list and update it.
@@ -147,29 +258,44 @@ func (r *ReconcileVirtualCluster) Reconcile(ctx context.Context, request reconci
 	case "":
 		// set vc status as ClusterPending if no status is set
 		r.Log.Info("will create a VirtualCluster", "vc", vc.Name)
-		// will retry three times
 		kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterPending,
-			"retry: 3", "ClusterCreating")
+			"tenant control plane is being provisioned", "ClusterCreating")
+		resetProvisionRetries(vc)
+		vc.SetCondition(tenancyv1alpha1.ControlPlaneProvisioned, corev1.ConditionFalse,
+			"Provisioning", "tenant control plane is being provisioned")
 		err = kubeutil.RetryUpdateVCStatusOnConflict(ctx, r, vc, r.Log)
 		return
 	case tenancyv1alpha1.ClusterPending:
 		// create new virtualcluster when vc is pending
 		r.Log.Info("VirtualCluster is pending", "vc", vc.Name)
-		retryTimes, _ := strconv.Atoi(strings.TrimSpace(strings.Split(vc.Status.Message, ":")[1]))
-		if retryTimes > 0 {
-			err = r.Provisioner.CreateVirtualCluster(ctx, vc)
-			if err != nil {
-				r.Log.Error(err, "fail to create virtualcluster", "vc", vc.GetName(), "retrytimes", retryTimes)
-				errReason := fmt.Sprintf("fail to create virtualcluster(%s): %s", vc.GetName(), err)
-				errMsg := fmt.Sprintf("retry: %d", retryTimes-1)
-				kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterPending, errMsg, errReason)
+		if delay := provisionRetryRemaining(vc, time.Now()); delay > 0 {
+			r.Log.Info("VirtualCluster is backing off", "vc", vc.Name, "retries", vc.Status.Retries, "delay", delay)
+			rncilRslt.RequeueAfter = delay
+			return
+		}
+		if err = r.Provisioner.CreateVirtualCluster(ctx, vc); err != nil {
+			vc.Status.Retries++
+			now := metav1.Now()
+			vc.Status.LastRetryTime = &now
+			r.Log.Error(err, "fail to create virtualcluster", "vc", vc.GetName(), "retries", vc.Status.Retries)
+			vc.SetCondition(tenancyv1alpha1.ControlPlaneProvisioned, corev1.ConditionFalse, "ProvisionFailed", err.Error())
+			if vc.Status.Retries >= maxProvisionRetries {
+				kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterError,
+					fmt.Sprintf("fail to create virtualcluster(%s) after %d attempts: %s, set the annotation %s to retry",
+						vc.GetName(), vc.Status.Retries, err, constants.LabelVCRetry),
+					"TenantControlPlaneError")
 			} else {
-				kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterRunning,
-					"tenant control plane is running", "TenantControlPlaneRunning")
+				kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterPending,
+					fmt.Sprintf("fail to create virtualcluster(%s), attempt %d: %s", vc.GetName(), vc.Status.Retries, err),
+					"TenantControlPlaneProvisionFailed")
+				rncilRslt.RequeueAfter = provisionRetryDelay(vc.Status.Retries)
 			}
 		} else {
-			kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterError,
-				"fail to create virtualcluster", "TenantControlPlaneError")
+			resetProvisionRetries(vc)
+			vc.SetCondition(tenancyv1alpha1.ControlPlaneProvisioned, corev1.ConditionTrue,
+				"Provisioned", "tenant control plane is provisioned")
+			kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterRunning,
+				"tenant control plane is running", "TenantControlPlaneRunning")
 		}
 
 		err = kubeutil.RetryUpdateVCStatusOnConflict(ctx, r, vc, r.Log)
@@ -179,44 +305,66 @@ func (r *ReconcileVirtualCluster) Reconcile(ctx context.Context, request reconci
 		if !featuregate.DefaultFeatureGate.Enabled(featuregate.ClusterVersionPartialUpgrade) {
 			return
 		}
+		if _, rollbackRequested := vc.Annotations[constants.LabelVCRollback]; rollbackRequested {
+			err = r.rollbackVirtualCluster(ctx, vc)
+			return
+		}
 		if isReady, ok := vc.Labels[constants.LabelVCReadyForUpgrade]; !ok || isReady != "true" {
 			return
 		}
 		r.Log.Info("VirtualCluster is ready for upgrade", "vc", vc.GetName())
+		vc.SetCondition(tenancyv1alpha1.UpgradeInProgress, corev1.ConditionTrue, "Upgrading",
+			fmt.Sprintf("tenant control plane is being upgraded to ClusterVersion %s", vc.Spec.ClusterVersionName))
+		if err = kubeutil.RetryUpdateVCStatusOnConflict(ctx, r, vc, r.Log); err != nil {
+			return
+		}
 		upgradeStartTimestamp := time.Now()
 		err = r.Provisioner.UpgradeVirtualCluster(ctx, vc)
 		clustersUpgradeSeconds.WithLabelValues(vc.Spec.ClusterVersionName, vc.Labels[constants.LabelClusterVersionApplied]).Observe(time.Since(upgradeStartTimestamp).Seconds())
-		if err != nil {
+		var rolledBackErr *provisioner.UpgradeRolledBackError
+		if errors.As(err, &rolledBackErr) {
+			r.Log.Error(err, "fail to upgrade virtualcluster, rolled back", "vc", vc.GetName(), "clusterversion", rolledBackErr.ClusterVersion)
+			kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterRunning, fmt.Sprintf("fail to upgrade: %s", err), "TenantControlPlaneUpgradeRolledBack")
+			vc.SetCondition(tenancyv1alpha1.UpgradeInProgress, corev1.ConditionFalse, "UpgradeRolledBack", err.Error())
+			clustersUpgradeFailedCounter.WithLabelValues(vc.Spec.ClusterVersionName, vc.Labels[constants.LabelClusterVersionApplied]).Inc()
+		} else if err != nil {
 			r.Log.Error(err, "fail to upgrade virtualcluster", "vc", vc.GetName())
-			kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterRunning, fmt.Sprintf("fail to upgrade: %s", err), "TenantControlPlaneUpgradeFailed")
+			kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterRunning,
+				fmt.Sprintf("fail to upgrade: %s, set the annotation %s to roll back", err, constants.LabelVCRollback), "TenantControlPlaneUpgradeFailed")
+			vc.SetCondition(tenancyv1alpha1.UpgradeInProgress, corev1.ConditionFalse, "UpgradeFailed", err.Error())
 			clustersUpgradeFailedCounter.WithLabelValues(vc.Spec.ClusterVersionName, vc.Labels[constants.LabelClusterVersionApplied]).Inc()
 		} else {
 			r.Log.Info("upgrade finished", "vc", vc.GetName())
 			kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterRunning, "tenant control plane is upgraded", "TenantControlPlaneUpgradeCompleted")
+			vc.SetCondition(tenancyv1alpha1.UpgradeInProgress, corev1.ConditionFalse, "UpgradeCompleted", "tenant control plane is upgraded")
 			clustersUpgradedCounter.WithLabelValues(vc.Spec.ClusterVersionName, vc.Labels[constants.LabelClusterVersionApplied]).Inc()
 		}
 
+		delete(vc.Labels, constants.LabelVCReadyForUpgrade)
+		err = r.updateUpgradedVC(ctx, vc)
+		return
+	case tenancyv1alpha1.ClusterError:
+		if _, retryRequested := vc.Annotations[constants.LabelVCRetry]; !retryRequested {
+			r.Log.Info("fail to create virtualcluster", "vc", vc.GetName())
+			return
+		}
+		r.Log.Info("retry to create virtualcluster", "vc", vc.GetName())
 		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
-			vcStatus := vc.Status
-			delete(vc.Labels, constants.LabelVCReadyForUpgrade)
-			vcLabels := vc.Labels
-			updateErr := r.Update(ctx, vc)
-			if updateErr != nil {
-				if err := r.Get(ctx, types.NamespacedName{
-					Namespace: vc.GetNamespace(),
-					Name:      vc.GetName(),
-				}, vc); err != nil {
-					r.Log.Info("fail to get obj on update failure", "object", vc.GetName(), "error", err.Error())
-				}
-				vc.Status = vcStatus
-				vc.Labels = vcLabels
+			if err := r.Get(ctx, request.NamespacedName, vc); err != nil {
+				return err
+			}
+			if _, retryRequested := vc.Annotations[constants.LabelVCRetry]; !retryRequested || vc.Status.Phase != tenancyv1alpha1.ClusterError {
+				return nil
 			}
-			return updateErr
+			delete(vc.Annotations, constants.LabelVCRetry)
+			resetProvisionRetries(vc)
+			kubeutil.SetVCStatus(vc, tenancyv1alpha1.ClusterPending,
+				"retry to provision tenant control plane", "ClusterRetrying")
+			vc.SetCondition(tenancyv1alpha1.ControlPlaneProvisioned, corev1.ConditionFalse,
+				"Provisioning", "tenant control plane is being provisioned")
+			return r.Update(ctx, vc)
 		})
 		return
-	case tenancyv1alpha1.ClusterError:
-		r.Log.Info("fail to create virtualcluster", "vc", vc.GetName())
-		return
 	default:
 		err = fmt.Errorf("unknown vc phase: %s", vc.Status.Phase)
 		return
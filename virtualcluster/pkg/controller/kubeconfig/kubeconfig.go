@@ -26,8 +26,14 @@ import (
 	"net"
 	"strings"
 	"text/template"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdv1 "k8s.io/client-go/tools/clientcmd/api/v1"
+	"sigs.k8s.io/yaml"
 
 	vcpki "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/pki"
+	pkiutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/pki"
 )
 
 const (
@@ -119,3 +125,53 @@ func getTemplateContent(kubeConfigTmpl string, context interface{}) (string, err
 
 	return writer.String(), nil
 }
+
+// ClientCertificate returns the client certificate of the current user of the kubeconfig.
+func ClientCertificate(kubeconfig []byte) (*x509.Certificate, error) {
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	currentContext, exists := config.Contexts[config.CurrentContext]
+	if !exists {
+		return nil, fmt.Errorf("current context %q is not found", config.CurrentContext)
+	}
+	authInfo, exists := config.AuthInfos[currentContext.AuthInfo]
+	if !exists || len(authInfo.ClientCertificateData) == 0 {
+		return nil, fmt.Errorf("user %q has no client certificate", currentContext.AuthInfo)
+	}
+	return pkiutil.DecodeCertPEM(authInfo.ClientCertificateData)
+}
+
+// RenewKubeconfig renews the client certificates of the kubeconfig with rootCA, and sets
+// the certificate authority of the clusters to caBundle.
+func RenewKubeconfig(kubeconfig []byte, rootCA *vcpki.CrtKeyPair, caBundle []byte, validity time.Duration) ([]byte, error) {
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	for name, authInfo := range config.AuthInfos {
+		if len(authInfo.ClientCertificateData) == 0 {
+			continue
+		}
+		crt, err := pkiutil.DecodeCertPEM(authInfo.ClientCertificateData)
+		if err != nil {
+			return nil, fmt.Errorf("fail to decode client certificate of user %s: %v", name, err)
+		}
+		renewed, err := vcpki.RenewCrtKeyPair(rootCA, crt, validity)
+		if err != nil {
+			return nil, err
+		}
+		authInfo.ClientCertificateData = encodeCertPEM(renewed.Crt)
+		authInfo.ClientKeyData = encodePrivateKeyPEM(renewed.Key)
+	}
+	for _, cluster := range config.Clusters {
+		cluster.CertificateAuthorityData = caBundle
+	}
+	out := &clientcmdv1.Config{}
+	if err := clientcmdv1.Convert_api_Config_To_v1_Config(config, out, nil); err != nil {
+		return nil, err
+	}
+	out.APIVersion, out.Kind = clientcmdv1.SchemeGroupVersion.Version, "Config"
+	return yaml.Marshal(out)
+}
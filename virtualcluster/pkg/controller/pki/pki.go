@@ -33,10 +33,6 @@ import (
 	pkiutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/pki"
 )
 
-const (
-	defaultClusterDomain = "cluster.local"
-)
-
 // CrtKeyPair is a pair of Cert and Key
 type CrtKeyPair struct {
 	Crt *x509.Certificate
@@ -52,14 +48,14 @@ type ClusterCAGroup struct {
 	CtrlMgrKbCfg             string // the kubeconfig used by controller-manager
 	AdminKbCfg               string // the kubeconfig used by admin user
 	ServiceAccountPrivateKey *rsa.PrivateKey
+	// RootCABundle is the PEM of the root CA followed by the previous root CA trusted
+	// during the rotation of the root CA, only the root CA is trusted if not set
+	RootCABundle []byte
 }
 
 // NewAPIServerCrtAndKey creates crt and key for apiserver using ca.
 func NewAPIServerCrtAndKey(ca *CrtKeyPair, vc *tenancyv1alpha1.VirtualCluster, apiserverDomain string, apiserverIPs ...string) (*CrtKeyPair, error) {
-	clusterDomain := defaultClusterDomain
-	if vc.Spec.ClusterDomain != "" {
-		clusterDomain = vc.Spec.ClusterDomain
-	}
+	clusterDomain := vc.GetClusterDomain()
 
 	// create AltNames with defaults DNSNames/IPs
 	altNames := &cert.AltNames{
@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pki
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/client-go/util/cert"
+
+	pkiutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/pki"
+)
+
+// NewRootCrtAndKey creates the root CA of a tenant control plane.
+func NewRootCrtAndKey() (*CrtKeyPair, error) {
+	rootCACrt, rootKey, err := pkiutil.NewCertificateAuthority(
+		&pkiutil.CertConfig{
+			Config: cert.Config{
+				CommonName:   "kubernetes",
+				Organization: []string{"kubernetes-sig.kubernetes-sigs/multi-tenancy.virtualcluster"},
+			},
+		})
+	if err != nil {
+		return nil, err
+	}
+	rootRsaKey, ok := rootKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("fail to assert rsa PrivateKey")
+	}
+	return &CrtKeyPair{Crt: rootCACrt, Key: rootRsaKey}, nil
+}
+
+// RenewCrtKeyPair creates a new crt-key pair signed by ca with the subject, the alternative
+// names and the usages of crt. The new certificate is valid for validity, or
+// pkiutil.CertificateValidity if validity is zero.
+func RenewCrtKeyPair(ca *CrtKeyPair, crt *x509.Certificate, validity time.Duration) (*CrtKeyPair, error) {
+	config := &pkiutil.CertConfig{
+		Config: cert.Config{
+			CommonName:   crt.Subject.CommonName,
+			Organization: crt.Subject.Organization,
+			AltNames: cert.AltNames{
+				DNSNames: crt.DNSNames,
+				IPs:      crt.IPAddresses,
+			},
+			Usages: crt.ExtKeyUsage,
+		},
+		Validity: validity,
+	}
+	newCrt, newKey, err := pkiutil.NewCertAndKey(ca.Crt, ca.Key, config)
+	if err != nil {
+		return nil, fmt.Errorf("fail to renew crt and key of %s: %v", crt.Subject.CommonName, err)
+	}
+	rsaKey, ok := newKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("fail to assert rsa private key")
+	}
+	return &CrtKeyPair{newCrt, rsaKey}, nil
+}
+
+// EncodeCertBundlePEM returns the PEM-encoded bundle of the certificates, e.g. the root CA
+// followed by the previous root CA still trusted during the rotation of the root CA.
+func EncodeCertBundlePEM(certs ...*x509.Certificate) []byte {
+	var buf bytes.Buffer
+	for _, crt := range certs {
+		buf.Write(pkiutil.EncodeCertPEM(crt))
+	}
+	return buf.Bytes()
+}
@@ -28,6 +28,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -70,28 +71,54 @@ func GetSvcClusterIP(cli client.Client, namespace, name string) (string, error)
 }
 
 // WaitStatefulSetReady checks if the statefulset 'namespace/name' can be ready within
-// the 'timeout'
+// the 'timeout', it is checked right away and then every 'period'
 func WaitStatefulSetReady(cli client.Client, namespace, name string, timeOutSec, periodSec int64) error {
-	timeOut := time.After(time.Duration(timeOutSec) * time.Second)
-	for {
-		period := time.After(time.Duration(periodSec) * time.Second)
-		select {
-		case <-timeOut:
-			return fmt.Errorf("%s/%s is not ready in %d seconds", namespace, name, timeOutSec)
-		case <-period:
-			sts := &appsv1.StatefulSet{}
-			if err := cli.Get(context.TODO(), types.NamespacedName{
-				Namespace: namespace,
-				Name:      name,
-			}, sts); err != nil {
-				return err
-			}
+	err := wait.PollImmediate(time.Duration(periodSec)*time.Second, time.Duration(timeOutSec)*time.Second, func() (bool, error) {
+		sts := &appsv1.StatefulSet{}
+		if err := cli.Get(context.TODO(), types.NamespacedName{
+			Namespace: namespace,
+			Name:      name,
+		}, sts); err != nil {
+			return false, err
+		}
+		return sts.Status.ReadyReplicas == *sts.Spec.Replicas, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("%s/%s is not ready in %d seconds", namespace, name, timeOutSec)
+	}
+	return err
+}
 
-			if sts.Status.ReadyReplicas == *sts.Spec.Replicas {
-				return nil
-			}
+// WaitStatefulSetRolledOut checks if the statefulset 'namespace/name' is rolled out to its
+// latest revision and ready within the 'timeout', it is checked right away and then every 'period'
+func WaitStatefulSetRolledOut(cli client.Client, namespace, name string, timeOutSec, periodSec int64) error {
+	err := wait.PollImmediate(time.Duration(periodSec)*time.Second, time.Duration(timeOutSec)*time.Second, func() (bool, error) {
+		sts := &appsv1.StatefulSet{}
+		if err := cli.Get(context.TODO(), types.NamespacedName{
+			Namespace: namespace,
+			Name:      name,
+		}, sts); err != nil {
+			return false, err
 		}
+		return IsStatefulSetRolledOut(sts), nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("%s/%s is not rolled out in %d seconds", namespace, name, timeOutSec)
 	}
+	return err
+}
+
+// IsStatefulSetRolledOut checks if the controller observed the latest spec of the statefulset
+// and all its replicas are updated to the latest revision and ready.
+func IsStatefulSetRolledOut(sts *appsv1.StatefulSet) bool {
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	return sts.Status.ObservedGeneration >= sts.Generation &&
+		sts.Status.UpdatedReplicas == replicas &&
+		sts.Status.ReadyReplicas == replicas &&
+		sts.Status.CurrentRevision == sts.Status.UpdateRevision
 }
 
 // CreateRootNS creates the root namespace for the vc
@@ -164,18 +191,13 @@ func RetryUpdateVCStatusOnConflict(ctx context.Context, cli client.Client, vc *t
 	})
 }
 
-// SetVCStatus set the virtualcluster 'vc' status, and append the new status to conditions list
+// SetVCStatus set the virtualcluster 'vc' status, the conditions are set with vc.SetCondition
 func SetVCStatus(vc *tenancyv1alpha1.VirtualCluster, phase tenancyv1alpha1.ClusterPhase, message, reason string) {
 	nsName := conversion.ToClusterKey(vc)
 	vc.Status.ClusterNamespace = nsName
 	vc.Status.Phase = phase
 	vc.Status.Message = message
 	vc.Status.Reason = reason
-	vc.Status.Conditions = append(vc.Status.Conditions, tenancyv1alpha1.ClusterCondition{
-		LastTransitionTime: metav1.NewTime(time.Now()),
-		Reason:             reason,
-		Message:            message,
-	})
 }
 
 // IsObjExist check if object with 'key' exist
@@ -17,6 +17,8 @@ limitations under the License.
 package config
 
 import (
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
@@ -76,6 +78,21 @@ type SyncerConfiguration struct {
 	// is used for the feature VNodeProviderPodIP
 	VNAgentLabelSelector string
 
+	// AggregatedVNodeCount defines the number of vNodes presenting the super cluster
+	// nodes to each tenant, this is used for the feature VNodeProviderAggregated.
+	AggregatedVNodeCount int
+
+	// VNodeCapacityPolicy defines how the capacity and allocatable of the vNode are computed
+	// for each tenant, one of Node, ResourceQuota, Fraction and Free.
+	VNodeCapacityPolicy string
+
+	// VNodeConditionTypes is the list of the physical node condition types mirrored on the vNodes.
+	VNodeConditionTypes []string
+
+	// VNodeHeartbeatPeriod is the minimum period between two vNode updates that only
+	// refresh the heartbeat times of the conditions.
+	VNodeHeartbeatPeriod time.Duration
+
 	// FeatureGates enabled by the user.
 	FeatureGates map[string]bool
 
@@ -87,6 +104,26 @@ type SyncerConfiguration struct {
 
 	// The DNSOptions are the DNS options in resolv.conf that is attached to pod
 	DNSOptions []corev1.PodDNSConfigOption
+	// PatrolDryRun indicates whether the periodic checkers only report the mismatched
+	// objects without remediating them.
+	PatrolDryRun bool
+	// PatrolReportNamespace is the super cluster namespace where the report of each
+	// checker run is stored as a configmap. Reports are not stored if it is empty.
+	PatrolReportNamespace string
+	// PatrolBackstopPeriod is the period of the full scans done by the periodic
+	// checkers when the IncrementalPatrol feature is enabled.
+	PatrolBackstopPeriod time.Duration
+
+	// SuperClusterKubeconfigs maps the id of each super cluster managed by the syncer to
+	// its kubeconfig file. The syncer manages the single super cluster of ClientConnection
+	// if it is empty.
+	SuperClusterKubeconfigs map[string]string
+	// DefaultSuperClusterID is the super cluster where the tenant namespaces without
+	// placement are synced to when the syncer manages multiple super clusters.
+	DefaultSuperClusterID string
+	// SuperClusterID is the id of the super cluster the configuration is used for, it is
+	// set for each super cluster when the syncer manages multiple super clusters.
+	SuperClusterID string
 }
 
 // SyncerLeaderElectionConfiguration expands LeaderElectionConfiguration
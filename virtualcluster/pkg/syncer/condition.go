@@ -0,0 +1,74 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+const (
+	// reasonConnected is the SyncerConnected reason of a VirtualCluster whose objects are cached
+	// and whose apiserver is reachable.
+	reasonConnected  = "Connected"
+	connectedMessage = "the syncer is connected to the tenant control plane"
+	// reasonCacheSyncFailed is the SyncerConnected reason of a VirtualCluster whose objects fail to be cached.
+	reasonCacheSyncFailed = "CacheSyncFailed"
+	// reasonClusterUnhealthy is the SyncerConnected reason of a VirtualCluster whose apiserver is unreachable.
+	reasonClusterUnhealthy = "ClusterUnHealth"
+)
+
+// updateSyncerConnectedCondition sets the SyncerConnected condition of the VirtualCluster.
+// The cached VirtualCluster is checked first and the VirtualCluster is only updated if the
+// status or the reason of the condition changes, a new message alone is not written.
+func (s *Syncer) updateSyncerConnectedCondition(namespace, name string, status corev1.ConditionStatus, reason, message string) {
+	vc, err := s.lister.VirtualClusters(namespace).Get(name)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.Errorf("failed to get VirtualCluster %s/%s from cache: %v", namespace, name, err)
+		}
+		return
+	}
+	if !syncerConnectedChanged(vc, status, reason) {
+		return
+	}
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest, err := s.vcClient.TenancyV1alpha1().VirtualClusters(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if !syncerConnectedChanged(latest, status, reason) {
+			return nil
+		}
+		latest.SetCondition(v1alpha1.SyncerConnected, status, reason, message)
+		_, err = s.vcClient.TenancyV1alpha1().VirtualClusters(namespace).Update(latest)
+		return err
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("failed to update SyncerConnected condition of VirtualCluster %s/%s: %v", namespace, name, err)
+	}
+}
+
+func syncerConnectedChanged(vc *v1alpha1.VirtualCluster, status corev1.ConditionStatus, reason string) bool {
+	condition := vc.GetCondition(v1alpha1.SyncerConnected)
+	return condition == nil || condition.Status != status || condition.Reason != reason
+}
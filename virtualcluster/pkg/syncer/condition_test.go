@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcfake "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned/fake"
+	vclisters "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/listers/tenancy/v1alpha1"
+)
+
+func TestUpdateSyncerConnectedCondition(t *testing.T) {
+	vc := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "tenant",
+			Name:      "vc",
+			UID:       "vc-uid",
+		},
+		Status: v1alpha1.VirtualClusterStatus{Phase: v1alpha1.ClusterRunning},
+	}
+	vcClient := vcfake.NewSimpleClientset(vc)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	s := &Syncer{vcClient: vcClient, lister: vclisters.NewVirtualClusterLister(indexer)}
+
+	for _, tc := range []struct {
+		name            string
+		status          corev1.ConditionStatus
+		reason          string
+		message         string
+		expectedUpdated bool
+	}{
+		{
+			name:            "connected",
+			status:          corev1.ConditionTrue,
+			reason:          reasonConnected,
+			expectedUpdated: true,
+		},
+		{
+			name:   "still connected",
+			status: corev1.ConditionTrue,
+			reason: reasonConnected,
+		},
+		{
+			name:            "unhealthy",
+			status:          corev1.ConditionFalse,
+			reason:          reasonClusterUnhealthy,
+			message:         "connection refused",
+			expectedUpdated: true,
+		},
+		{
+			name:    "still unhealthy with another message",
+			status:  corev1.ConditionFalse,
+			reason:  reasonClusterUnhealthy,
+			message: "i/o timeout",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cached, err := vcClient.TenancyV1alpha1().VirtualClusters("tenant").Get("vc", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get vc: %v", err)
+			}
+			if err := indexer.Update(cached); err != nil {
+				t.Fatalf("failed to cache vc: %v", err)
+			}
+			vcClient.ClearActions()
+			s.updateSyncerConnectedCondition("tenant", "vc", tc.status, tc.reason, tc.message)
+
+			updated := false
+			for _, action := range vcClient.Actions() {
+				if _, ok := action.(core.UpdateAction); ok {
+					updated = true
+				}
+			}
+			if updated != tc.expectedUpdated {
+				t.Errorf("expected updated %v, got actions %v", tc.expectedUpdated, vcClient.Actions())
+			}
+			if !tc.expectedUpdated && len(vcClient.Actions()) != 0 {
+				t.Errorf("expected the cached condition to be used, got actions %v", vcClient.Actions())
+			}
+
+			latest, err := vcClient.TenancyV1alpha1().VirtualClusters("tenant").Get("vc", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get vc: %v", err)
+			}
+			if len(latest.Status.Conditions) != 1 {
+				t.Fatalf("expected one condition, got %+v", latest.Status.Conditions)
+			}
+			condition := latest.GetCondition(v1alpha1.SyncerConnected)
+			if condition == nil || condition.Status != tc.status || condition.Reason != tc.reason {
+				t.Errorf("expected SyncerConnected %s with reason %s, got %+v", tc.status, tc.reason, condition)
+			}
+		})
+	}
+}
@@ -49,15 +49,54 @@ const (
 	LabelVCUID = "tenancy.x-k8s.io/vcuid"
 	// LabelVCRootNS means the namespace is the rootns created by vc-manager.
 	LabelVCRootNS = "tenancy.x-k8s.io/vcrootns"
+	// LabelSyncPaused is set to "true" on a VirtualCluster to pause syncing it, e.g. during
+	// the maintenance of the tenant control plane. Only the downward syncing and the checkers
+	// are paused, the upward syncing, e.g. the pod status, keeps updating the tenant objects.
+	LabelSyncPaused = "tenancy.x-k8s.io/sync-paused"
+	// LabelVNodeCapacityFraction is the fraction, e.g. "0.25", of the physical node capacity
+	// presented on the vNodes of a VirtualCluster when the vNode capacity policy is Fraction.
+	LabelVNodeCapacityFraction = "tenancy.x-k8s.io/vnode-capacity-fraction"
+
+	// LabelVCRetry is set on a VirtualCluster in the Error phase to retry provisioning its control
+	// plane, it is removed once the retry starts.
+	LabelVCRetry = "tenancy.x-k8s.io/retry"
 
 	// LabelVCReadyForUpgrade is set to "true" when the cluster is ready for the upgrade being applied
 	// (use featuregate.VirtualClusterApplyUpdate to enable it in the provisioner)
 	LabelVCReadyForUpgrade = "tenancy.x-k8s.io/ready-for-upgrade"
 
-	// LabelClusterVersionApplied should be set equal to the ClusterVersion.metadata.resourceVersion value
+	// LabelClusterVersionApplied should be set equal to the ClusterVersion.metadata.generation value
 	// This label is used in featuregate.VirtualClusterApplyUpdate to compare if the update must be applied.
 	LabelClusterVersionApplied = "tenancy.x-k8s.io/cluster-version-applied"
 
+	// LabelClusterVersionSpecHash is set to the ClusterVersion.status.specHash of the spec applied to
+	// the control plane of a VirtualCluster.
+	LabelClusterVersionSpecHash = "tenancy.x-k8s.io/cluster-version-spec-hash"
+
+	// LabelClusterVersionDeprecated marks a ClusterVersion as deprecated, its value is the deprecation
+	// message, e.g. the ClusterVersion to use instead.
+	LabelClusterVersionDeprecated = "tenancy.x-k8s.io/deprecated"
+
+	// LabelVCUpgradeAutoRollback is set to "true" on a VirtualCluster to roll its control plane back
+	// to the previously applied ClusterVersion when an upgrade fails.
+	LabelVCUpgradeAutoRollback = "tenancy.x-k8s.io/upgrade.auto-rollback"
+
+	// LabelVCRollback is set on a running VirtualCluster to roll its control plane back to the
+	// previously applied ClusterVersion, it is removed once the rollback is done.
+	LabelVCRollback = "tenancy.x-k8s.io/rollback"
+
+	// LabelVCRotateRootCA is set on a running VirtualCluster to rotate the root CA of its control plane,
+	// it is removed once the rotation starts (use featuregate.PKIRotation to enable it).
+	LabelVCRotateRootCA = "tenancy.x-k8s.io/rotate-root-ca"
+
+	// LabelPKIPreviousCATrustedUntil is set on the root CA secret of a tenant control plane being rotated
+	// to the time, in RFC3339, until which the previous root CA is trusted.
+	LabelPKIPreviousCATrustedUntil = "tenancy.x-k8s.io/pki.previous-ca-trusted-until"
+
+	// LabelPKIRenewedAt is set on the pod template of the control plane components to restart them
+	// when their certificates are renewed.
+	LabelPKIRenewedAt = "tenancy.x-k8s.io/pki.renewed-at"
+
 	// LabelExternalApiserverDomain is the domain name for apiserver url from outside the cluster
 	LabelExternalApiserverDomain = "tenancy.x-k8s.io/external-apiserver-domain"
 
@@ -67,6 +106,22 @@ const (
 	// LabelTenantIgnoreSync is used by resources that do not need to be synced.
 	LabelTenantIgnoreSync = "tenancy.x-k8s.io/ignore-sync"
 
+	// LabelPatrolReport marks the configmap holding the latest patrol report of a resource kind.
+	LabelPatrolReport = "tenancy.x-k8s.io/patrol-report"
+	// LabelPatrolReportDryRun records whether the reported patrol run skipped the remediation.
+	LabelPatrolReportDryRun = "tenancy.x-k8s.io/patrol-report.dry-run"
+	// LabelPatrolReportStartTime is the start time of the reported patrol run.
+	LabelPatrolReportStartTime = "tenancy.x-k8s.io/patrol-report.start-time"
+	// LabelPatrolReportEndTime is the end time of the reported patrol run.
+	LabelPatrolReportEndTime = "tenancy.x-k8s.io/patrol-report.end-time"
+
+	// VirtualClusterOffboardingFinalizer is held on the VirtualCluster by the syncer until the synced
+	// objects are removed from super cluster.
+	VirtualClusterOffboardingFinalizer = "tenancy.x-k8s.io/syncer-offboarding"
+	// DefaultOffboardingForceDeleteDelay is the time to wait after the grace period of a
+	// terminating pPod before force deleting it during offboarding.
+	DefaultOffboardingForceDeleteDelay = time.Second * 30
+
 	// UwsControllerWorkerHigh is the quantity of the worker routine for a resource that generates high number of uws requests.
 	UwsControllerWorkerHigh = 10
 	// UwsControllerWorkerLow is the quantity of the worker routine for a resource that generates low number of uws requests.
@@ -93,6 +148,10 @@ const (
 	// DefaultvNodeGCGracePeriod is the grace period of time before deleting an orphan vNode in tenant control plane.
 	DefaultvNodeGCGracePeriod = time.Second * 120
 
+	// PodConditionVNodeReady is the condition type set on the tenant pods to reflect whether
+	// the physical node they are running on is ready.
+	PodConditionVNodeReady = "tenancy.x-k8s.io/NodeReady"
+
 	DefaultOpaqueMetaPrefix      = "tenancy.x-k8s.io"
 	DefaultTransparentMetaPrefix = "transparency.tenancy.x-k8s.io"
 
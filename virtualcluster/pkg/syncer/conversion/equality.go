@@ -324,7 +324,8 @@ func (e vcEquality) CheckUWPodStatusEquality(pObj, vObj *v1.Pod) *v1.PodStatus {
 	}
 	vConditionMap := make(map[string]v1.PodCondition)
 	for _, c := range vObj.Status.Conditions {
-		if vReadinessGateSet.Has(string(c.Type)) {
+		// the vNode readiness is only known by the tenant pods.
+		if vReadinessGateSet.Has(string(c.Type)) || c.Type == constants.PodConditionVNodeReady {
 			vConditionMap[string(c.Type)] = c
 		}
 	}
@@ -25,6 +25,7 @@ import (
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 )
 
 func TestCheckDWKVEquality(t *testing.T) {
@@ -794,6 +795,45 @@ func TestCheckUWPodStatusEquality(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "vNode ready condition is kept",
+			pObj: &v1.Pod{
+				Status: v1.PodStatus{
+					Conditions: []v1.PodCondition{
+						{
+							Type:   v1.PodReady,
+							Status: v1.ConditionTrue,
+						},
+					},
+				},
+			},
+			vObj: &v1.Pod{
+				Status: v1.PodStatus{
+					Conditions: []v1.PodCondition{
+						{
+							Type:   v1.PodReady,
+							Status: v1.ConditionFalse,
+						},
+						{
+							Type:   constants.PodConditionVNodeReady,
+							Status: v1.ConditionFalse,
+						},
+					},
+				},
+			},
+			updatedVal: &v1.PodStatus{
+				Conditions: []v1.PodCondition{
+					{
+						Type:   v1.PodReady,
+						Status: v1.ConditionTrue,
+					},
+					{
+						Type:   constants.PodConditionVNodeReady,
+						Status: v1.ConditionFalse,
+					},
+				},
+			},
+		},
 	} {
 		t.Run(tt.name, func(tc *testing.T) {
 			val := Equality(nil, nil).CheckUWPodStatusEquality(tt.pObj, tt.vObj)
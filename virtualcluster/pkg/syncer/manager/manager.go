@@ -37,6 +37,8 @@ import (
 // then starts the controllers.
 type ControllerManager struct {
 	resourceSyncers map[ResourceSyncer]struct{}
+	// listeners are notified when a tenant cluster is added or removed.
+	listeners []listener.ClusterChangeListener
 }
 
 type ResourceSyncerOptions struct {
@@ -57,6 +59,7 @@ type ResourceSyncer interface {
 	reconciler.PatrolReconciler
 	GetMCController() *mc.MultiClusterController
 	GetUpwardController() *uw.UpwardController
+	GetPatroller() *pa.Patroller
 	GetListener() listener.ClusterChangeListener
 	StartUWS(stopCh <-chan struct{}) error
 	StartDWS(stopCh <-chan struct{}) error
@@ -72,7 +75,21 @@ func (m *ControllerManager) AddResourceSyncer(s ResourceSyncer) {
 		panic("resource Syncer should provide listener")
 	}
 
-	listener.AddListener(l)
+	m.listeners = append(m.listeners, l)
+}
+
+// GetListeners returns the cluster change listeners of the resource syncers.
+func (m *ControllerManager) GetListeners() []listener.ClusterChangeListener {
+	return m.listeners
+}
+
+// GetResourceSyncers returns the resource syncers managed by the ControllerManager.
+func (m *ControllerManager) GetResourceSyncers() []ResourceSyncer {
+	syncers := make([]ResourceSyncer, 0, len(m.resourceSyncers))
+	for s := range m.resourceSyncers {
+		syncers = append(syncers, s)
+	}
+	return syncers
 }
 
 type ResourceSyncerNew func(*config.SyncerConfiguration,
@@ -113,6 +130,10 @@ func (b *BaseResourceSyncer) GetUpwardController() *uw.UpwardController {
 	return b.UpwardController
 }
 
+func (b *BaseResourceSyncer) GetPatroller() *pa.Patroller {
+	return b.Patroller
+}
+
 func (b *BaseResourceSyncer) StartUWS(stopCh <-chan struct{}) error {
 	return nil
 }
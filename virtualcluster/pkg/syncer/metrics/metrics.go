@@ -25,17 +25,21 @@ import (
 )
 
 const (
-	ResourceSyncerSubsystem  = "syncer"
-	PodOperationsKey         = "pod_operations_total"
-	PodOperationsDurationKey = "pod_operations_duration_seconds"
-	CheckerMissMatchKey      = "checker_missmatch_count"
-	CheckerRemedyKey         = "checker_remedy_count"
-	CheckerScanDurationKey   = "checker_scan_duration_seconds"
-	DWSOperationCounterKey   = "dws_operations_total"
-	DWSOperationDurationKey  = "dws_operations_duration_seconds"
-	UWSOperationCounterKey   = "uws_operations_total"
-	UWSOperationDurationKey  = "uws_operations_duration_seconds"
-	ClusterHealthKey         = "virtual_cluster_health"
+	ResourceSyncerSubsystem           = "syncer"
+	PodOperationsKey                  = "pod_operations_total"
+	PodOperationsDurationKey          = "pod_operations_duration_seconds"
+	CheckerMissMatchKey               = "checker_missmatch_count"
+	CheckerRemedyKey                  = "checker_remedy_count"
+	CheckerReportKey                  = "checker_report_missmatch_count"
+	CheckerScanDurationKey            = "checker_scan_duration_seconds"
+	CheckerIncrementalScanDurationKey = "checker_incremental_scan_duration_seconds"
+	DWSOperationCounterKey            = "dws_operations_total"
+	DWSOperationDurationKey           = "dws_operations_duration_seconds"
+	UWSOperationCounterKey            = "uws_operations_total"
+	UWSOperationDurationKey           = "uws_operations_duration_seconds"
+	ClusterHealthKey                  = "virtual_cluster_health"
+	SuperClusterHealthKey             = "super_cluster_health"
+	ClusterSyncPausedKey              = "virtual_cluster_sync_paused"
 )
 
 var (
@@ -72,6 +76,14 @@ var (
 		},
 		[]string{"counter_name"},
 	)
+	CheckerReportedMissMatchStats = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: ResourceSyncerSubsystem,
+			Name:      CheckerReportKey,
+			Help:      "Number of mismatched objects recorded in the last checker report.",
+		},
+		[]string{"resource", "dry_run"},
+	)
 	CheckerScanDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Subsystem: ResourceSyncerSubsystem,
@@ -81,6 +93,15 @@ var (
 		},
 		[]string{"resource"},
 	)
+	CheckerIncrementalScanDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: ResourceSyncerSubsystem,
+			Name:      CheckerIncrementalScanDurationKey,
+			Help:      "Duration in seconds of each resource checker's scan time of the objects changed since its previous scan.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"resource"},
+	)
 	DWSOperationDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Subsystem: ResourceSyncerSubsystem,
@@ -120,6 +141,22 @@ var (
 		},
 		[]string{"status"},
 	)
+	SuperClusterHealthStats = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: ResourceSyncerSubsystem,
+			Name:      SuperClusterHealthKey,
+			Help:      "Whether each super cluster managed by the syncer is reachable (1) or not (0).",
+		},
+		[]string{"super_cluster"},
+	)
+	ClusterSyncPausedStats = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: ResourceSyncerSubsystem,
+			Name:      ClusterSyncPausedKey,
+			Help:      "Whether syncing each virtual cluster is paused (1) or not (0).",
+		},
+		[]string{"super_cluster", "vc_name"},
+	)
 )
 
 var registerMetrics sync.Once
@@ -131,12 +168,16 @@ func Register() {
 		prometheus.MustRegister(PodOperationsDuration)
 		prometheus.MustRegister(CheckerMissMatchStats)
 		prometheus.MustRegister(CheckerRemedyStats)
+		prometheus.MustRegister(CheckerReportedMissMatchStats)
 		prometheus.MustRegister(CheckerScanDuration)
+		prometheus.MustRegister(CheckerIncrementalScanDuration)
 		prometheus.MustRegister(DWSOperationCounter)
 		prometheus.MustRegister(DWSOperationDuration)
 		prometheus.MustRegister(UWSOperationDuration)
 		prometheus.MustRegister(UWSOperationCounter)
 		prometheus.MustRegister(ClusterHealthStats)
+		prometheus.MustRegister(SuperClusterHealthStats)
+		prometheus.MustRegister(ClusterSyncPausedStats)
 	})
 }
 
@@ -154,6 +195,10 @@ func RecordCheckerScanDuration(resource string, start time.Time) {
 	CheckerScanDuration.WithLabelValues(resource).Observe(SinceInSeconds(start))
 }
 
+func RecordCheckerIncrementalScanDuration(resource string, start time.Time) {
+	CheckerIncrementalScanDuration.WithLabelValues(resource).Observe(SinceInSeconds(start))
+}
+
 func RecordUWSOperationDuration(resource string, start time.Time) {
 	UWSOperationDuration.With(prometheus.Labels{"resource": resource}).Observe(SinceInSeconds(start))
 }
@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+)
+
+// SuperCluster holds the clients of a super cluster managed by the syncer.
+type SuperCluster struct {
+	ID              string
+	RestConfig      *rest.Config
+	Client          clientset.Interface
+	InformerFactory informers.SharedInformerFactory
+}
+
+// MultiSyncer syncs the virtual clusters to multiple super clusters. Each super cluster
+// is served by a Syncer with its own informers and workers, the tenant namespaces are
+// routed to the super clusters by their placement annotations. The reachability of each
+// super cluster is checked periodically.
+type MultiSyncer struct {
+	ids          []string
+	syncers      map[string]*Syncer
+	superClients map[string]clientset.Interface
+
+	mu      sync.Mutex
+	healthy map[string]bool
+}
+
+var _ Bootstrap = &MultiSyncer{}
+
+func NewMultiSyncer(
+	config *config.SyncerConfiguration,
+	virtualClusterClient vcclient.Interface,
+	virtualClusterInformer vcinformers.VirtualClusterInformer,
+	metaClusterClient clientset.Interface,
+	superClusters []SuperCluster,
+	recorder record.EventRecorder,
+) (*MultiSyncer, error) {
+	if len(superClusters) == 0 {
+		return nil, fmt.Errorf("no super cluster is given")
+	}
+	m := &MultiSyncer{
+		syncers:      make(map[string]*Syncer),
+		superClients: make(map[string]clientset.Interface),
+		healthy:      make(map[string]bool),
+	}
+	for _, sc := range superClusters {
+		if sc.ID == "" {
+			return nil, fmt.Errorf("super cluster id is required")
+		}
+		if _, exists := m.syncers[sc.ID]; exists {
+			return nil, fmt.Errorf("duplicated super cluster %s", sc.ID)
+		}
+
+		superConfig := *config
+		superConfig.SuperClusterID = sc.ID
+		superConfig.RestConfig = sc.RestConfig
+		s, err := New(&superConfig, virtualClusterClient, virtualClusterInformer, metaClusterClient, sc.Client, sc.InformerFactory, recorder)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create syncer for super cluster %s: %v", sc.ID, err)
+		}
+		m.ids = append(m.ids, sc.ID)
+		m.syncers[sc.ID] = s
+		m.superClients[sc.ID] = sc.Client
+	}
+	sort.Strings(m.ids)
+	if config.DefaultSuperClusterID != "" {
+		if _, exists := m.syncers[config.DefaultSuperClusterID]; !exists {
+			return nil, fmt.Errorf("default super cluster %s is not managed by the syncer", config.DefaultSuperClusterID)
+		}
+	}
+	return m, nil
+}
+
+// Run starts the syncer of each super cluster.
+func (m *MultiSyncer) Run(stopChan <-chan struct{}) {
+	for _, id := range m.ids {
+		klog.Infof("starting syncer for super cluster %s", id)
+		m.syncers[id].Run(stopChan)
+	}
+	go wait.Until(m.healthPatrol, 1*time.Minute, stopChan)
+}
+
+// ListenAndServe initializes a server to respond to HTTP network requests on the syncer.
+// Only one server is started for all the super clusters. It serves the metrics, which are
+// registered globally, so the metrics of the syncers of all the super clusters are served.
+func (m *MultiSyncer) ListenAndServe(address, certFile, keyFile string) {
+	m.syncers[m.ids[0]].ListenAndServe(address, certFile, keyFile)
+}
+
+// IsHealthy returns whether the super cluster was reachable at the last health check.
+func (m *MultiSyncer) IsHealthy(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.healthy[id]
+}
+
+func (m *MultiSyncer) healthPatrol() {
+	var wg sync.WaitGroup
+	for _, id := range m.ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			m.checkSuperClusterHealth(id)
+		}(id)
+	}
+	wg.Wait()
+}
+
+// checkSuperClusterHealth checks if we can connect to super apiserver.
+func (m *MultiSyncer) checkSuperClusterHealth(id string) {
+	_, err := m.superClients[id].Discovery().ServerVersion()
+	healthy := err == nil
+
+	m.mu.Lock()
+	wasHealthy, checked := m.healthy[id]
+	m.healthy[id] = healthy
+	m.mu.Unlock()
+
+	if healthy {
+		metrics.SuperClusterHealthStats.WithLabelValues(id).Set(1)
+		if checked && !wasHealthy {
+			klog.Infof("super cluster %s is healthy again", id)
+		}
+		return
+	}
+	metrics.SuperClusterHealthStats.WithLabelValues(id).Set(0)
+	klog.Warningf("super cluster %s unhealth: %v", id, err)
+}
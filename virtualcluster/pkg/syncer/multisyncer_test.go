@@ -0,0 +1,190 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+
+	vcfake "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned/fake"
+	vcinformerFactory "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
+)
+
+type fakeResourceSyncer struct {
+	manager.BaseResourceSyncer
+}
+
+func init() {
+	plugin.SyncerResourceRegister.Register(&plugin.Registration{
+		ID: "multisyncer-test",
+		InitFn: func(ctx *plugin.InitContext) (interface{}, error) {
+			s := &fakeResourceSyncer{}
+			mcc, err := mc.NewMCController(&corev1.Namespace{}, &corev1.NamespaceList{}, s)
+			if err != nil {
+				return nil, err
+			}
+			s.MultiClusterController = mcc
+			return s, nil
+		},
+	})
+}
+
+func newSuperCluster(id string) SuperCluster {
+	client := fake.NewSimpleClientset()
+	return SuperCluster{ID: id, Client: client, InformerFactory: informers.NewSharedInformerFactory(client, 0)}
+}
+
+func TestNewMultiSyncer(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		superClusters []SuperCluster
+		defaultID     string
+		expectedIDs   []string
+		expectedErr   bool
+	}{
+		{
+			name:        "no super cluster",
+			expectedErr: true,
+		},
+		{
+			name:          "super cluster without id",
+			superClusters: []SuperCluster{newSuperCluster("")},
+			expectedErr:   true,
+		},
+		{
+			name:          "duplicated super clusters",
+			superClusters: []SuperCluster{newSuperCluster("s1"), newSuperCluster("s1")},
+			expectedErr:   true,
+		},
+		{
+			name:          "default super cluster not managed",
+			superClusters: []SuperCluster{newSuperCluster("s1")},
+			defaultID:     "s2",
+			expectedErr:   true,
+		},
+		{
+			name:          "multiple super clusters",
+			superClusters: []SuperCluster{newSuperCluster("s2"), newSuperCluster("s1")},
+			defaultID:     "s1",
+			expectedIDs:   []string{"s1", "s2"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			vcClient := vcfake.NewSimpleClientset()
+			vcInformer := vcinformerFactory.NewSharedInformerFactory(vcClient, 0).Tenancy().V1alpha1().VirtualClusters()
+			m, err := NewMultiSyncer(&config.SyncerConfiguration{DefaultSuperClusterID: tc.defaultID}, vcClient, vcInformer,
+				fake.NewSimpleClientset(), tc.superClusters, record.NewFakeRecorder(10))
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(m.ids) != len(tc.expectedIDs) {
+				t.Fatalf("expected super clusters %v, got %v", tc.expectedIDs, m.ids)
+			}
+
+			// a namespace without placement is only synced to the default super cluster.
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+			// a namespace placed to a super cluster is only synced to it.
+			placedNS := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:        "placed",
+				Annotations: map[string]string{utilconstants.LabelScheduledPlacements: `{"s2":1}`},
+			}}
+			for i, id := range tc.expectedIDs {
+				if m.ids[i] != id {
+					t.Fatalf("expected super clusters %v, got %v", tc.expectedIDs, m.ids)
+				}
+				s := m.syncers[id]
+				if s.config.SuperClusterID != id {
+					t.Errorf("expected syncer of super cluster %s, got %s", id, s.config.SuperClusterID)
+				}
+				var mcc *mc.MultiClusterController
+				for _, rs := range s.controllerManager.GetResourceSyncers() {
+					if f, ok := rs.(*fakeResourceSyncer); ok {
+						mcc = f.MultiClusterController
+					}
+				}
+				if mcc == nil {
+					t.Fatalf("expected the resource syncer to be loaded for super cluster %s", id)
+				}
+				if mcc.GetSuperClusterID() != id {
+					t.Errorf("expected mc-controller of super cluster %s, got %s", id, mcc.GetSuperClusterID())
+				}
+				if got := mcc.IsNamespaceScheduled(ns); got != (id == tc.defaultID) {
+					t.Errorf("unexpected namespace without placement scheduled %v to super cluster %s", got, id)
+				}
+				if got := mcc.IsNamespaceScheduled(placedNS); got != (id == "s2") {
+					t.Errorf("unexpected placed namespace scheduled %v to super cluster %s", got, id)
+				}
+			}
+		})
+	}
+}
+
+func TestSuperClusterHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	unreachable := newSuperCluster("s2")
+	unreachable.Client = clientset.NewForConfigOrDie(&rest.Config{Host: server.URL})
+
+	vcClient := vcfake.NewSimpleClientset()
+	vcInformer := vcinformerFactory.NewSharedInformerFactory(vcClient, 0).Tenancy().V1alpha1().VirtualClusters()
+	m, err := NewMultiSyncer(&config.SyncerConfiguration{}, vcClient, vcInformer,
+		fake.NewSimpleClientset(), []SuperCluster{newSuperCluster("s1"), unreachable}, record.NewFakeRecorder(10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.IsHealthy("s1") || m.IsHealthy("s2") {
+		t.Errorf("expected the super clusters not to be healthy before checked")
+	}
+
+	m.healthPatrol()
+	if !m.IsHealthy("s1") {
+		t.Errorf("expected super cluster s1 to be healthy")
+	}
+	if m.IsHealthy("s2") {
+		t.Errorf("expected super cluster s2 not to be healthy")
+	}
+	if got := testutil.ToFloat64(metrics.SuperClusterHealthStats.WithLabelValues("s1")); got != 1 {
+		t.Errorf("expected the health of super cluster s1 to be 1, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.SuperClusterHealthStats.WithLabelValues("s2")); got != 0 {
+		t.Errorf("expected the health of super cluster s2 to be 0, got %v", got)
+	}
+}
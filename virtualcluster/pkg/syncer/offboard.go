@@ -0,0 +1,269 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	strutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/util/strings"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+// offboardingRequeuePeriod is the period to check whether the super cluster objects
+// of a deleted VirtualCluster are gone.
+const offboardingRequeuePeriod = 5 * time.Second
+
+// offboardingFinalizer returns the finalizer held by the syncer. Each super cluster holds
+// its own finalizer if the syncer manages multiple super clusters.
+func (s *Syncer) offboardingFinalizer() string {
+	if s.config.SuperClusterID == "" {
+		return constants.VirtualClusterOffboardingFinalizer
+	}
+	return constants.VirtualClusterOffboardingFinalizer + "." + s.config.SuperClusterID
+}
+
+// ensureOffboardingFinalizer adds the offboarding finalizer to a running VirtualCluster.
+func (s *Syncer) ensureOffboardingFinalizer(vc *v1alpha1.VirtualCluster) error {
+	finalizer := s.offboardingFinalizer()
+	if strutil.ContainString(vc.Finalizers, finalizer) {
+		return nil
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest, err := s.vcClient.TenancyV1alpha1().VirtualClusters(vc.Namespace).Get(vc.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if latest.DeletionTimestamp != nil || strutil.ContainString(latest.Finalizers, finalizer) {
+			return nil
+		}
+		latest.Finalizers = append(latest.Finalizers, finalizer)
+		_, err = s.vcClient.TenancyV1alpha1().VirtualClusters(vc.Namespace).Update(latest)
+		return err
+	})
+}
+
+// offboardCluster stops syncing a deleted VirtualCluster and removes its objects from the
+// super cluster: the pPods are drained with their grace periods, then the super cluster
+// namespaces are deleted. The finalizer is released once nothing of the cluster remains.
+func (s *Syncer) offboardCluster(key string, vc *v1alpha1.VirtualCluster) error {
+	// stop syncing the cluster so that nothing is created in super cluster again.
+	s.removeCluster(key)
+
+	if !strutil.ContainString(vc.Finalizers, s.offboardingFinalizer()) {
+		return nil
+	}
+
+	clusterName := conversion.ToClusterKey(vc)
+	done, err := s.gcSuperClusterObjects(clusterName)
+	if err != nil {
+		return err
+	}
+	if !done {
+		s.queue.AddAfter(key, offboardingRequeuePeriod)
+		return nil
+	}
+
+	remaining, err := s.remainingSuperClusterObjects(clusterName, vc)
+	if err != nil {
+		return err
+	}
+	if len(remaining) != 0 {
+		s.recorder.Eventf(vc, corev1.EventTypeWarning, "OffboardingBlocked", "VirtualCluster %s objects remain in super cluster: %s", clusterName, strings.Join(remaining, ", "))
+		s.queue.AddAfter(key, offboardingRequeuePeriod)
+		return nil
+	}
+
+	if err := s.releaseOffboardingFinalizer(vc); err != nil {
+		return err
+	}
+	klog.Infof("cluster %s is offboarded from super cluster", clusterName)
+	s.recorder.Eventf(vc, corev1.EventTypeNormal, "Offboarded", "VirtualCluster %s objects are removed from super cluster", clusterName)
+	return nil
+}
+
+// gcSuperClusterObjects drains the pPods and deletes the super cluster namespaces of the
+// cluster. It returns true if no super cluster namespace is left.
+func (s *Syncer) gcSuperClusterObjects(clusterName string) (bool, error) {
+	namespaces, err := s.superClusterNamespaces(clusterName)
+	if err != nil {
+		return false, err
+	}
+
+	drained := true
+	for _, ns := range namespaces {
+		nsDrained, err := s.drainPods(ns.Name)
+		if err != nil {
+			return false, err
+		}
+		drained = drained && nsDrained
+	}
+	if !drained {
+		return false, nil
+	}
+
+	for _, ns := range namespaces {
+		if ns.DeletionTimestamp != nil {
+			continue
+		}
+		deleteOptions := metav1.DeleteOptions{PropagationPolicy: &constants.DefaultDeletionPolicy}
+		if err := s.superClient.CoreV1().Namespaces().Delete(context.TODO(), ns.Name, deleteOptions); err != nil && !apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("failed to delete super cluster namespace %s: %v", ns.Name, err)
+		}
+		klog.Infof("deleted super cluster namespace %s of cluster %s", ns.Name, clusterName)
+	}
+	return len(namespaces) == 0, nil
+}
+
+// drainPods deletes the pPods in the namespace with their grace periods, the pPods
+// still terminating long after their grace periods are force deleted. It returns true if
+// no pPod is left.
+func (s *Syncer) drainPods(namespace string) (bool, error) {
+	pods, err := s.superClient.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		deleteOptions := metav1.DeleteOptions{
+			GracePeriodSeconds: pod.Spec.TerminationGracePeriodSeconds,
+			Preconditions:      metav1.NewUIDPreconditions(string(pod.UID)),
+		}
+		if pod.DeletionTimestamp != nil {
+			if now.Before(pod.DeletionTimestamp.Add(constants.DefaultOffboardingForceDeleteDelay)) {
+				continue
+			}
+			klog.Warningf("force deleting pPod %s/%s which is terminating beyond its grace period", pod.Namespace, pod.Name)
+			deleteOptions.GracePeriodSeconds = pointer.Int64Ptr(0)
+		}
+		if err := s.superClient.CoreV1().Pods(namespace).Delete(context.TODO(), pod.Name, deleteOptions); err != nil && !apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("failed to delete pPod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	return len(pods.Items) == 0, nil
+}
+
+// superClusterNamespaces returns the super cluster namespaces created for the cluster.
+func (s *Syncer) superClusterNamespaces(clusterName string) ([]corev1.Namespace, error) {
+	nsList, err := s.superClient.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var namespaces []corev1.Namespace
+	for _, ns := range nsList.Items {
+		if ns.Annotations[constants.LabelCluster] == clusterName {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces, nil
+}
+
+// remainingSuperClusterObjects returns the objects of the cluster left in super cluster.
+func (s *Syncer) remainingSuperClusterObjects(clusterName string, vc *v1alpha1.VirtualCluster) ([]string, error) {
+	var remaining []string
+	namespaces, err := s.superClusterNamespaces(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	for _, ns := range namespaces {
+		remaining = append(remaining, "Namespace "+ns.Name)
+	}
+
+	opts := metav1.ListOptions{LabelSelector: labels.SelectorFromSet(labels.Set{
+		constants.LabelVCName:      vc.Name,
+		constants.LabelVCNamespace: vc.Namespace,
+	}).String()}
+	ctx := context.TODO()
+	listFuncs := map[string]func() (runtime.Object, error){
+		"Pod": func() (runtime.Object, error) {
+			return s.superClient.CoreV1().Pods(metav1.NamespaceAll).List(ctx, opts)
+		},
+		"Service": func() (runtime.Object, error) {
+			return s.superClient.CoreV1().Services(metav1.NamespaceAll).List(ctx, opts)
+		},
+		"ConfigMap": func() (runtime.Object, error) {
+			return s.superClient.CoreV1().ConfigMaps(metav1.NamespaceAll).List(ctx, opts)
+		},
+		"Secret": func() (runtime.Object, error) {
+			return s.superClient.CoreV1().Secrets(metav1.NamespaceAll).List(ctx, opts)
+		},
+		"ServiceAccount": func() (runtime.Object, error) {
+			return s.superClient.CoreV1().ServiceAccounts(metav1.NamespaceAll).List(ctx, opts)
+		},
+		"PersistentVolumeClaim": func() (runtime.Object, error) {
+			return s.superClient.CoreV1().PersistentVolumeClaims(metav1.NamespaceAll).List(ctx, opts)
+		},
+	}
+	for kind, list := range listFuncs {
+		objList, err := list()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %v", kind, err)
+		}
+		items, err := meta.ExtractList(objList)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			obj, err := meta.Accessor(item)
+			if err != nil {
+				return nil, err
+			}
+			if obj.GetAnnotations()[constants.LabelCluster] == clusterName {
+				remaining = append(remaining, fmt.Sprintf("%s %s/%s", kind, obj.GetNamespace(), obj.GetName()))
+			}
+		}
+	}
+	sort.Strings(remaining)
+	return remaining, nil
+}
+
+// releaseOffboardingFinalizer removes the offboarding finalizer from the VirtualCluster.
+func (s *Syncer) releaseOffboardingFinalizer(vc *v1alpha1.VirtualCluster) error {
+	finalizer := s.offboardingFinalizer()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest, err := s.vcClient.TenancyV1alpha1().VirtualClusters(vc.Namespace).Get(vc.Name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if !strutil.ContainString(latest.Finalizers, finalizer) {
+			return nil
+		}
+		latest.Finalizers = strutil.RemoveString(latest.Finalizers, finalizer)
+		_, err = s.vcClient.TenancyV1alpha1().VirtualClusters(vc.Namespace).Update(latest)
+		return err
+	})
+}
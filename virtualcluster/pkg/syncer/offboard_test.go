@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcfake "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned/fake"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
+)
+
+func TestOffboardCluster(t *testing.T) {
+	now := metav1.Now()
+	vc := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "tenant",
+			Name:              "vc",
+			UID:               "vc-uid",
+			DeletionTimestamp: &now,
+			Finalizers:        []string{constants.VirtualClusterOffboardingFinalizer, "other"},
+		},
+	}
+	clusterName := conversion.ToClusterKey(vc)
+	superNS := conversion.ToSuperClusterNamespace(clusterName, "default")
+	superClient := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        superNS,
+			Annotations: map[string]string{constants.LabelCluster: clusterName},
+		}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "unrelated"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace:   superNS,
+			Name:        "pod",
+			Annotations: map[string]string{constants.LabelCluster: clusterName},
+		}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "unrelated", Name: "pod"}},
+	)
+	vcClient := vcfake.NewSimpleClientset(vc)
+
+	s := &Syncer{
+		config:      &config.SyncerConfiguration{},
+		vcClient:    vcClient,
+		superClient: superClient,
+		recorder:    record.NewFakeRecorder(10),
+		queue:       workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "virtual_cluster"),
+		clusterSet:  map[string]mc.ClusterInterface{"tenant/vc": nil},
+	}
+	key := "tenant/vc"
+
+	// the pPods are drained before the namespaces are deleted.
+	if err := s.offboardCluster(key, vc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, exists := s.clusterSet[key]; exists {
+		t.Errorf("expected the cluster to stop syncing")
+	}
+	if _, err := superClient.CoreV1().Namespaces().Get(context.TODO(), superNS, metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the namespace to be kept until the pPods are drained: %v", err)
+	}
+	if pods, _ := superClient.CoreV1().Pods(superNS).List(context.TODO(), metav1.ListOptions{}); len(pods.Items) != 0 {
+		t.Errorf("expected the pPods to be drained, got %d", len(pods.Items))
+	}
+
+	// the namespaces are deleted.
+	if err := s.offboardCluster(key, vc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := superClient.CoreV1().Namespaces().Get(context.TODO(), superNS, metav1.GetOptions{}); err == nil {
+		t.Errorf("expected the namespace %s to be deleted", superNS)
+	}
+	assertFinalizers(t, vcClient, []string{constants.VirtualClusterOffboardingFinalizer, "other"})
+
+	// nothing remains, the finalizer is released.
+	if err := s.offboardCluster(key, vc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertFinalizers(t, vcClient, []string{"other"})
+	if _, err := superClient.CoreV1().Pods("unrelated").Get(context.TODO(), "pod", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected unrelated pod to be kept: %v", err)
+	}
+}
+
+func TestOffboardingFinalizer(t *testing.T) {
+	s := &Syncer{config: &config.SyncerConfiguration{}}
+	if s.offboardingFinalizer() != constants.VirtualClusterOffboardingFinalizer {
+		t.Errorf("unexpected finalizer %s", s.offboardingFinalizer())
+	}
+	s.config.SuperClusterID = "s1"
+	if s.offboardingFinalizer() != constants.VirtualClusterOffboardingFinalizer+".s1" {
+		t.Errorf("unexpected finalizer %s", s.offboardingFinalizer())
+	}
+}
+
+func assertFinalizers(t *testing.T, vcClient *vcfake.Clientset, expected []string) {
+	t.Helper()
+	vc, err := vcClient.TenancyV1alpha1().VirtualClusters("tenant").Get("vc", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get vc: %v", err)
+	}
+	if len(vc.Finalizers) != len(expected) {
+		t.Fatalf("expected finalizers %v, got %v", expected, vc.Finalizers)
+	}
+	for i := range expected {
+		if vc.Finalizers[i] != expected[i] {
+			t.Errorf("expected finalizers %v, got %v", expected, vc.Finalizers)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package differ
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	clientgocache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+// SuperObjectFunc converts an object of the super control plane into the ClusterObject
+// inserted into the super diff set. The object is skipped if it returns false.
+type SuperObjectFunc func(obj client.Object) (ClusterObject, bool)
+
+// TenantObjectFunc converts an object of a tenant control plane into the ClusterObject
+// inserted into the tenant diff set. The object is skipped if it returns false.
+type TenantObjectFunc func(cluster string, obj client.Object) (ClusterObject, bool)
+
+// DefaultSuperObject keys the super object by its namespace and name.
+func DefaultSuperObject(obj client.Object) (ClusterObject, bool) {
+	return ClusterObject{Object: obj, Key: DefaultClusterObjectKey(obj, "")}, true
+}
+
+// DefaultTenantObject keys the tenant object by its super control plane namespace and name.
+func DefaultTenantObject(cluster string, obj client.Object) (ClusterObject, bool) {
+	return ClusterObject{Object: obj, OwnerCluster: cluster, Key: DefaultClusterObjectKey(obj, cluster)}, true
+}
+
+type event struct {
+	tenant  bool
+	deleted bool
+	object  ClusterObject
+	// removedCluster is set when all the tenant objects of the cluster are dropped.
+	removedCluster string
+}
+
+// Incremental maintains the tenant and super diff sets from informer events so that
+// a checker only compares the objects changed since its last run. The sets are seeded
+// and periodically replaced by a full scan, which catches anything the events missed.
+type Incremental struct {
+	superFunc  SuperObjectFunc
+	tenantFunc TenantObjectFunc
+
+	mu    sync.Mutex
+	vSet  Differ
+	pSet  Differ
+	dirty sets.String
+	// pending holds the events received during an ongoing full scan, they are
+	// replayed on top of the sets built by the scan.
+	pending []event
+	seeded  bool
+}
+
+// NewIncremental creates an incremental differ. Nil funcs fall back to the default keys.
+func NewIncremental(superFunc SuperObjectFunc, tenantFunc TenantObjectFunc) *Incremental {
+	if superFunc == nil {
+		superFunc = DefaultSuperObject
+	}
+	if tenantFunc == nil {
+		tenantFunc = DefaultTenantObject
+	}
+	return &Incremental{
+		superFunc:  superFunc,
+		tenantFunc: tenantFunc,
+		vSet:       NewDiffSet(),
+		pSet:       NewDiffSet(),
+		dirty:      sets.NewString(),
+	}
+}
+
+// SuperEventHandler returns the handler feeding the super diff set.
+func (d *Incremental) SuperEventHandler() clientgocache.ResourceEventHandler {
+	return d.eventHandler(func(obj client.Object) (ClusterObject, bool) {
+		return d.superFunc(obj)
+	}, false)
+}
+
+// TenantEventHandler returns the handler feeding the tenant diff set with the objects of the cluster.
+func (d *Incremental) TenantEventHandler(cluster string) clientgocache.ResourceEventHandler {
+	return d.eventHandler(func(obj client.Object) (ClusterObject, bool) {
+		return d.tenantFunc(cluster, obj)
+	}, true)
+}
+
+func (d *Incremental) eventHandler(convert func(client.Object) (ClusterObject, bool), tenant bool) clientgocache.ResourceEventHandler {
+	observe := func(obj interface{}, deleted bool) {
+		if tombstone, ok := obj.(clientgocache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		o, ok := obj.(client.Object)
+		if !ok {
+			return
+		}
+		co, ok := convert(o)
+		if !ok {
+			return
+		}
+		d.observe(event{tenant: tenant, deleted: deleted, object: co})
+	}
+	return clientgocache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			observe(obj, false)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			observe(newObj, false)
+		},
+		DeleteFunc: func(obj interface{}) {
+			observe(obj, true)
+		},
+	}
+}
+
+func (d *Incremental) observe(e event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.apply(e)
+	if d.pending != nil {
+		d.pending = append(d.pending, e)
+	}
+}
+
+// RemoveCluster drops the tenant objects of a cluster that is no longer watched, the
+// super objects it owned are compared again by the next Sync.
+func (d *Incremental) RemoveCluster(cluster string) {
+	d.observe(event{tenant: true, removedCluster: cluster})
+}
+
+func (d *Incremental) apply(e event) {
+	if e.removedCluster != "" {
+		for key := range d.vSet.GetKeys() {
+			if d.vSet.Get(key).OwnerCluster == e.removedCluster {
+				d.vSet.Delete(ClusterObject{Key: key})
+				d.dirty.Insert(key)
+			}
+		}
+		return
+	}
+	set := d.pSet
+	if e.tenant {
+		set = d.vSet
+	}
+	if e.deleted {
+		set.Delete(e.object)
+	} else {
+		set.Insert(e.object)
+	}
+	d.dirty.Insert(e.object.Key)
+}
+
+// Seeded returns true once a full scan has populated the diff sets.
+func (d *Incremental) Seeded() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.seeded
+}
+
+// Dirty returns the number of keys changed since the last Sync.
+func (d *Incremental) Dirty() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.dirty.Len()
+}
+
+// BeginFullScan must be called before a full scan lists the caches, so that the
+// events received while scanning are not lost when Reset replaces the sets.
+func (d *Incremental) BeginFullScan() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pending = []event{}
+}
+
+// Reset replaces the diff sets with the ones built by a full scan. The objects found
+// without a counterpart stay dirty, so a remediation failed by the scan is retried
+// by the next Sync.
+func (d *Incremental) Reset(vSet, pSet Differ) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.vSet = vSet
+	d.pSet = pSet
+	d.dirty = unmatchedKeys(vSet, pSet)
+	// replaying is idempotent, the events may or may not be observed by the scan.
+	for _, e := range d.pending {
+		d.apply(e)
+	}
+	d.pending = nil
+	d.seeded = true
+}
+
+// Sync compares the keys changed since the last Sync and calls the handler like
+// Differ.Difference does. The keys whose object is still missing its counterpart
+// after the handler returns, e.g. the remediation failed or was skipped, are compared
+// again by the next Sync. It returns the number of compared keys.
+func (d *Incremental) Sync(handler Handler) int {
+	d.mu.Lock()
+	keys := d.dirty
+	d.dirty = sets.NewString()
+	vSet, pSet := d.vSet, d.pSet
+	d.mu.Unlock()
+
+	vDirty, pDirty := NewDiffSet(), NewDiffSet()
+	for key := range keys {
+		if vSet.Has(ClusterObject{Key: key}) {
+			vDirty.Insert(vSet.Get(key))
+		}
+		if pSet.Has(ClusterObject{Key: key}) {
+			pDirty.Insert(pSet.Get(key))
+		}
+	}
+	vDirty.Difference(pDirty, handler)
+
+	// the keys changed while the handler ran are already dirty again.
+	d.mu.Lock()
+	d.dirty = d.dirty.Union(unmatchedKeys(vDirty, pDirty))
+	d.mu.Unlock()
+	return keys.Len()
+}
+
+// unmatchedKeys returns the keys of the tenant objects missing in the super set and
+// of the super objects owned by a tenant cluster missing in the tenant set.
+func unmatchedKeys(vSet, pSet Differ) sets.String {
+	vKeys, pKeys := vSet.GetKeys(), pSet.GetKeys()
+	keys := vKeys.Difference(pKeys)
+	for key := range pKeys.Difference(vKeys) {
+		if cluster, _ := conversion.GetVirtualOwner(pSet.Get(key)); cluster != "" {
+			keys.Insert(key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package differ
+
+import (
+	"sync"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	clientgocache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+type counter struct {
+	mu      sync.Mutex
+	add     map[string]int
+	update  map[string]int
+	deleted map[string]int
+}
+
+func newCounter() *counter {
+	return &counter{add: map[string]int{}, update: map[string]int{}, deleted: map[string]int{}}
+}
+
+func (c *counter) handler() Handler {
+	return HandlerFuncs{
+		AddFunc: func(obj ClusterObject) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.add[obj.Key]++
+		},
+		UpdateFunc: func(obj1, obj2 ClusterObject) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.update[obj1.Key]++
+		},
+		DeleteFunc: func(obj ClusterObject) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.deleted[obj.Key]++
+		},
+	}
+}
+
+func TestIncrementalSync(t *testing.T) {
+	superNS := conversion.ToSuperClusterNamespace("t1", "n1")
+	d := NewIncremental(nil, nil)
+	tenant := d.TenantEventHandler("t1")
+	super := d.SuperEventHandler()
+
+	// seed the sets by a full scan, a is synced and b is missing in super.
+	d.BeginFullScan()
+	ta, _ := DefaultTenantObject("t1", makeObject("n1", "a"))
+	tb, _ := DefaultTenantObject("t1", makeObject("n1", "b"))
+	pa, _ := DefaultSuperObject(makeObject(superNS, "a"))
+	vSet, pSet := NewDiffSet(ta, tb), NewDiffSet(pa)
+	// an event received during the scan is replayed after the reset.
+	tenant.OnAdd(makeObject("n1", "c"))
+	vSet.Difference(pSet, HandlerFuncs{})
+	d.Reset(vSet, pSet)
+	if !d.Seeded() {
+		t.Fatalf("expected the differ to be seeded")
+	}
+	// b is still missing in super, c is replayed.
+	if d.Dirty() != 2 {
+		t.Errorf("expected 2 dirty keys, got %d", d.Dirty())
+	}
+
+	super.OnUpdate(makeObject(superNS, "a"), makeObject(superNS, "a"))
+	// d is not owned by a tenant cluster, e is an orphan of t1.
+	super.OnAdd(makeObject(superNS, "d"))
+	orphan := makeObject(superNS, "e")
+	orphan.SetAnnotations(map[string]string{constants.LabelCluster: "t1", constants.LabelNamespace: "n1"})
+	super.OnAdd(orphan)
+	tenant.OnDelete(clientgocache.DeletedFinalStateUnknown{Key: "n1/b", Obj: makeObject("n1", "b")})
+
+	c := newCounter()
+	if n := d.Sync(c.handler()); n != 5 {
+		t.Errorf("expected 5 compared keys, got %d", n)
+	}
+	if !equality.Semantic.DeepEqual(c.add, map[string]int{superNS + "/c": 1}) {
+		t.Errorf("unexpected add %+v", c.add)
+	}
+	if !equality.Semantic.DeepEqual(c.update, map[string]int{superNS + "/a": 1}) {
+		t.Errorf("unexpected update %+v", c.update)
+	}
+	if !equality.Semantic.DeepEqual(c.deleted, map[string]int{superNS + "/d": 1, superNS + "/e": 1}) {
+		t.Errorf("unexpected delete %+v", c.deleted)
+	}
+
+	// c and e are compared again as their remediation didn't take effect yet.
+	c = newCounter()
+	if n := d.Sync(c.handler()); n != 2 {
+		t.Errorf("expected 2 compared keys, got %d", n)
+	}
+	if !equality.Semantic.DeepEqual(c.add, map[string]int{superNS + "/c": 1}) {
+		t.Errorf("unexpected add %+v", c.add)
+	}
+	if !equality.Semantic.DeepEqual(c.deleted, map[string]int{superNS + "/e": 1}) {
+		t.Errorf("unexpected delete %+v", c.deleted)
+	}
+
+	// c is created and e is deleted in super.
+	super.OnAdd(makeObject(superNS, "c"))
+	super.OnDelete(orphan)
+	c = newCounter()
+	if n := d.Sync(c.handler()); n != 2 || len(c.add)+len(c.deleted) != 0 {
+		t.Errorf("expected c and e to be in sync, got %d keys, add %+v, delete %+v", n, c.add, c.deleted)
+	}
+
+	// nothing changed since the last sync.
+	c = newCounter()
+	if n := d.Sync(c.handler()); n != 0 || len(c.add)+len(c.update)+len(c.deleted) != 0 {
+		t.Errorf("expected no comparison, got %d keys", n)
+	}
+}
+
+func TestIncrementalSkip(t *testing.T) {
+	d := NewIncremental(func(obj client.Object) (ClusterObject, bool) {
+		return ClusterObject{}, false
+	}, nil)
+	d.SuperEventHandler().OnAdd(makeObject("ns", "a"))
+	d.SuperEventHandler().OnAdd("not an object")
+	if d.Dirty() != 0 {
+		t.Errorf("expected skipped objects not to be tracked, got %d dirty keys", d.Dirty())
+	}
+}
+
+func TestIncrementalRemoveCluster(t *testing.T) {
+	ns1 := conversion.ToSuperClusterNamespace("t1", "n1")
+	ns2 := conversion.ToSuperClusterNamespace("t2", "n1")
+	d := NewIncremental(nil, nil)
+
+	t1a, _ := DefaultTenantObject("t1", makeObject("n1", "a"))
+	t2a, _ := DefaultTenantObject("t2", makeObject("n1", "a"))
+	p1a, _ := DefaultSuperObject(makeObject(ns1, "a"))
+	p2a, _ := DefaultSuperObject(makeObject(ns2, "a"))
+	d.BeginFullScan()
+	// a removal received during the scan is replayed after the reset.
+	d.RemoveCluster("t2")
+	d.Reset(NewDiffSet(t1a, t2a), NewDiffSet(p1a, p2a))
+	d.Sync(HandlerFuncs{})
+
+	d.RemoveCluster("t1")
+	c := newCounter()
+	if n := d.Sync(c.handler()); n != 1 {
+		t.Errorf("expected 1 compared key, got %d", n)
+	}
+	if !equality.Semantic.DeepEqual(c.deleted, map[string]int{ns1 + "/a": 1}) {
+		t.Errorf("unexpected delete %+v", c.deleted)
+	}
+	if len(c.add)+len(c.update) != 0 {
+		t.Errorf("unexpected add %+v or update %+v", c.add, c.update)
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package patrol
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	clientgocache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+)
+
+// TenantClusterSource feeds the events of the watched tenant clusters, it is
+// implemented by the MultiClusterController.
+type TenantClusterSource interface {
+	AddClusterEventHandler(fn func(clusterName string) clientgocache.ResourceEventHandler)
+	AddClusterRemovedHandler(fn func(clusterName string))
+}
+
+// WithIncrementalDiffer creates an incremental differ fed by the super informer and the tenant
+// clusters if the IncrementalPatrol feature is enabled, the option is a no-op otherwise. Like
+// the full scans, the super objects not matching the super cluster lister selector are skipped.
+func WithIncrementalDiffer(superInformer clientgocache.SharedIndexInformer, tenants TenantClusterSource,
+	superFunc differ.SuperObjectFunc, tenantFunc differ.TenantObjectFunc, backstop time.Duration) OptConfig {
+	if !featuregate.DefaultFeatureGate.Enabled(featuregate.IncrementalPatrol) {
+		return func(*Options) {}
+	}
+	if superFunc == nil {
+		superFunc = differ.DefaultSuperObject
+	}
+	d := differ.NewIncremental(func(obj client.Object) (differ.ClusterObject, bool) {
+		if !util.GetSuperClusterListerLabelsSelector().Matches(labels.Set(obj.GetLabels())) {
+			return differ.ClusterObject{}, false
+		}
+		return superFunc(obj)
+	}, tenantFunc)
+	superInformer.AddEventHandler(d.SuperEventHandler())
+	tenants.AddClusterEventHandler(d.TenantEventHandler)
+	tenants.AddClusterRemovedHandler(d.RemoveCluster)
+	return WithIncremental(d, backstop)
+}
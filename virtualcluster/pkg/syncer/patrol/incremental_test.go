@@ -0,0 +1,62 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package patrol
+
+import (
+	"testing"
+
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgocache "k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+)
+
+type fakeTenantClusterSource struct{}
+
+func (fakeTenantClusterSource) AddClusterEventHandler(fn func(clusterName string) clientgocache.ResourceEventHandler) {
+}
+
+func (fakeTenantClusterSource) AddClusterRemovedHandler(fn func(clusterName string)) {}
+
+func TestIncrementalDifferSuperClusterSelector(t *testing.T) {
+	for _, gate := range []featuregate.Feature{featuregate.IncrementalPatrol, featuregate.SuperClusterLabelling, featuregate.SuperClusterLabelFilter} {
+		if err := featuregate.DefaultFeatureGate.Set(gate, true); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer func(gate featuregate.Feature) {
+			_ = featuregate.DefaultFeatureGate.Set(gate, false)
+		}(gate)
+	}
+
+	informer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Core().V1().Pods().Informer()
+	opts := &Options{}
+	WithIncrementalDiffer(informer, fakeTenantClusterSource{}, nil, nil, 0)(opts)
+	if opts.Incremental == nil {
+		t.Fatalf("expected the incremental differ to be created")
+	}
+
+	controlled := makePod("c1-default", "a", "c1")
+	controlled.Labels = map[string]string{constants.LabelControlled: "true"}
+	opts.Incremental.SuperEventHandler().OnAdd(controlled)
+	// the super objects not listed by the full scans are skipped.
+	opts.Incremental.SuperEventHandler().OnAdd(makePod("c1-default", "b", "c1"))
+	if opts.Incremental.Dirty() != 1 {
+		t.Errorf("expected only the controlled object to be tracked, got %d dirty keys", opts.Incremental.Dirty())
+	}
+}
@@ -19,6 +19,7 @@ package patrol
 import (
 	"time"
 
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
 )
 
@@ -33,6 +34,10 @@ func WithOptions(o *Options) OptConfig {
 		WithControllerName(o.name)(options)
 		WithReconciler(o.Reconciler)(options)
 		WithPeriod(o.Period)(options)
+		WithDryRun(o.DryRun)(options)
+		WithReporter(o.Reporter)(options)
+		WithIncremental(o.Incremental, o.BackstopPeriod)(options)
+		WithClusterPausedFunc(o.ClusterPaused)(options)
 	}
 }
 
@@ -62,3 +67,42 @@ func WithPeriod(t time.Duration) OptConfig {
 		}
 	}
 }
+
+// WithDryRun set whether the checker only reports the mismatches without remediation.
+func WithDryRun(dryRun bool) OptConfig {
+	return func(options *Options) {
+		if dryRun {
+			options.DryRun = true
+		}
+	}
+}
+
+// WithReporter set the reporter publishing the result of each patrol run.
+func WithReporter(r Reporter) OptConfig {
+	return func(options *Options) {
+		if r != nil {
+			options.Reporter = r
+		}
+	}
+}
+
+// WithIncremental set the incremental differ and the period of the backstop full scans.
+func WithIncremental(d *differ.Incremental, backstop time.Duration) OptConfig {
+	return func(options *Options) {
+		if d != nil {
+			options.Incremental = d
+		}
+		if backstop > 0 {
+			options.BackstopPeriod = backstop
+		}
+	}
+}
+
+// WithClusterPausedFunc set the func telling whether syncing a cluster is paused.
+func WithClusterPausedFunc(fn func(clusterName string) bool) OptConfig {
+	return func(options *Options) {
+		if fn != nil {
+			options.ClusterPaused = fn
+		}
+	}
+}
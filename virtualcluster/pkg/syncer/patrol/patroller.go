@@ -18,14 +18,19 @@ package patrol
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog/v2"
 
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -34,6 +39,13 @@ type Patroller struct {
 	// objectKind is the kind of target object this controller watched.
 	objectKind string
 
+	// report collects the mismatches found by the ongoing patrol run.
+	mu     sync.Mutex
+	report *Report
+	// fullScan is true if the ongoing patrol run has to list all the objects.
+	fullScan     bool
+	lastFullScan time.Time
+
 	Options
 }
 
@@ -42,6 +54,17 @@ type Options struct {
 	name       string
 	Reconciler reconciler.PatrolReconciler
 	Period     time.Duration
+	// DryRun makes the checker report the mismatches without remediating them.
+	DryRun bool
+	// Reporter publishes the report of each patrol run, optional.
+	Reporter Reporter
+	// Incremental maintains the diff sets from informer events, a full scan
+	// is only done every BackstopPeriod if it is set.
+	Incremental    *differ.Incremental
+	BackstopPeriod time.Duration
+	// ClusterPaused returns true if syncing the cluster is paused, the mismatches of
+	// a paused cluster are reported but not remediated.
+	ClusterPaused func(clusterName string) bool
 }
 
 func NewPatroller(objectType client.Object, rc reconciler.PatrolReconciler, opts ...OptConfig) (*Patroller, error) {
@@ -56,6 +79,8 @@ func NewPatroller(objectType client.Object, rc reconciler.PatrolReconciler, opts
 			name:       fmt.Sprintf("%s-patroller", strings.ToLower(kinds[0].Kind)),
 			Reconciler: rc,
 			Period:     60 * time.Second,
+			// BackstopPeriod only takes effect in incremental mode.
+			BackstopPeriod: 30 * time.Minute,
 		},
 	}
 
@@ -75,6 +100,119 @@ func (p *Patroller) Start(stop <-chan struct{}) {
 }
 
 func (p *Patroller) run() {
-	defer metrics.RecordCheckerScanDuration(p.objectKind, time.Now())
-	p.Reconciler.PatrollerDo()
+	p.mu.Lock()
+	fullScan := p.Incremental == nil || !p.Incremental.Seeded() || time.Since(p.lastFullScan) >= p.BackstopPeriod
+	// only a full scan finds all the mismatches, the report of an incremental run would
+	// overwrite the published one with the changed objects only.
+	var report *Report
+	if fullScan {
+		report = NewReport(p.objectKind, p.DryRun)
+	}
+	p.report = report
+	p.fullScan = fullScan
+	p.mu.Unlock()
+
+	if !fullScan {
+		func() {
+			defer metrics.RecordCheckerIncrementalScanDuration(p.objectKind, time.Now())
+			p.Reconciler.PatrollerDo()
+		}()
+		return
+	}
+
+	if p.Incremental != nil {
+		p.Incremental.BeginFullScan()
+	}
+	func() {
+		defer metrics.RecordCheckerScanDuration(p.objectKind, time.Now())
+		p.Reconciler.PatrollerDo()
+	}()
+	p.mu.Lock()
+	p.lastFullScan = time.Now()
+	p.report = nil
+	p.mu.Unlock()
+	report.EndTime = metav1.Now()
+
+	metrics.CheckerReportedMissMatchStats.WithLabelValues(p.objectKind, strconv.FormatBool(p.DryRun)).Set(float64(report.Len()))
+	if p.Reporter == nil {
+		return
+	}
+	if err := p.Reporter.Publish(report); err != nil {
+		klog.Errorf("failed to publish %s report: %v", p.name, err)
+	}
+}
+
+// FullScan returns whether the ongoing run has to list all the objects to build
+// the diff sets. It is always true if the patroller is not in incremental mode.
+// The checkers reset and publish their mismatch counters on the full scans only,
+// as an incremental run doesn't compare the objects unchanged since the previous run.
+func (p *Patroller) FullScan() bool {
+	if p == nil {
+		return true
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.fullScan || p.Incremental == nil
+}
+
+// Diff calls the handler for the mismatched objects. A full scan compares the
+// given sets and seeds the incremental differ with them. Otherwise the sets are
+// left empty by the checker and ignored: the incremental differ keeps its own
+// sets up to date from the informer events between the full scans, and only the
+// objects changed since the previous run, or still missing their counterpart,
+// are compared.
+func (p *Patroller) Diff(vSet, pSet differ.Differ, handler differ.Handler) {
+	if !p.FullScan() {
+		n := p.Incremental.Sync(handler)
+		klog.V(5).Infof("%s compared %d changed objects", p.name, n)
+		return
+	}
+	vSet.Difference(pSet, handler)
+	if p != nil && p.Incremental != nil {
+		p.Incremental.Reset(vSet, pSet)
+	}
+}
+
+// RequestFullScan makes the next run list all the objects, e.g. the changes of a
+// paused cluster are not remediated by the incremental runs.
+func (p *Patroller) RequestFullScan() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastFullScan = time.Time{}
+}
+
+// Record adds a mismatched object to the report of the ongoing run. An empty
+// cluster is resolved from the ownership annotations of a super control plane object.
+func (p *Patroller) Record(cluster string, obj client.Object, action Action, target Target, reason string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	report := p.report
+	p.mu.Unlock()
+	report.Add(cluster, obj, action, target, reason)
+}
+
+// Remediate records the remediation a checker intends to take on a mismatched
+// object and returns whether the checker should carry it out. Nothing should be
+// mutated when the patroller runs in dry-run mode or syncing the cluster is paused.
+func (p *Patroller) Remediate(cluster string, obj client.Object, action Action, target Target, reason string) bool {
+	if p == nil {
+		return true
+	}
+	p.Record(cluster, obj, action, target, reason)
+	if p.DryRun {
+		klog.V(4).Infof("%s dry-run: skip %s %s %s/%s: %s", p.name, action, target, obj.GetNamespace(), obj.GetName(), reason)
+		return false
+	}
+	if p.ClusterPaused != nil {
+		if cluster == "" {
+			cluster, _ = conversion.GetVirtualOwner(obj)
+		}
+		if cluster != "" && p.ClusterPaused(cluster) {
+			klog.V(4).Infof("%s cluster %s is paused: skip %s %s %s/%s: %s", p.name, cluster, action, target, obj.GetNamespace(), obj.GetName(), reason)
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,132 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package patrol
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
+)
+
+func TestPatrollerIncremental(t *testing.T) {
+	d := differ.NewIncremental(nil, nil)
+	rc := &fakePatrolReconciler{}
+	reporter := &fakeReporter{}
+	p, err := NewPatroller(&corev1.Pod{}, rc, WithIncremental(d, time.Hour), WithReporter(reporter))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var scans []bool
+	var added []map[string]int
+	rc.do = func() {
+		scans = append(scans, p.FullScan())
+		vSet := differ.NewDiffSet()
+		if p.FullScan() {
+			a, _ := differ.DefaultTenantObject("c1", makePod("default", "a", ""))
+			vSet.Insert(a)
+		}
+		var mu sync.Mutex
+		run := map[string]int{}
+		p.Diff(vSet, differ.NewDiffSet(), differ.HandlerFuncs{
+			AddFunc: func(obj differ.ClusterObject) {
+				mu.Lock()
+				defer mu.Unlock()
+				run[obj.GetName()]++
+				p.Record(obj.GetOwnerCluster(), obj.Object, ActionCreate, TargetSuper, "not found")
+			},
+		})
+		added = append(added, run)
+	}
+
+	// the first run seeds the differ.
+	p.run()
+	// the second run only compares the changed objects and a, which is still missing.
+	p.run()
+	d.TenantEventHandler("c1").OnAdd(makePod("default", "b", ""))
+	p.run()
+	// the backstop period elapses.
+	p.lastFullScan = time.Now().Add(-2 * time.Hour)
+	p.run()
+
+	expectedScans := []bool{true, false, false, true}
+	for i := range expectedScans {
+		if scans[i] != expectedScans[i] {
+			t.Errorf("run %d: expected full scan %v, got %v", i, expectedScans[i], scans[i])
+		}
+	}
+	expectedAdded := []map[string]int{{"a": 1}, {"a": 1}, {"a": 1, "b": 1}, {"a": 1}}
+	if !equality.Semantic.DeepEqual(added, expectedAdded) {
+		t.Errorf("expected added %v, got %v", expectedAdded, added)
+	}
+	// only the full scans are published.
+	if len(reporter.reports) != 2 {
+		t.Fatalf("expected 2 published reports, got %d", len(reporter.reports))
+	}
+	for i, report := range reporter.reports {
+		if report.Len() != 1 {
+			t.Errorf("report %d: expected 1 mismatch, got %d", i, report.Len())
+		}
+	}
+}
+
+func TestPatrollerFullScan(t *testing.T) {
+	p, err := NewPatroller(&corev1.Pod{}, &fakePatrolReconciler{do: func() {}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p.run()
+	if !p.FullScan() {
+		t.Errorf("expected a full scan without incremental differ")
+	}
+}
+
+func TestPatrollerPausedCluster(t *testing.T) {
+	d := differ.NewIncremental(nil, nil)
+	p, err := NewPatroller(&corev1.Pod{}, &fakePatrolReconciler{do: func() {}},
+		WithIncremental(d, time.Hour),
+		WithClusterPausedFunc(func(clusterName string) bool { return clusterName == "c1" }))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p.run()
+
+	if p.Remediate("c1", makePod("default", "a", ""), ActionUpdate, TargetSuper, "spec mismatch") {
+		t.Errorf("expected the paused cluster not to be remediated")
+	}
+	if p.Remediate("", makePod("c1-default", "a", "c1"), ActionDelete, TargetSuper, "orphan") {
+		t.Errorf("expected the super object of the paused cluster not to be remediated")
+	}
+	if !p.Remediate("c2", makePod("default", "a", ""), ActionUpdate, TargetSuper, "spec mismatch") {
+		t.Errorf("expected the cluster to be remediated")
+	}
+
+	// the resumed cluster is fully scanned.
+	d.Reset(differ.NewDiffSet(), differ.NewDiffSet())
+	p.RequestFullScan()
+	var fullScan bool
+	p.Reconciler = &fakePatrolReconciler{do: func() { fullScan = p.FullScan() }}
+	p.run()
+	if !fullScan {
+		t.Errorf("expected a full scan after it is requested")
+	}
+}
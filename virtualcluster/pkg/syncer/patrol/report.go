@@ -0,0 +1,230 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package patrol
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+// Action is the remediation a checker takes on a mismatched object.
+type Action string
+
+const (
+	ActionCreate Action = "Create"
+	ActionUpdate Action = "Update"
+	ActionDelete Action = "Delete"
+)
+
+// Target is the control plane a remediation action is applied to.
+type Target string
+
+const (
+	TargetSuper  Target = "super"
+	TargetTenant Target = "tenant"
+)
+
+const (
+	// maxReportEntriesPerCluster bounds the number of entries kept per cluster so
+	// that a published report stays well below the object size limit.
+	maxReportEntriesPerCluster = 200
+
+	// unknownCluster groups the entries whose owner cluster cannot be resolved.
+	unknownCluster = "unknown"
+)
+
+// ReportEntry describes one mismatched object found by a patrol run.
+type ReportEntry struct {
+	Action    Action `json:"action"`
+	Target    Target `json:"target"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// ClusterReport holds the entries of a single VirtualCluster.
+type ClusterReport struct {
+	// Total is the number of mismatches found, including the truncated ones.
+	Total   int           `json:"total"`
+	Entries []ReportEntry `json:"entries"`
+}
+
+// Report lists the mismatched objects found by a single patrol run, grouped
+// by the VirtualCluster they belong to.
+type Report struct {
+	Resource  string      `json:"resource"`
+	DryRun    bool        `json:"dryRun"`
+	StartTime metav1.Time `json:"startTime"`
+	EndTime   metav1.Time `json:"endTime,omitempty"`
+
+	mu       sync.Mutex
+	Clusters map[string]*ClusterReport `json:"clusters"`
+}
+
+// NewReport returns an empty report for the given resource kind.
+func NewReport(resource string, dryRun bool) *Report {
+	return &Report{
+		Resource:  resource,
+		DryRun:    dryRun,
+		StartTime: metav1.Now(),
+		Clusters:  make(map[string]*ClusterReport),
+	}
+}
+
+// Add records a mismatched object. An empty cluster is resolved from the
+// object's super control plane ownership annotations.
+func (r *Report) Add(cluster string, obj client.Object, action Action, target Target, reason string) {
+	if r == nil || obj == nil {
+		return
+	}
+	if cluster == "" {
+		cluster, _ = conversion.GetVirtualOwner(obj)
+	}
+	if cluster == "" {
+		cluster = unknownCluster
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cr, exists := r.Clusters[cluster]
+	if !exists {
+		cr = &ClusterReport{}
+		r.Clusters[cluster] = cr
+	}
+	cr.Total++
+	if len(cr.Entries) >= maxReportEntriesPerCluster {
+		return
+	}
+	cr.Entries = append(cr.Entries, ReportEntry{
+		Action:    action,
+		Target:    target,
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		Reason:    reason,
+	})
+}
+
+// Len returns the number of mismatches recorded in the report.
+func (r *Report) Len() int {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	total := 0
+	for _, cr := range r.Clusters {
+		total += cr.Total
+	}
+	return total
+}
+
+// ClusterNames returns the sorted names of the clusters having mismatches.
+func (r *Report) ClusterNames() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.Clusters))
+	for name := range r.Clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the report of the given cluster, or nil if it has no mismatches.
+func (r *Report) Get(cluster string) *ClusterReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Clusters[cluster]
+}
+
+// Reporter publishes the report of a patrol run.
+type Reporter interface {
+	Publish(report *Report) error
+}
+
+// configMapReporter writes each report into a ConfigMap named after the
+// resource kind, one data key per VirtualCluster.
+type configMapReporter struct {
+	client    v1core.ConfigMapsGetter
+	namespace string
+}
+
+var _ Reporter = &configMapReporter{}
+
+// NewConfigMapReporter returns a Reporter which stores the latest report of
+// each resource kind in a ConfigMap of the given super control plane namespace.
+func NewConfigMapReporter(client v1core.ConfigMapsGetter, namespace string) Reporter {
+	return &configMapReporter{client: client, namespace: namespace}
+}
+
+// ReportConfigMapName returns the name of the ConfigMap holding the report of a resource kind.
+func ReportConfigMapName(resource string) string {
+	return fmt.Sprintf("patrol-report-%s", strings.ToLower(resource))
+}
+
+func (c *configMapReporter) Publish(report *Report) error {
+	cm, err := buildReportConfigMap(c.namespace, report)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.client.ConfigMaps(c.namespace).Update(context.TODO(), cm, metav1.UpdateOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = c.client.ConfigMaps(c.namespace).Create(context.TODO(), cm, metav1.CreateOptions{})
+	}
+	return err
+}
+
+func buildReportConfigMap(namespace string, report *Report) (*corev1.ConfigMap, error) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ReportConfigMapName(report.Resource),
+			Namespace: namespace,
+			Labels: map[string]string{
+				constants.LabelPatrolReport: strings.ToLower(report.Resource),
+			},
+			Annotations: map[string]string{
+				constants.LabelPatrolReportDryRun:    strconv.FormatBool(report.DryRun),
+				constants.LabelPatrolReportStartTime: report.StartTime.UTC().Format(metav1.RFC3339Micro),
+				constants.LabelPatrolReportEndTime:   report.EndTime.UTC().Format(metav1.RFC3339Micro),
+			},
+		},
+		Data: make(map[string]string),
+	}
+	for _, cluster := range report.ClusterNames() {
+		data, err := json.Marshal(report.Get(cluster))
+		if err != nil {
+			return nil, err
+		}
+		cm.Data[cluster] = string(data)
+	}
+	return cm, nil
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package patrol
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+type fakePatrolReconciler struct {
+	do func()
+}
+
+func (f *fakePatrolReconciler) PatrollerDo() {
+	f.do()
+}
+
+type fakeReporter struct {
+	reports []*Report
+}
+
+func (f *fakeReporter) Publish(report *Report) error {
+	f.reports = append(f.reports, report)
+	return nil
+}
+
+func makePod(ns, name, cluster string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ns,
+			Name:      name,
+		},
+	}
+	if cluster != "" {
+		pod.Annotations = map[string]string{
+			constants.LabelCluster:   cluster,
+			constants.LabelNamespace: "default",
+		}
+	}
+	return pod
+}
+
+func TestPatrollerRemediate(t *testing.T) {
+	for _, dryRun := range []bool{false, true} {
+		reporter := &fakeReporter{}
+		var remediated []bool
+		rc := &fakePatrolReconciler{}
+		p, err := NewPatroller(&corev1.Pod{}, rc, WithDryRun(dryRun), WithReporter(reporter))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		rc.do = func() {
+			remediated = append(remediated,
+				p.Remediate("c1", makePod("default", "a", ""), ActionCreate, TargetSuper, "pPod not found"),
+				p.Remediate("", makePod("c2-default", "b", "c2"), ActionDelete, TargetSuper, "orphan pPod"))
+			p.Record("c1", makePod("default", "c", ""), ActionUpdate, TargetSuper, "spec mismatch")
+		}
+		p.run()
+
+		for _, r := range remediated {
+			if r == dryRun {
+				t.Errorf("dryRun=%v: expected remediate %v, got %v", dryRun, !dryRun, r)
+			}
+		}
+		if len(reporter.reports) != 1 {
+			t.Fatalf("dryRun=%v: expected 1 report, got %d", dryRun, len(reporter.reports))
+		}
+		report := reporter.reports[0]
+		if report.Resource != "Pod" || report.DryRun != dryRun {
+			t.Errorf("dryRun=%v: unexpected report header %s/%v", dryRun, report.Resource, report.DryRun)
+		}
+		if report.Len() != 3 {
+			t.Errorf("dryRun=%v: expected 3 entries, got %d", dryRun, report.Len())
+		}
+		if c1 := report.Get("c1"); c1 == nil || c1.Total != 2 {
+			t.Errorf("dryRun=%v: expected 2 entries for c1, got %+v", dryRun, c1)
+		}
+		if c2 := report.Get("c2"); c2 == nil || c2.Entries[0].Name != "b" || c2.Entries[0].Action != ActionDelete {
+			t.Errorf("dryRun=%v: expected pPod b resolved to c2, got %+v", dryRun, c2)
+		}
+	}
+}
+
+func TestReportTruncation(t *testing.T) {
+	report := NewReport("Pod", true)
+	for i := 0; i < maxReportEntriesPerCluster+10; i++ {
+		report.Add("c1", makePod("default", "a", ""), ActionCreate, TargetSuper, "")
+	}
+	c1 := report.Get("c1")
+	if c1.Total != maxReportEntriesPerCluster+10 || len(c1.Entries) != maxReportEntriesPerCluster {
+		t.Errorf("expected total %d with %d entries, got %d with %d", maxReportEntriesPerCluster+10, maxReportEntriesPerCluster, c1.Total, len(c1.Entries))
+	}
+}
+
+func TestConfigMapReporter(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	reporter := NewConfigMapReporter(client.CoreV1(), "vc-manager")
+
+	report := NewReport("Pod", true)
+	report.Add("c1", makePod("default", "a", ""), ActionCreate, TargetSuper, "pPod not found")
+	report.EndTime = metav1.Now()
+	if err := reporter.Publish(report); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the second publish updates the existing configmap
+	report = NewReport("Pod", false)
+	report.Add("c2", makePod("default", "b", ""), ActionDelete, TargetTenant, "orphan vPod")
+	report.EndTime = metav1.Now()
+	if err := reporter.Publish(report); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cm, err := client.CoreV1().ConfigMaps("vc-manager").Get(context.TODO(), ReportConfigMapName("Pod"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get report configmap: %v", err)
+	}
+	if cm.Annotations[constants.LabelPatrolReportDryRun] != "false" {
+		t.Errorf("expected dry-run annotation false, got %q", cm.Annotations[constants.LabelPatrolReportDryRun])
+	}
+	if _, exists := cm.Data["c1"]; exists {
+		t.Errorf("expected c1 to be removed from the latest report")
+	}
+	cr := &ClusterReport{}
+	if err := json.Unmarshal([]byte(cm.Data["c2"]), cr); err != nil {
+		t.Fatalf("failed to decode report of c2: %v", err)
+	}
+	if cr.Total != 1 || cr.Entries[0].Name != "b" || cr.Entries[0].Target != TargetTenant {
+		t.Errorf("unexpected report of c2: %+v", cr)
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+)
+
+const (
+	// reasonSyncPaused is the condition reason of a VirtualCluster whose syncing is paused.
+	reasonSyncPaused = "SyncPaused"
+	// reasonSyncResumed is the condition reason of a VirtualCluster whose syncing is resumed.
+	reasonSyncResumed = "SyncResumed"
+)
+
+// isSyncPaused returns true if the VirtualCluster asks the syncer to pause syncing it.
+func isSyncPaused(vc *v1alpha1.VirtualCluster) bool {
+	return vc.GetAnnotations()[constants.LabelSyncPaused] == "true"
+}
+
+// isClusterPaused returns true if syncing the cluster is paused. The downward requests
+// of a paused cluster are dropped and the checkers don't remediate its objects, but the
+// informer caches of the cluster are kept warm. The upward requests are keyed by the
+// super cluster objects only and are still reconciled, see constants.LabelSyncPaused.
+func (s *Syncer) isClusterPaused(clusterName string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pausedClusters.Has(clusterName)
+}
+
+// syncPause pauses or resumes syncing the VirtualCluster according to its annotation.
+// A resumed cluster is fully resynced.
+func (s *Syncer) syncPause(vc *v1alpha1.VirtualCluster) error {
+	clusterName := conversion.ToClusterKey(vc)
+	paused := isSyncPaused(vc)
+
+	s.mu.Lock()
+	wasPaused := s.pausedClusters.Has(clusterName)
+	if paused {
+		s.pausedClusters.Insert(clusterName)
+	} else {
+		s.pausedClusters.Delete(clusterName)
+	}
+	s.mu.Unlock()
+
+	if paused {
+		metrics.ClusterSyncPausedStats.WithLabelValues(s.config.SuperClusterID, clusterName).Set(1)
+	} else {
+		metrics.ClusterSyncPausedStats.WithLabelValues(s.config.SuperClusterID, clusterName).Set(0)
+	}
+	if paused && !wasPaused {
+		klog.Infof("syncing cluster %s is paused", clusterName)
+	}
+	if !paused && wasPaused {
+		klog.Infof("syncing cluster %s is resumed", clusterName)
+		s.resyncCluster(clusterName)
+	}
+	return s.updatePauseCondition(vc, paused)
+}
+
+// resyncCluster requeues all the objects of the cluster and makes the next patrol
+// runs fully scan the objects, the changes made during the pause are synced then.
+func (s *Syncer) resyncCluster(clusterName string) {
+	for _, rs := range s.controllerManager.GetResourceSyncers() {
+		if mcc := rs.GetMCController(); mcc != nil && mcc.GetCluster(clusterName) != nil {
+			if err := mcc.RequeueCluster(clusterName); err != nil {
+				klog.Errorf("failed to requeue %s objects of cluster %s: %v", mcc.GetObjectKind(), clusterName, err)
+			}
+		}
+		if patroller := rs.GetPatroller(); patroller != nil {
+			patroller.RequestFullScan()
+		}
+	}
+}
+
+// updatePauseCondition sets the SyncPaused condition of the VirtualCluster when its syncing
+// is paused or resumed. A cluster never paused has no condition to resume.
+func (s *Syncer) updatePauseCondition(vc *v1alpha1.VirtualCluster, paused bool) error {
+	status, reason, message := corev1.ConditionFalse, reasonSyncResumed, "syncing the virtual cluster is resumed"
+	if paused {
+		status, reason, message = corev1.ConditionTrue, reasonSyncPaused, "syncing the virtual cluster is paused"
+	}
+	if !setPauseCondition(vc.DeepCopy(), status, reason, message) {
+		return nil
+	}
+	changed := false
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest, err := s.vcClient.TenancyV1alpha1().VirtualClusters(vc.Namespace).Get(vc.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if !setPauseCondition(latest, status, reason, message) {
+			return nil
+		}
+		_, err = s.vcClient.TenancyV1alpha1().VirtualClusters(vc.Namespace).Update(latest)
+		changed = err == nil
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if changed {
+		s.recorder.Event(vc, corev1.EventTypeNormal, reason, message)
+	}
+	return nil
+}
+
+// setPauseCondition sets the SyncPaused condition of the VirtualCluster and returns
+// true if it is changed.
+func setPauseCondition(vc *v1alpha1.VirtualCluster, status corev1.ConditionStatus, reason, message string) bool {
+	if vc.GetCondition(v1alpha1.SyncPaused) == nil && status != corev1.ConditionTrue {
+		return false
+	}
+	return vc.SetCondition(v1alpha1.SyncPaused, status, reason, message)
+}
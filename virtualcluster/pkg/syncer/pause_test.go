@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcfake "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned/fake"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+)
+
+func TestSyncPause(t *testing.T) {
+	vc := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "tenant",
+			Name:      "vc",
+			UID:       "vc-uid",
+		},
+		Status: v1alpha1.VirtualClusterStatus{Phase: v1alpha1.ClusterRunning},
+	}
+	clusterName := conversion.ToClusterKey(vc)
+	vcClient := vcfake.NewSimpleClientset(vc)
+	s := &Syncer{
+		config:            &config.SyncerConfiguration{SuperClusterID: "s1"},
+		vcClient:          vcClient,
+		recorder:          record.NewFakeRecorder(10),
+		controllerManager: manager.New(),
+		pausedClusters:    sets.NewString(),
+	}
+
+	for _, tc := range []struct {
+		name               string
+		paused             bool
+		expectedConditions []string
+	}{
+		{
+			name:               "never paused",
+			expectedConditions: nil,
+		},
+		{
+			name:               "paused",
+			paused:             true,
+			expectedConditions: []string{reasonSyncPaused},
+		},
+		{
+			name:               "still paused",
+			paused:             true,
+			expectedConditions: []string{reasonSyncPaused},
+		},
+		{
+			name:               "resumed",
+			expectedConditions: []string{reasonSyncResumed},
+		},
+		{
+			name:               "paused again",
+			paused:             true,
+			expectedConditions: []string{reasonSyncPaused},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			latest, err := vcClient.TenancyV1alpha1().VirtualClusters("tenant").Get("vc", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get vc: %v", err)
+			}
+			latest.Annotations = nil
+			if tc.paused {
+				latest.Annotations = map[string]string{constants.LabelSyncPaused: "true"}
+			}
+			if err := s.syncPause(latest); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if s.isClusterPaused(clusterName) != tc.paused {
+				t.Errorf("expected cluster paused %v", tc.paused)
+			}
+			expectedStats := 0.0
+			if tc.paused {
+				expectedStats = 1
+			}
+			if got := testutil.ToFloat64(metrics.ClusterSyncPausedStats.WithLabelValues("s1", clusterName)); got != expectedStats {
+				t.Errorf("expected paused stats %v, got %v", expectedStats, got)
+			}
+
+			latest, err = vcClient.TenancyV1alpha1().VirtualClusters("tenant").Get("vc", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get vc: %v", err)
+			}
+			if len(latest.Status.Conditions) != len(tc.expectedConditions) {
+				t.Fatalf("expected conditions %v, got %+v", tc.expectedConditions, latest.Status.Conditions)
+			}
+			for i, reason := range tc.expectedConditions {
+				if latest.Status.Conditions[i].Type != v1alpha1.SyncPaused || latest.Status.Conditions[i].Reason != reason {
+					t.Errorf("expected conditions %v, got %+v", tc.expectedConditions, latest.Status.Conditions)
+				}
+			}
+			if latest.IsConditionTrue(v1alpha1.SyncPaused) != tc.paused {
+				t.Errorf("expected SyncPaused condition %v, got %+v", tc.paused, latest.Status.Conditions)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"bytes"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/informers"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+// newAdminKubeconfigInformerFactory returns the informer factory of the admin kubeconfig
+// Secrets in the root namespaces of the meta cluster.
+func newAdminKubeconfigInformerFactory(metaClient clientset.Interface) informers.SharedInformerFactory {
+	return informers.NewSharedInformerFactoryWithOptions(metaClient, 0,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", constants.KubeconfigAdminSecretName).String()
+		}))
+}
+
+// enqueueAdminKubeconfigChange requeues the VirtualCluster whose admin kubeconfig is changed,
+// e.g. when its certificates are renewed or its root CA is rotated. The tenant cluster is
+// reconnected with the new kubeconfig since its client is only built when it is added.
+func (s *Syncer) enqueueAdminKubeconfigChange(oldObj, newObj interface{}) {
+	oldSrt, ok := oldObj.(*corev1.Secret)
+	if !ok {
+		return
+	}
+	newSrt, ok := newObj.(*corev1.Secret)
+	if !ok {
+		return
+	}
+	if bytes.Equal(oldSrt.Data[constants.KubeconfigAdminSecretName], newSrt.Data[constants.KubeconfigAdminSecretName]) {
+		return
+	}
+
+	vcs, err := s.lister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, vc := range vcs {
+		if conversion.ToClusterKey(vc) != newSrt.Namespace {
+			continue
+		}
+		key, err := cache.MetaNamespaceKeyFunc(vc)
+		if err != nil {
+			utilruntime.HandleError(err)
+			continue
+		}
+		s.mu.Lock()
+		if _, exists := s.clusterSet[key]; exists {
+			klog.Infof("admin kubeconfig of cluster %s is changed, reconnecting", key)
+			s.reconnectClusters.Insert(key)
+		}
+		s.mu.Unlock()
+		s.queue.Add(key)
+	}
+}
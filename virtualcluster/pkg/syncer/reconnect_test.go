@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcfake "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned/fake"
+	vcinformerFactory "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
+)
+
+func TestReconnectOnAdminKubeconfigChange(t *testing.T) {
+	// the tenant apiserver never answers so that the clusters stay in the cluster set
+	done := make(chan struct{})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	vc := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "vc", UID: "vc-uid"},
+		Status:     v1alpha1.VirtualClusterStatus{Phase: v1alpha1.ClusterRunning},
+	}
+	key := "tenant/vc"
+	adminKubeconfig := func(token string) *corev1.Secret {
+		kubeconfig := fmt.Sprintf("apiVersion: v1\nkind: Config\n"+
+			"clusters:\n- name: vc\n  cluster:\n    server: %s\n    insecure-skip-tls-verify: true\n"+
+			"users:\n- name: admin\n  user:\n    token: %s\n"+
+			"contexts:\n- name: vc\n  context:\n    cluster: vc\n    user: admin\ncurrent-context: vc\n", server.URL, token)
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: conversion.ToClusterKey(vc), Name: constants.KubeconfigAdminSecretName},
+			Data:       map[string][]byte{constants.KubeconfigAdminSecretName: []byte(kubeconfig)},
+		}
+	}
+	metaClient := fake.NewSimpleClientset(adminKubeconfig("before-rotation"))
+	vcClient := vcfake.NewSimpleClientset(vc)
+	vcInformer := vcinformerFactory.NewSharedInformerFactory(vcClient, 0).Tenancy().V1alpha1().VirtualClusters()
+	if err := vcInformer.Informer().GetIndexer().Add(vc); err != nil {
+		t.Fatalf("fail to add vc to the cache: %v", err)
+	}
+	s := &Syncer{
+		config:            &config.SyncerConfiguration{},
+		vcClient:          vcClient,
+		metaClient:        metaClient,
+		recorder:          record.NewFakeRecorder(10),
+		controllerManager: manager.New(),
+		lister:            vcInformer.Lister(),
+		queue:             workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "virtual_cluster"),
+		clusterSet:        map[string]mc.ClusterInterface{},
+		pausedClusters:    sets.NewString(),
+		reconnectClusters: sets.NewString(),
+	}
+	expectToken := func(token string) mc.ClusterInterface {
+		t.Helper()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		c, exists := s.clusterSet[key]
+		if !exists {
+			t.Fatalf("expected cluster %s to be synced", key)
+		}
+		if got := c.GetRestConfig().BearerToken; got != token {
+			t.Errorf("expected cluster %s to connect with token %s, got %s", key, token, got)
+		}
+		return c
+	}
+
+	if err := s.syncVirtualCluster(key); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	connected := expectToken("before-rotation")
+
+	// a Secret update not changing the kubeconfig keeps the connection
+	unchanged := adminKubeconfig("before-rotation")
+	unchanged.Labels = map[string]string{"renewed": "false"}
+	s.enqueueAdminKubeconfigChange(adminKubeconfig("before-rotation"), unchanged)
+	if s.queue.Len() != 0 {
+		t.Errorf("expected no cluster to be requeued")
+	}
+
+	// the certificates in the admin kubeconfig are renewed
+	rotated := adminKubeconfig("after-rotation")
+	if _, err := metaClient.CoreV1().Secrets(rotated.Namespace).Update(context.TODO(), rotated, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("fail to update admin kubeconfig: %v", err)
+	}
+	s.enqueueAdminKubeconfigChange(adminKubeconfig("before-rotation"), rotated)
+	if s.queue.Len() != 1 {
+		t.Fatalf("expected the virtualcluster to be requeued, got %d", s.queue.Len())
+	}
+	item, _ := s.queue.Get()
+	if err := s.syncVirtualCluster(item.(string)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.queue.Done(item)
+	if expectToken("after-rotation") == connected {
+		t.Errorf("expected cluster %s to be reconnected", key)
+	}
+
+	// the cluster is not reconnected again until the kubeconfig changes
+	reconnected := expectToken("after-rotation")
+	if err := s.syncVirtualCluster(key); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expectToken("after-rotation") != reconnected {
+		t.Errorf("expected cluster %s to keep its connection", key)
+	}
+}
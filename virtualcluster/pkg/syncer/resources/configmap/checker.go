@@ -27,10 +27,12 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
@@ -57,48 +59,22 @@ func (c *controller) PatrollerDo() {
 		return
 	}
 
-	pConfigMaps, err := c.configMapLister.List(util.GetSuperClusterListerLabelsSelector())
-	if err != nil {
-		klog.Errorf("error listing configmaps from super control plane informer cache: %v", err)
-		return
-	}
-	pSet := differ.NewDiffSet()
-	for _, pCM := range pConfigMaps {
-		// Ingore RootCACertConfigMapName from the super.
-		// TenantRootCACertConfigMapName is created from the vRootCACertConfigMap and
-		// TenantRootCACertConfigMapName should be renamed.
-		if featuregate.DefaultFeatureGate.Enabled(featuregate.RootCACertConfigMapSupport) {
-			if pCM.Name == constants.RootCACertConfigMapName {
-				continue
-			}
-			if pCM.Name == constants.TenantRootCACertConfigMapName {
-				pCM.Name = constants.RootCACertConfigMapName
-			}
-		}
-		pSet.Insert(differ.ClusterObject{Object: pCM, Key: differ.DefaultClusterObjectKey(pCM, "")})
-	}
-
 	knownClusterSet := sets.NewString(clusterNames...)
+	pSet := differ.NewDiffSet()
 	vSet := differ.NewDiffSet()
-	for _, cluster := range clusterNames {
-		cmList := &corev1.ConfigMapList{}
-		if err := c.MultiClusterController.List(cluster, cmList); err != nil {
-			klog.Errorf("error listing configmaps from cluster %s informer cache: %v", cluster, err)
-			knownClusterSet.Delete(cluster)
-			continue
-		}
-
-		for i := range cmList.Items {
-			vSet.Insert(differ.ClusterObject{
-				Object:       &cmList.Items[i],
-				OwnerCluster: cluster,
-				Key:          differ.DefaultClusterObjectKey(&cmList.Items[i], cluster),
-			})
+	fullScan := c.Patroller.FullScan()
+	if fullScan {
+		numMissMatchedConfigMaps = 0
+		if !c.listConfigMaps(clusterNames, knownClusterSet, vSet, pSet) {
+			return
 		}
 	}
 
 	configMapDiffer := differ.HandlerFuncs{}
 	configMapDiffer.AddFunc = func(vObj differ.ClusterObject) {
+		if !c.Patroller.Remediate(vObj.GetOwnerCluster(), vObj.Object, pa.ActionCreate, pa.TargetSuper, "pConfigMap not found") {
+			return
+		}
 		if err := c.MultiClusterController.RequeueObject(vObj.OwnerCluster, vObj.Object); err != nil {
 			klog.Errorf("error requeue vConfigMap %v/%v in cluster %s: %v", vObj.GetNamespace(), vObj.GetName(), vObj.GetOwnerCluster(), err)
 		} else {
@@ -123,23 +99,83 @@ func (c *controller) PatrollerDo() {
 		if updated != nil {
 			atomic.AddUint64(&numMissMatchedConfigMaps, 1)
 			klog.Warningf("ConfigMap %s diff in super&tenant control plane", pObj.Key)
+			c.Patroller.Record(vObj.GetOwnerCluster(), vObj.Object, pa.ActionUpdate, pa.TargetSuper, "spec mismatch")
 		}
 	}
 	configMapDiffer.DeleteFunc = func(pObj differ.ClusterObject) {
+		if !c.Patroller.Remediate("", pObj.Object, pa.ActionDelete, pa.TargetSuper, "orphan pConfigMap") {
+			return
+		}
 		_, pName := conversion.GetConfigMapName(pObj.GetName())
 		deleteOptions := &metav1.DeleteOptions{}
 		deleteOptions.Preconditions = metav1.NewUIDPreconditions(string(pObj.GetUID()))
-		if err = c.configMapClient.ConfigMaps(pObj.GetNamespace()).Delete(context.TODO(), pName, *deleteOptions); err != nil {
+		if err := c.configMapClient.ConfigMaps(pObj.GetNamespace()).Delete(context.TODO(), pName, *deleteOptions); err != nil {
 			klog.Errorf("error deleting pConfigMap %s in super control plane: %v", pObj.Key, err)
 		} else {
 			metrics.CheckerRemedyStats.WithLabelValues("DeletedOrphanSuperControlPlaneConfigMaps").Inc()
 		}
 	}
 
-	vSet.Difference(pSet, differ.FilteringHandler{
+	c.Patroller.Diff(vSet, pSet, differ.FilteringHandler{
 		Handler:    configMapDiffer,
 		FilterFunc: differ.DefaultDifferFilter(knownClusterSet),
 	})
 
-	metrics.CheckerMissMatchStats.WithLabelValues("MissMatchedConfigMaps").Set(float64(numMissMatchedConfigMaps))
+	if fullScan {
+		metrics.CheckerMissMatchStats.WithLabelValues("MissMatchedConfigMaps").Set(float64(numMissMatchedConfigMaps))
+	}
+}
+
+// listConfigMaps builds the diff sets from the informer caches, the clusters failing
+// to be listed are removed from knownClusterSet.
+func (c *controller) listConfigMaps(clusterNames []string, knownClusterSet sets.String, vSet, pSet differ.Differ) bool {
+	pConfigMaps, err := c.configMapLister.List(util.GetSuperClusterListerLabelsSelector())
+	if err != nil {
+		klog.Errorf("error listing configmaps from super control plane informer cache: %v", err)
+		return false
+	}
+	for _, pCM := range pConfigMaps {
+		if co, ok := superConfigMapObject(pCM); ok {
+			pSet.Insert(co)
+		}
+	}
+
+	for _, cluster := range clusterNames {
+		cmList := &corev1.ConfigMapList{}
+		if err := c.MultiClusterController.List(cluster, cmList); err != nil {
+			klog.Errorf("error listing configmaps from cluster %s informer cache: %v", cluster, err)
+			knownClusterSet.Delete(cluster)
+			continue
+		}
+
+		for i := range cmList.Items {
+			vSet.Insert(differ.ClusterObject{
+				Object:       &cmList.Items[i],
+				OwnerCluster: cluster,
+				Key:          differ.DefaultClusterObjectKey(&cmList.Items[i], cluster),
+			})
+		}
+	}
+	return true
+}
+
+// superConfigMapObject converts the pConfigMap to the object compared with the vConfigMap.
+func superConfigMapObject(obj client.Object) (differ.ClusterObject, bool) {
+	pCM, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		return differ.ClusterObject{}, false
+	}
+	// Ingore RootCACertConfigMapName from the super.
+	// TenantRootCACertConfigMapName is created from the vRootCACertConfigMap and
+	// TenantRootCACertConfigMapName should be renamed.
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.RootCACertConfigMapSupport) {
+		if pCM.Name == constants.RootCACertConfigMapName {
+			return differ.ClusterObject{}, false
+		}
+		if pCM.Name == constants.TenantRootCACertConfigMapName {
+			pCM = pCM.DeepCopy()
+			pCM.Name = constants.RootCACertConfigMapName
+		}
+	}
+	return differ.ClusterObject{Object: pCM, Key: differ.DefaultClusterObjectKey(pCM, "")}, true
 }
@@ -29,6 +29,8 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	util "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/test"
 )
@@ -60,6 +62,7 @@ func TestConfigMapPatrol(t *testing.T) {
 		ExpectedUpdatedPObject []runtime.Object
 		ExpectedUpdatedVObject []runtime.Object
 		ExpectedNoOperation    bool
+		DryRun                 bool
 		WaitDWS                bool // Make sure to set this flag if the test involves DWS.
 		WaitUWS                bool // Make sure to set this flag if the test involves UWS.
 	}{
@@ -136,6 +139,20 @@ func TestConfigMapPatrol(t *testing.T) {
 			},
 			WaitDWS: true,
 		},
+		"dry run: pConfigMap exists, vConfigMap does not exists": {
+			ExistingObjectInSuper: []runtime.Object{
+				superConfigMap("cm-6", superDefaultNSName, "12345", defaultClusterKey),
+			},
+			DryRun:              true,
+			ExpectedNoOperation: true,
+		},
+		"dry run: vConfigMap exists, pConfigMap does not exists": {
+			ExistingObjectInTenant: []runtime.Object{
+				tenantConfigMap("cm-7", "default", "12345"),
+			},
+			DryRun:              true,
+			ExpectedNoOperation: true,
+		},
 		"vConfigMap RootCACertConfigMapName exists, pConfigMap does not exists": {
 			ExistingObjectInTenant: []runtime.Object{
 				tenantConfigMap(constants.RootCACertConfigMapName, "default", "12345"),
@@ -149,7 +166,13 @@ func TestConfigMapPatrol(t *testing.T) {
 
 	for k, tc := range testcases {
 		t.Run(k, func(t *testing.T) {
-			tenantActions, superActions, err := util.RunPatrol(NewConfigMapController, testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, nil, tc.WaitDWS, tc.WaitUWS, nil)
+			var modifier func(manager.ResourceSyncer)
+			if tc.DryRun {
+				modifier = func(rs manager.ResourceSyncer) {
+					pa.WithDryRun(true)(&rs.GetPatroller().Options)
+				}
+			}
+			tenantActions, superActions, err := util.RunPatrol(NewConfigMapController, testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, nil, tc.WaitDWS, tc.WaitUWS, modifier)
 			if err != nil {
 				t.Errorf("%s: error running patrol: %v", k, err)
 				return
@@ -77,7 +77,9 @@ func NewConfigMapController(config *config.SyncerConfiguration,
 		c.configMapSynced = informer.Core().V1().ConfigMaps().Informer().HasSynced
 	}
 
-	c.Patroller, err = pa.NewPatroller(&corev1.ConfigMap{}, c, pa.WithOptions(options.PatrolOptions))
+	c.Patroller, err = pa.NewPatroller(&corev1.ConfigMap{}, c, pa.WithOptions(options.PatrolOptions),
+		pa.WithIncrementalDiffer(informer.Core().V1().ConfigMaps().Informer(), c.MultiClusterController,
+			superConfigMapObject, nil, config.PatrolBackstopPeriod))
 	if err != nil {
 		return nil, err
 	}
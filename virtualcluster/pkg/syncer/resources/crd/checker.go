@@ -35,6 +35,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
 )
 
 var numMissMatchedCRD uint64
@@ -80,6 +81,9 @@ func (c *controller) PatrollerDo() {
 		for _, clusterName := range clusterNames {
 			if err := c.MultiClusterController.Get(clusterName, "", pCRD.Name, &apiextensionsv1.CustomResourceDefinition{}); err != nil {
 				if apierrors.IsNotFound(err) {
+					if !c.Patroller.Remediate(clusterName, &pCRDList.Items[i], pa.ActionCreate, pa.TargetTenant, "vCRD not found") {
+						continue
+					}
 					metrics.CheckerRemedyStats.WithLabelValues("RequeuedSuperControlPlaneCRD").Inc()
 					klog.Infof("patroller create crd %v in virtual cluster", clusterName+"/"+pCRD.Name)
 					c.UpwardController.AddToQueue(clusterName + "/" + pCRD.Name)
@@ -126,6 +130,9 @@ func (c *controller) checkCRDOfTenantCluster(clusterName string) {
 			Name: vCRD.Name,
 		}, pCRD)
 		if apierrors.IsNotFound(err) {
+			if !c.Patroller.Remediate(clusterName, &crdList.Items[i], pa.ActionDelete, pa.TargetTenant, "orphan vCRD") {
+				continue
+			}
 			opts := &metav1.DeleteOptions{
 				PropagationPolicy: &constants.DefaultDeletionPolicy,
 			}
@@ -146,7 +153,7 @@ func (c *controller) checkCRDOfTenantCluster(clusterName string) {
 		updatedCRD := conversion.Equality(nil, nil).CheckCRDEquality(pCRD, &crdList.Items[i])
 		if updatedCRD != nil {
 			atomic.AddUint64(&numMissMatchedCRD, 1)
-			if publicCRD(pCRD) {
+			if publicCRD(pCRD) && c.Patroller.Remediate(clusterName, &crdList.Items[i], pa.ActionUpdate, pa.TargetTenant, "spec mismatch") {
 				klog.Infof("patroller update CRD %v in tenant cluster %v", vCRD.Name, clusterName)
 				c.UpwardController.AddToQueue(clusterName + "/" + pCRD.Name)
 			}
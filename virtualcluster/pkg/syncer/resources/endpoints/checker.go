@@ -28,6 +28,7 @@ import (
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 )
@@ -55,41 +56,24 @@ func (c *controller) PatrollerDo() {
 		return
 	}
 
-	numMissingEndPoints = 0
-	numMissMatchedEndPoints = 0
-
-	pList, err := c.endpointsLister.List(util.GetSuperClusterListerLabelsSelector())
-	if err != nil {
-		klog.Errorf("error listing endpoints from super control plane informer cache: %v", err)
-		return
-	}
-	pSet := differ.NewDiffSet()
-	for _, p := range pList {
-		pSet.Insert(differ.ClusterObject{Object: p, Key: differ.DefaultClusterObjectKey(p, "")})
-	}
-
 	knownClusterSet := sets.NewString(clusterNames...)
+	pSet := differ.NewDiffSet()
 	vSet := differ.NewDiffSet()
-	for _, cluster := range clusterNames {
-		vList := &corev1.EndpointsList{}
-		if err := c.MultiClusterController.List(cluster, vList); err != nil {
-			klog.Errorf("error listing endpoints from cluster %s informer cache: %v", cluster, err)
-			knownClusterSet.Delete(cluster)
-			continue
-		}
-
-		for i := range vList.Items {
-			vSet.Insert(differ.ClusterObject{
-				Object:       &vList.Items[i],
-				OwnerCluster: cluster,
-				Key:          differ.DefaultClusterObjectKey(&vList.Items[i], cluster),
-			})
+	fullScan := c.Patroller.FullScan()
+	if fullScan {
+		numMissingEndPoints = 0
+		numMissMatchedEndPoints = 0
+		if !c.listEndpoints(clusterNames, knownClusterSet, vSet, pSet) {
+			return
 		}
 	}
 
 	d := differ.HandlerFuncs{}
 	d.AddFunc = func(vObj differ.ClusterObject) {
 		atomic.AddUint64(&numMissingEndPoints, 1)
+		if !c.Patroller.Remediate(vObj.GetOwnerCluster(), vObj.Object, pa.ActionCreate, pa.TargetSuper, "pEndpoints not found") {
+			return
+		}
 		if err := c.MultiClusterController.RequeueObject(vObj.OwnerCluster, vObj); err != nil {
 			klog.Errorf("error requeue vEndpoints %s: %v", vObj.Key, err)
 		} else {
@@ -102,6 +86,9 @@ func (c *controller) PatrollerDo() {
 		updated := conversion.Equality(c.Config, nil).CheckEndpointsEquality(p, v)
 		if updated != nil {
 			atomic.AddUint64(&numMissMatchedEndPoints, 1)
+			if !c.Patroller.Remediate(vObj.GetOwnerCluster(), vObj.Object, pa.ActionUpdate, pa.TargetSuper, "spec mismatch") {
+				return
+			}
 			if err := c.MultiClusterController.RequeueObject(vObj.OwnerCluster, vObj); err != nil {
 				klog.Errorf("error requeue vEndpoints %s: %v", vObj.Key, err)
 			} else {
@@ -110,11 +97,44 @@ func (c *controller) PatrollerDo() {
 		}
 	}
 
-	vSet.Difference(pSet, differ.FilteringHandler{
+	c.Patroller.Diff(vSet, pSet, differ.FilteringHandler{
 		Handler:    d,
 		FilterFunc: differ.DefaultDifferFilter(knownClusterSet),
 	})
 
-	metrics.CheckerMissMatchStats.WithLabelValues("MissingEndPoints").Set(float64(numMissingEndPoints))
-	metrics.CheckerMissMatchStats.WithLabelValues("MissMatchedEndPoints").Set(float64(numMissMatchedEndPoints))
+	if fullScan {
+		metrics.CheckerMissMatchStats.WithLabelValues("MissingEndPoints").Set(float64(numMissingEndPoints))
+		metrics.CheckerMissMatchStats.WithLabelValues("MissMatchedEndPoints").Set(float64(numMissMatchedEndPoints))
+	}
+}
+
+// listEndpoints builds the diff sets from the informer caches, the clusters failing
+// to be listed are removed from knownClusterSet.
+func (c *controller) listEndpoints(clusterNames []string, knownClusterSet sets.String, vSet, pSet differ.Differ) bool {
+	pList, err := c.endpointsLister.List(util.GetSuperClusterListerLabelsSelector())
+	if err != nil {
+		klog.Errorf("error listing endpoints from super control plane informer cache: %v", err)
+		return false
+	}
+	for _, p := range pList {
+		pSet.Insert(differ.ClusterObject{Object: p, Key: differ.DefaultClusterObjectKey(p, "")})
+	}
+
+	for _, cluster := range clusterNames {
+		vList := &corev1.EndpointsList{}
+		if err := c.MultiClusterController.List(cluster, vList); err != nil {
+			klog.Errorf("error listing endpoints from cluster %s informer cache: %v", cluster, err)
+			knownClusterSet.Delete(cluster)
+			continue
+		}
+
+		for i := range vList.Items {
+			vSet.Insert(differ.ClusterObject{
+				Object:       &vList.Items[i],
+				OwnerCluster: cluster,
+				Key:          differ.DefaultClusterObjectKey(&vList.Items[i], cluster),
+			})
+		}
+	}
+	return true
 }
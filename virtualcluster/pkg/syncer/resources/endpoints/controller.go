@@ -77,7 +77,9 @@ func NewEndpointsController(config *config.SyncerConfiguration,
 		c.endpointsSynced = informer.Core().V1().Endpoints().Informer().HasSynced
 	}
 
-	c.Patroller, err = pa.NewPatroller(&corev1.Endpoints{}, c, pa.WithOptions(options.PatrolOptions))
+	c.Patroller, err = pa.NewPatroller(&corev1.Endpoints{}, c, pa.WithOptions(options.PatrolOptions),
+		pa.WithIncrementalDiffer(informer.Core().V1().Endpoints().Informer(), c.MultiClusterController,
+			nil, nil, config.PatrolBackstopPeriod))
 	if err != nil {
 		return nil, err
 	}
@@ -32,6 +32,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 )
 
@@ -93,7 +94,7 @@ func (c *controller) PatrollerDo() {
 				klog.Warningf("Found pIngress %s/%s delegated UID is different from tenant object.", pIngress.Namespace, pIngress.Name)
 			}
 		}
-		if shouldDelete {
+		if shouldDelete && c.Patroller.Remediate(clusterName, pIngress, pa.ActionDelete, pa.TargetSuper, "orphan pIngress") {
 			deleteOptions := metav1.NewPreconditionDeleteOptions(string(pIngress.UID))
 			if err = c.ingressClient.Ingresses(pIngress.Namespace).Delete(context.TODO(), pIngress.Name, *deleteOptions); err != nil {
 				klog.Errorf("error deleting pIngress %s/%s in super control plane: %v", pIngress.Namespace, pIngress.Name, err)
@@ -120,6 +121,9 @@ func (c *controller) checkIngressesOfTenantCluster(clusterName string) {
 		targetNamespace := conversion.ToSuperClusterNamespace(clusterName, vIngress.Namespace)
 		pIngress, err := c.ingressLister.Ingresses(targetNamespace).Get(vIngress.Name)
 		if apierrors.IsNotFound(err) {
+			if !c.Patroller.Remediate(clusterName, &ingList.Items[i], pa.ActionCreate, pa.TargetSuper, "pIngress not found") {
+				continue
+			}
 			if err := c.MultiClusterController.RequeueObject(clusterName, &ingList.Items[i]); err != nil {
 				klog.Errorf("error requeue vingress %v/%v in cluster %s: %v", vIngress.Namespace, vIngress.Name, clusterName, err)
 			} else {
@@ -147,10 +151,12 @@ func (c *controller) checkIngressesOfTenantCluster(clusterName string) {
 		if updatedIngress != nil {
 			atomic.AddUint64(&numSpecMissMatchedIngresses, 1)
 			klog.Warningf("spec of ingress %v/%v diff in super&tenant control plane", vIngress.Namespace, vIngress.Name)
-			if err := c.MultiClusterController.RequeueObject(clusterName, &ingList.Items[i]); err != nil {
-				klog.Errorf("error requeue vingress %v/%v in cluster %s: %v", vIngress.Namespace, vIngress.Name, clusterName, err)
-			} else {
-				metrics.CheckerRemedyStats.WithLabelValues("RequeuedTenantIngresses").Inc()
+			if c.Patroller.Remediate(clusterName, &ingList.Items[i], pa.ActionUpdate, pa.TargetSuper, "spec mismatch") {
+				if err := c.MultiClusterController.RequeueObject(clusterName, &ingList.Items[i]); err != nil {
+					klog.Errorf("error requeue vingress %v/%v in cluster %s: %v", vIngress.Namespace, vIngress.Name, clusterName, err)
+				} else {
+					metrics.CheckerRemedyStats.WithLabelValues("RequeuedTenantIngresses").Inc()
+				}
 			}
 		}
 
@@ -166,7 +172,7 @@ func (c *controller) checkIngressesOfTenantCluster(clusterName string) {
 			atomic.AddUint64(&numStatusMissMatchedIngresses, 1)
 			klog.Warningf("Status of vIngress %v/%v diff in super&tenant control plane", vIngress.Namespace, vIngress.Name)
 		}
-		if enqueue {
+		if enqueue && c.Patroller.Remediate(clusterName, &ingList.Items[i], pa.ActionUpdate, pa.TargetTenant, "status or metadata mismatch") {
 			c.enqueueIngress(pIngress)
 		}
 	}
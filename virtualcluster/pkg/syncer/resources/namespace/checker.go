@@ -27,15 +27,14 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
-	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
-	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
-	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
 )
 
 func (c *controller) StartPatrol(stopCh <-chan struct{}) error {
@@ -87,56 +86,36 @@ func (c *controller) PatrollerDo() {
 		klog.V(4).Infof("super cluster has no tenant control planes, still check %s for gc purpose", "namespace")
 	}
 
-	pList, err := c.nsLister.List(util.GetSuperClusterListerLabelsSelector())
-	if err != nil {
-		klog.Errorf("error listing namespaces from super control plane informer cache: %v", err)
-		return
-	}
-	pSet := differ.NewDiffSet()
-	for _, p := range pList {
-		pSet.Insert(differ.ClusterObject{Object: p, Key: p.GetName()})
-	}
-
 	knownClusterSet := sets.NewString(clusterNames...)
+	pSet := differ.NewDiffSet()
 	vSet := differ.NewDiffSet()
-	for _, cluster := range clusterNames {
-		vList := &corev1.NamespaceList{}
-		if err := c.MultiClusterController.List(cluster, vList); err != nil {
-			klog.Errorf("error listing namespaces from cluster %s informer cache: %v", cluster, err)
-			knownClusterSet.Delete(cluster)
-			continue
-		}
-
-		for i := range vList.Items {
-			if featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterPooling) {
-				if err := mc.IsNamespaceScheduledToCluster(&vList.Items[i], utilconstants.SuperClusterID); err != nil {
-					klog.V(4).Infof("skip ns object which is not belongs to this super cluster: %v", err)
-					continue
-				}
-			}
-			vSet.Insert(differ.ClusterObject{
-				Object:       &vList.Items[i],
-				OwnerCluster: cluster,
-				Key:          conversion.ToSuperClusterNamespace(cluster, vList.Items[i].GetName()),
-			})
+	if c.Patroller.FullScan() {
+		if !c.listNamespaces(clusterNames, knownClusterSet, vSet, pSet) {
+			return
 		}
 	}
 
-	d := differ.HandlerFuncs{}
-	d.AddFunc = func(vObj differ.ClusterObject) {
+	requeue := func(vObj differ.ClusterObject) {
 		if err := c.MultiClusterController.RequeueObject(vObj.OwnerCluster, vObj.Object); err != nil {
 			klog.Errorf("error requeue vNamespace %v in cluster %s: %v", vObj.GetName(), vObj.GetOwnerCluster(), err)
 		} else {
 			metrics.CheckerRemedyStats.WithLabelValues("RequeuedTenantNamespaces").Inc()
 		}
 	}
+
+	d := differ.HandlerFuncs{}
+	d.AddFunc = func(vObj differ.ClusterObject) {
+		if c.Patroller.Remediate(vObj.GetOwnerCluster(), vObj.Object, pa.ActionCreate, pa.TargetSuper, "pNamespace not found") {
+			requeue(vObj)
+		}
+	}
 	d.UpdateFunc = func(vObj, pObj differ.ClusterObject) {
 		v := vObj.Object.(*corev1.Namespace)
 		p := pObj.Object.(*corev1.Namespace)
 
 		// if vc object is deleted, we should reach here
 		if c.shouldBeGarbageCollected(p) || p.Annotations[constants.LabelUID] != string(v.UID) {
-			c.deleteNamespace(p)
+			c.deleteNamespace(p, "owner mismatch")
 			return
 		}
 
@@ -148,7 +127,9 @@ func (c *controller) PatrollerDo() {
 		updatedNamespace := conversion.Equality(c.Config, vc).CheckNamespaceEquality(p, v)
 		if updatedNamespace != nil {
 			klog.Warningf("metadata of namespace %s diff in super&tenant cluster", pObj.Key)
-			d.OnAdd(vObj)
+			if c.Patroller.Remediate(vObj.GetOwnerCluster(), vObj.Object, pa.ActionUpdate, pa.TargetSuper, "metadata mismatch") {
+				requeue(vObj)
+			}
 		}
 	}
 	d.DeleteFunc = func(pObj differ.ClusterObject) {
@@ -157,25 +138,25 @@ func (c *controller) PatrollerDo() {
 		// only delete the root ns if vc is gone
 		if p.Annotations[constants.LabelVCRootNS] == "true" {
 			if c.shouldBeGarbageCollected(p) {
-				c.deleteNamespace(p)
+				c.deleteNamespace(p, "owner vc is gone")
 			}
 			return
 		}
 		clusterName, _ := conversion.GetVirtualOwner(p)
 		// most possible case. vc is loaded and tenant ns is missing
 		if knownClusterSet.Has(clusterName) {
-			c.deleteNamespace(p)
+			c.deleteNamespace(p, "orphan pNamespace")
 			return
 		}
 
 		// vc status is unknown or not loaded. confirm for gc purpose
 		if c.shouldBeGarbageCollected(p) {
-			c.deleteNamespace(p)
+			c.deleteNamespace(p, "owner vc is gone")
 			return
 		}
 	}
 
-	vSet.Difference(pSet, differ.FilteringHandler{
+	c.Patroller.Diff(vSet, pSet, differ.FilteringHandler{
 		Handler: d,
 		FilterFunc: func(obj differ.ClusterObject) bool {
 			// vObj
@@ -197,7 +178,62 @@ func (c *controller) PatrollerDo() {
 	})
 }
 
-func (c *controller) deleteNamespace(ns *corev1.Namespace) {
+// listNamespaces builds the diff sets from the informer caches, the clusters failing
+// to be listed are removed from knownClusterSet.
+func (c *controller) listNamespaces(clusterNames []string, knownClusterSet sets.String, vSet, pSet differ.Differ) bool {
+	pList, err := c.nsLister.List(util.GetSuperClusterListerLabelsSelector())
+	if err != nil {
+		klog.Errorf("error listing namespaces from super control plane informer cache: %v", err)
+		return false
+	}
+	for _, p := range pList {
+		if co, ok := superNamespaceObject(p); ok {
+			pSet.Insert(co)
+		}
+	}
+
+	for _, cluster := range clusterNames {
+		vList := &corev1.NamespaceList{}
+		if err := c.MultiClusterController.List(cluster, vList); err != nil {
+			klog.Errorf("error listing namespaces from cluster %s informer cache: %v", cluster, err)
+			knownClusterSet.Delete(cluster)
+			continue
+		}
+
+		for i := range vList.Items {
+			if co, ok := c.tenantNamespaceObject(cluster, &vList.Items[i]); ok {
+				vSet.Insert(co)
+			}
+		}
+	}
+	return true
+}
+
+// superNamespaceObject keys the pNamespace by its name.
+func superNamespaceObject(obj client.Object) (differ.ClusterObject, bool) {
+	return differ.ClusterObject{Object: obj, Key: obj.GetName()}, true
+}
+
+// tenantNamespaceObject keys the vNamespace by its super control plane name, the namespaces
+// scheduled to other super clusters are skipped.
+func (c *controller) tenantNamespaceObject(cluster string, obj client.Object) (differ.ClusterObject, bool) {
+	if c.MultiClusterController.SchedulingEnabled() {
+		if !c.MultiClusterController.IsNamespaceScheduled(obj) {
+			klog.V(4).Infof("skip ns object %s/%s which is not belongs to this super cluster", cluster, obj.GetName())
+			return differ.ClusterObject{}, false
+		}
+	}
+	return differ.ClusterObject{
+		Object:       obj,
+		OwnerCluster: cluster,
+		Key:          conversion.ToSuperClusterNamespace(cluster, obj.GetName()),
+	}, true
+}
+
+func (c *controller) deleteNamespace(ns *corev1.Namespace, reason string) {
+	if !c.Patroller.Remediate("", ns, pa.ActionDelete, pa.TargetSuper, reason) {
+		return
+	}
 	deleteOptions := &metav1.DeleteOptions{}
 	deleteOptions.Preconditions = metav1.NewUIDPreconditions(string(ns.GetUID()))
 	if err := c.namespaceClient.Namespaces().Delete(context.TODO(), ns.GetName(), *deleteOptions); err != nil {
@@ -86,10 +86,16 @@ func NewNamespaceController(config *config.SyncerConfiguration,
 		c.vcSynced = vcInformer.Informer().HasSynced
 	}
 
-	c.Patroller, err = pa.NewPatroller(&corev1.Namespace{}, c, pa.WithOptions(options.PatrolOptions))
+	c.Patroller, err = pa.NewPatroller(&corev1.Namespace{}, c, pa.WithOptions(options.PatrolOptions),
+		pa.WithIncrementalDiffer(informer.Core().V1().Namespaces().Informer(), c.MultiClusterController,
+			superNamespaceObject, c.tenantNamespaceObject, config.PatrolBackstopPeriod))
 	if err != nil {
 		return nil, err
 	}
+	// the root namespace of a removed cluster is only garbage collected by a full scan.
+	c.MultiClusterController.AddClusterRemovedHandler(func(string) {
+		c.Patroller.RequestFullScan()
+	})
 
 	return c, nil
 }
@@ -74,10 +74,13 @@ func NewNodeController(config *config.SyncerConfiguration,
 		},
 		nodeNameToCluster: make(map[string]map[string]struct{}),
 		nodeClient:        client.CoreV1(),
-		vnodeProvider:     vnode.GetNodeProvider(config, client),
 	}
 
 	var err error
+	c.vnodeProvider, err = vnode.GetNodeProvider(config, client, informer)
+	if err != nil {
+		return nil, err
+	}
 	c.MultiClusterController, err = mc.NewMCController(&corev1.Node{}, &corev1.NodeList{}, c, mc.WithOptions(options.MCOptions))
 	if err != nil {
 		return nil, err
@@ -107,8 +110,9 @@ func NewNodeController(config *config.SyncerConfiguration,
 				}
 
 				if equality.Semantic.DeepEqual(newNode.Status.Conditions, oldNode.Status.Conditions) &&
-					equality.Semantic.DeepEqual(newNode.Status.Addresses, oldNode.Status.Addresses) {
-					// We only update tenant virtual nodes if there are condition or addresses changes, e.g., updating LastHeartBeatTime.
+					equality.Semantic.DeepEqual(newNode.Status.Addresses, oldNode.Status.Addresses) &&
+					equality.Semantic.DeepEqual(newNode.Status.Allocatable, oldNode.Status.Allocatable) {
+					// We only update tenant virtual nodes if there are condition, addresses or allocatable changes, e.g., updating LastHeartBeatTime.
 					return
 				}
 
@@ -17,15 +17,23 @@ limitations under the License.
 package node
 
 import (
+	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/provider"
 )
@@ -46,14 +54,25 @@ func (c *controller) enqueueNode(obj interface{}) {
 
 func (c *controller) BackPopulate(nodeName string) error {
 	node, err := c.nodeLister.Get(nodeName)
-	if err != nil {
-		if apierrors.IsNotFound(err) {
+	switch {
+	case apierrors.IsNotFound(err):
+		// the vNode aggregating the removed node still presents the remaining nodes.
+		node, err = vnode.GetNodeForRemovedNode(c.vnodeProvider, nodeName)
+		if err != nil || node == nil {
 			// TODO: notify every tenant.
-			return nil
+			return err
 		}
+		klog.V(4).Infof("back populate node %s aggregating the removed node %s", node.Name, nodeName)
+	case err != nil:
 		return err
+	default:
+		klog.V(4).Infof("back populate node %s/%s", node.Namespace, node.Name)
+		// the vNode may aggregate several physical nodes.
+		node, err = vnode.GetNodeForVirtualNode(c.vnodeProvider, node)
+		if err != nil {
+			return err
+		}
 	}
-	klog.V(4).Infof("back populate node %s/%s", node.Namespace, node.Name)
 	c.Lock()
 	clusterList := make([]string, 0, len(c.nodeNameToCluster[node.Name]))
 	for clusterName := range c.nodeNameToCluster[node.Name] {
@@ -102,7 +121,7 @@ func (c *controller) updateClusterNode(clusterName string, node *corev1.Node, wg
 	}
 
 	newVNode := vNode.DeepCopy()
-	newVNode.Status.Conditions = node.Status.Conditions
+	newVNode.Status.Conditions = vnode.NodeConditions(c.vnodeProvider, node)
 	vNodeAddress, err := c.vnodeProvider.GetNodeAddress(node)
 	if err != nil {
 		klog.Errorf("unable get node address from provider: %v", err)
@@ -115,11 +134,96 @@ func (c *controller) updateClusterNode(clusterName string, node *corev1.Node, wg
 		return
 	}
 	newVNode.Status.DaemonEndpoints = nodeDaemonEndpoints
+	// the heartbeats and conditions are kept updated with the physical node capacity if the
+	// virtualcluster can't be fetched.
+	vc, err := util.GetVirtualClusterObject(c.MultiClusterController, clusterName)
+	if err != nil {
+		klog.Errorf("unable to get virtualcluster of cluster %s, using the node capacity: %v", clusterName, err)
+		vc = nil
+	}
+	newVNode.Status.Capacity, newVNode.Status.Allocatable, err = provider.GetNodeCapacity(c.vnodeProvider, node, vc)
+	if err != nil {
+		klog.Errorf("unable get node capacity from provider: %v", err)
+		return
+	}
 
 	newVNode.Spec.Taints = provider.GetNodeTaints(c.vnodeProvider, node, metav1.Now())
-	newVNode.ObjectMeta.SetLabels(provider.GetNodeLabels(c.vnodeProvider, node))
+	newVNode.ObjectMeta.SetLabels(provider.GetNodeLabels(c.vnodeProvider, node, c.MultiClusterController.GetScheduledSuperClusterID()))
+
+	// rate limit the updates only refreshing the heartbeats.
+	if c.Config.VNodeHeartbeatPeriod > 0 && vnode.IsHeartbeatOnlyUpdate(vNode, newVNode) {
+		lastHeartbeat := vnode.LastHeartbeatTime(vNode)
+		if time.Since(lastHeartbeat.Time) < c.Config.VNodeHeartbeatPeriod {
+			klog.V(5).Infof("skip heartbeat of node %s/%s, last heartbeat at %v", clusterName, node.Name, lastHeartbeat)
+			return
+		}
+	}
 
 	if err := vnode.UpdateNode(tenantClient.CoreV1().Nodes(), vNode, newVNode); err != nil {
 		klog.Errorf("failed to update node %s/%s's heartbeats: %v", clusterName, node.Name, err)
+		return
+	}
+
+	if ready := vnode.IsNodeReady(newVNode); ready != vnode.IsNodeReady(vNode) {
+		if err := markPodsNodeReady(tenantClient, node.Name, ready); err != nil {
+			klog.Errorf("failed to mark pods on node %s/%s: %v", clusterName, node.Name, err)
+		}
+	}
+}
+
+// markPodsNodeReady sets the PodConditionVNodeReady condition of the tenant pods on the
+// node so that the tenant controllers can react before the pods are evicted in the super
+// cluster. PodReady is left to the upward sync of the pod status, setting it here would
+// be reverted by the next pod status sync.
+func markPodsNodeReady(tenantClient clientset.Interface, nodeName string, ready bool) error {
+	pods, err := tenantClient.CoreV1().Pods(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	condition := corev1.PodCondition{
+		Type:   constants.PodConditionVNodeReady,
+		Status: corev1.ConditionTrue,
+		Reason: "NodeReady",
+	}
+	if !ready {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "NodeNotReady"
+		condition.Message = fmt.Sprintf("node %s is not ready", nodeName)
+	}
+
+	var errs []error
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if !setPodCondition(&pod.Status, condition) {
+			continue
+		}
+		if _, err := tenantClient.CoreV1().Pods(pod.Namespace).UpdateStatus(context.TODO(), pod, metav1.UpdateOptions{}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// setPodCondition sets the condition in the pod status, it returns false if the condition
+// is already set with the same status and reason.
+func setPodCondition(status *corev1.PodStatus, condition corev1.PodCondition) bool {
+	condition.LastTransitionTime = metav1.Now()
+	for i := range status.Conditions {
+		if status.Conditions[i].Type != condition.Type {
+			continue
+		}
+		if status.Conditions[i].Status == condition.Status && status.Conditions[i].Reason == condition.Reason {
+			return false
+		}
+		status.Conditions[i] = condition
+		return true
 	}
+	status.Conditions = append(status.Conditions, condition)
+	return true
 }
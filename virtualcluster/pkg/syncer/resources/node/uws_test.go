@@ -19,6 +19,7 @@ package node
 import (
 	"strings"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -26,6 +27,7 @@ import (
 	core "k8s.io/client-go/testing"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
 	util "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/test"
@@ -48,6 +50,45 @@ func makeNode(name string) *corev1.Node {
 	}
 }
 
+func makeNodeWithReady(name string, ready corev1.ConditionStatus, heartbeat time.Time) *corev1.Node {
+	node := makeNode(name)
+	node.Status.Conditions = []corev1.NodeCondition{
+		{
+			Type:               corev1.NodeReady,
+			Status:             ready,
+			LastHeartbeatTime:  metav1.NewTime(heartbeat),
+			LastTransitionTime: metav1.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			// not mirrored on the vNode.
+			Type:               "CustomCondition",
+			Status:             corev1.ConditionTrue,
+			LastHeartbeatTime:  metav1.NewTime(heartbeat),
+			LastTransitionTime: metav1.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	return node
+}
+
+func makeVNode(name string, ready corev1.ConditionStatus, heartbeat time.Time) *corev1.Node {
+	node := makeNodeWithReady(name, ready, heartbeat)
+	node.Status.Conditions = node.Status.Conditions[:1]
+	node.Labels[constants.LabelVirtualNode] = "true"
+	node.Spec.Taints = []corev1.Taint{{Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule}}
+	return node
+}
+
+func makePodOnNode(name, nodeName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       corev1.PodSpec{NodeName: nodeName},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
 func TestUWNode(t *testing.T) {
 	testTenant := &v1alpha1.VirtualCluster{
 		ObjectMeta: metav1.ObjectMeta{
@@ -71,11 +112,19 @@ func TestUWNode(t *testing.T) {
 		}
 	}
 
+	mFunc2 := func(r manager.ResourceSyncer) {
+		mFunc1(r)
+		r.(*controller).Config.VNodeHeartbeatPeriod = time.Minute
+	}
+
+	now := time.Now()
+
 	testcases := map[string]struct {
 		ExistingObjectInSuper  []runtime.Object
 		ExistingObjectInTenant []runtime.Object
 		EnqueuedKey            string
 		ExpectedUpdatedObject  []string
+		ExpectedNotReadyPods   []string
 		ExpectedNoOperation    bool
 		ExpectedError          string
 		StateModifyFunc        func(manager.ResourceSyncer)
@@ -112,6 +161,47 @@ func TestUWNode(t *testing.T) {
 				"n1",
 			},
 		},
+		"pNode heartbeat within heartbeat period": {
+			ExistingObjectInSuper: []runtime.Object{
+				makeNodeWithReady("n1", corev1.ConditionTrue, now),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				makeVNode("n1", corev1.ConditionTrue, now.Add(-10*time.Second)),
+			},
+			EnqueuedKey:         "n1",
+			StateModifyFunc:     mFunc2,
+			ExpectedNoOperation: true,
+		},
+		"pNode heartbeat after heartbeat period": {
+			ExistingObjectInSuper: []runtime.Object{
+				makeNodeWithReady("n1", corev1.ConditionTrue, now),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				makeVNode("n1", corev1.ConditionTrue, now.Add(-2*time.Minute)),
+			},
+			EnqueuedKey:     "n1",
+			StateModifyFunc: mFunc2,
+			ExpectedUpdatedObject: []string{
+				"n1",
+			},
+		},
+		"pNode becomes not ready": {
+			ExistingObjectInSuper: []runtime.Object{
+				makeNodeWithReady("n1", corev1.ConditionFalse, now),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				makeVNode("n1", corev1.ConditionTrue, now.Add(-10*time.Second)),
+				makePodOnNode("p1", "n1"),
+			},
+			EnqueuedKey:     "n1",
+			StateModifyFunc: mFunc2,
+			ExpectedUpdatedObject: []string{
+				"n1",
+			},
+			ExpectedNotReadyPods: []string{
+				"p1",
+			},
+		},
 	}
 
 	for k, tc := range testcases {
@@ -159,6 +249,35 @@ func TestUWNode(t *testing.T) {
 					t.Errorf("%s: Expect patch Node %+v but not found", k, expectedName)
 				}
 			}
+
+			for _, expectedName := range tc.ExpectedNotReadyPods {
+				matched := false
+				for _, action := range actions {
+					if !action.Matches("update", "pods") || action.GetSubresource() != "status" {
+						continue
+					}
+					pod := action.(core.UpdateAction).GetObject().(*corev1.Pod)
+					if pod.Name != expectedName {
+						continue
+					}
+					matched = true
+					for _, condition := range pod.Status.Conditions {
+						if condition.Type == constants.PodConditionVNodeReady && condition.Status != corev1.ConditionFalse {
+							t.Errorf("%s: Expect pod %s condition %s to be false, got %s", k, expectedName, condition.Type, condition.Status)
+						}
+						// PodReady is left to the pod status sync.
+						if condition.Type == corev1.PodReady && condition.Status != corev1.ConditionTrue {
+							t.Errorf("%s: Expect pod %s condition %s to be kept, got %s", k, expectedName, condition.Type, condition.Status)
+						}
+					}
+					if len(pod.Status.Conditions) != 2 {
+						t.Errorf("%s: Expect pod %s to have 2 conditions, got %v", k, expectedName, pod.Status.Conditions)
+					}
+				}
+				if !matched {
+					t.Errorf("%s: Expect pod %s status update but not found", k, expectedName)
+				}
+			}
 		})
 	}
 }
@@ -31,6 +31,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
 )
 
@@ -85,6 +86,9 @@ func (c *controller) PatrollerDo() {
 
 	d := differ.HandlerFuncs{}
 	d.AddFunc = func(pObj differ.ClusterObject) {
+		if !c.Patroller.Remediate("", pObj.Object, pa.ActionCreate, pa.TargetTenant, "vPV not found") {
+			return
+		}
 		c.UpwardController.AddToQueue(pObj.GetName())
 		metrics.CheckerRemedyStats.WithLabelValues("RequeuedSuperControlPlanePVs").Inc()
 	}
@@ -117,7 +121,7 @@ func (c *controller) PatrollerDo() {
 		if updatedPVSpec != nil {
 			atomic.AddUint64(&numSpecMissMatchedPVs, 1)
 			klog.Warningf("spec of pv %v diff in super&tenant control plane %s", vPV.Name, clusterName)
-			if boundPersistentVolume(pPV) {
+			if boundPersistentVolume(pPV) && c.Patroller.Remediate(clusterName, vPV, pa.ActionUpdate, pa.TargetTenant, "spec mismatch") {
 				c.enqueuePersistentVolume(pPV)
 			}
 		}
@@ -131,6 +135,9 @@ func (c *controller) PatrollerDo() {
 			klog.Errorf("Removed pv %s in cluster %s is bound to a pvc", vPV.Name, vObj.GetOwnerCluster())
 		}
 
+		if !c.Patroller.Remediate(vObj.GetOwnerCluster(), vPV, pa.ActionDelete, pa.TargetTenant, "orphan vPV") {
+			return
+		}
 		tenantClient, err := c.MultiClusterController.GetClusterClient(vObj.GetOwnerCluster())
 		if err != nil {
 			klog.Errorf("error getting cluster %s clientset: %v", vObj.GetOwnerCluster(), err)
@@ -30,6 +30,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 )
@@ -53,39 +54,22 @@ func (c *controller) PatrollerDo() {
 		return
 	}
 
-	numMissMatchedPVCs = 0
-
-	pList, err := c.pvcLister.List(util.GetSuperClusterListerLabelsSelector())
-	if err != nil {
-		klog.Errorf("error listing pvc from super control plane informer cache: %v", err)
-		return
-	}
-	pSet := differ.NewDiffSet()
-	for _, p := range pList {
-		pSet.Insert(differ.ClusterObject{Object: p, Key: differ.DefaultClusterObjectKey(p, "")})
-	}
-
 	knownClusterSet := sets.NewString(clusterNames...)
+	pSet := differ.NewDiffSet()
 	vSet := differ.NewDiffSet()
-	for _, cluster := range clusterNames {
-		vList := &corev1.PersistentVolumeClaimList{}
-		if err := c.MultiClusterController.List(cluster, vList); err != nil {
-			klog.Errorf("error listing pvc from cluster %s informer cache: %v", cluster, err)
-			knownClusterSet.Delete(cluster)
-			continue
-		}
-
-		for i := range vList.Items {
-			vSet.Insert(differ.ClusterObject{
-				Object:       &vList.Items[i],
-				OwnerCluster: cluster,
-				Key:          differ.DefaultClusterObjectKey(&vList.Items[i], cluster),
-			})
+	fullScan := c.Patroller.FullScan()
+	if fullScan {
+		numMissMatchedPVCs = 0
+		if !c.listPVCs(clusterNames, knownClusterSet, vSet, pSet) {
+			return
 		}
 	}
 
 	d := differ.HandlerFuncs{}
 	d.AddFunc = func(vObj differ.ClusterObject) {
+		if !c.Patroller.Remediate(vObj.GetOwnerCluster(), vObj.Object, pa.ActionCreate, pa.TargetSuper, "pPVC not found") {
+			return
+		}
 		if err := c.MultiClusterController.RequeueObject(vObj.OwnerCluster, vObj.Object); err != nil {
 			klog.Errorf("error requeue vPVC %s in cluster %s: %v", vObj.Key, vObj.GetOwnerCluster(), err)
 		} else {
@@ -110,27 +94,66 @@ func (c *controller) PatrollerDo() {
 		if updatedPVC != nil {
 			atomic.AddUint64(&numMissMatchedPVCs, 1)
 			klog.Warningf("spec of pvc %s diff in super&tenant control plane", pObj.Key)
+			c.Patroller.Record(vObj.GetOwnerCluster(), v, pa.ActionUpdate, pa.TargetSuper, "spec mismatch")
 		}
 
 		if conversion.Equality(c.Config, vc).CheckUWPVCStatusEquality(p, v) != nil {
 			klog.Warningf("status of pvc %v/%v diff in super&tenant control plane", p.Namespace, p.Name)
-			c.enqueuePersistentVolumeClaim(p)
+			if c.Patroller.Remediate(vObj.GetOwnerCluster(), v, pa.ActionUpdate, pa.TargetTenant, "status mismatch") {
+				c.enqueuePersistentVolumeClaim(p)
+			}
 		}
 	}
 	d.DeleteFunc = func(pObj differ.ClusterObject) {
+		if !c.Patroller.Remediate("", pObj.Object, pa.ActionDelete, pa.TargetSuper, "orphan pPVC") {
+			return
+		}
 		deleteOptions := &metav1.DeleteOptions{}
 		deleteOptions.Preconditions = metav1.NewUIDPreconditions(string(pObj.GetUID()))
-		if err = c.pvcClient.PersistentVolumeClaims(pObj.GetNamespace()).Delete(context.TODO(), pObj.GetName(), *deleteOptions); err != nil {
+		if err := c.pvcClient.PersistentVolumeClaims(pObj.GetNamespace()).Delete(context.TODO(), pObj.GetName(), *deleteOptions); err != nil {
 			klog.Errorf("error deleting pPVC %s in super control plane: %v", pObj.Key, err)
 		} else {
 			metrics.CheckerRemedyStats.WithLabelValues("DeletedOrphanSuperControlPlanePVCs").Inc()
 		}
 	}
 
-	vSet.Difference(pSet, differ.FilteringHandler{
+	c.Patroller.Diff(vSet, pSet, differ.FilteringHandler{
 		Handler:    d,
 		FilterFunc: differ.DefaultDifferFilter(knownClusterSet),
 	})
 
-	metrics.CheckerMissMatchStats.WithLabelValues("MissMatchedPVCs").Set(float64(numMissMatchedPVCs))
+	if fullScan {
+		metrics.CheckerMissMatchStats.WithLabelValues("MissMatchedPVCs").Set(float64(numMissMatchedPVCs))
+	}
+}
+
+// listPVCs builds the diff sets from the informer caches, the clusters failing
+// to be listed are removed from knownClusterSet.
+func (c *controller) listPVCs(clusterNames []string, knownClusterSet sets.String, vSet, pSet differ.Differ) bool {
+	pList, err := c.pvcLister.List(util.GetSuperClusterListerLabelsSelector())
+	if err != nil {
+		klog.Errorf("error listing pvc from super control plane informer cache: %v", err)
+		return false
+	}
+	for _, p := range pList {
+		pSet.Insert(differ.ClusterObject{Object: p, Key: differ.DefaultClusterObjectKey(p, "")})
+	}
+
+	for _, cluster := range clusterNames {
+		vList := &corev1.PersistentVolumeClaimList{}
+		if err := c.MultiClusterController.List(cluster, vList); err != nil {
+			klog.Errorf("error listing pvc from cluster %s informer cache: %v", cluster, err)
+			knownClusterSet.Delete(cluster)
+			continue
+		}
+
+		for i := range vList.Items {
+			vSet.Insert(differ.ClusterObject{
+				Object:       &vList.Items[i],
+				OwnerCluster: cluster,
+				Key:          differ.DefaultClusterObjectKey(&vList.Items[i], cluster),
+			})
+		}
+	}
+	return true
 }
@@ -91,7 +91,9 @@ func NewPVCController(config *config.SyncerConfiguration,
 		return nil, err
 	}
 
-	c.Patroller, err = pa.NewPatroller(&corev1.PersistentVolumeClaim{}, c, pa.WithOptions(options.PatrolOptions))
+	c.Patroller, err = pa.NewPatroller(&corev1.PersistentVolumeClaim{}, c, pa.WithOptions(options.PatrolOptions),
+		pa.WithIncrementalDiffer(informer.Core().V1().PersistentVolumeClaims().Informer(), c.MultiClusterController,
+			nil, nil, config.PatrolBackstopPeriod))
 	if err != nil {
 		return nil, err
 	}
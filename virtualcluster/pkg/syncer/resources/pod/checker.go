@@ -36,10 +36,11 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
-	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/provider"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
 )
 
@@ -106,6 +107,10 @@ func (c *controller) vNodeGCDo() {
 }
 
 func (c *controller) deleteClusterVNode(cluster, nodeName string) {
+	vNode := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}
+	if !c.Patroller.Remediate(cluster, vNode, pa.ActionDelete, pa.TargetTenant, "orphan vNode") {
+		return
+	}
 	tenantClient, err := c.MultiClusterController.GetClusterClient(cluster)
 	if err != nil {
 		klog.Infof("cluster is removed, clear clusterVNodeGCMap entry for cluster %s", cluster)
@@ -142,51 +147,16 @@ func (c *controller) PatrollerDo() {
 
 	wg := sync.WaitGroup{}
 
-	numStatusMissMatchedPods = 0
-	numSpecMissMatchedPods = 0
-	numUWMetaMissMatchedPods = 0
-
-	pList, err := c.podLister.List(util.GetSuperClusterListerLabelsSelector())
-	if err != nil {
-		klog.Errorf("error listing pod from super control plane informer cache: %v", err)
-		return
-	}
-	pSet := differ.NewDiffSet()
-	for _, p := range pList {
-		pSet.Insert(differ.ClusterObject{Object: p, Key: differ.DefaultClusterObjectKey(p, "")})
-	}
-
 	knownClusterSet := sets.NewString(clusterNames...)
+	pSet := differ.NewDiffSet()
 	vSet := differ.NewDiffSet()
-
-	sel := labels.NewSelector()
-	if featuregate.DefaultFeatureGate.Enabled(featuregate.TenantAllowResourceNoSync) {
-		r, err := labels.NewRequirement(constants.LabelTenantIgnoreSync, selection.NotEquals, []string{"true"})
-		if err == nil {
-			sel = sel.Add(*r)
-		}
-	}
-	for _, cluster := range clusterNames {
-		vList := &corev1.PodList{}
-		if err := c.MultiClusterController.List(cluster, vList, &client.MatchingLabelsSelector{Selector: sel}); err != nil {
-			klog.Errorf("error listing pod from cluster %s informer cache: %v", cluster, err)
-			knownClusterSet.Delete(cluster)
-			continue
-		}
-
-		for i := range vList.Items {
-			if featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterPooling) {
-				cname, ok := vList.Items[i].GetAnnotations()[utilconstants.LabelScheduledCluster]
-				if !ok || cname != utilconstants.SuperClusterID {
-					continue
-				}
-			}
-
-			vSet.Insert(differ.ClusterObject{
-				Object:       &vList.Items[i],
-				OwnerCluster: cluster,
-				Key:          differ.DefaultClusterObjectKey(&vList.Items[i], cluster),
-			})
+	fullScan := c.Patroller.FullScan()
+	if fullScan {
+		numStatusMissMatchedPods = 0
+		numSpecMissMatchedPods = 0
+		numUWMetaMissMatchedPods = 0
+		if !c.listPods(clusterNames, knownClusterSet, vSet, pSet) {
+			return
 		}
 	}
 
@@ -195,7 +165,7 @@ func (c *controller) PatrollerDo() {
 	d.UpdateFunc = c.differUpdateFunc
 	d.DeleteFunc = c.differDeleteFunc
 
-	vSet.Difference(pSet, differ.FilteringHandler{
+	c.Patroller.Diff(vSet, pSet, differ.FilteringHandler{
 		Handler: d,
 		FilterFunc: func(obj differ.ClusterObject) bool {
 			// vObj
@@ -215,9 +185,11 @@ func (c *controller) PatrollerDo() {
 		},
 	})
 
-	metrics.CheckerMissMatchStats.WithLabelValues("StatusMissMatchedPods").Set(float64(numStatusMissMatchedPods))
-	metrics.CheckerMissMatchStats.WithLabelValues("SpecMissMatchedPods").Set(float64(numSpecMissMatchedPods))
-	metrics.CheckerMissMatchStats.WithLabelValues("UWMetaMissMatchedPods").Set(float64(numUWMetaMissMatchedPods))
+	if fullScan {
+		metrics.CheckerMissMatchStats.WithLabelValues("StatusMissMatchedPods").Set(float64(numStatusMissMatchedPods))
+		metrics.CheckerMissMatchStats.WithLabelValues("SpecMissMatchedPods").Set(float64(numSpecMissMatchedPods))
+		metrics.CheckerMissMatchStats.WithLabelValues("UWMetaMissMatchedPods").Set(float64(numUWMetaMissMatchedPods))
+	}
 
 	for _, clusterName := range clusterNames {
 		wg.Add(1)
@@ -232,8 +204,60 @@ func (c *controller) PatrollerDo() {
 	c.vNodeGCDo()
 }
 
+// listPods builds the diff sets from the informer caches, the clusters failing
+// to be listed are removed from knownClusterSet.
+func (c *controller) listPods(clusterNames []string, knownClusterSet sets.String, vSet, pSet differ.Differ) bool {
+	pList, err := c.podLister.List(util.GetSuperClusterListerLabelsSelector())
+	if err != nil {
+		klog.Errorf("error listing pod from super control plane informer cache: %v", err)
+		return false
+	}
+	for _, p := range pList {
+		pSet.Insert(differ.ClusterObject{Object: p, Key: differ.DefaultClusterObjectKey(p, "")})
+	}
+
+	sel := labels.NewSelector()
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.TenantAllowResourceNoSync) {
+		r, err := labels.NewRequirement(constants.LabelTenantIgnoreSync, selection.NotEquals, []string{"true"})
+		if err == nil {
+			sel = sel.Add(*r)
+		}
+	}
+	for _, cluster := range clusterNames {
+		vList := &corev1.PodList{}
+		if err := c.MultiClusterController.List(cluster, vList, &client.MatchingLabelsSelector{Selector: sel}); err != nil {
+			klog.Errorf("error listing pod from cluster %s informer cache: %v", cluster, err)
+			knownClusterSet.Delete(cluster)
+			continue
+		}
+
+		for i := range vList.Items {
+			if co, ok := c.tenantPodObject(cluster, &vList.Items[i]); ok {
+				vSet.Insert(co)
+			}
+		}
+	}
+	return true
+}
+
+// tenantPodObject converts the vPod to the object compared with the pPod, the pods which
+// are not synced or scheduled to other super clusters are skipped.
+func (c *controller) tenantPodObject(cluster string, obj client.Object) (differ.ClusterObject, bool) {
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.TenantAllowResourceNoSync) &&
+		obj.GetLabels()[constants.LabelTenantIgnoreSync] == "true" {
+		return differ.ClusterObject{}, false
+	}
+	// only the scheduler annotates the super cluster of the pods.
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterPooling) {
+		if !c.MultiClusterController.IsPodScheduled(obj) {
+			return differ.ClusterObject{}, false
+		}
+	}
+	return differ.DefaultTenantObject(cluster, obj)
+}
+
 func (c *controller) differDeleteFunc(pObj differ.ClusterObject) {
-	c.graceDeletePPod(pObj.Object.(*corev1.Pod))
+	c.graceDeletePPod(pObj.Object.(*corev1.Pod), "orphan pPod")
 }
 
 func (c *controller) differUpdateFunc(vObj differ.ClusterObject, pObj differ.ClusterObject) {
@@ -241,7 +265,9 @@ func (c *controller) differUpdateFunc(vObj differ.ClusterObject, pObj differ.Clu
 	pPod := pObj.Object.(*corev1.Pod)
 
 	if vPod.DeletionTimestamp != nil && pPod.DeletionTimestamp == nil {
-		c.requeuePod(vObj.GetOwnerCluster(), vPod)
+		if c.Patroller.Remediate(vObj.GetOwnerCluster(), vPod, pa.ActionDelete, pa.TargetSuper, "vPod is under deletion") {
+			c.requeuePod(vObj.GetOwnerCluster(), vPod)
+		}
 		return
 	}
 
@@ -251,18 +277,19 @@ func (c *controller) differUpdateFunc(vObj differ.ClusterObject, pObj differ.Clu
 			return
 		}
 		klog.Errorf("Found pPod %s delegated UID is different from tenant object", pObj.Key)
-		c.graceDeletePPod(pPod)
+		c.graceDeletePPod(pPod, "delegated UID mismatch")
 		return
 	}
 
-	if pPod.Spec.NodeName != "" && vPod.Spec.NodeName != "" && pPod.Spec.NodeName != vPod.Spec.NodeName {
+	if pPod.Spec.NodeName != "" && vPod.Spec.NodeName != "" && provider.GetVirtualNodeName(c.vnodeProvider, pPod.Spec.NodeName) != vPod.Spec.NodeName {
 		// If pPod can be deleted arbitrarily, e.g., evicted by node controller, this inconsistency may happen.
 		// For example, if pPod is deleted just before uws tries to bind the vPod and dws gets a request from checker or
 		// user update at the same time, a new pPod is going to be created potentially in a different node.
 		// However, uws bound vPod to a wrong node already. There is no easy remediation besides deleting tenant pod.
-		c.forceDeleteVPod(vObj.GetOwnerCluster(), vPod, true)
 		klog.Errorf("Found pPod %s nodename is different from tenant pod nodename, delete the vPod", pObj.Key)
-		metrics.CheckerRemedyStats.WithLabelValues("DeletedTenantPodsDueToNodeMissMatch").Inc()
+		if c.forceDeleteVPod(vObj.GetOwnerCluster(), vPod, true, "node name mismatch") {
+			metrics.CheckerRemedyStats.WithLabelValues("DeletedTenantPodsDueToNodeMissMatch").Inc()
+		}
 		return
 	}
 
@@ -276,27 +303,23 @@ func (c *controller) differUpdateFunc(vObj differ.ClusterObject, pObj differ.Clu
 	if conversion.Equality(c.Config, vc).CheckPodEquality(pPod, vPod) != nil {
 		atomic.AddUint64(&numSpecMissMatchedPods, 1)
 		klog.Warningf("spec of pod %s diff in super&tenant control plane", pObj.Key)
-		if err := c.MultiClusterController.RequeueObject(clusterName, vPod); err != nil {
-			klog.Errorf("error requeue vPod %s: %v", vObj.Key, err)
-		} else {
-			metrics.CheckerRemedyStats.WithLabelValues("RequeuedTenantPods").Inc()
+		if c.Patroller.Remediate(clusterName, vPod, pa.ActionUpdate, pa.TargetSuper, "spec mismatch") {
+			c.requeuePod(clusterName, vPod)
 		}
 	}
 
 	if conversion.CheckDWPodConditionEquality(pPod, vPod) != nil {
 		atomic.AddUint64(&numSpecMissMatchedPods, 1)
 		klog.Warningf("DWStatus of pod %s diff in super&tenant control plane", pObj.Key)
-		if err := c.MultiClusterController.RequeueObject(clusterName, vPod); err != nil {
-			klog.Errorf("error requeue vpod %v/%v in cluster %s: %v", vPod.Namespace, vPod.Name, clusterName, err)
-		} else {
-			metrics.CheckerRemedyStats.WithLabelValues("RequeuedTenantPods").Inc()
+		if c.Patroller.Remediate(clusterName, vPod, pa.ActionUpdate, pa.TargetSuper, "condition mismatch") {
+			c.requeuePod(clusterName, vPod)
 		}
 	}
 
 	if conversion.Equality(c.Config, nil).CheckUWPodStatusEquality(pPod, vPod) != nil {
 		atomic.AddUint64(&numStatusMissMatchedPods, 1)
 		klog.Warningf("status of pod %v/%v diff in super&tenant control plane", pPod.Namespace, pPod.Name)
-		if assignedPod(pPod) {
+		if assignedPod(pPod) && c.Patroller.Remediate(clusterName, vPod, pa.ActionUpdate, pa.TargetTenant, "status mismatch") {
 			c.enqueuePod(pPod)
 		}
 	}
@@ -304,7 +327,7 @@ func (c *controller) differUpdateFunc(vObj differ.ClusterObject, pObj differ.Clu
 	if conversion.Equality(c.Config, vc).CheckUWObjectMetaEquality(&pPod.ObjectMeta, &vPod.ObjectMeta) != nil {
 		atomic.AddUint64(&numUWMetaMissMatchedPods, 1)
 		klog.Warningf("UWObjectMeta of pod %v/%v diff in super&tenant control plane", vPod.Namespace, vPod.Name)
-		if assignedPod(pPod) {
+		if assignedPod(pPod) && c.Patroller.Remediate(clusterName, vPod, pa.ActionUpdate, pa.TargetTenant, "metadata mismatch") {
 			c.enqueuePod(pPod)
 		}
 	}
@@ -316,7 +339,7 @@ func (c *controller) differAddFunc(vObj differ.ClusterObject) {
 	// pPod not found and vPod is under deletion, we need to delete vPod manually
 	if vPod.DeletionTimestamp != nil {
 		// since pPod not found in super control plane, we can force delete vPod
-		c.forceDeleteVPod(vObj.GetOwnerCluster(), vPod, false)
+		c.forceDeleteVPod(vObj.GetOwnerCluster(), vPod, false, "pPod not found and vPod is under deletion")
 		return
 	}
 	// pPod not found and vPod still exists, the pPod may be deleted manually or by controller pod eviction.
@@ -329,18 +352,26 @@ func (c *controller) differAddFunc(vObj differ.ClusterObject) {
 			klog.Warningf("pPod %s may exist, should not delete vPod", vObj.Key)
 			return
 		}
-		c.forceDeleteVPod(vObj.GetOwnerCluster(), vPod, false)
-		metrics.CheckerRemedyStats.WithLabelValues("DeletedTenantPodsDueToSuperEviction").Inc()
+		if c.forceDeleteVPod(vObj.GetOwnerCluster(), vPod, false, "pPod not found and vPod is scheduled") {
+			metrics.CheckerRemedyStats.WithLabelValues("DeletedTenantPodsDueToSuperEviction").Inc()
+		}
 		return
 	}
-	c.requeuePod(vObj.GetOwnerCluster(), vPod)
+	if c.Patroller.Remediate(vObj.GetOwnerCluster(), vPod, pa.ActionCreate, pa.TargetSuper, "pPod not found") {
+		c.requeuePod(vObj.GetOwnerCluster(), vPod)
+	}
 }
 
-func (c *controller) forceDeleteVPod(clusterName string, vPod *corev1.Pod, graceful bool) {
+// forceDeleteVPod deletes the vPod in tenant control plane and reports whether the
+// deletion is issued. The vPod is kept if the checker runs in dry-run mode.
+func (c *controller) forceDeleteVPod(clusterName string, vPod *corev1.Pod, graceful bool, reason string) bool {
+	if !c.Patroller.Remediate(clusterName, vPod, pa.ActionDelete, pa.TargetTenant, reason) {
+		return false
+	}
 	client, err := c.MultiClusterController.GetClusterClient(clusterName)
 	if err != nil {
 		klog.Errorf("error getting cluster %s clientset: %v", clusterName, err)
-		return
+		return false
 	}
 	var deleteOptions *metav1.DeleteOptions
 	if graceful {
@@ -355,12 +386,18 @@ func (c *controller) forceDeleteVPod(clusterName string, vPod *corev1.Pod, grace
 	deleteOptions.Preconditions = metav1.NewUIDPreconditions(string(vPod.UID))
 	if err = client.CoreV1().Pods(vPod.Namespace).Delete(context.TODO(), vPod.Name, *deleteOptions); err != nil {
 		klog.Errorf("error deleting pod %v/%v in cluster %s: %v", vPod.Namespace, vPod.Name, clusterName, err)
-	} else if vPod.Spec.NodeName != "" {
+		return false
+	}
+	if vPod.Spec.NodeName != "" {
 		c.updateClusterVNodePodMap(clusterName, vPod.Spec.NodeName, string(vPod.UID), reconciler.DeleteEvent)
 	}
+	return true
 }
 
-func (c *controller) graceDeletePPod(pPod *corev1.Pod) {
+func (c *controller) graceDeletePPod(pPod *corev1.Pod, reason string) {
+	if !c.Patroller.Remediate("", pPod, pa.ActionDelete, pa.TargetSuper, reason) {
+		return
+	}
 	gracePeriod := int64(minimumGracePeriodInSeconds)
 	deleteOptions := metav1.NewDeleteOptions(gracePeriod)
 	deleteOptions.Preconditions = metav1.NewUIDPreconditions(string(pPod.UID))
@@ -398,7 +435,7 @@ func (c *controller) checkNodesOfTenantCluster(clusterName string) {
 		func() {
 			c.Lock()
 			defer c.Unlock()
-			if featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterPooling) && vNode.GetLabels()[constants.LabelSuperClusterID] != utilconstants.SuperClusterID {
+			if c.MultiClusterController.SchedulingEnabled() && vNode.GetLabels()[constants.LabelSuperClusterID] != c.MultiClusterController.GetSuperClusterID() {
 				return
 			}
 			if _, exist := c.clusterVNodePodMap[clusterName]; exist {
@@ -109,10 +109,13 @@ func NewPodController(config *config.SyncerConfiguration,
 		clusterVNodePodMap: make(map[string]map[string]map[string]struct{}),
 		clusterVNodeGCMap:  make(map[string]map[string]VNodeGCStatus),
 		vNodeGCGracePeriod: constants.DefaultvNodeGCGracePeriod,
-		vnodeProvider:      vnode.GetNodeProvider(config, client),
 	}
 
 	var err error
+	c.vnodeProvider, err = vnode.GetNodeProvider(config, client, informer)
+	if err != nil {
+		return nil, err
+	}
 	c.MultiClusterController, err = mc.NewMCController(&corev1.Pod{}, &corev1.PodList{}, c,
 		mc.WithMaxConcurrentReconciles(constants.DwsControllerWorkerHigh), mc.WithOptions(options.MCOptions))
 	if err != nil {
@@ -173,7 +176,9 @@ func NewPodController(config *config.SyncerConfiguration,
 		return nil, err
 	}
 
-	c.Patroller, err = pa.NewPatroller(&corev1.Pod{}, c, pa.WithOptions(options.PatrolOptions))
+	c.Patroller, err = pa.NewPatroller(&corev1.Pod{}, c, pa.WithOptions(options.PatrolOptions),
+		pa.WithIncrementalDiffer(informer.Core().V1().Pods().Informer(), c.MultiClusterController,
+			nil, c.tenantPodObject, config.PatrolBackstopPeriod))
 	if err != nil {
 		return nil, err
 	}
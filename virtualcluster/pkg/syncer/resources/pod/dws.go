@@ -39,6 +39,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/provider"
 	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
 )
@@ -105,7 +106,7 @@ func (c *controller) Reconcile(request reconciler.Request) (res reconciler.Resul
 			return reconciler.Result{Requeue: true}, err
 		}
 		if pPod.Spec.NodeName != "" {
-			c.updateClusterVNodePodMap(request.ClusterName, pPod.Spec.NodeName, request.UID, reconciler.DeleteEvent)
+			c.updateClusterVNodePodMap(request.ClusterName, provider.GetVirtualNodeName(c.vnodeProvider, pPod.Spec.NodeName), request.UID, reconciler.DeleteEvent)
 		}
 	case vPod != nil && pPod != nil:
 		operation = "pod_update"
@@ -33,6 +33,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/provider"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
 )
 
@@ -165,31 +166,36 @@ func (c *controller) bindPodToNode(pPod *corev1.Pod, clusterName string, tenantC
 	if err != nil {
 		return fmt.Errorf("failed to get node %s from super control plane: %v", pPod.Spec.NodeName, err)
 	}
+	vNodeName := provider.GetVirtualNodeName(c.vnodeProvider, n.Name)
 	// We need to handle the race with vNodeGC thread here.
 	if err = func() error {
 		c.Lock()
 		defer c.Unlock()
-		if !c.removeQuiescingNodeFromClusterVNodeGCMap(clusterName, pPod.Spec.NodeName) {
-			return fmt.Errorf("the bind target vNode %s is being GCed in cluster %s, retry", pPod.Spec.NodeName, clusterName)
+		if !c.removeQuiescingNodeFromClusterVNodeGCMap(clusterName, vNodeName) {
+			return fmt.Errorf("the bind target vNode %s is being GCed in cluster %s, retry", vNodeName, clusterName)
 		}
 		return nil
 	}(); err != nil {
 		return err
 	}
 
-	if err := c.MultiClusterController.Get(clusterName, "", n.GetName(), &corev1.Node{}); err != nil {
+	if err := c.MultiClusterController.Get(clusterName, "", vNodeName, &corev1.Node{}); err != nil {
 		// check if target node has already registered on the vc
 		// before creating
 		if !apierrors.IsNotFound(err) {
 			return err
 		}
-		vn, err := vnode.NewVirtualNode(c.vnodeProvider, n)
+		vc, err := util.GetVirtualClusterObject(c.MultiClusterController, clusterName)
 		if err != nil {
-			return fmt.Errorf("failed to create virtual node %s in cluster %s from provider: %v", pPod.Spec.NodeName, clusterName, err)
+			return err
+		}
+		vn, err := vnode.NewVirtualNode(c.vnodeProvider, n, vc, c.MultiClusterController.GetScheduledSuperClusterID())
+		if err != nil {
+			return fmt.Errorf("failed to create virtual node %s in cluster %s from provider: %v", vNodeName, clusterName, err)
 		}
 		_, err = tenantClient.CoreV1().Nodes().Create(context.TODO(), vn, metav1.CreateOptions{})
 		if err != nil && !apierrors.IsAlreadyExists(err) {
-			return fmt.Errorf("failed to create virtual node %s in cluster %s with err: %v", pPod.Spec.NodeName, clusterName, err)
+			return fmt.Errorf("failed to create virtual node %s in cluster %s with err: %v", vNodeName, clusterName, err)
 		}
 	}
 
@@ -200,12 +206,12 @@ func (c *controller) bindPodToNode(pPod *corev1.Pod, clusterName string, tenantC
 		},
 		Target: corev1.ObjectReference{
 			Kind:       "Node",
-			Name:       pPod.Spec.NodeName,
+			Name:       vNodeName,
 			APIVersion: "v1",
 		},
 	}, metav1.CreateOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to bind vPod %s/%s to node %s %v", vPod.Namespace, vPod.Name, pPod.Spec.NodeName, err)
+		return fmt.Errorf("failed to bind vPod %s/%s to node %s %v", vPod.Namespace, vPod.Name, vNodeName, err)
 	}
 	return nil
 }
@@ -32,6 +32,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
 )
 
 var numMissMatchedPriorityClasses uint64
@@ -76,7 +77,7 @@ func (c *controller) PatrollerDo() {
 		}
 		for _, clusterName := range clusterNames {
 			if err := c.MultiClusterController.Get(clusterName, "", pPriorityClass.Name, &schedulingv1.PriorityClass{}); err != nil {
-				if apierrors.IsNotFound(err) {
+				if apierrors.IsNotFound(err) && c.Patroller.Remediate(clusterName, pPriorityClass, pa.ActionCreate, pa.TargetTenant, "vPriorityClass not found") {
 					metrics.CheckerRemedyStats.WithLabelValues("RequeuedSuperControlPlanePriorityClasses").Inc()
 					c.UpwardController.AddToQueue(clusterName + "/" + pPriorityClass.Name)
 				}
@@ -102,6 +103,9 @@ func (c *controller) checkPriorityClassOfTenantCluster(clusterName string) {
 		pPriorityClass, err := c.priorityclassLister.Get(vPriorityClass.Name)
 		if apierrors.IsNotFound(err) {
 			// super control plane is the source of the truth for priorityclass object, delete tenant control plane obj
+			if !c.Patroller.Remediate(clusterName, &scList.Items[i], pa.ActionDelete, pa.TargetTenant, "orphan vPriorityClass") {
+				continue
+			}
 			tenantClient, err := c.MultiClusterController.GetClusterClient(clusterName)
 			if err != nil {
 				klog.Errorf("error getting cluster %s clientset: %v", clusterName, err)
@@ -127,7 +131,7 @@ func (c *controller) checkPriorityClassOfTenantCluster(clusterName string) {
 		if updatedPriorityClass != nil {
 			atomic.AddUint64(&numMissMatchedPriorityClasses, 1)
 			klog.Warningf("spec of priorityClass %v diff in super&tenant control plane", vPriorityClass.Name)
-			if publicPriorityClass(pPriorityClass) {
+			if publicPriorityClass(pPriorityClass) && c.Patroller.Remediate(clusterName, &scList.Items[i], pa.ActionUpdate, pa.TargetTenant, "spec mismatch") {
 				c.UpwardController.AddToQueue(clusterName + "/" + pPriorityClass.Name)
 			}
 		}
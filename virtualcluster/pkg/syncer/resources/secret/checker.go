@@ -32,6 +32,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 )
 
@@ -106,7 +107,7 @@ func (c *controller) PatrollerDo() {
 			}
 		}
 
-		if shouldDelete {
+		if shouldDelete && c.Patroller.Remediate(clusterName, pSecret, pa.ActionDelete, pa.TargetSuper, "orphan pSecret") {
 			deleteOptions := metav1.NewPreconditionDeleteOptions(string(pSecret.UID))
 			if err := c.secretClient.Secrets(pSecret.Namespace).Delete(context.TODO(), pSecret.Name, *deleteOptions); err != nil {
 				klog.Errorf("error deleting pSecret %s/%s in super control plane: %v", pSecret.Namespace, pSecret.Name, err)
@@ -139,6 +140,9 @@ func (c *controller) checkSecretOfTenantCluster(clusterName string) {
 
 		pSecret, err := c.secretLister.Secrets(targetNamespace).Get(vSecret.Name)
 		if apierrors.IsNotFound(err) {
+			if !c.Patroller.Remediate(clusterName, &secretList.Items[i], pa.ActionCreate, pa.TargetSuper, "pSecret not found") {
+				continue
+			}
 			if err := c.MultiClusterController.RequeueObject(clusterName, &secretList.Items[i]); err != nil {
 				klog.Errorf("error requeue vSecret %v/%v in cluster %s: %v", vSecret.Namespace, vSecret.Name, clusterName, err)
 			} else {
@@ -166,6 +170,7 @@ func (c *controller) checkSecretOfTenantCluster(clusterName string) {
 		if updatedSecret != nil {
 			atomic.AddUint64(&numMissMatchedOpaqueSecrets, 1)
 			klog.Warningf("spec of secret %v/%v diff in super&tenant control plane", vSecret.Namespace, vSecret.Name)
+			c.Patroller.Record(clusterName, &secretList.Items[i], pa.ActionUpdate, pa.TargetSuper, "spec mismatch")
 		}
 	}
 }
@@ -175,6 +180,9 @@ func (c *controller) checkServiceAccountTokenTypeSecretOfTenantCluster(clusterNa
 		constants.LabelSecretUID: string(vSecret.UID),
 	}))
 	if apierrors.IsNotFound(err) || len(secretList) == 0 {
+		if !c.Patroller.Remediate(clusterName, vSecret, pa.ActionCreate, pa.TargetSuper, "service account token pSecret not found") {
+			return
+		}
 		if err := c.MultiClusterController.RequeueObject(clusterName, vSecret); err != nil {
 			klog.Errorf("error requeue service account type vSecret %v/%v in cluster %s: %v", vSecret.Namespace, vSecret.Name, clusterName, err)
 		} else {
@@ -206,5 +214,6 @@ func (c *controller) checkServiceAccountTokenTypeSecretOfTenantCluster(clusterNa
 	if updatedSecret != nil {
 		atomic.AddUint64(&numMissMatchedSASecrets, 1)
 		klog.Warningf("spec of service account token type secret %v/%v diff in super&tenant control plane", vSecret.Namespace, vSecret.Name)
+		c.Patroller.Record(clusterName, vSecret, pa.ActionUpdate, pa.TargetSuper, "spec mismatch")
 	}
 }
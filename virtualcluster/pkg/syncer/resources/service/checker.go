@@ -31,6 +31,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 )
@@ -56,47 +57,33 @@ func (c *controller) PatrollerDo() {
 		return
 	}
 
-	numSpecMissMatchedServices = 0
-	numStatusMissMatchedServices = 0
-	numUWMetaMissMatchedServices = 0
-
-	pList, err := c.serviceLister.List(util.GetSuperClusterListerLabelsSelector())
-	if err != nil {
-		klog.Errorf("error listing service from super control plane informer cache: %v", err)
-		return
-	}
-	pSet := differ.NewDiffSet()
-	for _, p := range pList {
-		pSet.Insert(differ.ClusterObject{Object: p, Key: differ.DefaultClusterObjectKey(p, "")})
-	}
-
 	knownClusterSet := sets.NewString(clusterNames...)
+	pSet := differ.NewDiffSet()
 	vSet := differ.NewDiffSet()
-	for _, cluster := range clusterNames {
-		vList := &corev1.ServiceList{}
-		if err := c.MultiClusterController.List(cluster, vList); err != nil {
-			klog.Errorf("error listing service from cluster %s informer cache: %v", cluster, err)
-			knownClusterSet.Delete(cluster)
-			continue
-		}
-
-		for i := range vList.Items {
-			vSet.Insert(differ.ClusterObject{
-				Object:       &vList.Items[i],
-				OwnerCluster: cluster,
-				Key:          differ.DefaultClusterObjectKey(&vList.Items[i], cluster),
-			})
+	fullScan := c.Patroller.FullScan()
+	if fullScan {
+		numSpecMissMatchedServices = 0
+		numStatusMissMatchedServices = 0
+		numUWMetaMissMatchedServices = 0
+		if !c.listServices(clusterNames, knownClusterSet, vSet, pSet) {
+			return
 		}
 	}
 
-	d := differ.HandlerFuncs{}
-	d.AddFunc = func(vObj differ.ClusterObject) {
+	requeue := func(vObj differ.ClusterObject) {
 		if err := c.MultiClusterController.RequeueObject(vObj.GetOwnerCluster(), vObj.Object); err != nil {
 			klog.Errorf("error requeue vService %s in cluster %s: %v", vObj.Key, vObj.GetOwnerCluster(), err)
 		} else {
 			metrics.CheckerRemedyStats.WithLabelValues("RequeuedTenantServices").Inc()
 		}
 	}
+
+	d := differ.HandlerFuncs{}
+	d.AddFunc = func(vObj differ.ClusterObject) {
+		if c.Patroller.Remediate(vObj.GetOwnerCluster(), vObj.Object, pa.ActionCreate, pa.TargetSuper, "pService not found") {
+			requeue(vObj)
+		}
+	}
 	d.UpdateFunc = func(vObj, pObj differ.ClusterObject) {
 		v := vObj.Object.(*corev1.Service)
 		p := pObj.Object.(*corev1.Service)
@@ -116,7 +103,9 @@ func (c *controller) PatrollerDo() {
 		if updatedService != nil {
 			atomic.AddUint64(&numSpecMissMatchedServices, 1)
 			klog.Warningf("spec of service %s diff in super&tenant control plane", pObj.Key)
-			d.OnAdd(vObj)
+			if c.Patroller.Remediate(vObj.GetOwnerCluster(), v, pa.ActionUpdate, pa.TargetSuper, "spec mismatch") {
+				requeue(vObj)
+			}
 			return
 		}
 
@@ -133,26 +122,62 @@ func (c *controller) PatrollerDo() {
 				atomic.AddUint64(&numStatusMissMatchedServices, 1)
 				klog.Warningf("Status of service %s diff in super&tenant control plane", pObj)
 			}
-			if enqueue {
+			if enqueue && c.Patroller.Remediate(vObj.GetOwnerCluster(), v, pa.ActionUpdate, pa.TargetTenant, "status or metadata mismatch") {
 				c.enqueueService(p)
 			}
 		}
 	}
 	d.DeleteFunc = func(pObj differ.ClusterObject) {
+		if !c.Patroller.Remediate("", pObj.Object, pa.ActionDelete, pa.TargetSuper, "orphan pService") {
+			return
+		}
 		deleteOptions := metav1.NewPreconditionDeleteOptions(string(pObj.GetUID()))
-		if err = c.serviceClient.Services(pObj.GetNamespace()).Delete(context.TODO(), pObj.GetName(), *deleteOptions); err != nil {
+		if err := c.serviceClient.Services(pObj.GetNamespace()).Delete(context.TODO(), pObj.GetName(), *deleteOptions); err != nil {
 			klog.Errorf("error deleting pService %s in super control plane: %v", pObj.Key, err)
 		} else {
 			metrics.CheckerRemedyStats.WithLabelValues("DeletedOrphanSuperControlPlaneServices").Inc()
 		}
 	}
 
-	vSet.Difference(pSet, differ.FilteringHandler{
+	c.Patroller.Diff(vSet, pSet, differ.FilteringHandler{
 		Handler:    d,
 		FilterFunc: differ.DefaultDifferFilter(knownClusterSet),
 	})
 
-	metrics.CheckerMissMatchStats.WithLabelValues("SpecMissMatchedServices").Set(float64(numSpecMissMatchedServices))
-	metrics.CheckerMissMatchStats.WithLabelValues("StatusMissMatchedServices").Set(float64(numStatusMissMatchedServices))
-	metrics.CheckerMissMatchStats.WithLabelValues("UWMetaMissMatchedServices").Set(float64(numUWMetaMissMatchedServices))
+	if fullScan {
+		metrics.CheckerMissMatchStats.WithLabelValues("SpecMissMatchedServices").Set(float64(numSpecMissMatchedServices))
+		metrics.CheckerMissMatchStats.WithLabelValues("StatusMissMatchedServices").Set(float64(numStatusMissMatchedServices))
+		metrics.CheckerMissMatchStats.WithLabelValues("UWMetaMissMatchedServices").Set(float64(numUWMetaMissMatchedServices))
+	}
+}
+
+// listServices builds the diff sets from the informer caches, the clusters failing
+// to be listed are removed from knownClusterSet.
+func (c *controller) listServices(clusterNames []string, knownClusterSet sets.String, vSet, pSet differ.Differ) bool {
+	pList, err := c.serviceLister.List(util.GetSuperClusterListerLabelsSelector())
+	if err != nil {
+		klog.Errorf("error listing service from super control plane informer cache: %v", err)
+		return false
+	}
+	for _, p := range pList {
+		pSet.Insert(differ.ClusterObject{Object: p, Key: differ.DefaultClusterObjectKey(p, "")})
+	}
+
+	for _, cluster := range clusterNames {
+		vList := &corev1.ServiceList{}
+		if err := c.MultiClusterController.List(cluster, vList); err != nil {
+			klog.Errorf("error listing service from cluster %s informer cache: %v", cluster, err)
+			knownClusterSet.Delete(cluster)
+			continue
+		}
+
+		for i := range vList.Items {
+			vSet.Insert(differ.ClusterObject{
+				Object:       &vList.Items[i],
+				OwnerCluster: cluster,
+				Key:          differ.DefaultClusterObjectKey(&vList.Items[i], cluster),
+			})
+		}
+	}
+	return true
 }
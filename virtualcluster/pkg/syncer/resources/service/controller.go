@@ -87,7 +87,9 @@ func NewServiceController(config *config.SyncerConfiguration,
 		return nil, err
 	}
 
-	c.Patroller, err = pa.NewPatroller(&corev1.Service{}, c, pa.WithOptions(options.PatrolOptions))
+	c.Patroller, err = pa.NewPatroller(&corev1.Service{}, c, pa.WithOptions(options.PatrolOptions),
+		pa.WithIncrementalDiffer(informer.Core().V1().Services().Informer(), c.MultiClusterController,
+			nil, nil, config.PatrolBackstopPeriod))
 	if err != nil {
 		return nil, err
 	}
@@ -74,7 +74,9 @@ func NewServiceAccountController(config *config.SyncerConfiguration,
 		c.saSynced = informer.Core().V1().ServiceAccounts().Informer().HasSynced
 	}
 
-	c.Patroller, err = pa.NewPatroller(&corev1.ServiceAccount{}, c, pa.WithOptions(options.PatrolOptions))
+	c.Patroller, err = pa.NewPatroller(&corev1.ServiceAccount{}, c, pa.WithOptions(options.PatrolOptions),
+		pa.WithIncrementalDiffer(informer.Core().V1().ServiceAccounts().Informer(), c.MultiClusterController,
+			nil, nil, config.PatrolBackstopPeriod))
 	if err != nil {
 		return nil, err
 	}
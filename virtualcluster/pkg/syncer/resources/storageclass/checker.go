@@ -32,6 +32,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
 )
 
 var numMissMatchedStorageClasses uint64
@@ -76,7 +77,7 @@ func (c *controller) PatrollerDo() {
 		}
 		for _, clusterName := range clusterNames {
 			if err := c.MultiClusterController.Get(clusterName, "", pStorageClass.Name, &storagev1.StorageClass{}); err != nil {
-				if apierrors.IsNotFound(err) {
+				if apierrors.IsNotFound(err) && c.Patroller.Remediate(clusterName, pStorageClass, pa.ActionCreate, pa.TargetTenant, "vStorageClass not found") {
 					metrics.CheckerRemedyStats.WithLabelValues("RequeuedSuperControlPlaneStorageClasses").Inc()
 					c.UpwardController.AddToQueue(clusterName + "/" + pStorageClass.Name)
 				}
@@ -100,6 +101,9 @@ func (c *controller) checkStorageClassOfTenantCluster(clusterName string) {
 		pStorageClass, err := c.storageclassLister.Get(vStorageClass.Name)
 		if apierrors.IsNotFound(err) {
 			// super control plane is the source of the truth for sc object, delete tenant control plane obj
+			if !c.Patroller.Remediate(clusterName, &scList.Items[i], pa.ActionDelete, pa.TargetTenant, "orphan vStorageClass") {
+				continue
+			}
 			tenantClient, err := c.MultiClusterController.GetClusterClient(clusterName)
 			if err != nil {
 				klog.Errorf("error getting cluster %s clientset: %v", clusterName, err)
@@ -125,7 +129,7 @@ func (c *controller) checkStorageClassOfTenantCluster(clusterName string) {
 		if updatedStorageClass != nil {
 			atomic.AddUint64(&numMissMatchedStorageClasses, 1)
 			klog.Warningf("spec of storageClass %v diff in super&tenant control plane", vStorageClass.Name)
-			if publicStorageClass(pStorageClass) {
+			if publicStorageClass(pStorageClass) && c.Patroller.Remediate(clusterName, &scList.Items[i], pa.ActionUpdate, pa.TargetTenant, "spec mismatch") {
 				c.UpwardController.AddToQueue(clusterName + "/" + pStorageClass.Name)
 			}
 		}
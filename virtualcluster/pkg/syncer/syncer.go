@@ -50,10 +50,10 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/cluster"
 	utilconst "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
-	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/listener"
 	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
 )
@@ -65,6 +65,7 @@ var (
 
 type Syncer struct {
 	config            *config.SyncerConfiguration
+	vcClient          vcclient.Interface
 	metaClient        clientset.Interface
 	superClient       clientset.Interface
 	recorder          record.EventRecorder
@@ -79,6 +80,12 @@ type Syncer struct {
 	// clusterSet holds the cluster collection in which cluster is running.
 	mu         sync.Mutex
 	clusterSet map[string]mc.ClusterInterface
+	// pausedClusters holds the clusters whose syncing is paused.
+	pausedClusters sets.String
+	// reconnectClusters holds the clusters to reconnect since their admin kubeconfig is changed.
+	reconnectClusters sets.String
+	// adminKubeconfigInformers watches the admin kubeconfig Secrets on the meta cluster
+	adminKubeconfigInformers informers.SharedInformerFactory
 }
 
 type virtualclusterGetter struct {
@@ -111,13 +118,18 @@ func New(
 	recorder record.EventRecorder,
 ) (*Syncer, error) {
 	syncer := &Syncer{
-		config:      config,
-		metaClient:  metaClusterClient,
-		superClient: superClusterClient,
-		recorder:    recorder,
-		queue:       workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "virtual_cluster"),
-		workers:     constants.UwsControllerWorkerLow,
-		clusterSet:  make(map[string]mc.ClusterInterface),
+		config:         config,
+		vcClient:       virtualClusterClient,
+		metaClient:     metaClusterClient,
+		superClient:    superClusterClient,
+		recorder:       recorder,
+		queue:          workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "virtual_cluster"),
+		workers:        constants.UwsControllerWorkerLow,
+		clusterSet:     make(map[string]mc.ClusterInterface),
+		pausedClusters: sets.NewString(),
+
+		reconnectClusters:        sets.NewString(),
+		adminKubeconfigInformers: newAdminKubeconfigInformerFactory(metaClusterClient),
 	}
 
 	// Handle VirtualCluster add&delete
@@ -138,10 +150,22 @@ func New(
 	syncer.lister = virtualClusterInformer.Lister()
 	syncer.virtualClusterSynced = virtualClusterInformer.Informer().HasSynced
 
+	// Reconnect the tenant clusters whose admin kubeconfig is changed
+	syncer.adminKubeconfigInformers.Core().V1().Secrets().Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			UpdateFunc: syncer.enqueueAdminKubeconfigChange,
+		},
+	)
+
 	// Create the multi cluster controller manager
 	multiClusterControllerManager := manager.New()
 	syncer.controllerManager = multiClusterControllerManager
 
+	var reporter pa.Reporter
+	if config.PatrolReportNamespace != "" {
+		reporter = pa.NewConfigMapReporter(superClusterClient.CoreV1(), config.PatrolReportNamespace)
+	}
+
 	plugins := LoadPlugins(config)
 	initContext := &plugin.InitContext{
 		Context:    context.Background(),
@@ -164,6 +188,17 @@ func New(
 
 		s, ok := instance.(manager.ResourceSyncer)
 		if ok {
+			if mcc := s.GetMCController(); mcc != nil {
+				if config.SuperClusterID != "" {
+					mc.WithSuperCluster(config.SuperClusterID, config.SuperClusterID == config.DefaultSuperClusterID)(&mcc.Options)
+				}
+				mc.WithClusterPausedFunc(syncer.isClusterPaused)(&mcc.Options)
+			}
+			if patroller := s.GetPatroller(); patroller != nil {
+				pa.WithDryRun(config.PatrolDryRun)(&patroller.Options)
+				pa.WithReporter(reporter)(&patroller.Options)
+				pa.WithClusterPausedFunc(syncer.isClusterPaused)(&patroller.Options)
+			}
 			multiClusterControllerManager.AddResourceSyncer(s)
 		} else {
 			klog.Warningf("unrecognized plugin %q", p.ID)
@@ -214,7 +249,8 @@ func (s *Syncer) enqueueVirtualCluster(obj interface{}) {
 
 // Run begins watching and downward&upward syncing.
 func (s *Syncer) Run(stopChan <-chan struct{}) {
-	if featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterPooling) {
+	// the super cluster id is given when the syncer manages multiple super clusters.
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterPooling) && s.config.SuperClusterID == "" {
 		klog.Infof("SuperClusterPooling featuregate is enabled!")
 		cfg, err := s.superClient.CoreV1().ConfigMaps("kube-system").Get(context.TODO(), utilconst.SuperClusterInfoCfgMap, metav1.GetOptions{})
 		if err != nil {
@@ -243,6 +279,7 @@ func (s *Syncer) Run(stopChan <-chan struct{}) {
 		if !cache.WaitForCacheSync(stopChan, s.virtualClusterSynced) {
 			return
 		}
+		s.adminKubeconfigInformers.Start(stopChan)
 
 		klog.V(5).Infof("starting workers")
 		for i := 0; i < s.workers; i++ {
@@ -306,8 +343,22 @@ func (s *Syncer) syncVirtualCluster(key string) error {
 		return nil
 	}
 
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.VirtualClusterOffboarding) {
+		if vc.DeletionTimestamp != nil {
+			return s.offboardCluster(key, vc)
+		}
+		if vc.Status.Phase == v1alpha1.ClusterRunning {
+			if err := s.ensureOffboardingFinalizer(vc); err != nil {
+				return err
+			}
+		}
+	}
+
 	switch vc.Status.Phase {
 	case v1alpha1.ClusterRunning:
+		if err := s.syncPause(vc); err != nil {
+			return err
+		}
 		return s.addCluster(key, vc)
 	case v1alpha1.ClusterError:
 		s.removeCluster(key)
@@ -335,8 +386,11 @@ func (s *Syncer) removeCluster(key string) {
 	}
 
 	vc.Stop()
+	s.reconnectClusters.Delete(key)
+	s.pausedClusters.Delete(vc.GetClusterName())
+	metrics.ClusterSyncPausedStats.DeleteLabelValues(s.config.SuperClusterID, vc.GetClusterName())
 
-	for _, clusterChangeListener := range listener.Listeners {
+	for _, clusterChangeListener := range s.controllerManager.GetListeners() {
 		clusterChangeListener.RemoveCluster(vc)
 	}
 
@@ -349,10 +403,17 @@ func (s *Syncer) addCluster(key string, vc *v1alpha1.VirtualCluster) error {
 
 	s.mu.Lock()
 	if _, exist := s.clusterSet[key]; exist {
+		reconnect := s.reconnectClusters.Has(key)
+		s.reconnectClusters.Delete(key)
+		s.mu.Unlock()
+		if !reconnect {
+			return nil
+		}
+		// the tenant client is rebuilt with the changed admin kubeconfig
+		s.removeCluster(key)
+	} else {
 		s.mu.Unlock()
-		return nil
 	}
-	s.mu.Unlock()
 
 	clusterName := conversion.ToClusterKey(vc)
 
@@ -366,7 +427,7 @@ func (s *Syncer) addCluster(key string, vc *v1alpha1.VirtualCluster) error {
 	}
 
 	// for each resource type of the newly added VirtualCluster, we add the object to informer cache.
-	for _, clusterChangeListener := range listener.Listeners {
+	for _, clusterChangeListener := range s.controllerManager.GetListeners() {
 		clusterChangeListener.AddCluster(tenantCluster)
 	}
 
@@ -385,7 +446,16 @@ func (s *Syncer) runCluster(cluster *cluster.Cluster, vc *v1alpha1.VirtualCluste
 		klog.Infof("cluster %s shutdown: %v", cluster.GetClusterName(), err)
 	}()
 
-	if !cluster.WaitForCacheSync() {
+	synced := cluster.WaitForCacheSync()
+	key, _ := cache.DeletionHandlingMetaNamespaceKeyFunc(vc)
+	s.mu.Lock()
+	current := s.clusterSet[key] == mc.ClusterInterface(cluster)
+	s.mu.Unlock()
+	if !current {
+		// the cluster is removed or reconnected meanwhile
+		return
+	}
+	if !synced {
 		s.recorder.Eventf(&corev1.ObjectReference{
 			Kind:      "VirtualCluster",
 			Namespace: vc.Namespace,
@@ -394,16 +464,17 @@ func (s *Syncer) runCluster(cluster *cluster.Cluster, vc *v1alpha1.VirtualCluste
 		}, corev1.EventTypeWarning, "ClusterUnHealth", "VirtualCluster %v unhealth: failed to sync cache", cluster.GetClusterName())
 
 		klog.Warningf("failed to sync cache for cluster %s, retry", cluster.GetClusterName())
-		key, _ := cache.DeletionHandlingMetaNamespaceKeyFunc(vc)
+		s.updateSyncerConnectedCondition(vc.Namespace, vc.Name, corev1.ConditionFalse, reasonCacheSyncFailed, "failed to sync cache")
 		s.removeCluster(key)
 		s.queue.AddAfter(key, 5*time.Second)
 		return
 	}
 	cluster.SetSynced()
 	klog.Infof("cluster %s cache sync done", cluster.GetClusterName())
+	s.updateSyncerConnectedCondition(vc.Namespace, vc.Name, corev1.ConditionTrue, reasonConnected, connectedMessage)
 
 	// start watching cluster resource event after cache sync done.
-	for _, clusterChangeListener := range listener.Listeners {
+	for _, clusterChangeListener := range s.controllerManager.GetListeners() {
 		clusterChangeListener.WatchCluster(cluster)
 	}
 }
@@ -444,15 +515,16 @@ func (s *Syncer) checkTenantClusterHealth(cluster mc.ClusterInterface) {
 		return
 	}
 
+	ns, name, uid := cluster.GetOwnerInfo()
 	_, discoveryErr := cs.Discovery().ServerVersion()
 	if discoveryErr == nil {
 		atomic.AddUint64(&numHealthCluster, 1)
+		s.updateSyncerConnectedCondition(ns, name, corev1.ConditionTrue, reasonConnected, connectedMessage)
 		return
 	}
 
 	atomic.AddUint64(&numUnHealthCluster, 1)
-
-	ns, name, uid := cluster.GetOwnerInfo()
+	s.updateSyncerConnectedCondition(ns, name, corev1.ConditionFalse, reasonClusterUnhealthy, discoveryErr.Error())
 
 	s.recorder.Eventf(&corev1.ObjectReference{
 		Kind:      "VirtualCluster",
@@ -88,6 +88,38 @@ const (
 	// add clusterIP of pService to vService's externalIPs.
 	// So that vService can be resolved by using the k8s_external plugin in coredns.
 	VServiceExternalIP = "VServiceExternalIP"
+
+	// IncrementalPatrol is an experimental feature that allows the periodic checkers
+	// to compare only the objects changed since the previous run, a full scan is
+	// done every PatrolBackstopPeriod. It covers the configmap, endpoints, namespace,
+	// persistentvolumeclaim, pod, service and serviceaccount checkers, the other
+	// checkers always do a full scan until they are ported.
+	IncrementalPatrol = "IncrementalPatrol"
+
+	// VirtualClusterOffboarding is an experimental feature that allows the syncer to
+	// hold a finalizer on each VirtualCluster and garbage collect the synced objects
+	// in super cluster before the VirtualCluster is deleted.
+	VirtualClusterOffboarding = "VirtualClusterOffboarding"
+
+	// VNodeProviderAggregated is an experimental feature that presents the super cluster
+	// nodes to each tenant through AggregatedVNodeCount aggregated vNodes addressed by the
+	// vn-agent service, the vn-agent must forward the requests to the super apiserver.
+	VNodeProviderAggregated = "VNodeProviderAggregated"
+
+	// KubeletCheckpoint is an experimental feature that allows the vn-agent
+	// to proxy the kubelet /checkpoint endpoint for the tenant pods.
+	KubeletCheckpoint = "KubeletCheckpoint"
+
+	// KubeletConfigz is an experimental feature that allows the vn-agent
+	// to serve the kubelet /configz endpoint, filtered to the non-sensitive fields.
+	KubeletConfigz = "KubeletConfigz"
+
+	// KubeletDebugHandlers is an experimental feature that allows the vn-agent
+	// to proxy the kubelet /debug/pprof handlers for the tenants listed in --debug-tenants.
+	KubeletDebugHandlers = "KubeletDebugHandlers"
+	// PKIRotation is an experimental feature that allows vc-manager to renew the certificates
+	// of the tenant control planes ahead of expiry and to rotate their root CA.
+	PKIRotation = "PKIRotation"
 )
 
 var defaultFeatures = FeatureList{
@@ -103,6 +135,13 @@ var defaultFeatures = FeatureList{
 	DisableCRDPreserveUnknownFields: {Default: false},
 	RootCACertConfigMapSupport:      {Default: false},
 	VServiceExternalIP:              {Default: false},
+	IncrementalPatrol:               {Default: false},
+	VirtualClusterOffboarding:       {Default: false},
+	VNodeProviderAggregated:         {Default: false},
+	KubeletCheckpoint:               {Default: false},
+	KubeletConfigz:                  {Default: false},
+	KubeletDebugHandlers:            {Default: false},
+	PKIRotation:                     {Default: false},
 }
 
 type Feature string
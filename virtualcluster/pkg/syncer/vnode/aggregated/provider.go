@@ -0,0 +1,73 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aggregated
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+
+	vnodeprovider "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/provider"
+)
+
+// NodeNamePrefix is the name prefix of the aggregated vNodes.
+const NodeNamePrefix = "virtual-node"
+
+// provider presents the super cluster nodes through nodeCount aggregated vNodes, each
+// physical node is presented by the vNode its name hashes to. The vNodes are addressed
+// through the given provider, which must route to a vn-agent forwarding the requests to
+// the super apiserver, so that the logs and exec requests reach the physical node of
+// the pod.
+type provider struct {
+	vnodeprovider.VirtualNodeProvider
+	nodeCount  int
+	nodeLister listersv1.NodeLister
+}
+
+var _ vnodeprovider.NodeAggregator = &provider{}
+
+// NewAggregatedVirtualNodeProvider creates a provider aggregating the super cluster nodes
+// into nodeCount vNodes, addressed through the given provider.
+func NewAggregatedVirtualNodeProvider(p vnodeprovider.VirtualNodeProvider, nodeCount int, nodeLister listersv1.NodeLister) vnodeprovider.VirtualNodeProvider {
+	if nodeCount < 1 {
+		nodeCount = 1
+	}
+	return &provider{VirtualNodeProvider: p, nodeCount: nodeCount, nodeLister: nodeLister}
+}
+
+func (p *provider) GetVirtualNodeName(nodeName string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(nodeName))
+	return fmt.Sprintf("%s-%d", NodeNamePrefix, h.Sum32()%uint32(p.nodeCount))
+}
+
+func (p *provider) GetAggregatedNodes(vNodeName string) ([]*corev1.Node, error) {
+	nodes, err := p.nodeLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var aggregated []*corev1.Node
+	for _, node := range nodes {
+		if p.GetVirtualNodeName(node.Name) == vNodeName {
+			aggregated = append(aggregated, node)
+		}
+	}
+	return aggregated, nil
+}
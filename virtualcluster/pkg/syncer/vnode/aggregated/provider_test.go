@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aggregated
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	vnodeprovider "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/provider"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/service"
+)
+
+func newProvider(t *testing.T, nodeCount int, nodeNames ...string) vnodeprovider.VirtualNodeProvider {
+	client := fake.NewSimpleClientset()
+	informer := informers.NewSharedInformerFactory(client, 0).Core().V1().Nodes()
+	for _, name := range nodeNames {
+		if err := informer.Informer().GetStore().Add(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}); err != nil {
+			t.Fatalf("failed to add node %s: %v", name, err)
+		}
+	}
+	base := service.NewServiceVirtualNodeProvider(10550, "vc-manager/vn-agent", client, nil, nil)
+	return NewAggregatedVirtualNodeProvider(base, nodeCount, informer.Lister())
+}
+
+func TestGetVirtualNodeName(t *testing.T) {
+	for _, nodeCount := range []int{0, 1, 3} {
+		p := newProvider(t, nodeCount)
+		expected := nodeCount
+		if expected < 1 {
+			expected = 1
+		}
+		seen := map[string]bool{}
+		for i := 0; i < 100; i++ {
+			nodeName := fmt.Sprintf("node-%d", i)
+			name := vnodeprovider.GetVirtualNodeName(p, nodeName)
+			if !strings.HasPrefix(name, NodeNamePrefix+"-") {
+				t.Errorf("unexpected vNode name %s of node %s", name, nodeName)
+			}
+			if again := vnodeprovider.GetVirtualNodeName(p, nodeName); again != name {
+				t.Errorf("vNode name of node %s is not stable, got %s and %s", nodeName, name, again)
+			}
+			seen[name] = true
+		}
+		if len(seen) != expected {
+			t.Errorf("expected %d vNodes with count %d, got %v", expected, nodeCount, seen)
+		}
+	}
+}
+
+func TestGetAggregatedNodes(t *testing.T) {
+	nodeNames := []string{"n1", "n2", "n3", "n4", "n5", "n6"}
+	p := newProvider(t, 2, nodeNames...)
+	aggregator, ok := vnodeprovider.GetNodeAggregator(p)
+	if !ok {
+		t.Fatalf("provider is not a NodeAggregator")
+	}
+
+	total := 0
+	for i := 0; i < 2; i++ {
+		vNodeName := fmt.Sprintf("%s-%d", NodeNamePrefix, i)
+		nodes, err := aggregator.GetAggregatedNodes(vNodeName)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, node := range nodes {
+			if got := aggregator.GetVirtualNodeName(node.Name); got != vNodeName {
+				t.Errorf("node %s of vNode %s is presented by %s", node.Name, vNodeName, got)
+			}
+		}
+		total += len(nodes)
+	}
+	if total != len(nodeNames) {
+		t.Errorf("expected %d aggregated nodes, got %d", len(nodeNames), total)
+	}
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacity
+
+import (
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	vnodeprovider "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/provider"
+)
+
+// Policy defines how the vNode capacity and allocatable are computed for a tenant.
+type Policy string
+
+const (
+	// PolicyNode presents the capacity and allocatable of the physical node.
+	PolicyNode Policy = "Node"
+	// PolicyResourceQuota caps the capacity and allocatable with the hard limits of the
+	// resource quotas in the super cluster namespaces of the tenant.
+	PolicyResourceQuota Policy = "ResourceQuota"
+	// PolicyFraction scales the capacity and allocatable with the fraction annotated on
+	// the VirtualCluster, the physical node is presented if there is no annotation.
+	PolicyFraction Policy = "Fraction"
+	// PolicyFree subtracts the requests of the pods of the other tenants and of the
+	// super cluster from the capacity and allocatable. It has no effect on the vNodes
+	// aggregating several physical nodes.
+	PolicyFree Policy = "Free"
+)
+
+const podNodeNameIndex = "spec.nodeName"
+
+type provider struct {
+	vnodeprovider.VirtualNodeProvider
+	policy Policy
+
+	nsLister    listersv1.NamespaceLister
+	quotaLister listersv1.ResourceQuotaLister
+	podIndexer  cache.Indexer
+}
+
+var _ vnodeprovider.CapacityProvider = &provider{}
+var _ vnodeprovider.Wrapper = &provider{}
+
+// NewCapacityVirtualNodeProvider wraps the VirtualNodeProvider to present the vNode capacity
+// and allocatable computed by the policy. The super cluster objects the policy needs are
+// read from the informer.
+func NewCapacityVirtualNodeProvider(p vnodeprovider.VirtualNodeProvider, policy Policy, informer informers.SharedInformerFactory) (vnodeprovider.VirtualNodeProvider, error) {
+	switch policy {
+	case "", PolicyNode:
+		return p, nil
+	case PolicyFraction:
+		return &provider{VirtualNodeProvider: p, policy: policy}, nil
+	case PolicyResourceQuota:
+		return &provider{
+			VirtualNodeProvider: p,
+			policy:              policy,
+			nsLister:            informer.Core().V1().Namespaces().Lister(),
+			quotaLister:         informer.Core().V1().ResourceQuotas().Lister(),
+		}, nil
+	case PolicyFree:
+		podInformer := informer.Core().V1().Pods().Informer()
+		if _, exists := podInformer.GetIndexer().GetIndexers()[podNodeNameIndex]; !exists {
+			if err := podInformer.AddIndexers(cache.Indexers{podNodeNameIndex: indexPodByNodeName}); err != nil {
+				return nil, fmt.Errorf("failed to index pods by node name: %v", err)
+			}
+		}
+		return &provider{VirtualNodeProvider: p, policy: policy, podIndexer: podInformer.GetIndexer()}, nil
+	default:
+		return nil, fmt.Errorf("unknown vNode capacity policy %q", policy)
+	}
+}
+
+func indexPodByNodeName(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok || pod.Spec.NodeName == "" {
+		return nil, nil
+	}
+	return []string{pod.Spec.NodeName}, nil
+}
+
+func (p *provider) Unwrap() vnodeprovider.VirtualNodeProvider {
+	return p.VirtualNodeProvider
+}
+
+func (p *provider) GetNodeCapacity(node *corev1.Node, vc *v1alpha1.VirtualCluster) (corev1.ResourceList, corev1.ResourceList, error) {
+	switch p.policy {
+	case PolicyFraction:
+		return p.fractionCapacity(node, vc)
+	case PolicyResourceQuota:
+		return p.quotaCapacity(node, vc)
+	case PolicyFree:
+		return p.freeCapacity(node, vc)
+	}
+	return node.Status.Capacity, node.Status.Allocatable, nil
+}
+
+func (p *provider) fractionCapacity(node *corev1.Node, vc *v1alpha1.VirtualCluster) (corev1.ResourceList, corev1.ResourceList, error) {
+	value, exists := vc.GetAnnotations()[constants.LabelVNodeCapacityFraction]
+	if !exists {
+		return node.Status.Capacity, node.Status.Allocatable, nil
+	}
+	fraction, err := strconv.ParseFloat(value, 64)
+	if err != nil || fraction <= 0 || fraction > 1 {
+		// a malformed annotation must not stop the vNodes from being synced.
+		klog.Warningf("invalid vNode capacity fraction %q of cluster %s, it must be in (0, 1], presenting the node capacity", value, conversion.ToClusterKey(vc))
+		return node.Status.Capacity, node.Status.Allocatable, nil
+	}
+	return scale(node.Status.Capacity, fraction), scale(node.Status.Allocatable, fraction), nil
+}
+
+func (p *provider) quotaCapacity(node *corev1.Node, vc *v1alpha1.VirtualCluster) (corev1.ResourceList, corev1.ResourceList, error) {
+	clusterName := conversion.ToClusterKey(vc)
+	namespaces, err := p.nsLister.List(labels.Everything())
+	if err != nil {
+		return nil, nil, err
+	}
+	// a resource is only limited for the tenant if it is limited in every tenant namespace.
+	limits := corev1.ResourceList{}
+	limitedNamespaces := map[corev1.ResourceName]int{}
+	tenantNamespaces := 0
+	for _, ns := range namespaces {
+		if ns.GetAnnotations()[constants.LabelCluster] != clusterName {
+			continue
+		}
+		tenantNamespaces++
+		quotas, err := p.quotaLister.ResourceQuotas(ns.Name).List(labels.Everything())
+		if err != nil {
+			return nil, nil, err
+		}
+		// the tightest quota applies when several quotas limit the same resource.
+		nsLimits := corev1.ResourceList{}
+		for _, quota := range quotas {
+			for name, quantity := range quotaLimits(quota.Spec.Hard) {
+				if current, exists := nsLimits[name]; !exists || quantity.Cmp(current) < 0 {
+					nsLimits[name] = quantity
+				}
+			}
+		}
+		for name := range nsLimits {
+			limitedNamespaces[name]++
+		}
+		add(limits, nsLimits)
+	}
+	for name := range limits {
+		if limitedNamespaces[name] != tenantNamespaces {
+			delete(limits, name)
+		}
+	}
+	return capWith(node.Status.Capacity, limits), capWith(node.Status.Allocatable, limits), nil
+}
+
+func (p *provider) freeCapacity(node *corev1.Node, vc *v1alpha1.VirtualCluster) (corev1.ResourceList, corev1.ResourceList, error) {
+	clusterName := conversion.ToClusterKey(vc)
+	objs, err := p.podIndexer.ByIndex(podNodeNameIndex, node.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	used := corev1.ResourceList{}
+	for _, obj := range objs {
+		pod := obj.(*corev1.Pod)
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if pod.GetAnnotations()[constants.LabelCluster] == clusterName {
+			continue
+		}
+		add(used, podRequests(pod))
+		add(used, corev1.ResourceList{corev1.ResourcePods: *resource.NewQuantity(1, resource.DecimalSI)})
+	}
+	return subtract(node.Status.Capacity, used), subtract(node.Status.Allocatable, used), nil
+}
+
+// quotaLimits returns the node resources limited by the hard limits of a resource quota,
+// the requests limits are preferred to the limits of the same resource.
+func quotaLimits(hard corev1.ResourceList) corev1.ResourceList {
+	limits := corev1.ResourceList{}
+	for name, quantity := range hard {
+		switch name {
+		case corev1.ResourceRequestsCPU:
+			limits[corev1.ResourceCPU] = quantity
+		case corev1.ResourceRequestsMemory:
+			limits[corev1.ResourceMemory] = quantity
+		case corev1.ResourceRequestsEphemeralStorage:
+			limits[corev1.ResourceEphemeralStorage] = quantity
+		case corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage:
+			if _, exists := limits[name]; !exists {
+				limits[name] = quantity
+			}
+		case corev1.ResourcePods:
+			limits[name] = quantity
+		}
+	}
+	return limits
+}
+
+// podRequests returns the resources requested by the pod, which is the maximum of the
+// sum of its containers requests and of any init container requests, plus the overhead.
+func podRequests(pod *corev1.Pod) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for _, c := range pod.Spec.Containers {
+		add(requests, c.Resources.Requests)
+	}
+	for _, c := range pod.Spec.InitContainers {
+		for name, quantity := range c.Resources.Requests {
+			if current, exists := requests[name]; !exists || quantity.Cmp(current) > 0 {
+				requests[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	add(requests, pod.Spec.Overhead)
+	return requests
+}
+
+func add(total, list corev1.ResourceList) {
+	for name, quantity := range list {
+		current := total[name]
+		current.Add(quantity)
+		total[name] = current
+	}
+}
+
+func subtract(list, used corev1.ResourceList) corev1.ResourceList {
+	result := list.DeepCopy()
+	for name, quantity := range result {
+		if u, exists := used[name]; exists {
+			quantity.Sub(u)
+			if quantity.Sign() < 0 {
+				quantity.Set(0)
+			}
+			result[name] = quantity
+		}
+	}
+	return result
+}
+
+func capWith(list, limits corev1.ResourceList) corev1.ResourceList {
+	result := list.DeepCopy()
+	for name, quantity := range result {
+		if limit, exists := limits[name]; exists && limit.Cmp(quantity) < 0 {
+			result[name] = limit.DeepCopy()
+		}
+	}
+	return result
+}
+
+func scale(list corev1.ResourceList, fraction float64) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for name, quantity := range list {
+		if name == corev1.ResourceCPU {
+			result[name] = *resource.NewMilliQuantity(int64(float64(quantity.MilliValue())*fraction), quantity.Format)
+			continue
+		}
+		result[name] = *resource.NewQuantity(int64(float64(quantity.Value())*fraction), quantity.Format)
+	}
+	return result
+}
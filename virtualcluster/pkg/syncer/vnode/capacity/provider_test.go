@@ -0,0 +1,223 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacity
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/native"
+	vnodeprovider "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/provider"
+)
+
+func resources(cpu, memory, pods string) corev1.ResourceList {
+	return corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(memory),
+		corev1.ResourcePods:   resource.MustParse(pods),
+	}
+}
+
+func newVirtualCluster(name string, annotations map[string]string) *v1alpha1.VirtualCluster {
+	return &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			UID:         types.UID("uid-" + name),
+			Annotations: annotations,
+		},
+	}
+}
+
+func newTenantPod(name, clusterName, cpu, memory string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   clusterName + "-default",
+			Annotations: map[string]string{constants.LabelCluster: clusterName},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "n1",
+			Containers: []corev1.Container{{
+				Name: "c",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				}},
+			}},
+		},
+	}
+}
+
+func TestGetNodeCapacity(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "n1"},
+		Status: corev1.NodeStatus{
+			Capacity:    resources("8", "32Gi", "110"),
+			Allocatable: resources("7", "30Gi", "110"),
+		},
+	}
+	vc := newVirtualCluster("vc", nil)
+	other := newVirtualCluster("other", nil)
+	clusterName := conversion.ToClusterKey(vc)
+	otherClusterName := conversion.ToClusterKey(other)
+
+	for _, tc := range []struct {
+		name                string
+		policy              Policy
+		vc                  *v1alpha1.VirtualCluster
+		objects             []interface{}
+		expectedCapacity    corev1.ResourceList
+		expectedAllocatable corev1.ResourceList
+	}{
+		{
+			name:                "node",
+			policy:              PolicyNode,
+			vc:                  vc,
+			expectedCapacity:    node.Status.Capacity,
+			expectedAllocatable: node.Status.Allocatable,
+		},
+		{
+			name:                "fraction",
+			policy:              PolicyFraction,
+			vc:                  newVirtualCluster("vc", map[string]string{constants.LabelVNodeCapacityFraction: "0.25"}),
+			expectedCapacity:    resources("2", "8Gi", "27"),
+			expectedAllocatable: resources("1750m", "7680Mi", "27"),
+		},
+		{
+			name:                "fraction not annotated",
+			policy:              PolicyFraction,
+			vc:                  vc,
+			expectedCapacity:    node.Status.Capacity,
+			expectedAllocatable: node.Status.Allocatable,
+		},
+		{
+			name:                "fraction out of range",
+			policy:              PolicyFraction,
+			vc:                  newVirtualCluster("vc", map[string]string{constants.LabelVNodeCapacityFraction: "2"}),
+			expectedCapacity:    node.Status.Capacity,
+			expectedAllocatable: node.Status.Allocatable,
+		},
+		{
+			name:                "malformed fraction",
+			policy:              PolicyFraction,
+			vc:                  newVirtualCluster("vc", map[string]string{constants.LabelVNodeCapacityFraction: "a quarter"}),
+			expectedCapacity:    node.Status.Capacity,
+			expectedAllocatable: node.Status.Allocatable,
+		},
+		{
+			name:   "resource quota",
+			policy: PolicyResourceQuota,
+			vc:     vc,
+			objects: []interface{}{
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: clusterName + "-a", Annotations: map[string]string{constants.LabelCluster: clusterName}}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: clusterName + "-b", Annotations: map[string]string{constants.LabelCluster: clusterName}}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: otherClusterName + "-a", Annotations: map[string]string{constants.LabelCluster: otherClusterName}}},
+				&corev1.ResourceQuota{
+					ObjectMeta: metav1.ObjectMeta{Name: "q1", Namespace: clusterName + "-a"},
+					Spec: corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+						corev1.ResourceRequestsCPU: resource.MustParse("2"),
+						corev1.ResourceMemory:      resource.MustParse("4Gi"),
+					}},
+				},
+				&corev1.ResourceQuota{
+					ObjectMeta: metav1.ObjectMeta{Name: "q2", Namespace: clusterName + "-a"},
+					Spec: corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+						corev1.ResourceRequestsCPU: resource.MustParse("1"),
+					}},
+				},
+				&corev1.ResourceQuota{
+					ObjectMeta: metav1.ObjectMeta{Name: "q", Namespace: clusterName + "-b"},
+					Spec: corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2"),
+					}},
+				},
+				&corev1.ResourceQuota{
+					ObjectMeta: metav1.ObjectMeta{Name: "q", Namespace: otherClusterName + "-a"},
+					Spec: corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+						corev1.ResourcePods: resource.MustParse("1"),
+					}},
+				},
+			},
+			// memory is not limited in the namespace b.
+			expectedCapacity:    resources("3", "32Gi", "110"),
+			expectedAllocatable: resources("3", "30Gi", "110"),
+		},
+		{
+			name:   "free",
+			policy: PolicyFree,
+			vc:     vc,
+			objects: []interface{}{
+				newTenantPod("own", clusterName, "4", "16Gi"),
+				newTenantPod("other", otherClusterName, "2", "8Gi"),
+				func() *corev1.Pod {
+					pod := newTenantPod("completed", otherClusterName, "2", "8Gi")
+					pod.Status.Phase = corev1.PodSucceeded
+					return pod
+				}(),
+			},
+			expectedCapacity:    resources("6", "24Gi", "109"),
+			expectedAllocatable: resources("5", "22Gi", "109"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			informer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+			p, err := NewCapacityVirtualNodeProvider(native.NewNativeVirtualNodeProvider(10550, nil, nil), tc.policy, informer)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, obj := range tc.objects {
+				switch o := obj.(type) {
+				case *corev1.Namespace:
+					informer.Core().V1().Namespaces().Informer().GetStore().Add(o)
+				case *corev1.ResourceQuota:
+					informer.Core().V1().ResourceQuotas().Informer().GetStore().Add(o)
+				case *corev1.Pod:
+					informer.Core().V1().Pods().Informer().GetStore().Add(o)
+				}
+			}
+
+			capacity, allocatable, err := vnodeprovider.GetNodeCapacity(p, node, tc.vc)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !equality.Semantic.DeepEqual(capacity, tc.expectedCapacity) {
+				t.Errorf("expected capacity %v, got %v", tc.expectedCapacity, capacity)
+			}
+			if !equality.Semantic.DeepEqual(allocatable, tc.expectedAllocatable) {
+				t.Errorf("expected allocatable %v, got %v", tc.expectedAllocatable, allocatable)
+			}
+		})
+	}
+}
+
+func TestNewCapacityVirtualNodeProviderUnknownPolicy(t *testing.T) {
+	informer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	if _, err := NewCapacityVirtualNodeProvider(native.NewNativeVirtualNodeProvider(10550, nil, nil), "Unknown", informer); err == nil {
+		t.Errorf("expected error for unknown policy, got none")
+	}
+}
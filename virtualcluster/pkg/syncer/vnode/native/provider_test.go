@@ -92,7 +92,7 @@ func Test_provider_GetNodeLabels(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := NewNativeVirtualNodeProvider(8080, tt.fields.labelsToSync, map[string]struct{}{})
-			got := vnodeprovider.GetNodeLabels(p, newNode())
+			got := vnodeprovider.GetNodeLabels(p, newNode(), "")
 			if len(tt.want) != 0 && !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("vnodeprovider.GetNodeLabels() = %v, want %v", got, tt.want)
 			}
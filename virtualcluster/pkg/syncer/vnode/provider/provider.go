@@ -20,9 +20,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
-	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
-	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
 )
 
 // VirtualNodeProvider is the interface used for registering the node address.
@@ -33,14 +32,90 @@ type VirtualNodeProvider interface {
 	GetTaintsToSync() map[string]struct{}
 }
 
-// GetNodeLabels is used to sync allowed node labels to vNode
-func GetNodeLabels(p VirtualNodeProvider, node *corev1.Node) map[string]string {
+// CapacityProvider is implemented by the VirtualNodeProviders presenting a tenant scoped
+// capacity and allocatable on the vNode instead of the ones of the physical node.
+type CapacityProvider interface {
+	GetNodeCapacity(node *corev1.Node, vc *v1alpha1.VirtualCluster) (capacity, allocatable corev1.ResourceList, err error)
+}
+
+// GetNodeCapacity returns the capacity and allocatable of the vNode presented to the tenant,
+// they are the ones of the physical node unless the provider is a CapacityProvider.
+func GetNodeCapacity(p VirtualNodeProvider, node *corev1.Node, vc *v1alpha1.VirtualCluster) (corev1.ResourceList, corev1.ResourceList, error) {
+	if cp, ok := p.(CapacityProvider); ok && vc != nil {
+		return cp.GetNodeCapacity(node, vc)
+	}
+	return node.Status.Capacity, node.Status.Allocatable, nil
+}
+
+// NodeAggregator is implemented by the VirtualNodeProviders presenting the physical nodes
+// through a fixed set of aggregated vNodes instead of one vNode per physical node.
+type NodeAggregator interface {
+	// GetVirtualNodeName returns the name of the vNode presenting the physical node.
+	GetVirtualNodeName(nodeName string) string
+	// GetAggregatedNodes returns the physical nodes presented by the vNode.
+	GetAggregatedNodes(vNodeName string) ([]*corev1.Node, error)
+}
+
+// Wrapper is implemented by the VirtualNodeProviders decorating another provider.
+type Wrapper interface {
+	Unwrap() VirtualNodeProvider
+}
+
+// ConditionsProvider is implemented by the VirtualNodeProviders mirroring a configured set
+// of physical node conditions on the vNodes instead of the default ones.
+type ConditionsProvider interface {
+	GetConditionsToSync() map[corev1.NodeConditionType]struct{}
+}
+
+// GetConditionsToSync returns the node conditions mirrored by the provider, it returns
+// false if the provider doesn't configure them.
+func GetConditionsToSync(p VirtualNodeProvider) (map[corev1.NodeConditionType]struct{}, bool) {
+	for p != nil {
+		if cp, ok := p.(ConditionsProvider); ok {
+			return cp.GetConditionsToSync(), true
+		}
+		wrapper, ok := p.(Wrapper)
+		if !ok {
+			break
+		}
+		p = wrapper.Unwrap()
+	}
+	return nil, false
+}
+
+// GetNodeAggregator returns the NodeAggregator of the provider, if any.
+func GetNodeAggregator(p VirtualNodeProvider) (NodeAggregator, bool) {
+	for p != nil {
+		if aggregator, ok := p.(NodeAggregator); ok {
+			return aggregator, true
+		}
+		wrapper, ok := p.(Wrapper)
+		if !ok {
+			break
+		}
+		p = wrapper.Unwrap()
+	}
+	return nil, false
+}
+
+// GetVirtualNodeName returns the name of the vNode presenting the physical node, which
+// is the name of the physical node unless the provider is a NodeAggregator.
+func GetVirtualNodeName(p VirtualNodeProvider, nodeName string) string {
+	if aggregator, ok := GetNodeAggregator(p); ok {
+		return aggregator.GetVirtualNodeName(nodeName)
+	}
+	return nodeName
+}
+
+// GetNodeLabels is used to sync allowed node labels to vNode, the vNode is labelled
+// with the superClusterID if it is not empty.
+func GetNodeLabels(p VirtualNodeProvider, node *corev1.Node, superClusterID string) map[string]string {
 	labels := map[string]string{
 		constants.LabelVirtualNode: "true",
 	}
 
-	if featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterPooling) {
-		labels[constants.LabelSuperClusterID] = utilconstants.SuperClusterID
+	if superClusterID != "" {
+		labels[constants.LabelSuperClusterID] = superClusterID
 	}
 
 	labelsToSync := p.GetLabelsToSync()
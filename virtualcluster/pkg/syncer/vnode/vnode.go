@@ -27,18 +27,22 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/informers"
 	clientset "k8s.io/client-go/kubernetes"
 	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
 
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/aggregated"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/capacity"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/native"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/pod"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/provider"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/service"
 )
 
-func GetNodeProvider(config *config.SyncerConfiguration, client clientset.Interface) provider.VirtualNodeProvider {
+func GetNodeProvider(config *config.SyncerConfiguration, client clientset.Interface, informer informers.SharedInformerFactory) (provider.VirtualNodeProvider, error) {
 	for _, labelKey := range config.ExtraNodeLabels {
 		defaultLabelsToSync[labelKey] = struct{}{}
 	}
@@ -46,21 +50,153 @@ func GetNodeProvider(config *config.SyncerConfiguration, client clientset.Interf
 	for _, taintKey := range config.OpaqueTaintKeys {
 		taintsToSync[taintKey] = struct{}{}
 	}
-	if featuregate.DefaultFeatureGate.Enabled(featuregate.VNodeProviderService) {
-		return service.NewServiceVirtualNodeProvider(config.VNAgentPort, config.VNAgentNamespacedName, client, defaultLabelsToSync, taintsToSync)
+	var p provider.VirtualNodeProvider
+	switch {
+	case featuregate.DefaultFeatureGate.Enabled(featuregate.VNodeProviderAggregated):
+		p = aggregated.NewAggregatedVirtualNodeProvider(
+			service.NewServiceVirtualNodeProvider(config.VNAgentPort, config.VNAgentNamespacedName, client, defaultLabelsToSync, taintsToSync),
+			config.AggregatedVNodeCount, informer.Core().V1().Nodes().Lister())
+	case featuregate.DefaultFeatureGate.Enabled(featuregate.VNodeProviderService):
+		p = service.NewServiceVirtualNodeProvider(config.VNAgentPort, config.VNAgentNamespacedName, client, defaultLabelsToSync, taintsToSync)
+	case featuregate.DefaultFeatureGate.Enabled(featuregate.VNodeProviderPodIP):
+		p = pod.NewPodVirtualNodeProvider(config.VNAgentPort, config.VNAgentNamespacedName, config.VNAgentLabelSelector, client, defaultLabelsToSync, taintsToSync)
+	default:
+		p = native.NewNativeVirtualNodeProvider(config.VNAgentPort, defaultLabelsToSync, taintsToSync)
 	}
-	if featuregate.DefaultFeatureGate.Enabled(featuregate.VNodeProviderPodIP) {
-		return pod.NewPodVirtualNodeProvider(config.VNAgentPort, config.VNAgentNamespacedName, config.VNAgentLabelSelector, client, defaultLabelsToSync, taintsToSync)
+	if len(config.VNodeConditionTypes) != 0 {
+		conditionsToSync := make(map[corev1.NodeConditionType]struct{})
+		for _, conditionType := range config.VNodeConditionTypes {
+			conditionsToSync[corev1.NodeConditionType(conditionType)] = struct{}{}
+		}
+		p = &conditionsProvider{VirtualNodeProvider: p, conditionsToSync: conditionsToSync}
+	}
+	return capacity.NewCapacityVirtualNodeProvider(p, capacity.Policy(config.VNodeCapacityPolicy), informer)
+}
+
+// GetNodeForVirtualNode returns the node the vNode presenting the physical node is built
+// from. It is the physical node itself, or the aggregate of the physical nodes presented
+// by the same vNode if the provider is a NodeAggregator.
+func GetNodeForVirtualNode(vNodeProvider provider.VirtualNodeProvider, node *corev1.Node) (*corev1.Node, error) {
+	aggregator, ok := provider.GetNodeAggregator(vNodeProvider)
+	if !ok {
+		return node, nil
+	}
+	vNodeName := aggregator.GetVirtualNodeName(node.Name)
+	nodes, err := aggregator.GetAggregatedNodes(vNodeName)
+	if err != nil {
+		return nil, pkgerr.Wrapf(err, "get nodes aggregated by vNode %s", vNodeName)
+	}
+	if len(nodes) == 0 {
+		nodes = []*corev1.Node{node}
+	}
+	return aggregateNodes(vNodeName, nodes), nil
+}
+
+// GetNodeForRemovedNode returns the node the vNode which presented the removed physical node
+// is built from, i.e. the aggregate of the physical nodes it still presents. It returns nil
+// if the provider is not a NodeAggregator or if the vNode presents no physical node anymore.
+func GetNodeForRemovedNode(vNodeProvider provider.VirtualNodeProvider, nodeName string) (*corev1.Node, error) {
+	aggregator, ok := provider.GetNodeAggregator(vNodeProvider)
+	if !ok {
+		return nil, nil
+	}
+	vNodeName := aggregator.GetVirtualNodeName(nodeName)
+	nodes, err := aggregator.GetAggregatedNodes(vNodeName)
+	if err != nil {
+		return nil, pkgerr.Wrapf(err, "get nodes aggregated by vNode %s", vNodeName)
+	}
+	if len(nodes) == 0 {
+		return nil, nil
+	}
+	return aggregateNodes(vNodeName, nodes), nil
+}
+
+// aggregateNodes builds the node presenting all the given nodes. It has the labels and the
+// taints common to all the nodes, the sum of their capacity and allocatable, and is ready
+// if any of them is ready.
+func aggregateNodes(name string, nodes []*corev1.Node) *corev1.Node {
+	aggregate := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{},
+		},
+		Status: corev1.NodeStatus{
+			Capacity:    corev1.ResourceList{},
+			Allocatable: corev1.ResourceList{},
+			NodeInfo:    nodes[0].Status.NodeInfo,
+			Addresses:   nodes[0].Status.Addresses,
+		},
+	}
+	for k, v := range nodes[0].Labels {
+		aggregate.Labels[k] = v
+	}
+	aggregate.Spec.Taints = append(aggregate.Spec.Taints, nodes[0].Spec.Taints...)
+
+	var ready *corev1.NodeCondition
+	for _, node := range nodes {
+		for k, v := range aggregate.Labels {
+			if node.Labels[k] != v {
+				delete(aggregate.Labels, k)
+			}
+		}
+		var taints []corev1.Taint
+		for i := range aggregate.Spec.Taints {
+			for j := range node.Spec.Taints {
+				if aggregate.Spec.Taints[i].MatchTaint(&node.Spec.Taints[j]) {
+					taints = append(taints, aggregate.Spec.Taints[i])
+					break
+				}
+			}
+		}
+		aggregate.Spec.Taints = taints
+		addResources(aggregate.Status.Capacity, node.Status.Capacity)
+		addResources(aggregate.Status.Allocatable, node.Status.Allocatable)
+
+		for i := range node.Status.Conditions {
+			condition := node.Status.Conditions[i]
+			if condition.Type != corev1.NodeReady {
+				continue
+			}
+			switch {
+			case ready == nil:
+				ready = condition.DeepCopy()
+			case ready.Status != corev1.ConditionTrue && condition.Status == corev1.ConditionTrue:
+				ready = condition.DeepCopy()
+			case ready.Status == condition.Status:
+				// the earliest transition and the latest heartbeat of the nodes in the same state.
+				if condition.LastTransitionTime.Before(&ready.LastTransitionTime) {
+					ready.LastTransitionTime = condition.LastTransitionTime
+				}
+				if ready.LastHeartbeatTime.Before(&condition.LastHeartbeatTime) {
+					ready.LastHeartbeatTime = condition.LastHeartbeatTime
+				}
+			}
+		}
+	}
+	if ready != nil {
+		aggregate.Status.Conditions = []corev1.NodeCondition{*ready}
+	}
+	return aggregate
+}
+
+func addResources(total, list corev1.ResourceList) {
+	for name, quantity := range list {
+		current := total[name]
+		current.Add(quantity)
+		total[name] = current
 	}
-	return native.NewNativeVirtualNodeProvider(config.VNAgentPort, defaultLabelsToSync, taintsToSync)
 }
 
-func NewVirtualNode(vNodeProvider provider.VirtualNodeProvider, node *corev1.Node) (vnode *corev1.Node, err error) {
+func NewVirtualNode(vNodeProvider provider.VirtualNodeProvider, node *corev1.Node, vc *v1alpha1.VirtualCluster, superClusterID string) (vnode *corev1.Node, err error) {
+	node, err = GetNodeForVirtualNode(vNodeProvider, node)
+	if err != nil {
+		return nil, err
+	}
 	now := metav1.Now()
 	n := &corev1.Node{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:   node.Name,
-			Labels: provider.GetNodeLabels(vNodeProvider, node),
+			Labels: provider.GetNodeLabels(vNodeProvider, node, superClusterID),
 		},
 		Spec: corev1.NodeSpec{
 			Unschedulable: true,
@@ -69,7 +205,7 @@ func NewVirtualNode(vNodeProvider provider.VirtualNodeProvider, node *corev1.Nod
 	}
 
 	// fill in status
-	n.Status.Conditions = nodeConditions()
+	n.Status.Conditions = NodeConditions(vNodeProvider, node)
 	de, err := vNodeProvider.GetNodeDaemonEndpoints(node)
 	if err != nil {
 		return nil, pkgerr.Wrapf(err, "get node daemon endpoints from provider")
@@ -83,8 +219,10 @@ func NewVirtualNode(vNodeProvider provider.VirtualNodeProvider, node *corev1.Nod
 
 	n.Status.Addresses = na
 	n.Status.NodeInfo = node.Status.NodeInfo
-	n.Status.Capacity = node.Status.Capacity
-	n.Status.Allocatable = node.Status.Allocatable
+	n.Status.Capacity, n.Status.Allocatable, err = provider.GetNodeCapacity(vNodeProvider, node, vc)
+	if err != nil {
+		return nil, pkgerr.Wrapf(err, "get node capacity from provider")
+	}
 
 	return n, nil
 }
@@ -95,6 +233,94 @@ var defaultLabelsToSync = map[string]struct{}{
 	corev1.LabelHostname:   {},
 }
 
+// conditionsProvider mirrors the configured physical node conditions on the vNodes.
+type conditionsProvider struct {
+	provider.VirtualNodeProvider
+	conditionsToSync map[corev1.NodeConditionType]struct{}
+}
+
+var _ provider.ConditionsProvider = &conditionsProvider{}
+var _ provider.Wrapper = &conditionsProvider{}
+
+func (p *conditionsProvider) GetConditionsToSync() map[corev1.NodeConditionType]struct{} {
+	return p.conditionsToSync
+}
+
+func (p *conditionsProvider) Unwrap() provider.VirtualNodeProvider {
+	return p.VirtualNodeProvider
+}
+
+// defaultConditionsToSync are the physical node conditions mirrored on the vNodes unless
+// the provider configures them.
+var defaultConditionsToSync = map[corev1.NodeConditionType]struct{}{
+	corev1.NodeReady:              {},
+	corev1.NodeMemoryPressure:     {},
+	corev1.NodeDiskPressure:       {},
+	corev1.NodePIDPressure:        {},
+	corev1.NodeNetworkUnavailable: {},
+}
+
+// NodeConditions returns the conditions of the physical node mirrored on the vNode, the
+// default conditions are used if the physical node doesn't report any of them yet.
+func NodeConditions(vNodeProvider provider.VirtualNodeProvider, node *corev1.Node) []corev1.NodeCondition {
+	conditionsToSync, ok := provider.GetConditionsToSync(vNodeProvider)
+	if !ok {
+		conditionsToSync = defaultConditionsToSync
+	}
+	var conditions []corev1.NodeCondition
+	for _, condition := range node.Status.Conditions {
+		if _, found := conditionsToSync[condition.Type]; found {
+			conditions = append(conditions, condition)
+		}
+	}
+	if len(conditions) == 0 {
+		return nodeConditions()
+	}
+	return conditions
+}
+
+// IsNodeReady returns whether the Ready condition of the node is true.
+func IsNodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// LastHeartbeatTime returns the latest heartbeat time of the node conditions.
+func LastHeartbeatTime(node *corev1.Node) metav1.Time {
+	var last metav1.Time
+	for _, condition := range node.Status.Conditions {
+		if last.Before(&condition.LastHeartbeatTime) {
+			last = condition.LastHeartbeatTime
+		}
+	}
+	return last
+}
+
+// IsHeartbeatOnlyUpdate returns whether updating the vNode to the new vNode only refreshes
+// the heartbeat times of the conditions and of the taints.
+func IsHeartbeatOnlyUpdate(vNode, newVNode *corev1.Node) bool {
+	return equality.Semantic.DeepEqual(withoutHeartbeats(vNode), withoutHeartbeats(newVNode))
+}
+
+func withoutHeartbeats(node *corev1.Node) *corev1.Node {
+	n := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Labels: node.Labels},
+		Spec:       *node.Spec.DeepCopy(),
+		Status:     *node.Status.DeepCopy(),
+	}
+	for i := range n.Spec.Taints {
+		n.Spec.Taints[i].TimeAdded = nil
+	}
+	for i := range n.Status.Conditions {
+		n.Status.Conditions[i].LastHeartbeatTime = metav1.Time{}
+	}
+	return n
+}
+
 func nodeConditions() []corev1.NodeCondition {
 	return []corev1.NodeCondition{
 		{
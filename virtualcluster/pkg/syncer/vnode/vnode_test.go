@@ -0,0 +1,182 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vnode
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/aggregated"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/capacity"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/vnode/service"
+)
+
+func newNode(name, zone, cpu string, ready corev1.ConditionStatus, transition metav1.Time, taints ...corev1.Taint) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				"kubernetes.io/os":            "linux",
+				"topology.kubernetes.io/zone": zone,
+			},
+		},
+		Spec: corev1.NodeSpec{Taints: taints},
+		Status: corev1.NodeStatus{
+			Capacity:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+			Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+			Conditions: []corev1.NodeCondition{{
+				Type:               corev1.NodeReady,
+				Status:             ready,
+				LastHeartbeatTime:  transition,
+				LastTransitionTime: transition,
+			}},
+		},
+	}
+}
+
+func TestGetNodeForVirtualNode(t *testing.T) {
+	early := metav1.Date(2022, 1, 1, 0, 0, 0, 0, metav1.Now().Location())
+	late := metav1.Date(2022, 1, 2, 0, 0, 0, 0, metav1.Now().Location())
+	noSchedule := corev1.Taint{Key: "dedicated", Value: "tenant", Effect: corev1.TaintEffectNoSchedule}
+	noExecute := corev1.Taint{Key: "maintenance", Effect: corev1.TaintEffectNoExecute}
+	nodes := []*corev1.Node{
+		newNode("n1", "zone-a", "4", corev1.ConditionTrue, late, noSchedule),
+		newNode("n2", "zone-b", "8", corev1.ConditionTrue, early, noSchedule, noExecute),
+		newNode("n3", "zone-a", "2", corev1.ConditionFalse, early, noSchedule),
+	}
+
+	client := fake.NewSimpleClientset()
+	informer := informers.NewSharedInformerFactory(client, 0)
+	for _, node := range nodes {
+		if err := informer.Core().V1().Nodes().Informer().GetStore().Add(node); err != nil {
+			t.Fatalf("failed to add node %s: %v", node.Name, err)
+		}
+	}
+	p := aggregated.NewAggregatedVirtualNodeProvider(
+		service.NewServiceVirtualNodeProvider(10550, "vc-manager/vn-agent", client, nil, nil),
+		1, informer.Core().V1().Nodes().Lister())
+	p, err := capacity.NewCapacityVirtualNodeProvider(p, capacity.PolicyNode, informer)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	node, err := GetNodeForVirtualNode(p, nodes[2])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if node.Name != aggregated.NodeNamePrefix+"-0" {
+		t.Errorf("expected the aggregated vNode name, got %s", node.Name)
+	}
+	if expected := map[string]string{"kubernetes.io/os": "linux"}; !equality.Semantic.DeepEqual(node.Labels, expected) {
+		t.Errorf("expected common labels %v, got %v", expected, node.Labels)
+	}
+	if expected := []corev1.Taint{noSchedule}; !equality.Semantic.DeepEqual(node.Spec.Taints, expected) {
+		t.Errorf("expected common taints %v, got %v", expected, node.Spec.Taints)
+	}
+	if cpu := node.Status.Capacity[corev1.ResourceCPU]; cpu.Cmp(resource.MustParse("14")) != 0 {
+		t.Errorf("expected the capacity to be summed, got %s", cpu.String())
+	}
+	if cpu := node.Status.Allocatable[corev1.ResourceCPU]; cpu.Cmp(resource.MustParse("14")) != 0 {
+		t.Errorf("expected the allocatable to be summed, got %s", cpu.String())
+	}
+	if len(node.Status.Conditions) != 1 {
+		t.Fatalf("expected one Ready condition, got %v", node.Status.Conditions)
+	}
+	ready := node.Status.Conditions[0]
+	if ready.Status != corev1.ConditionTrue || !ready.LastTransitionTime.Equal(&early) || !ready.LastHeartbeatTime.Equal(&late) {
+		t.Errorf("expected ready since the earliest transition of the ready nodes, got %+v", ready)
+	}
+}
+
+func TestNodeConditions(t *testing.T) {
+	node := newNode("n1", "z1", "4", corev1.ConditionTrue, metav1.Now())
+	node.Status.Conditions = append(node.Status.Conditions,
+		corev1.NodeCondition{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionFalse},
+		corev1.NodeCondition{Type: "FrequentKubeletRestart", Status: corev1.ConditionFalse})
+	informer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+
+	for _, tc := range []struct {
+		name           string
+		conditionTypes []string
+		expected       []corev1.NodeConditionType
+	}{
+		{
+			name:     "default conditions",
+			expected: []corev1.NodeConditionType{corev1.NodeReady, corev1.NodeMemoryPressure},
+		},
+		{
+			name:           "configured conditions",
+			conditionTypes: []string{string(corev1.NodeReady), "FrequentKubeletRestart"},
+			expected:       []corev1.NodeConditionType{corev1.NodeReady, "FrequentKubeletRestart"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := GetNodeProvider(&config.SyncerConfiguration{VNodeConditionTypes: tc.conditionTypes}, nil, informer)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []corev1.NodeConditionType
+			for _, condition := range NodeConditions(p, node) {
+				got = append(got, condition.Type)
+			}
+			if !equality.Semantic.DeepEqual(got, tc.expected) {
+				t.Errorf("expected conditions %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestGetNodeForRemovedNode(t *testing.T) {
+	now := metav1.Now()
+	remaining := newNode("n1", "zone-a", "4", corev1.ConditionTrue, now)
+
+	client := fake.NewSimpleClientset()
+	informer := informers.NewSharedInformerFactory(client, 0)
+	if err := informer.Core().V1().Nodes().Informer().GetStore().Add(remaining); err != nil {
+		t.Fatalf("failed to add node %s: %v", remaining.Name, err)
+	}
+	lister := informer.Core().V1().Nodes().Lister()
+	base := service.NewServiceVirtualNodeProvider(10550, "vc-manager/vn-agent", client, nil, nil)
+
+	node, err := GetNodeForRemovedNode(aggregated.NewAggregatedVirtualNodeProvider(base, 1, lister), "n2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if node == nil || node.Name != aggregated.NodeNamePrefix+"-0" {
+		t.Fatalf("expected the aggregated vNode, got %v", node)
+	}
+	if cpu := node.Status.Capacity[corev1.ResourceCPU]; cpu.Cmp(resource.MustParse("4")) != 0 {
+		t.Errorf("expected the capacity of the remaining node, got %s", cpu.String())
+	}
+
+	if err := informer.Core().V1().Nodes().Informer().GetStore().Delete(remaining); err != nil {
+		t.Fatalf("failed to delete node %s: %v", remaining.Name, err)
+	}
+	if node, err := GetNodeForRemovedNode(aggregated.NewAggregatedVirtualNodeProvider(base, 1, lister), "n1"); err != nil || node != nil {
+		t.Errorf("expected no node once all the aggregated nodes are removed, got %v, %v", node, err)
+	}
+	if node, err := GetNodeForRemovedNode(base, "n1"); err != nil || node != nil {
+		t.Errorf("expected no node without aggregation, got %v, %v", node, err)
+	}
+}
@@ -121,6 +121,7 @@ func NewCluster(key, namespace, name, uid string, getter mccontroller.Getter, co
 		clusterRestConfig.Burst = constants.DefaultSyncerClientBurst
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Cluster{
 		key:           key,
 		name:          name,
@@ -130,8 +131,8 @@ func NewCluster(key, namespace, name, uid string, getter mccontroller.Getter, co
 		RestConfig:    clusterRestConfig,
 		options:       o,
 		synced:        false,
-		context:       context.Background(),
-		cancelContext: func() {},
+		context:       ctx,
+		cancelContext: cancel,
 	}, nil
 }
 
@@ -294,14 +295,11 @@ func (c *Cluster) GetInformer(objectType client.Object) (cache.Informer, error)
 // Start starts the Cluster's cache and blocks,
 // until context for the cache is cancelled.
 func (c *Cluster) Start() error {
-	ctx, cancel := context.WithCancel(c.context)
-	c.cancelContext = cancel
-
 	ca, err := c.getCache()
 	if err != nil {
 		return err
 	}
-	return ca.Start(ctx)
+	return ca.Start(c.context)
 }
 
 // WaitForCacheSync waits for the Cluster's cache to sync,
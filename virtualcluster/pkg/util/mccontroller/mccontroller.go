@@ -107,9 +107,17 @@ type MultiClusterController struct {
 	// objectKind is the kind of target object this controller watched.
 	objectKind string
 
+	// objectListType is the list type of the object, e.g. &corev1.PodList{}
+	objectListType client.ObjectList
+
 	// clusters is the internal cluster set this controller watches.
 	clusters map[string]ClusterInterface
 
+	// clusterEventHandlers build the extra event handlers added to each watched cluster.
+	clusterEventHandlers []func(clusterName string) clientgocache.ResourceEventHandler
+	// clusterRemovedHandlers are called once a cluster is no longer watched.
+	clusterRemovedHandlers []func(clusterName string)
+
 	Options
 }
 
@@ -126,6 +134,17 @@ type Options struct {
 	// Queue can be used to override the default queue.
 	Queue workqueue.RateLimitingInterface
 
+	// SuperClusterID is the super cluster the controller syncs to if the syncer manages
+	// multiple super clusters. Only the tenant namespaces placed to it are reconciled.
+	SuperClusterID string
+	// DefaultSuperCluster indicates the tenant namespaces without placement are synced
+	// to this super cluster.
+	DefaultSuperCluster bool
+
+	// ClusterPaused returns true if syncing the cluster is paused, the requests of a
+	// paused cluster are dropped while its informer caches are kept.
+	ClusterPaused func(clusterName string) bool
+
 	// name is used to uniquely identify a Controller in tracing, logging and monitoring.  Name is required.
 	name string
 }
@@ -138,9 +157,10 @@ func NewMCController(objectType client.Object, objectListType client.ObjectList,
 	}
 
 	c := &MultiClusterController{
-		objectType: objectType,
-		objectKind: kinds[0].Kind,
-		clusters:   make(map[string]ClusterInterface),
+		objectType:     objectType,
+		objectKind:     kinds[0].Kind,
+		objectListType: objectListType,
+		clusters:       make(map[string]ClusterInterface),
 		Options: Options{
 			name:                    fmt.Sprintf("%s-mccontroller", strings.ToLower(kinds[0].Kind)),
 			JitterPeriod:            1 * time.Second,
@@ -182,7 +202,23 @@ func (c *MultiClusterController) WatchClusterResource(cluster ClusterInterface,
 	}
 
 	h := &handler.EnqueueRequestForObject{ClusterName: cluster.GetClusterName(), Queue: c.Queue, AttachUID: o.AttachUID}
-	return cluster.AddEventHandler(c.objectType, h)
+	if err := cluster.AddEventHandler(c.objectType, h); err != nil {
+		return err
+	}
+	for _, fn := range c.clusterEventHandlers {
+		if err := cluster.AddEventHandler(c.objectType, fn(cluster.GetClusterName())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddClusterEventHandler adds an extra event handler to the clusters watched afterwards,
+// e.g., the incremental differ of a checker.
+func (c *MultiClusterController) AddClusterEventHandler(fn func(clusterName string) clientgocache.ResourceEventHandler) {
+	c.Lock()
+	defer c.Unlock()
+	c.clusterEventHandlers = append(c.clusterEventHandlers, fn)
 }
 
 // RegisterClusterResource get the informer *before* trying to wait for the
@@ -207,8 +243,20 @@ func (c *MultiClusterController) RegisterClusterResource(cluster ClusterInterfac
 // The cluster informer should stop together.
 func (c *MultiClusterController) TeardownClusterResource(cluster ClusterInterface) {
 	c.Lock()
-	defer c.Unlock()
 	delete(c.clusters, cluster.GetClusterName())
+	handlers := c.clusterRemovedHandlers
+	c.Unlock()
+	for _, fn := range handlers {
+		fn(cluster.GetClusterName())
+	}
+}
+
+// AddClusterRemovedHandler adds a handler called with the name of each cluster torn down,
+// e.g., to drop the cluster from the incremental differ of a checker.
+func (c *MultiClusterController) AddClusterRemovedHandler(fn func(clusterName string)) {
+	c.Lock()
+	defer c.Unlock()
+	c.clusterRemovedHandlers = append(c.clusterRemovedHandlers, fn)
 }
 
 // Start starts the ClustersController's control loops (as many as MaxConcurrentReconciles) in separate channels
@@ -378,6 +426,33 @@ func (c *MultiClusterController) RequeueObject(clusterName string, obj interface
 	return nil
 }
 
+// RequeueCluster requeues all the cached objects of the cluster, thus reconcileHandler
+// can reconcile the whole cluster again.
+func (c *MultiClusterController) RequeueCluster(clusterName string) error {
+	if c.objectListType == nil {
+		return fmt.Errorf("mccontroller %q: unknown object list type", c.objectKind)
+	}
+	instanceList := c.objectListType.DeepCopyObject().(client.ObjectList)
+	if err := c.List(clusterName, instanceList); err != nil {
+		return err
+	}
+	items, err := meta.ExtractList(instanceList)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := c.RequeueObject(clusterName, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsClusterPaused returns true if syncing the cluster is paused.
+func (c *MultiClusterController) IsClusterPaused(clusterName string) bool {
+	return c.ClusterPaused != nil && c.ClusterPaused(clusterName)
+}
+
 // worker runs a worker thread that just dequeues items, processes them, and marks them done.
 // It enforces that the reconcileHandler is never invoked concurrently with the same object.
 func (c *MultiClusterController) worker() {
@@ -424,8 +499,14 @@ func (c *MultiClusterController) processNextWorkItem() bool {
 		c.Queue.Forget(obj)
 		return true
 	}
+	if c.IsClusterPaused(req.ClusterName) {
+		// The whole cluster is requeued once syncing is resumed.
+		klog.V(4).Infof("The cluster %s is paused, drop the dws request %v", req.ClusterName, req)
+		c.Queue.Forget(obj)
+		return true
+	}
 
-	if featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterPooling) {
+	if c.SchedulingEnabled() {
 		if c.FilterObjectFromSchedulingResult(req) {
 			c.Queue.Forget(req)
 			c.Queue.Done(req)
@@ -505,26 +586,62 @@ func filterSuperClusterRelatedObject(c *MultiClusterController, clusterName, nsN
 		return true
 	}
 
-	if IsNamespaceScheduledToCluster(namespace, utilconstants.SuperClusterID) != nil {
-		return true
-	}
-
-	return false
+	return !c.IsNamespaceScheduled(namespace)
 }
 
 func filterSuperClusterSchedulePod(c *MultiClusterController, req reconciler.Request) bool {
+	// without the scheduler, pods follow the placement of their namespaces.
+	if !featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterPooling) {
+		return false
+	}
 	pod := &corev1.Pod{}
 	if err := c.Get(req.ClusterName, req.Namespace, req.Name, pod); err != nil {
 		klog.Errorf("failed to get pod %+v: %v", req, err)
 		return true
 	}
 
-	cname, ok := pod.GetAnnotations()[utilconstants.LabelScheduledCluster]
-	if !ok {
+	return !c.IsPodScheduled(pod)
+}
+
+// GetSuperClusterID returns the id of the super cluster the controller syncs to.
+func (c *MultiClusterController) GetSuperClusterID() string {
+	if c.SuperClusterID != "" {
+		return c.SuperClusterID
+	}
+	return utilconstants.SuperClusterID
+}
+
+// GetScheduledSuperClusterID returns the super cluster id the synced objects are labelled
+// with, it is empty if the tenant namespaces are not spread over multiple super clusters.
+func (c *MultiClusterController) GetScheduledSuperClusterID() string {
+	if !c.SchedulingEnabled() {
+		return ""
+	}
+	return c.GetSuperClusterID()
+}
+
+// SchedulingEnabled returns true if the tenant namespaces are spread over multiple super
+// clusters, either by the scheduler or by a syncer managing multiple super clusters.
+func (c *MultiClusterController) SchedulingEnabled() bool {
+	return c.SuperClusterID != "" || featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterPooling)
+}
+
+// IsNamespaceScheduled returns true if the tenant namespace is placed to the super cluster of the controller.
+func (c *MultiClusterController) IsNamespaceScheduled(namespace client.Object) bool {
+	if IsNamespaceScheduledToCluster(namespace, c.GetSuperClusterID()) == nil {
 		return true
 	}
+	_, placed := namespace.GetAnnotations()[utilconstants.LabelScheduledPlacements]
+	return !placed && c.DefaultSuperCluster
+}
 
-	return cname != utilconstants.SuperClusterID
+// IsPodScheduled returns true if the tenant pod is annotated to be scheduled to the super cluster of the controller.
+func (c *MultiClusterController) IsPodScheduled(pod client.Object) bool {
+	cname, ok := pod.GetAnnotations()[utilconstants.LabelScheduledCluster]
+	if !ok {
+		return false
+	}
+	return cname == c.GetSuperClusterID()
 }
 
 func IsNamespaceScheduledToCluster(obj client.Object, clusterID string) error {
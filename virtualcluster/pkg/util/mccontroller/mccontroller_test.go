@@ -0,0 +1,200 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mccontroller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
+)
+
+type fakeReconciler struct {
+	requests []reconciler.Request
+}
+
+func (f *fakeReconciler) Reconcile(req reconciler.Request) (reconciler.Result, error) {
+	f.requests = append(f.requests, req)
+	return reconciler.Result{}, nil
+}
+
+// fakeCluster is a registered cluster, none of its methods are expected to be called.
+type fakeCluster struct {
+	ClusterInterface
+}
+
+func TestSuperClusterRouting(t *testing.T) {
+	placedNS := func(placements string) *corev1.Namespace {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+		if placements != "" {
+			ns.Annotations = map[string]string{utilconstants.LabelScheduledPlacements: placements}
+		}
+		return ns
+	}
+	scheduledPod := func(cluster string) *corev1.Pod {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod"}}
+		if cluster != "" {
+			pod.Annotations = map[string]string{utilconstants.LabelScheduledCluster: cluster}
+		}
+		return pod
+	}
+
+	for _, tc := range []struct {
+		name        string
+		id          string
+		isDefault   bool
+		ns          *corev1.Namespace
+		pod         *corev1.Pod
+		expectedNS  bool
+		expectedPod bool
+	}{
+		{
+			name:        "namespace placed to the super cluster",
+			id:          "s1",
+			ns:          placedNS(`{"s1":1}`),
+			pod:         scheduledPod("s1"),
+			expectedNS:  true,
+			expectedPod: true,
+		},
+		{
+			name:        "pod without scheduled super cluster",
+			id:          "s1",
+			isDefault:   true,
+			ns:          placedNS(""),
+			pod:         scheduledPod(""),
+			expectedNS:  true,
+			expectedPod: false,
+		},
+		{
+			name:        "namespace placed to another super cluster",
+			id:          "s1",
+			ns:          placedNS(`{"s2":1}`),
+			pod:         scheduledPod("s2"),
+			expectedNS:  false,
+			expectedPod: false,
+		},
+		{
+			name:        "namespace without placement in the default super cluster",
+			id:          "s1",
+			isDefault:   true,
+			ns:          placedNS(""),
+			pod:         scheduledPod("s1"),
+			expectedNS:  true,
+			expectedPod: true,
+		},
+		{
+			name:       "namespace without placement in other super clusters",
+			id:         "s2",
+			ns:         placedNS(""),
+			pod:        scheduledPod("s1"),
+			expectedNS: false,
+		},
+		{
+			name:        "default super cluster only takes namespaces without placement",
+			id:          "s1",
+			isDefault:   true,
+			ns:          placedNS(`{"s2":1}`),
+			pod:         scheduledPod("s2"),
+			expectedNS:  false,
+			expectedPod: false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewMCController(&corev1.Namespace{}, &corev1.NamespaceList{}, &fakeReconciler{}, WithSuperCluster(tc.id, tc.isDefault))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !c.SchedulingEnabled() || c.GetScheduledSuperClusterID() != tc.id {
+				t.Errorf("expected scheduling to super cluster %s", tc.id)
+			}
+			if got := c.IsNamespaceScheduled(tc.ns); got != tc.expectedNS {
+				t.Errorf("expected namespace scheduled %v, got %v", tc.expectedNS, got)
+			}
+			if got := c.IsPodScheduled(tc.pod); got != tc.expectedPod {
+				t.Errorf("expected pod scheduled %v, got %v", tc.expectedPod, got)
+			}
+		})
+	}
+}
+
+func TestSingleSuperCluster(t *testing.T) {
+	c, err := NewMCController(&corev1.Namespace{}, &corev1.NamespaceList{}, &fakeReconciler{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.SchedulingEnabled() || c.GetScheduledSuperClusterID() != "" {
+		t.Errorf("expected scheduling disabled without super cluster id")
+	}
+}
+
+func TestPausedCluster(t *testing.T) {
+	rc := &fakeReconciler{}
+	c, err := NewMCController(&corev1.Pod{}, &corev1.PodList{}, rc, WithClusterPausedFunc(func(clusterName string) bool {
+		return clusterName == "paused"
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c.clusters["paused"] = &fakeCluster{}
+	c.clusters["running"] = &fakeCluster{}
+
+	for _, clusterName := range []string{"paused", "running"} {
+		req := reconciler.Request{ClusterName: clusterName}
+		req.Namespace, req.Name = "ns", "pod"
+		c.Queue.Add(req)
+		c.processNextWorkItem()
+	}
+	if len(rc.requests) != 1 || rc.requests[0].ClusterName != "running" {
+		t.Errorf("expected only the running cluster to be reconciled, got %+v", rc.requests)
+	}
+	if !c.IsClusterPaused("paused") || c.IsClusterPaused("running") {
+		t.Errorf("unexpected paused clusters")
+	}
+}
+
+type namedCluster struct {
+	fakeCluster
+	name string
+}
+
+func (n *namedCluster) GetClusterName() string {
+	return n.name
+}
+
+func TestTeardownClusterResource(t *testing.T) {
+	c, err := NewMCController(&corev1.Pod{}, &corev1.PodList{}, &fakeReconciler{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var removed []string
+	c.AddClusterRemovedHandler(func(clusterName string) {
+		// the handler must be able to call back into the controller.
+		if len(c.GetClusterNames()) != 0 {
+			t.Errorf("expected the cluster to be forgotten before the handler is called")
+		}
+		removed = append(removed, clusterName)
+	})
+	cluster := &namedCluster{name: "t1"}
+	c.clusters["t1"] = cluster
+	c.TeardownClusterResource(cluster)
+	if len(removed) != 1 || removed[0] != "t1" {
+		t.Errorf("expected the removed handler to be called with t1, got %v", removed)
+	}
+}
@@ -37,6 +37,8 @@ func WithOptions(o *Options) OptConfig {
 		WithWorkQueue(o.Queue)(options)
 		WithJitterPeriod(o.JitterPeriod)(options)
 		WithMaxConcurrentReconciles(o.MaxConcurrentReconciles)(options)
+		WithSuperCluster(o.SuperClusterID, o.DefaultSuperCluster)(options)
+		WithClusterPausedFunc(o.ClusterPaused)(options)
 	}
 }
 
@@ -84,3 +86,23 @@ func WithMaxConcurrentReconciles(n int) OptConfig {
 		}
 	}
 }
+
+// WithSuperCluster set the super cluster the controller syncs to when the syncer manages
+// multiple super clusters. The default super cluster receives the tenant namespaces without placement.
+func WithSuperCluster(id string, isDefault bool) OptConfig {
+	return func(options *Options) {
+		if id != "" {
+			options.SuperClusterID = id
+			options.DefaultSuperCluster = isDefault
+		}
+	}
+}
+
+// WithClusterPausedFunc set the func telling whether syncing a cluster is paused.
+func WithClusterPausedFunc(fn func(clusterName string) bool) OptConfig {
+	return func(options *Options) {
+		if fn != nil {
+			options.ClusterPaused = fn
+		}
+	}
+}
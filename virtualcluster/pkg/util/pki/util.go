@@ -53,6 +53,8 @@ const (
 type CertConfig struct {
 	certutil.Config
 	PublicKeyAlgorithm x509.PublicKeyAlgorithm
+	// Validity of the signed certificate, CertificateValidity if not set
+	Validity time.Duration
 }
 
 // NewCertificateAuthority creates new certificate and private key for the certificate authority
@@ -107,6 +109,10 @@ func NewSignedCert(cfg *CertConfig, key crypto.Signer, caCert *x509.Certificate,
 		return nil, errors.New("must specify at least one ExtKeyUsage")
 	}
 
+	validity := cfg.Validity
+	if validity == 0 {
+		validity = CertificateValidity
+	}
 	certTmpl := x509.Certificate{
 		Subject: pkix.Name{
 			CommonName:   cfg.CommonName,
@@ -116,7 +122,7 @@ func NewSignedCert(cfg *CertConfig, key crypto.Signer, caCert *x509.Certificate,
 		IPAddresses:  cfg.AltNames.IPs,
 		SerialNumber: serial,
 		NotBefore:    caCert.NotBefore,
-		NotAfter:     time.Now().Add(CertificateValidity).UTC(),
+		NotAfter:     time.Now().Add(validity).UTC(),
 		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
 		ExtKeyUsage:  cfg.Usages,
 	}
@@ -100,3 +100,11 @@ func (reg *ResourceRegister) List() []*Registration {
 	}
 	return r
 }
+
+// Get returns the plugin registered with the id.
+func (reg *ResourceRegister) Get(id string) (*Registration, bool) {
+	reg.RLock()
+	defer reg.RUnlock()
+	r, exists := reg.resources[id]
+	return r, exists
+}
@@ -84,3 +84,22 @@ func TestResourceRegister_List(t *testing.T) {
 		})
 	}
 }
+
+func TestResourceRegister_Get(t *testing.T) {
+	var reg ResourceRegister
+	if _, exists := reg.Get("00_test"); exists {
+		t.Errorf("expected no plugin in an empty register")
+	}
+
+	reg.Register(&Registration{ID: "00_test", InitFn: none("test")})
+	r, exists := reg.Get("00_test")
+	if !exists {
+		t.Fatalf("expected the plugin to be registered")
+	}
+	if instance, _ := r.Init(&InitContext{}).Instance(); instance != "test" {
+		t.Errorf("Get() = %v, want test", instance)
+	}
+	if _, exists := reg.Get("01_test"); exists {
+		t.Errorf("expected 01_test not to be registered")
+	}
+}
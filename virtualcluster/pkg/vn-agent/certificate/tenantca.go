@@ -0,0 +1,183 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificate
+
+import (
+	"crypto/x509"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	clientset "k8s.io/client-go/kubernetes"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+// TenantCAStore holds the root CA of each tenant control plane. The CAs are discovered
+// from the root CA secrets the native provisioner creates in the root namespaces of
+// the super cluster, and reloaded when the secrets are added or rotated.
+type TenantCAStore struct {
+	mu  sync.RWMutex
+	cas map[string]*x509.CertPool
+
+	informer   cache.SharedIndexInformer
+	nsInformer cache.SharedIndexInformer
+	nsLister   listersv1.NamespaceLister
+}
+
+// NewTenantCAStore creates a TenantCAStore watching the root CA secrets in the super cluster.
+func NewTenantCAStore(client clientset.Interface) *TenantCAStore {
+	s := &TenantCAStore{cas: make(map[string]*x509.CertPool)}
+	factory := informers.NewSharedInformerFactoryWithOptions(client, 10*time.Minute,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", secret.RootCASecretName).String()
+		}))
+	s.informer = factory.Core().V1().Secrets().Informer()
+	nsInformer := informers.NewSharedInformerFactory(client, 10*time.Minute).Core().V1().Namespaces()
+	s.nsInformer = nsInformer.Informer()
+	s.nsLister = nsInformer.Lister()
+	s.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: s.onSecret,
+		UpdateFunc: func(_, newObj interface{}) {
+			s.onSecret(newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if srt, ok := obj.(*corev1.Secret); ok {
+				s.Delete(srt.Namespace)
+			}
+		},
+	})
+	return s
+}
+
+// Run starts watching the root CA secrets and waits for the initial list.
+func (s *TenantCAStore) Run(stopCh <-chan struct{}) error {
+	// the namespaces are synced first to tell the root namespaces apart.
+	go s.nsInformer.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, s.nsInformer.HasSynced) {
+		return fmt.Errorf("failed to wait for namespaces to sync")
+	}
+	go s.informer.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, s.informer.HasSynced) {
+		return fmt.Errorf("failed to wait for tenant root CA secrets to sync")
+	}
+	return nil
+}
+
+func (s *TenantCAStore) onSecret(obj interface{}) {
+	srt, ok := obj.(*corev1.Secret)
+	if !ok {
+		return
+	}
+	// the syncer copies the secrets of the tenants to the super cluster, only the root
+	// namespaces created by the vc-manager hold the root CA of a tenant.
+	if !s.isRootNamespace(srt.Namespace) {
+		klog.Warningf("ignore %s/%s which is not in a root namespace", srt.Namespace, srt.Name)
+		return
+	}
+	if err := s.Set(srt.Namespace, srt.Data[corev1.TLSCertKey]); err != nil {
+		klog.Errorf("failed to load root CA of tenant %s: %v", srt.Namespace, err)
+	}
+}
+
+func (s *TenantCAStore) isRootNamespace(name string) bool {
+	ns, err := s.nsLister.Get(name)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.Errorf("failed to get namespace %s: %v", name, err)
+		}
+		return false
+	}
+	return ns.Annotations[constants.LabelVCRootNS] == "true" && ns.Annotations[constants.LabelVCUID] != ""
+}
+
+// Set replaces the root CA of the tenant with the PEM encoded certificates.
+func (s *TenantCAStore) Set(tenantName string, caPEM []byte) error {
+	cas, err := certutil.ParseCertsPEM(caPEM)
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	for _, ca := range cas {
+		pool.AddCert(ca)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cas[tenantName] = pool
+	klog.V(4).Infof("loaded root CA of tenant %s", tenantName)
+	return nil
+}
+
+// Delete removes the root CA of the tenant.
+func (s *TenantCAStore) Delete(tenantName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cas, tenantName)
+	klog.V(4).Infof("removed root CA of tenant %s", tenantName)
+}
+
+// VerifyPeerCertificate verifies the client certificate against the root CA of the tenant
+// named by its CommonName, so that a tenant cannot impersonate another one with a
+// certificate signed by its own CA. It is meant to be used as tls.Config.VerifyPeerCertificate.
+func (s *TenantCAStore) VerifyPeerCertificate(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return fmt.Errorf("no client certificate is provided")
+	}
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse client certificate: %v", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	tenantName := certs[0].Subject.CommonName
+	s.mu.RLock()
+	roots, exists := s.cas[tenantName]
+	s.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("no root CA is found for tenant %q", tenantName)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return fmt.Errorf("client certificate of tenant %q is not signed by its root CA: %v", tenantName, err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificate
+
+import (
+	"crypto"
+	"crypto/x509"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	certutil "k8s.io/client-go/util/cert"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	pkiutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/pki"
+)
+
+func newCA(t *testing.T, name string) (*x509.Certificate, crypto.Signer) {
+	ca, key, err := pkiutil.NewCertificateAuthority(&pkiutil.CertConfig{Config: certutil.Config{CommonName: name}})
+	if err != nil {
+		t.Fatalf("failed to create ca: %v", err)
+	}
+	return ca, key
+}
+
+func newClientCert(t *testing.T, ca *x509.Certificate, caKey crypto.Signer, cn string) [][]byte {
+	cert, _, err := pkiutil.NewCertAndKey(ca, caKey, &pkiutil.CertConfig{Config: certutil.Config{
+		CommonName: cn,
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}})
+	if err != nil {
+		t.Fatalf("failed to create client cert: %v", err)
+	}
+	return [][]byte{cert.Raw}
+}
+
+func addRootNamespace(t *testing.T, store *TenantCAStore, name string) {
+	if err := store.nsInformer.GetIndexer().Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name: name,
+		Annotations: map[string]string{
+			constants.LabelVCRootNS: "true",
+			constants.LabelVCUID:    "vc-uid",
+		},
+	}}); err != nil {
+		t.Fatalf("failed to add namespace: %v", err)
+	}
+}
+
+func TestTenantCAStoreVerifyPeerCertificate(t *testing.T) {
+	ca1, key1 := newCA(t, "t1-ca")
+	ca2, key2 := newCA(t, "t2-ca")
+
+	store := NewTenantCAStore(fake.NewSimpleClientset())
+	if err := store.Set("t1", pkiutil.EncodeCertPEM(ca1)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.Set("t2", pkiutil.EncodeCertPEM(ca2)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		rawCerts [][]byte
+		valid    bool
+	}{
+		{
+			name:     "signed by the tenant CA",
+			rawCerts: newClientCert(t, ca1, key1, "t1"),
+			valid:    true,
+		},
+		{
+			name:     "impersonating another tenant",
+			rawCerts: newClientCert(t, ca2, key2, "t1"),
+		},
+		{
+			name:     "unknown tenant",
+			rawCerts: newClientCert(t, ca1, key1, "t3"),
+		},
+		{
+			name: "no certificate",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := store.VerifyPeerCertificate(tc.rawCerts, nil)
+			if tc.valid != (err == nil) {
+				t.Errorf("expected valid %v, got %v", tc.valid, err)
+			}
+		})
+	}
+
+	store.Delete("t1")
+	if err := store.VerifyPeerCertificate(newClientCert(t, ca1, key1, "t1"), nil); err == nil {
+		t.Errorf("expected the removed tenant to be rejected")
+	}
+}
+
+func TestTenantCAStoreReload(t *testing.T) {
+	ca, key := newCA(t, "t1-ca")
+	rotated, rotatedKey := newCA(t, "t1-ca")
+	rootCA := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "t1", Name: secret.RootCASecretName},
+		Data:       map[string][]byte{corev1.TLSCertKey: pkiutil.EncodeCertPEM(ca)},
+	}
+	store := NewTenantCAStore(fake.NewSimpleClientset())
+	addRootNamespace(t, store, "t1")
+
+	store.onSecret(rootCA)
+	if err := store.VerifyPeerCertificate(newClientCert(t, ca, key, "t1"), nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// the root CA is rotated.
+	rootCA = rootCA.DeepCopy()
+	rootCA.Data[corev1.TLSCertKey] = pkiutil.EncodeCertPEM(rotated)
+	store.onSecret(rootCA)
+	if err := store.VerifyPeerCertificate(newClientCert(t, ca, key, "t1"), nil); err == nil {
+		t.Errorf("expected the certificate signed by the old CA to be rejected")
+	}
+	if err := store.VerifyPeerCertificate(newClientCert(t, rotated, rotatedKey, "t1"), nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTenantCAStoreIgnoreTenantNamespace(t *testing.T) {
+	ca, key := newCA(t, "t1-ca")
+	store := NewTenantCAStore(fake.NewSimpleClientset())
+	addRootNamespace(t, store, "t1")
+	// a tenant namespace the syncer copies the secrets of the tenant to.
+	tenantNS := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "t1-default",
+		Annotations: map[string]string{constants.LabelCluster: "t1", constants.LabelNamespace: "default"},
+	}}
+	if err := store.nsInformer.GetIndexer().Add(tenantNS); err != nil {
+		t.Fatalf("failed to add namespace: %v", err)
+	}
+
+	for _, ns := range []string{"t1-default", "unknown"} {
+		store.onSecret(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: secret.RootCASecretName},
+			Data:       map[string][]byte{corev1.TLSCertKey: pkiutil.EncodeCertPEM(ca)},
+		})
+		if err := store.VerifyPeerCertificate(newClientCert(t, ca, key, ns), nil); err == nil {
+			t.Errorf("expected the root CA copied to namespace %s to be ignored", ns)
+		}
+	}
+}
@@ -18,6 +18,9 @@ package config
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+
+	listersv1 "k8s.io/client-go/listers/core/v1"
 )
 
 // TLSOptions holds the TLS options.
@@ -32,4 +35,11 @@ type TLSOptions struct {
 type Config struct {
 	KubeletClientCert *tls.Certificate
 	KubeletServerHost string
+	// KubeletCAs verifies the kubelet serving certificate, it is not verified if nil.
+	KubeletCAs *x509.CertPool
+	// KubeletServerName is the server name to verify the kubelet serving certificate.
+	KubeletServerName string
+	// NamespaceLister lists the super cluster namespaces to map them to the tenant
+	// namespaces in the kubelet responses, no namespace is mapped if nil.
+	NamespaceLister listersv1.NamespaceLister
 }
@@ -0,0 +1,328 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// AuditOutcomeSuccess is the outcome of a session served by the backend.
+	AuditOutcomeSuccess = "success"
+	// AuditOutcomeFailure is the outcome of a session rejected or failed to be proxied.
+	AuditOutcomeFailure = "failure"
+)
+
+// auditedActions are the interactive actions recorded in the audit trail.
+var auditedActions = map[string]bool{
+	"exec":        true,
+	"attach":      true,
+	"portForward": true,
+}
+
+// AuditRecord is the audit trail of an interactive session through vn-agent.
+type AuditRecord struct {
+	Tenant    string    `json:"tenant"`
+	Action    string    `json:"action"`
+	Namespace string    `json:"namespace"`
+	Pod       string    `json:"pod"`
+	Container string    `json:"container,omitempty"`
+	Command   []string  `json:"command,omitempty"`
+	TTY       bool      `json:"tty,omitempty"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	// BytesIn is the number of bytes received from the client.
+	BytesIn int64 `json:"bytesIn"`
+	// BytesOut is the number of bytes sent to the client.
+	BytesOut     int64  `json:"bytesOut"`
+	ResponseCode int    `json:"responseCode,omitempty"`
+	Outcome      string `json:"outcome"`
+	Error        string `json:"error,omitempty"`
+	// Recording is the file the session is recorded to, if any.
+	Recording string `json:"recording,omitempty"`
+}
+
+// AuditSink persists the audit records.
+type AuditSink interface {
+	Write(record *AuditRecord) error
+}
+
+// FileAuditSink appends the audit records to a file as JSON lines.
+type FileAuditSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+var _ AuditSink = &FileAuditSink{}
+
+// NewFileAuditSink opens the audit log file for appending.
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	f, err := os.OpenFile(filepath.Clean(path), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log %s: %v", path, err)
+	}
+	return &FileAuditSink{file: f}, nil
+}
+
+func (f *FileAuditSink) Write(record *AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, err = f.file.Write(append(data, '\n'))
+	return err
+}
+
+// webhookAuditQueueSize is the number of audit records waiting to be posted to the webhook,
+// the records are dropped once the queue is full.
+const webhookAuditQueueSize = 1024
+
+// WebhookAuditSink posts each audit record to a webhook as JSON. The records are queued and
+// posted by a background worker so that the sessions don't wait for the webhook.
+type WebhookAuditSink struct {
+	url    string
+	client *http.Client
+	queue  chan *AuditRecord
+}
+
+var _ AuditSink = &WebhookAuditSink{}
+
+// NewWebhookAuditSink creates a sink posting to the webhook url and starts its worker.
+func NewWebhookAuditSink(url string) *WebhookAuditSink {
+	w := &WebhookAuditSink{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+		queue:  make(chan *AuditRecord, webhookAuditQueueSize),
+	}
+	go w.run()
+	return w
+}
+
+// Write queues the record to be posted, it fails if the queue is full.
+func (w *WebhookAuditSink) Write(record *AuditRecord) error {
+	select {
+	case w.queue <- record:
+		return nil
+	default:
+		return fmt.Errorf("audit webhook %s queue is full, the record is dropped", w.url)
+	}
+}
+
+func (w *WebhookAuditSink) run() {
+	for record := range w.queue {
+		if err := w.post(record); err != nil {
+			klog.Errorf("failed to post audit record of tenant %s: %v", record.Tenant, err)
+		}
+	}
+}
+
+func (w *WebhookAuditSink) post(record *AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	resp, err := w.client.Post(w.url, restful.MIME_JSON, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("audit webhook %s responded %d", w.url, resp.StatusCode)
+	}
+	return nil
+}
+
+// Auditor records the interactive sessions proxied by vn-agent.
+type Auditor struct {
+	sinks []AuditSink
+	// recordingDir is where the TTY sessions are recorded, they are not recorded if empty.
+	recordingDir string
+}
+
+// NewAuditor creates an Auditor writing to the given sinks.
+func NewAuditor(recordingDir string, sinks ...AuditSink) *Auditor {
+	return &Auditor{sinks: sinks, recordingDir: recordingDir}
+}
+
+// auditSession tracks an ongoing interactive session.
+type auditSession struct {
+	auditor *Auditor
+	record  AuditRecord
+	writer  *auditResponseWriter
+
+	mu  sync.Mutex
+	err error
+}
+
+// Begin starts auditing the request if its action is audited. The returned writer
+// must be used to serve the request and the session must be ended once served.
+func (a *Auditor) Begin(req *restful.Request, w http.ResponseWriter, tenantName, action string) (*auditSession, http.ResponseWriter) {
+	if a == nil || !auditedActions[action] {
+		return nil, w
+	}
+	query := req.Request.URL.Query()
+	session := &auditSession{
+		auditor: a,
+		record: AuditRecord{
+			Tenant:    tenantName,
+			Action:    action,
+			Namespace: req.PathParameter("podNamespace"),
+			Pod:       req.PathParameter("podID"),
+			Container: req.PathParameter("containerName"),
+			Command:   query["command"],
+			TTY:       query.Get("tty") == "1" || query.Get("tty") == "true",
+			StartTime: time.Now(),
+		},
+	}
+	session.writer = &auditResponseWriter{ResponseWriter: w}
+	if a.recordingDir != "" && session.record.TTY {
+		recorder, err := newSessionRecorder(a.recordingDir, &session.record, req.Request.Header.Get("Upgrade"))
+		if err != nil {
+			klog.Errorf("failed to record %s session of tenant %s: %v", action, tenantName, err)
+		} else {
+			session.writer.recorder = recorder
+			session.record.Recording = recorder.path
+		}
+	}
+	return session, session.writer
+}
+
+// Fail marks the session as failed.
+func (s *auditSession) Fail(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// End completes the audit record and writes it to the sinks.
+func (s *auditSession) End() {
+	if s == nil {
+		return
+	}
+	s.writer.close()
+
+	s.mu.Lock()
+	record := s.record
+	err := s.err
+	s.mu.Unlock()
+
+	record.EndTime = time.Now()
+	record.BytesIn = atomic.LoadInt64(&s.writer.bytesIn)
+	record.BytesOut = atomic.LoadInt64(&s.writer.bytesOut)
+	record.ResponseCode = s.writer.status
+	record.Outcome = AuditOutcomeSuccess
+	if err != nil || record.ResponseCode >= http.StatusBadRequest {
+		record.Outcome = AuditOutcomeFailure
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	for _, sink := range s.auditor.sinks {
+		if err := sink.Write(&record); err != nil {
+			klog.Errorf("failed to write audit record of tenant %s: %v", record.Tenant, err)
+		}
+	}
+}
+
+// auditResponseWriter counts the bytes sent to the client, including the bytes
+// exchanged on the connection hijacked for the upgraded streams.
+type auditResponseWriter struct {
+	http.ResponseWriter
+	status   int
+	bytesIn  int64
+	bytesOut int64
+	recorder *sessionRecorder
+}
+
+func (w *auditResponseWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *auditResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	atomic.AddInt64(&w.bytesOut, int64(n))
+	return n, err
+}
+
+func (w *auditResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *auditResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("the response writer doesn't support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	// the upgrade response is written to the hijacked connection.
+	w.status = http.StatusSwitchingProtocols
+	return &auditConn{Conn: conn, writer: w}, rw, nil
+}
+
+func (w *auditResponseWriter) close() {
+	if w.recorder != nil {
+		w.recorder.close()
+	}
+}
+
+// auditConn counts and records the bytes of a hijacked connection.
+type auditConn struct {
+	net.Conn
+	writer *auditResponseWriter
+}
+
+func (c *auditConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	atomic.AddInt64(&c.writer.bytesIn, int64(n))
+	c.writer.recorder.record(fromClient, b[:n])
+	return n, err
+}
+
+func (c *auditConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	atomic.AddInt64(&c.writer.bytesOut, int64(n))
+	c.writer.recorder.record(toClient, b[:n])
+	return n, err
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"github.com/moby/spdystream/spdy"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+type fakeAuditSink struct {
+	records []*AuditRecord
+}
+
+func (f *fakeAuditSink) Write(record *AuditRecord) error {
+	f.records = append(f.records, record)
+	return nil
+}
+
+// hijackableRecorder is a response recorder whose connection can be hijacked.
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+	conn net.Conn
+}
+
+func (h *hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return h.conn, nil, nil
+}
+
+func newAuditRequest(t *testing.T, path string) *restful.Request {
+	httpReq, err := http.NewRequest(http.MethodPost, path, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req := restful.NewRequest(httpReq)
+	req.PathParameters()["podNamespace"] = "default"
+	req.PathParameters()["podID"] = "pod"
+	req.PathParameters()["containerName"] = "c"
+	return req
+}
+
+func TestAuditSession(t *testing.T) {
+	sink := &fakeAuditSink{}
+	dir := t.TempDir()
+	auditor := NewAuditor(dir, sink)
+
+	server, client := net.Pipe()
+	defer client.Close()
+	recorder := &hijackableRecorder{ResponseRecorder: httptest.NewRecorder(), conn: server}
+	req := newAuditRequest(t, "/exec/default/pod/c?command=ls&command=-l&tty=1")
+	req.Request.Header.Set("Upgrade", "SPDY/3.1")
+	session, w := auditor.Begin(req, recorder, "t1", "exec")
+	if session == nil {
+		t.Fatalf("expected the exec session to be audited")
+	}
+
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	in := spdyFrames(t,
+		&spdy.SynStreamFrame{StreamId: 1, Headers: http.Header{corev1.StreamType: {corev1.StreamTypeStdin}}},
+		&spdy.SynStreamFrame{StreamId: 3, Headers: http.Header{corev1.StreamType: {corev1.StreamTypeStdout}}},
+		&spdy.DataFrame{StreamId: 1, Data: []byte("ls\n")},
+	)
+	out := append([]byte("HTTP/1.1 101 Switching Protocols\r\nUpgrade: SPDY/3.1\r\n\r\n"),
+		spdyFrames(t, &spdy.DataFrame{StreamId: 3, Data: []byte("pod.yaml\n")})...)
+	go func() {
+		client.Write(in)
+		buf := make([]byte, len(out))
+		io.ReadFull(client, buf)
+	}()
+	buf := make([]byte, len(in))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := conn.Write(out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	session.End()
+
+	if len(sink.records) != 1 {
+		t.Fatalf("expected 1 audit record, got %d", len(sink.records))
+	}
+	record := sink.records[0]
+	if record.Tenant != "t1" || record.Namespace != "default" || record.Pod != "pod" || record.Container != "c" {
+		t.Errorf("unexpected record %+v", record)
+	}
+	if strings.Join(record.Command, " ") != "ls -l" || !record.TTY {
+		t.Errorf("unexpected command %v tty %v", record.Command, record.TTY)
+	}
+	if record.BytesIn != int64(len(in)) || record.BytesOut != int64(len(out)) {
+		t.Errorf("expected %d bytes in and %d bytes out, got %d and %d", len(in), len(out), record.BytesIn, record.BytesOut)
+	}
+	if record.Outcome != AuditOutcomeSuccess || record.ResponseCode != http.StatusSwitchingProtocols {
+		t.Errorf("unexpected outcome %s code %d", record.Outcome, record.ResponseCode)
+	}
+	if record.EndTime.Before(record.StartTime) {
+		t.Errorf("unexpected session time %v - %v", record.StartTime, record.EndTime)
+	}
+
+	if record.Recording == "" {
+		t.Fatalf("expected the tty session to be recorded")
+	}
+	expectRecording(t, record.Recording, []recordingEvent{
+		{Stream: corev1.StreamTypeStdin, Data: []byte("ls\n")},
+		{Stream: corev1.StreamTypeStdout, Data: []byte("pod.yaml\n")},
+	})
+}
+
+func TestAuditSessionFailure(t *testing.T) {
+	sink := &fakeAuditSink{}
+	auditor := NewAuditor("", sink)
+
+	session, w := auditor.Begin(newAuditRequest(t, "/attach/default/pod/c"), httptest.NewRecorder(), "t1", "attach")
+	session.Fail(errors.New("backend unreachable"))
+	http.Error(w, "backend unreachable", http.StatusInternalServerError)
+	session.End()
+
+	if len(sink.records) != 1 {
+		t.Fatalf("expected 1 audit record, got %d", len(sink.records))
+	}
+	record := sink.records[0]
+	if record.Outcome != AuditOutcomeFailure || record.Error != "backend unreachable" || record.ResponseCode != http.StatusInternalServerError {
+		t.Errorf("unexpected record %+v", record)
+	}
+	if record.Recording != "" {
+		t.Errorf("expected the session not to be recorded")
+	}
+}
+
+func TestAuditSkipsOtherActions(t *testing.T) {
+	sink := &fakeAuditSink{}
+	auditor := NewAuditor("", sink)
+	recorder := httptest.NewRecorder()
+
+	session, w := auditor.Begin(newAuditRequest(t, "/containerLogs/default/pod/c"), recorder, "t1", "containerLogs")
+	if session != nil || w != recorder {
+		t.Errorf("expected containerLogs not to be audited")
+	}
+	session.End()
+
+	var nilAuditor *Auditor
+	if session, _ := nilAuditor.Begin(newAuditRequest(t, "/exec/default/pod/c"), recorder, "t1", "exec"); session != nil {
+		t.Errorf("expected nothing to be audited without auditor")
+	}
+	if len(sink.records) != 0 {
+		t.Errorf("expected no audit record, got %d", len(sink.records))
+	}
+}
+
+func TestWebhookAuditSink(t *testing.T) {
+	release := make(chan struct{})
+	received := make(chan *AuditRecord, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		record := &AuditRecord{}
+		if err := json.NewDecoder(r.Body).Decode(record); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		received <- record
+	}))
+	defer webhook.Close()
+	defer close(release)
+
+	sink := NewWebhookAuditSink(webhook.URL)
+	done := make(chan error)
+	go func() {
+		done <- sink.Write(&AuditRecord{Tenant: "t1", Action: "exec"})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatalf("expected the record to be queued without waiting for the webhook")
+	}
+
+	release <- struct{}{}
+	select {
+	case record := <-received:
+		if record.Tenant != "t1" || record.Action != "exec" {
+			t.Errorf("unexpected record %+v", record)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatalf("expected the record to be posted to the webhook")
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/emicklei/go-restful"
+)
+
+// configzAllowedFields are the kubelet configuration fields exposed to the tenants, the
+// others, e.g. the paths, addresses and authentication settings of the node, are dropped.
+var configzAllowedFields = []string{
+	"maxPods",
+	"podsPerCore",
+	"podPidsLimit",
+	"evictionHard",
+	"evictionSoft",
+	"evictionSoftGracePeriod",
+	"evictionPressureTransitionPeriod",
+	"evictionMaxPodGracePeriod",
+	"kubeReserved",
+	"systemReserved",
+	"cpuManagerPolicy",
+	"memoryManagerPolicy",
+	"topologyManagerPolicy",
+	"topologyManagerScope",
+	"featureGates",
+	"containerLogMaxSize",
+	"containerLogMaxFiles",
+	"imageGCHighThresholdPercent",
+	"imageGCLowThresholdPercent",
+	"imageMinimumGCAge",
+	"serializeImagePulls",
+	"cgroupDriver",
+	"cpuCFSQuota",
+	"cpuCFSQuotaPeriod",
+}
+
+// configz fetches the kubelet configuration and keeps the fields allowed for the tenants.
+func (s *Server) configz(req *restful.Request, resp *restful.Response) {
+	s.serveFiltered(req, resp, func(body []byte, _ string) ([]byte, string, error) {
+		filtered, err := filterConfigz(body)
+		return filtered, restful.MIME_JSON, err
+	})
+}
+
+// filterConfigz keeps the allowed fields of the kubelet configuration.
+func filterConfigz(body []byte) ([]byte, error) {
+	var configz struct {
+		KubeletConfig map[string]json.RawMessage `json:"kubeletconfig"`
+	}
+	if err := json.Unmarshal(body, &configz); err != nil {
+		return nil, fmt.Errorf("failed to decode kubelet configz: %v", err)
+	}
+	filtered := make(map[string]json.RawMessage, len(configzAllowedFields))
+	for _, field := range configzAllowedFields {
+		if value, exists := configz.KubeletConfig[field]; exists {
+			filtered[field] = value
+		}
+	}
+	configz.KubeletConfig = filtered
+	return json.Marshal(configz)
+}
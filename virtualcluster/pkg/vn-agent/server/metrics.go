@@ -34,6 +34,7 @@ const (
 	metricNameRequestLatency             = "request_latencies"
 	errorProxyingRequest                 = "error_proxying_request"
 	errorTranslatingPath                 = "error_translating_path"
+	errorFilteringResponse               = "error_filtering_response"
 )
 
 var (
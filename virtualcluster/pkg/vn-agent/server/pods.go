@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+// pods lists the kubelet pods of the calling tenant in the tenant view.
+func (s *Server) pods(req *restful.Request, resp *restful.Response) {
+	s.serveFiltered(req, resp, func(body []byte, tenantName string) ([]byte, string, error) {
+		filtered, err := filterPods(body, s.tenantNamespaces(tenantName))
+		return filtered, restful.MIME_JSON, err
+	})
+}
+
+// filterPods keeps the pods in the super cluster namespaces of the tenant. The pods are
+// converted back to what the syncer shows in the tenant control plane: the namespaces
+// and uids are the tenant ones and the metadata added by the syncer is removed.
+func filterPods(body []byte, toTenant namespaceMapper) ([]byte, error) {
+	podList := &corev1.PodList{}
+	if err := json.Unmarshal(body, podList); err != nil {
+		return nil, fmt.Errorf("failed to decode pod list: %v", err)
+	}
+
+	pods := make([]corev1.Pod, 0, len(podList.Items))
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		namespace, ok := toTenant(pod.Namespace)
+		if !ok {
+			continue
+		}
+		if uid := pod.Annotations[constants.LabelUID]; uid != "" {
+			pod.UID = types.UID(uid)
+		}
+		pod.Namespace = namespace
+		pod.Labels = stripOpaqueKeys(pod.Labels)
+		pod.Annotations = stripOpaqueKeys(pod.Annotations)
+		pods = append(pods, *pod)
+	}
+	podList.Items = pods
+	return json.Marshal(podList)
+}
+
+// stripOpaqueKeys removes the keys added to the super cluster objects by the syncer. The
+// transparent keys are kept as the syncer populates them back to the tenant objects.
+func stripOpaqueKeys(kv map[string]string) map[string]string {
+	for k := range kv {
+		if strings.HasPrefix(k, constants.DefaultOpaqueMetaPrefix) {
+			delete(kv, k)
+		}
+	}
+	if len(kv) == 0 {
+		return nil
+	}
+	return kv
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+func TestFilterPods(t *testing.T) {
+	podList := &corev1.PodList{
+		Items: []corev1.Pod{
+			{ObjectMeta: metav1.ObjectMeta{
+				Namespace: "t1-default",
+				Name:      "a",
+				UID:       "super-uid",
+				Labels: map[string]string{
+					"app":                      "a",
+					constants.LabelVCName:      "vc",
+					constants.LabelVCNamespace: "tenant",
+				},
+				Annotations: map[string]string{
+					constants.LabelCluster:                            "t1",
+					constants.LabelNamespace:                          "default",
+					constants.LabelUID:                                "tenant-uid",
+					constants.DefaultTransparentMetaPrefix + "/owner": "team-a",
+				},
+			}},
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "t2-default", Name: "b"}},
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "c"}},
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "t1-7f3a9c2e1", Name: "d"}},
+		},
+	}
+	body, err := json.Marshal(podList)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	filtered, err := filterPods(body, newTestServer(t).tenantNamespaces("t1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := &corev1.PodList{}
+	if err := json.Unmarshal(filtered, got); err != nil {
+		t.Fatalf("failed to decode filtered pods: %v", err)
+	}
+	if len(got.Items) != 2 {
+		t.Fatalf("expected only the tenant pods, got %d pods", len(got.Items))
+	}
+	if got.Items[1].Namespace != longNamespace || got.Items[1].Name != "d" {
+		t.Errorf("expected the shortened namespace to be renamed, got %s/%s", got.Items[1].Namespace, got.Items[1].Name)
+	}
+	pod := got.Items[0]
+	if pod.Namespace != "default" || pod.Name != "a" || pod.UID != "tenant-uid" {
+		t.Errorf("unexpected pod %s/%s uid %s", pod.Namespace, pod.Name, pod.UID)
+	}
+	if len(pod.Labels) != 1 || pod.Labels["app"] != "a" {
+		t.Errorf("expected the syncer labels to be stripped, got %v", pod.Labels)
+	}
+	if len(pod.Annotations) != 1 || pod.Annotations[constants.DefaultTransparentMetaPrefix+"/owner"] != "team-a" {
+		t.Errorf("expected the syncer annotations to be stripped and the transparent ones kept, got %v", pod.Annotations)
+	}
+}
@@ -0,0 +1,311 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moby/spdystream/spdy"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// direction is the direction of the bytes exchanged on a hijacked connection.
+type direction bool
+
+const (
+	fromClient direction = true
+	toClient   direction = false
+)
+
+const (
+	// maxResponseHeadSize is the max size of the upgrade response preceding the frames.
+	maxResponseHeadSize = 64 * 1024
+	// maxWebsocketFrameSize is the max size of a websocket frame buffered to be decoded.
+	maxWebsocketFrameSize = 16 * 1024 * 1024
+)
+
+// websocketChannels are the streams of the channel.k8s.io websocket protocols by channel.
+var websocketChannels = []string{
+	corev1.StreamTypeStdin,
+	corev1.StreamTypeStdout,
+	corev1.StreamTypeStderr,
+	corev1.StreamTypeError,
+	corev1.StreamTypeResize,
+}
+
+// sessionRecorder records the streams of a TTY session as JSON lines of the elapsed
+// seconds "t", the stream "s", i.e. stdin, stdout, stderr, error or resize, and the
+// base64 encoded data "data". The streams are decoded from the SPDY/3.1 or the
+// channel.k8s.io websocket frames exchanged on the upgraded connection, the recording
+// stops if the frames fail to be decoded.
+type sessionRecorder struct {
+	mu    sync.Mutex
+	path  string
+	file  *os.File
+	start time.Time
+	// in and out decode the frames received from and sent to the client.
+	in, out frameDecoder
+}
+
+type recordingEvent struct {
+	Time   float64 `json:"t"`
+	Stream string  `json:"s"`
+	Data   []byte  `json:"data"`
+}
+
+func newSessionRecorder(dir string, record *AuditRecord, upgrade string) (*sessionRecorder, error) {
+	var in, out frameDecoder
+	switch protocol := strings.ToLower(upgrade); {
+	case protocol == "websocket":
+		in, out = &websocketDecoder{}, &websocketDecoder{}
+	case strings.HasPrefix(protocol, "spdy/"):
+		var err error
+		if in, out, err = newSPDYDecoders(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported upgrade protocol %q", upgrade)
+	}
+
+	name := fmt.Sprintf("%s_%s_%s_%d.log", record.Tenant, record.Namespace, record.Pod, record.StartTime.UnixNano())
+	path := filepath.Join(dir, name)
+	f, err := os.OpenFile(filepath.Clean(path), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &sessionRecorder{
+		path:  path,
+		file:  f,
+		start: record.StartTime,
+		in:    in,
+		// the upgrade response is written to the client before the frames.
+		out: &responseHeadDecoder{next: out},
+	}, nil
+}
+
+func (r *sessionRecorder) record(dir direction, data []byte) {
+	if r == nil || len(data) == 0 {
+		return
+	}
+	elapsed := time.Since(r.start).Seconds()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return
+	}
+	decoder := r.out
+	if dir == fromClient {
+		decoder = r.in
+	}
+	events, decodeErr := decoder.decode(data)
+	for _, event := range events {
+		event.Time = elapsed
+		line, err := json.Marshal(event)
+		if err != nil {
+			continue
+		}
+		if _, err := r.file.Write(append(line, '\n')); err != nil {
+			klog.Errorf("failed to record session to %s: %v", r.path, err)
+		}
+	}
+	if decodeErr != nil {
+		klog.Errorf("failed to decode the session frames, stop recording to %s: %v", r.path, decodeErr)
+		r.file.Close()
+		r.file = nil
+	}
+}
+
+func (r *sessionRecorder) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+}
+
+// frameDecoder decodes the frames exchanged in one direction of a connection.
+type frameDecoder interface {
+	// decode consumes the next bytes and returns the stream data of the complete frames,
+	// the incomplete frame is kept until its remaining bytes are consumed.
+	decode(data []byte) ([]recordingEvent, error)
+}
+
+// responseHeadDecoder skips the HTTP response head before decoding the frames.
+type responseHeadDecoder struct {
+	head []byte
+	done bool
+	next frameDecoder
+}
+
+func (d *responseHeadDecoder) decode(data []byte) ([]recordingEvent, error) {
+	if !d.done {
+		d.head = append(d.head, data...)
+		end := bytes.Index(d.head, []byte("\r\n\r\n"))
+		if end < 0 {
+			if len(d.head) > maxResponseHeadSize {
+				return nil, fmt.Errorf("upgrade response is larger than %d bytes", maxResponseHeadSize)
+			}
+			return nil, nil
+		}
+		data = d.head[end+4:]
+		d.head = nil
+		d.done = true
+	}
+	return d.next.decode(data)
+}
+
+// spdyDecoder decodes the SPDY/3.1 frames of the kubelet stream protocol.
+type spdyDecoder struct {
+	buf    bytes.Buffer
+	framer *spdy.Framer
+	// streams are the stream types by stream id, shared by both directions since the
+	// streams are all created by the client.
+	streams map[spdy.StreamId]string
+}
+
+// newSPDYDecoders returns the decoders of both directions, each direction has its own
+// header compression context.
+func newSPDYDecoders() (frameDecoder, frameDecoder, error) {
+	streams := map[spdy.StreamId]string{}
+	var decoders [2]*spdyDecoder
+	for i := range decoders {
+		d := &spdyDecoder{streams: streams}
+		framer, err := spdy.NewFramer(ioutil.Discard, &d.buf)
+		if err != nil {
+			return nil, nil, err
+		}
+		d.framer = framer
+		decoders[i] = d
+	}
+	return decoders[0], decoders[1], nil
+}
+
+func (d *spdyDecoder) decode(data []byte) ([]recordingEvent, error) {
+	d.buf.Write(data)
+	var events []recordingEvent
+	// the data and control frames both have an 8 bytes header ending with the length.
+	for d.buf.Len() >= 8 {
+		length := int(binary.BigEndian.Uint32(d.buf.Bytes()[4:8]) & 0xffffff)
+		if d.buf.Len() < 8+length {
+			break
+		}
+		frame, err := d.framer.ReadFrame()
+		if err != nil {
+			return events, err
+		}
+		switch frame := frame.(type) {
+		case *spdy.SynStreamFrame:
+			d.streams[frame.StreamId] = frame.Headers.Get(corev1.StreamType)
+		case *spdy.DataFrame:
+			if stream, ok := d.streams[frame.StreamId]; ok && len(frame.Data) != 0 {
+				events = append(events, recordingEvent{Stream: stream, Data: frame.Data})
+			}
+		}
+	}
+	return events, nil
+}
+
+// websocketDecoder decodes the websocket frames of the channel.k8s.io protocols, the
+// binary frames are prefixed with the channel and the text frames of the base64
+// protocols are prefixed with the channel digit.
+type websocketDecoder struct {
+	buf bytes.Buffer
+}
+
+func (d *websocketDecoder) decode(data []byte) ([]recordingEvent, error) {
+	d.buf.Write(data)
+	var events []recordingEvent
+	for {
+		opcode, payload, ok, err := d.next()
+		if err != nil || !ok {
+			return events, err
+		}
+		var channel int
+		switch {
+		case opcode == 0x2 && len(payload) > 0:
+			channel, payload = int(payload[0]), payload[1:]
+		case opcode == 0x1 && len(payload) > 0:
+			channel = int(payload[0]) - '0'
+			if payload, err = base64.StdEncoding.DecodeString(string(payload[1:])); err != nil {
+				return events, err
+			}
+		default:
+			// the control frames carry no stream data.
+			continue
+		}
+		if channel >= 0 && channel < len(websocketChannels) && len(payload) != 0 {
+			events = append(events, recordingEvent{Stream: websocketChannels[channel], Data: payload})
+		}
+	}
+}
+
+// next returns the opcode and the unmasked payload of the next complete frame.
+func (d *websocketDecoder) next() (byte, []byte, bool, error) {
+	frame := d.buf.Bytes()
+	if len(frame) < 2 {
+		return 0, nil, false, nil
+	}
+	opcode := frame[0] & 0x0f
+	masked := frame[1]&0x80 != 0
+	length, offset := uint64(frame[1]&0x7f), 2
+	switch length {
+	case 126:
+		if len(frame) < 4 {
+			return 0, nil, false, nil
+		}
+		length, offset = uint64(binary.BigEndian.Uint16(frame[2:4])), 4
+	case 127:
+		if len(frame) < 10 {
+			return 0, nil, false, nil
+		}
+		length, offset = binary.BigEndian.Uint64(frame[2:10]), 10
+	}
+	if length > maxWebsocketFrameSize {
+		return 0, nil, false, fmt.Errorf("websocket frame of %d bytes is larger than %d bytes", length, maxWebsocketFrameSize)
+	}
+	var mask []byte
+	if masked {
+		if len(frame) < offset+4 {
+			return 0, nil, false, nil
+		}
+		mask, offset = frame[offset:offset+4], offset+4
+	}
+	if uint64(len(frame)-offset) < length {
+		return 0, nil, false, nil
+	}
+	payload := make([]byte, length)
+	copy(payload, frame[offset:])
+	if mask != nil {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	d.buf.Next(offset + int(length))
+	return opcode, payload, true, nil
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moby/spdystream/spdy"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// spdyFrames encodes the frames sent in one direction.
+func spdyFrames(t *testing.T, frames ...spdy.Frame) []byte {
+	var buf bytes.Buffer
+	framer, err := spdy.NewFramer(&buf, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, frame := range frames {
+		if err := framer.WriteFrame(frame); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return buf.Bytes()
+}
+
+// websocketFrame encodes a websocket frame, the frames sent by the client are masked.
+func websocketFrame(opcode byte, payload []byte, masked bool) []byte {
+	frame := []byte{0x80 | opcode}
+	switch {
+	case len(payload) < 126:
+		frame = append(frame, byte(len(payload)))
+	default:
+		frame = append(frame, 126, byte(len(payload)>>8), byte(len(payload)))
+	}
+	if !masked {
+		return append(frame, payload...)
+	}
+	frame[1] |= 0x80
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	return frame
+}
+
+func expectRecording(t *testing.T, path string, expected []recordingEvent) {
+	t.Helper()
+	recording, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read recording: %v", err)
+	}
+	var events []recordingEvent
+	for _, line := range strings.Split(strings.TrimSpace(string(recording)), "\n") {
+		if line == "" {
+			continue
+		}
+		event := recordingEvent{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("failed to decode recorded event %q: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d recorded events, got %q", len(expected), recording)
+	}
+	for i := range expected {
+		if events[i].Stream != expected[i].Stream || !bytes.Equal(events[i].Data, expected[i].Data) {
+			t.Errorf("expected recorded event %d to be %s %q, got %s %q", i, expected[i].Stream, expected[i].Data, events[i].Stream, events[i].Data)
+		}
+	}
+}
+
+func newTestRecorder(t *testing.T, upgrade string) *sessionRecorder {
+	recorder, err := newSessionRecorder(t.TempDir(), &AuditRecord{Tenant: "t1", Namespace: "default", Pod: "pod", StartTime: time.Now()}, upgrade)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return recorder
+}
+
+func TestRecordSPDYSession(t *testing.T) {
+	recorder := newTestRecorder(t, "SPDY/3.1")
+	in := spdyFrames(t,
+		&spdy.SynStreamFrame{StreamId: 1, Headers: http.Header{corev1.StreamType: {corev1.StreamTypeStdin}}},
+		&spdy.SynStreamFrame{StreamId: 3, Headers: http.Header{corev1.StreamType: {corev1.StreamTypeStdout}}},
+		&spdy.SynStreamFrame{StreamId: 5, Headers: http.Header{corev1.StreamType: {corev1.StreamTypeResize}}},
+		&spdy.DataFrame{StreamId: 5, Data: []byte(`{"Width":80,"Height":24}`)},
+		&spdy.DataFrame{StreamId: 1, Data: []byte("whoami\n")},
+	)
+	out := append([]byte("HTTP/1.1 101 Switching Protocols\r\nUpgrade: SPDY/3.1\r\n\r\n"),
+		spdyFrames(t,
+			&spdy.SynReplyFrame{StreamId: 1, Headers: http.Header{}},
+			&spdy.DataFrame{StreamId: 3, Data: []byte("root\n")},
+		)...)
+
+	// the frames are split across the reads and the writes.
+	for _, b := range out[:len(out)/2] {
+		recorder.record(toClient, []byte{b})
+	}
+	for _, b := range in {
+		recorder.record(fromClient, []byte{b})
+	}
+	recorder.record(toClient, out[len(out)/2:])
+	recorder.close()
+
+	expectRecording(t, recorder.path, []recordingEvent{
+		{Stream: corev1.StreamTypeResize, Data: []byte(`{"Width":80,"Height":24}`)},
+		{Stream: corev1.StreamTypeStdin, Data: []byte("whoami\n")},
+		{Stream: corev1.StreamTypeStdout, Data: []byte("root\n")},
+	})
+}
+
+func TestRecordWebsocketSession(t *testing.T) {
+	recorder := newTestRecorder(t, "websocket")
+	longOutput := bytes.Repeat([]byte("x"), 200)
+	in := websocketFrame(0x2, append([]byte{0}, "whoami\n"...), true)
+	out := append([]byte("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\n\r\n"),
+		websocketFrame(0x2, append([]byte{1}, longOutput...), false)...)
+	out = append(out, websocketFrame(0x1, []byte("2"+base64.StdEncoding.EncodeToString([]byte("denied\n"))), false)...)
+	// the ping frames carry no stream data.
+	out = append(out, websocketFrame(0x9, nil, false)...)
+
+	recorder.record(fromClient, in[:3])
+	recorder.record(fromClient, in[3:])
+	for _, b := range out {
+		recorder.record(toClient, []byte{b})
+	}
+	recorder.close()
+
+	expectRecording(t, recorder.path, []recordingEvent{
+		{Stream: corev1.StreamTypeStdin, Data: []byte("whoami\n")},
+		{Stream: corev1.StreamTypeStdout, Data: longOutput},
+		{Stream: corev1.StreamTypeStderr, Data: []byte("denied\n")},
+	})
+}
+
+func TestRecordingStopsOnUndecodableFrames(t *testing.T) {
+	recorder := newTestRecorder(t, "websocket")
+	recorder.record(fromClient, websocketFrame(0x2, []byte{0, 'a'}, true))
+	recorder.record(fromClient, websocketFrame(0x1, []byte("0!not base64!"), true))
+	recorder.record(fromClient, websocketFrame(0x2, []byte{0, 'b'}, true))
+	recorder.close()
+
+	expectRecording(t, recorder.path, []recordingEvent{
+		{Stream: corev1.StreamTypeStdin, Data: []byte("a")},
+	})
+
+	if _, err := newSessionRecorder(t.TempDir(), &AuditRecord{}, "h2c"); err == nil {
+		t.Errorf("expected the unsupported protocol not to be recorded")
+	}
+}
@@ -17,104 +17,205 @@ limitations under the License.
 package server
 
 import (
+	"fmt"
+	"net/http"
+	"strings"
+
 	"github.com/emicklei/go-restful"
 
 	"k8s.io/apimachinery/pkg/util/httpstream"
 	"k8s.io/apimachinery/pkg/util/proxy"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
-	"net/http"
-	"strings"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 )
 
-// InstallHandlers set router and handlers.
-func (s *Server) InstallHandlers() {
-	ws := new(restful.WebService)
-	ws.Path("/pods").
-		Produces(restful.MIME_JSON)
-	ws.Route(ws.GET("").
-		To(s.proxy).
-		Operation("getPods"))
-	s.restfulCont.Add(ws)
+// Route declares a kubelet endpoint served by vn-agent.
+type Route struct {
+	// Root is the root path of the endpoint, e.g. /exec.
+	Root string
+	// Paths are the paths under the root, "" serves the root itself.
+	Paths []string
+	// Methods are the http methods served on each path.
+	Methods []string
+	// Operation names the endpoint.
+	Operation string
+	// Produces are the MIME types the endpoint produces, optional.
+	Produces []string
+	// Feature gates the endpoint, it is always served if empty.
+	Feature featuregate.Feature
+	// Allowed returns whether the tenant can access the endpoint, every tenant can if nil.
+	Allowed func(tenantName string) bool
+	// TranslateKubelet translates the request to kubelet, the request is forwarded as is if nil.
+	TranslateKubelet func(req *restful.Request, tenantName string) error
+	// TranslateSuper translates the request to super apiserver, the endpoint is not served
+	// in super apiserver proxy mode if nil.
+	TranslateSuper func(req *restful.Request, tenantName string) error
+	// Handler serves the request instead of proxying it, optional.
+	Handler func(s *Server, req *restful.Request, resp *restful.Response)
+}
 
-	ws = new(restful.WebService)
-	ws.Path("/run")
-	ws.Route(ws.POST("/{podNamespace}/{podID}/{containerName}").
-		To(s.proxy).
-		Operation("getRun"))
-	ws.Route(ws.POST("/{podNamespace}/{podID}/{uid}/{containerName}").
-		To(s.proxy).
-		Operation("getRun"))
-	s.restfulCont.Add(ws)
+// translateKubeletPath prefixes the pod namespace in the path with the tenant name.
+func translateKubeletPath(req *restful.Request, tenantName string) error {
+	TranslatePath(req, tenantName)
+	return nil
+}
 
-	ws = new(restful.WebService)
-	ws.Path("/logs/")
-	ws.Route(ws.GET("").
-		To(s.proxy).
-		Operation("getLogs"))
-	ws.Route(ws.GET("/{logpath:*}").
-		To(s.proxy).
-		Operation("getLogs").
-		Param(ws.PathParameter("logpath", "path to the log").DataType("string")))
-	s.restfulCont.Add(ws)
+// defaultRoutes returns the kubelet endpoints served by vn-agent.
+func defaultRoutes(debugTenants []string) []Route {
+	streamMethods := []string{http.MethodGet, http.MethodPost}
+	debugTenantSet := sets.NewString(debugTenants...)
+	return []Route{
+		{
+			Root:      "/pods",
+			Paths:     []string{""},
+			Methods:   []string{http.MethodGet},
+			Operation: "getPods",
+			Produces:  []string{restful.MIME_JSON},
+			Handler:   (*Server).pods,
+		},
+		{
+			Root:             "/run",
+			Paths:            []string{"/{podNamespace}/{podID}/{containerName}", "/{podNamespace}/{podID}/{uid}/{containerName}"},
+			Methods:          []string{http.MethodPost},
+			Operation:        "getRun",
+			TranslateKubelet: translateKubeletPath,
+		},
+		{
+			Root:             "/logs/",
+			Paths:            []string{"", "/{logpath:*}"},
+			Methods:          []string{http.MethodGet},
+			Operation:        "getLogs",
+			TranslateKubelet: translateKubeletPath,
+		},
+		{
+			Root:             "/containerLogs",
+			Paths:            []string{"/{podNamespace}/{podID}/{containerName}"},
+			Methods:          []string{http.MethodGet},
+			Operation:        "getContainerLogs",
+			TranslateKubelet: translateKubeletPath,
+			TranslateSuper:   TranslatePathForSuper,
+		},
+		{
+			Root:             "/exec",
+			Paths:            []string{"/{podNamespace}/{podID}/{containerName}", "/{podNamespace}/{podID}/{uid}/{containerName}"},
+			Methods:          streamMethods,
+			Operation:        "getExec",
+			TranslateKubelet: translateKubeletPath,
+			TranslateSuper:   TranslatePathForSuper,
+		},
+		{
+			Root:             "/attach",
+			Paths:            []string{"/{podNamespace}/{podID}/{containerName}", "/{podNamespace}/{podID}/{uid}/{containerName}"},
+			Methods:          streamMethods,
+			Operation:        "getAttach",
+			TranslateKubelet: translateKubeletPath,
+			TranslateSuper:   TranslatePathForSuper,
+		},
+		{
+			Root:             "/portForward",
+			Paths:            []string{"/{podNamespace}/{podID}", "/{podNamespace}/{podID}/{uid}"},
+			Methods:          streamMethods,
+			Operation:        "getPortForward",
+			TranslateKubelet: translateKubeletPath,
+			TranslateSuper:   TranslatePathForSuper,
+		},
+		{
+			Root:      "/stats",
+			Paths:     []string{"/summary"},
+			Methods:   []string{http.MethodGet},
+			Operation: "getStatsSummary",
+			Produces:  []string{restful.MIME_JSON},
+			Handler:   (*Server).stats,
+		},
+		{
+			Root:      "/metrics",
+			Paths:     []string{"/resource", "/cadvisor"},
+			Methods:   []string{http.MethodGet},
+			Operation: "getMetrics",
+			Handler:   (*Server).stats,
+		},
+		{
+			Root:             "/checkpoint",
+			Paths:            []string{"/{podNamespace}/{podID}/{containerName}"},
+			Methods:          []string{http.MethodPost},
+			Operation:        "checkpoint",
+			Feature:          featuregate.KubeletCheckpoint,
+			TranslateKubelet: translateKubeletPath,
+		},
+		{
+			Root:      "/configz",
+			Paths:     []string{""},
+			Methods:   []string{http.MethodGet},
+			Operation: "getConfigz",
+			Produces:  []string{restful.MIME_JSON},
+			Feature:   featuregate.KubeletConfigz,
+			Handler:   (*Server).configz,
+		},
+		{
+			Root:      "/debug/pprof",
+			Paths:     []string{"/", "/{subpath:*}"},
+			Methods:   []string{http.MethodGet},
+			Operation: "getDebugPprof",
+			Feature:   featuregate.KubeletDebugHandlers,
+			Allowed:   debugTenantSet.Has,
+		},
+	}
+}
 
-	ws = new(restful.WebService)
-	ws.Path("/containerLogs")
-	ws.Route(ws.GET("/{podNamespace}/{podID}/{containerName}").
-		To(s.proxy).
-		Operation("getContainerLogs"))
-	s.restfulCont.Add(ws)
+// InstallHandlers set router and handlers.
+func (s *Server) InstallHandlers() {
+	for _, route := range defaultRoutes(s.debugTenants) {
+		s.AddRoute(route)
+	}
+}
 
-	ws = new(restful.WebService)
-	ws.Path("/exec")
-	ws.Route(ws.GET("/{podNamespace}/{podID}/{containerName}").
-		To(s.proxy).
-		Operation("getExec"))
-	ws.Route(ws.POST("/{podNamespace}/{podID}/{containerName}").
-		To(s.proxy).
-		Operation("getExec"))
-	ws.Route(ws.GET("/{podNamespace}/{podID}/{uid}/{containerName}").
-		To(s.proxy).
-		Operation("getExec"))
-	ws.Route(ws.POST("/{podNamespace}/{podID}/{uid}/{containerName}").
-		To(s.proxy).
-		Operation("getExec"))
-	s.restfulCont.Add(ws)
+// AddRoute serves the kubelet endpoint if its feature is enabled.
+func (s *Server) AddRoute(route Route) {
+	if route.Feature != "" && !featuregate.DefaultFeatureGate.Enabled(route.Feature) {
+		klog.V(4).Infof("%s is not served as feature %s is disabled", route.Root, route.Feature)
+		return
+	}
 
-	ws = new(restful.WebService)
-	ws.Path("/attach")
-	ws.Route(ws.GET("/{podNamespace}/{podID}/{containerName}").
-		To(s.proxy).
-		Operation("getAttach"))
-	ws.Route(ws.POST("/{podNamespace}/{podID}/{containerName}").
-		To(s.proxy).
-		Operation("getAttach"))
-	ws.Route(ws.GET("/{podNamespace}/{podID}/{uid}/{containerName}").
-		To(s.proxy).
-		Operation("getAttach"))
-	ws.Route(ws.POST("/{podNamespace}/{podID}/{uid}/{containerName}").
-		To(s.proxy).
-		Operation("getAttach"))
+	ws := new(restful.WebService)
+	ws.Path(route.Root)
+	if len(route.Produces) != 0 {
+		ws.Produces(route.Produces...)
+	}
+	for _, path := range route.Paths {
+		for _, method := range route.Methods {
+			ws.Route(ws.Method(method).Path(path).
+				To(s.serveRoute(route)).
+				Operation(route.Operation))
+		}
+	}
 	s.restfulCont.Add(ws)
+}
 
-	ws = new(restful.WebService)
-	ws.Path("/portForward")
-	ws.Route(ws.GET("/{podNamespace}/{podID}").
-		To(s.proxy).
-		Operation("getPortForward"))
-	ws.Route(ws.POST("/{podNamespace}/{podID}").
-		To(s.proxy).
-		Operation("getPortForward"))
-	ws.Route(ws.GET("/{podNamespace}/{podID}/{uid}").
-		To(s.proxy).
-		Operation("getPortForward"))
-	ws.Route(ws.POST("/{podNamespace}/{podID}/{uid}").
-		To(s.proxy).
-		Operation("getPortForward"))
-	s.restfulCont.Add(ws)
+// serveRoute authorizes the tenant and serves the request of the route.
+func (s *Server) serveRoute(route Route) restful.RouteFunction {
+	return func(req *restful.Request, resp *restful.Response) {
+		// there must be a peer certificate in the tls connection
+		if req.Request.TLS == nil || len(req.Request.TLS.PeerCertificates) == 0 {
+			resp.ResponseWriter.WriteHeader(http.StatusForbidden)
+			return
+		}
+		tenantName := req.Request.TLS.PeerCertificates[0].Subject.CommonName
+		if route.Allowed != nil && !route.Allowed(tenantName) {
+			klog.Warningf("tenant %s is not allowed to access %s", tenantName, req.Request.URL.Path)
+			http.Error(resp.ResponseWriter, fmt.Sprintf("tenant %s is not allowed to access %s", tenantName, route.Root), http.StatusForbidden)
+			return
+		}
+		if route.Handler != nil {
+			route.Handler(s, req, resp)
+			return
+		}
+		s.proxy(route, req, resp)
+	}
 }
 
-func (s *Server) proxy(req *restful.Request, resp *restful.Response) {
+func (s *Server) proxy(route Route, req *restful.Request, resp *restful.Response) {
 	klog.V(4).Infof("request %+v", req.Request.URL)
 
 	var host string
@@ -129,22 +230,39 @@ func (s *Server) proxy(req *restful.Request, resp *restful.Response) {
 	action, podNamespace := extractFromPath(req)
 	tenantName := req.Request.TLS.PeerCertificates[0].Subject.CommonName
 
+	session, w := s.auditor.Begin(req, resp.ResponseWriter, tenantName, action)
+	defer session.End()
+
 	if s.config.KubeletClientCert != nil {
 		klog.Info("will forward request to kubelet")
 		host = s.config.KubeletServerHost
 		// forward request to kubelet
 		req.Request.URL.Host = host
 		req.Request.URL.Scheme = "https"
-		TranslatePath(req, tenantName)
+		if route.TranslateKubelet != nil {
+			if err := route.TranslateKubelet(req, tenantName); err != nil {
+				klog.Errorf("fail to translate url path for kubelet: %s", err)
+				session.Fail(err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				if s.enableMetrics {
+					failureCounter.WithLabelValues(host, action, tenantName, podNamespace, errorTranslatingPath).Inc()
+				}
+				return
+			}
+		}
 	} else {
 		klog.Info("will forward request to super apiserver")
 		host = s.superAPIServerAddress.Host
 		// forward request to super apiserver
-		err := TranslatePathForSuper(req, tenantName)
+		err := fmt.Errorf("unsupport action %s", action)
+		if route.TranslateSuper != nil {
+			err = route.TranslateSuper(req, tenantName)
+		}
 		if err != nil {
 			klog.Errorf("fail to translate url path for super control plane: %s", err)
-			resp.ResponseWriter.WriteHeader(http.StatusNotFound)
-			resp.ResponseWriter.Write([]byte(err.Error()))
+			session.Fail(err)
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(err.Error()))
 			if s.enableMetrics {
 				failureCounter.WithLabelValues(
 					s.superAPIServerAddress.Host, action, tenantName, podNamespace, errorTranslatingPath).
@@ -167,6 +285,7 @@ func (s *Server) proxy(req *restful.Request, resp *restful.Response) {
 		podNamespace:  podNamespace,
 		host:          host,
 		enableMetrics: s.enableMetrics,
+		audit:         session,
 	}
 
 	if s.enableMetrics {
@@ -178,7 +297,7 @@ func (s *Server) proxy(req *restful.Request, resp *restful.Response) {
 			httpstream.IsUpgradeRequest(req.Request) /*upgradeRequired*/, httpResponder)
 	}
 
-	handler.ServeHTTP(resp.ResponseWriter, req.Request)
+	handler.ServeHTTP(w, req.Request)
 }
 
 type responder struct {
@@ -187,6 +306,7 @@ type responder struct {
 	podNamespace  string
 	host          string
 	enableMetrics bool
+	audit         *auditSession
 }
 
 func (r *responder) Error(w http.ResponseWriter, req *http.Request, err error) {
@@ -195,6 +315,7 @@ func (r *responder) Error(w http.ResponseWriter, req *http.Request, err error) {
 			r.podNamespace, errorProxyingRequest).
 			Inc()
 	}
+	r.audit.Fail(err)
 	klog.Errorf("Error while proxying request: %v", err)
 	http.Error(w, err.Error(), http.StatusInternalServerError)
 }
@@ -0,0 +1,150 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/vn-agent/config"
+)
+
+func newTenantRequest(method, path, tenantName string) *http.Request {
+	req := httptest.NewRequest(method, path, nil)
+	req.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: tenantName}}},
+	}
+	return req
+}
+
+func TestRoutes(t *testing.T) {
+	defer func(gate featuregate.FeatureGate) {
+		featuregate.DefaultFeatureGate = gate
+	}(featuregate.DefaultFeatureGate)
+
+	for _, tc := range []struct {
+		name         string
+		features     map[string]bool
+		method       string
+		path         string
+		tenant       string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "disabled feature is not served",
+			method:       http.MethodGet,
+			path:         "/configz",
+			tenant:       "t1",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "tenant not allowed",
+			features:     map[string]bool{featuregate.KubeletDebugHandlers: true},
+			method:       http.MethodGet,
+			path:         "/debug/pprof/heap",
+			tenant:       "t2",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "configz is not served in super apiserver proxy mode",
+			features:     map[string]bool{featuregate.KubeletConfigz: true},
+			method:       http.MethodGet,
+			path:         "/configz",
+			tenant:       "t1",
+			expectedCode: http.StatusNotFound,
+			expectedBody: "only served when forwarding to kubelet",
+		},
+		{
+			name:         "checkpoint is not served in super apiserver proxy mode",
+			features:     map[string]bool{featuregate.KubeletCheckpoint: true},
+			method:       http.MethodPost,
+			path:         "/checkpoint/default/pod/c",
+			tenant:       "t1",
+			expectedCode: http.StatusNotFound,
+			expectedBody: "unsupport action checkpoint",
+		},
+		{
+			name:         "missing client certificate",
+			features:     map[string]bool{featuregate.KubeletCheckpoint: true},
+			method:       http.MethodPost,
+			path:         "/checkpoint/default/pod/c",
+			expectedCode: http.StatusForbidden,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gate, err := featuregate.NewFeatureGate(tc.features)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			featuregate.DefaultFeatureGate = gate
+
+			s := &Server{
+				config:                &config.Config{},
+				restfulCont:           restful.NewContainer(),
+				superAPIServerAddress: &url.URL{Scheme: "https", Host: "super:6443"},
+				debugTenants:          []string{"t1"},
+			}
+			s.InstallHandlers()
+
+			req := newTenantRequest(tc.method, tc.path, tc.tenant)
+			if tc.tenant == "" {
+				req.TLS = nil
+			}
+			recorder := httptest.NewRecorder()
+			s.ServeHTTP(recorder, req)
+			if recorder.Code != tc.expectedCode {
+				t.Errorf("expected code %d, got %d: %s", tc.expectedCode, recorder.Code, recorder.Body.String())
+			}
+			if !strings.Contains(recorder.Body.String(), tc.expectedBody) {
+				t.Errorf("expected body to contain %q, got %q", tc.expectedBody, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestFilterConfigz(t *testing.T) {
+	body := []byte(`{"kubeletconfig":{"maxPods":110,"staticPodPath":"/etc/kubernetes/manifests","authentication":{"x509":{"clientCAFile":"/etc/ca.crt"}},"featureGates":{"A":true}}}`)
+	filtered, err := filterConfigz(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got map[string]map[string]interface{}
+	if err := json.Unmarshal(filtered, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]map[string]interface{}{
+		"kubeletconfig": {
+			"maxPods":      float64(110),
+			"featureGates": map[string]interface{}{"A": true},
+		},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
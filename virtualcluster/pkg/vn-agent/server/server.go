@@ -40,6 +40,10 @@ type Server struct {
 	superAPIServerAddress *url.URL
 	restConfig            *rest.Config
 	enableMetrics         bool
+	// auditor records the interactive sessions, nil if auditing is disabled.
+	auditor *Auditor
+	// debugTenants are the tenants allowed to access the kubelet debug handlers.
+	debugTenants []string
 }
 
 // ServeHTTP responds to HTTP requests on the vn-agent.
@@ -59,14 +63,33 @@ func NewServer(cfg *config.Config, serverOption *options.ServerOption) (*Server,
 		restfulCont:   restful.NewContainer(),
 		config:        cfg,
 		enableMetrics: serverOption.EnableMetrics,
+		debugTenants:  serverOption.DebugTenants,
 	}
 
 	server.InstallHandlers()
 
+	var sinks []AuditSink
+	if serverOption.AuditLogPath != "" {
+		sink, err := NewFileAuditSink(serverOption.AuditLogPath)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if serverOption.AuditWebhookURL != "" {
+		sinks = append(sinks, NewWebhookAuditSink(serverOption.AuditWebhookURL))
+	}
+	if len(sinks) != 0 {
+		server.auditor = NewAuditor(serverOption.AuditSessionRecordingDir, sinks...)
+	}
+
 	if server.config.KubeletClientCert != nil {
 		server.transport = &http.Transport{
 			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true, //nolint:gosec
+				// the kubelet serving certificate is only verified if its CA is given.
+				InsecureSkipVerify: server.config.KubeletCAs == nil, //nolint:gosec
+				RootCAs:            server.config.KubeletCAs,
+				ServerName:         server.config.KubeletServerName,
 				Certificates:       []tls.Certificate{*server.config.KubeletClientCert},
 			},
 		}
@@ -0,0 +1,274 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	// namespaceLabel is the label of the pod namespace in kubelet metrics.
+	namespaceLabel = "namespace"
+)
+
+// filterFunc filters the kubelet response body for the tenant, it returns the filtered
+// body and its content type.
+type filterFunc func(body []byte, tenantName string) ([]byte, string, error)
+
+// stats fetches the stats or metrics from kubelet and filters them to the pods of
+// the calling tenant. The super cluster namespaces are renamed to the tenant namespaces.
+func (s *Server) stats(req *restful.Request, resp *restful.Response) {
+	if strings.HasPrefix(req.Request.URL.Path, "/stats/") {
+		s.serveFiltered(req, resp, func(body []byte, tenantName string) ([]byte, string, error) {
+			filtered, err := filterSummary(body, s.tenantNamespaces(tenantName))
+			return filtered, restful.MIME_JSON, err
+		})
+		return
+	}
+	s.serveFiltered(req, resp, func(body []byte, tenantName string) ([]byte, string, error) {
+		filtered, err := filterMetrics(body, s.tenantNamespaces(tenantName))
+		return filtered, string(expfmt.FmtText), err
+	})
+}
+
+// serveFiltered fetches the request path from kubelet and serves the response filtered
+// for the calling tenant.
+func (s *Server) serveFiltered(req *restful.Request, resp *restful.Response, filter filterFunc) {
+	klog.V(4).Infof("request %+v", req.Request.URL)
+
+	// there must be a peer certificate in the tls connection
+	if req.Request.TLS == nil || len(req.Request.TLS.PeerCertificates) == 0 {
+		resp.ResponseWriter.WriteHeader(http.StatusForbidden)
+		return
+	}
+	tenantName := req.Request.TLS.PeerCertificates[0].Subject.CommonName
+	action, _ := extractFromPath(req)
+
+	// the super apiserver doesn't know which node vn-agent runs on.
+	if s.config.KubeletClientCert == nil {
+		http.Error(resp.ResponseWriter, fmt.Sprintf("%s is only served when forwarding to kubelet", req.Request.URL.Path), http.StatusNotFound)
+		return
+	}
+
+	host := s.config.KubeletServerHost
+	u := url.URL{Scheme: "https", Host: host, Path: req.Request.URL.Path, RawQuery: req.Request.URL.RawQuery}
+	client := &http.Client{Transport: s.transport}
+	if s.enableMetrics {
+		client.Transport = getRoundTripper(s.transport, host, tenantName, action, "")
+	}
+	kubeletResp, err := client.Get(u.String())
+	if err != nil {
+		s.filterError(resp, host, action, tenantName, errorProxyingRequest, err)
+		return
+	}
+	defer kubeletResp.Body.Close()
+	body, err := ioutil.ReadAll(kubeletResp.Body)
+	if err != nil {
+		s.filterError(resp, host, action, tenantName, errorProxyingRequest, err)
+		return
+	}
+	if kubeletResp.StatusCode != http.StatusOK {
+		resp.ResponseWriter.Header().Set("Content-Type", kubeletResp.Header.Get("Content-Type"))
+		resp.ResponseWriter.WriteHeader(kubeletResp.StatusCode)
+		resp.ResponseWriter.Write(body)
+		return
+	}
+
+	filtered, contentType, err := filter(body, tenantName)
+	if err != nil {
+		s.filterError(resp, host, action, tenantName, errorFilteringResponse, err)
+		return
+	}
+	resp.ResponseWriter.Header().Set("Content-Type", contentType)
+	resp.ResponseWriter.WriteHeader(http.StatusOK)
+	resp.ResponseWriter.Write(filtered)
+}
+
+func (s *Server) filterError(resp *restful.Response, host, action, tenantName, reason string, err error) {
+	if s.enableMetrics {
+		failureCounter.WithLabelValues(host, action, tenantName, "", reason).Inc()
+	}
+	klog.Errorf("Error while serving %s for tenant %s: %v", action, tenantName, err)
+	http.Error(resp.ResponseWriter, err.Error(), http.StatusInternalServerError)
+}
+
+// namespaceMapper returns the tenant namespace of a super cluster namespace, it returns
+// false if the namespace doesn't belong to the tenant.
+type namespaceMapper func(namespace string) (string, bool)
+
+// tenantNamespaces maps the super cluster namespaces to the namespaces of the tenant by the
+// owner annotations the syncer sets, as the name of a long namespace is shortened.
+func (s *Server) tenantNamespaces(tenantName string) namespaceMapper {
+	return func(namespace string) (string, bool) {
+		if s.config.NamespaceLister == nil {
+			return "", false
+		}
+		ns, err := s.config.NamespaceLister.Get(namespace)
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				klog.Errorf("failed to get namespace %s: %v", namespace, err)
+			}
+			return "", false
+		}
+		cluster, tenantNamespace := conversion.GetVirtualOwner(ns)
+		if cluster != tenantName || tenantNamespace == "" {
+			return "", false
+		}
+		return tenantNamespace, true
+	}
+}
+
+// filterSummary keeps the pods of the tenant in the kubelet stats summary. The summary is
+// decoded loosely so that the fields unknown to vn-agent are kept.
+func filterSummary(body []byte, toTenant namespaceMapper) ([]byte, error) {
+	var summary map[string]json.RawMessage
+	if err := json.Unmarshal(body, &summary); err != nil {
+		return nil, fmt.Errorf("failed to decode stats summary: %v", err)
+	}
+	var pods []map[string]json.RawMessage
+	if raw, exists := summary["pods"]; exists {
+		if err := json.Unmarshal(raw, &pods); err != nil {
+			return nil, fmt.Errorf("failed to decode pod stats: %v", err)
+		}
+	}
+
+	tenantPods := make([]map[string]json.RawMessage, 0, len(pods))
+	for _, pod := range pods {
+		keep, err := renameRef(pod, "podRef", toTenant)
+		if err != nil {
+			return nil, err
+		}
+		if !keep {
+			continue
+		}
+		if raw, exists := pod["volume"]; exists {
+			var volumes []map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &volumes); err != nil {
+				return nil, fmt.Errorf("failed to decode volume stats: %v", err)
+			}
+			for _, volume := range volumes {
+				if _, err := renameRef(volume, "pvcRef", toTenant); err != nil {
+					return nil, err
+				}
+			}
+			if pod["volume"], err = json.Marshal(volumes); err != nil {
+				return nil, err
+			}
+		}
+		tenantPods = append(tenantPods, pod)
+	}
+
+	raw, err := json.Marshal(tenantPods)
+	if err != nil {
+		return nil, err
+	}
+	summary["pods"] = raw
+	return json.Marshal(summary)
+}
+
+// renameRef renames the namespace of the object reference in the given field to the
+// tenant namespace. It returns false if the reference doesn't belong to the tenant.
+func renameRef(obj map[string]json.RawMessage, field string, toTenant namespaceMapper) (bool, error) {
+	raw, exists := obj[field]
+	if !exists {
+		return false, nil
+	}
+	var ref map[string]interface{}
+	if err := json.Unmarshal(raw, &ref); err != nil {
+		return false, fmt.Errorf("failed to decode %s: %v", field, err)
+	}
+	namespace, _ := ref["namespace"].(string)
+	tenantNamespace, ok := toTenant(namespace)
+	if !ok {
+		return false, nil
+	}
+	ref["namespace"] = tenantNamespace
+	renamed, err := json.Marshal(ref)
+	if err != nil {
+		return false, err
+	}
+	obj[field] = renamed
+	return true, nil
+}
+
+// filterMetrics keeps the kubelet metrics of the tenant pods. The metrics without
+// namespace label, e.g. the node metrics, are kept while the metrics of the other
+// tenants and of the non-pod cgroups are dropped.
+func filterMetrics(body []byte, toTenant namespaceMapper) ([]byte, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metrics: %v", err)
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		family := families[name]
+		metrics := make([]*dto.Metric, 0, len(family.Metric))
+		for _, m := range family.Metric {
+			if filterMetricNamespace(m, toTenant) {
+				metrics = append(metrics, m)
+			}
+		}
+		if len(metrics) == 0 {
+			continue
+		}
+		family.Metric = metrics
+		if _, err := expfmt.MetricFamilyToText(&buf, family); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// filterMetricNamespace renames the namespace label of the metric to the tenant namespace,
+// it returns false if the metric belongs to another tenant.
+func filterMetricNamespace(m *dto.Metric, toTenant namespaceMapper) bool {
+	for _, label := range m.Label {
+		if label.GetName() != namespaceLabel {
+			continue
+		}
+		tenantNamespace, ok := toTenant(label.GetValue())
+		if !ok {
+			return false
+		}
+		label.Value = &tenantNamespace
+		return true
+	}
+	return true
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/vn-agent/config"
+)
+
+// longNamespace is a tenant namespace whose super cluster namespace name is shortened.
+const longNamespace = "a-tenant-namespace-whose-name-is-too-long-to-be-prefixed-by-the-tenant"
+
+// newTestServer creates a server listing the super cluster namespaces of the tenants t1 and t2.
+func newTestServer(t *testing.T) *Server {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for name, owner := range map[string][2]string{
+		"t1-default":   {"t1", "default"},
+		"t1-7f3a9c2e1": {"t1", longNamespace},
+		"t2-default":   {"t2", "default"},
+		"kube-system":  {},
+	} {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if owner[0] != "" {
+			ns.Annotations = map[string]string{constants.LabelCluster: owner[0], constants.LabelNamespace: owner[1]}
+		}
+		if err := indexer.Add(ns); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return &Server{config: &config.Config{NamespaceLister: listersv1.NewNamespaceLister(indexer)}}
+}
+
+func TestFilterSummary(t *testing.T) {
+	body := `{
+  "node": {"nodeName": "n1", "cpu": {"usageNanoCores": 100}},
+  "pods": [
+    {"podRef": {"name": "a", "namespace": "t1-default", "uid": "1"}, "cpu": {"usageNanoCores": 10},
+     "volume": [{"name": "data", "pvcRef": {"name": "pvc", "namespace": "t1-default"}}]},
+    {"podRef": {"name": "b", "namespace": "t2-default", "uid": "2"}},
+    {"podRef": {"name": "c", "namespace": "kube-system", "uid": "3"}},
+    {"podRef": {"name": "d", "namespace": "t1-7f3a9c2e1", "uid": "4"}}
+  ]
+}`
+	filtered, err := filterSummary([]byte(body), newTestServer(t).tenantNamespaces("t1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var summary struct {
+		Node struct {
+			NodeName string `json:"nodeName"`
+		} `json:"node"`
+		Pods []struct {
+			PodRef struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"podRef"`
+			CPU struct {
+				UsageNanoCores int `json:"usageNanoCores"`
+			} `json:"cpu"`
+			Volume []struct {
+				PVCRef struct {
+					Namespace string `json:"namespace"`
+				} `json:"pvcRef"`
+			} `json:"volume"`
+		} `json:"pods"`
+	}
+	if err := json.Unmarshal(filtered, &summary); err != nil {
+		t.Fatalf("failed to decode filtered summary: %v", err)
+	}
+	if summary.Node.NodeName != "n1" {
+		t.Errorf("expected the node stats to be kept, got %s", filtered)
+	}
+	if len(summary.Pods) != 2 {
+		t.Fatalf("expected only the tenant pods, got %s", filtered)
+	}
+	if summary.Pods[1].PodRef.Name != "d" || summary.Pods[1].PodRef.Namespace != longNamespace {
+		t.Errorf("expected the shortened namespace to be renamed, got %+v", summary.Pods[1].PodRef)
+	}
+	pod := summary.Pods[0]
+	if pod.PodRef.Name != "a" || pod.PodRef.Namespace != "default" || pod.CPU.UsageNanoCores != 10 {
+		t.Errorf("unexpected pod stats %+v", pod)
+	}
+	if len(pod.Volume) != 1 || pod.Volume[0].PVCRef.Namespace != "default" {
+		t.Errorf("expected the pvc namespace to be renamed, got %+v", pod.Volume)
+	}
+}
+
+func TestFilterMetrics(t *testing.T) {
+	body := `# HELP node_cpu_usage_seconds_total Cumulative cpu time consumed by the node in core-seconds
+# TYPE node_cpu_usage_seconds_total counter
+node_cpu_usage_seconds_total 100 1633253812125
+# HELP pod_cpu_usage_seconds_total Cumulative cpu time consumed by the pod in core-seconds
+# TYPE pod_cpu_usage_seconds_total counter
+pod_cpu_usage_seconds_total{namespace="t1-default",pod="a"} 10 1633253812125
+pod_cpu_usage_seconds_total{namespace="t2-default",pod="b"} 20 1633253812125
+# HELP container_memory_usage_bytes Current memory usage in bytes
+# TYPE container_memory_usage_bytes gauge
+container_memory_usage_bytes{container="",id="/system.slice",namespace="",pod=""} 30
+container_memory_usage_bytes{container="c",id="/kubepods/a",namespace="t1-default",pod="a"} 40
+container_memory_usage_bytes{container="c",id="/kubepods/d",namespace="t1-7f3a9c2e1",pod="d"} 50
+`
+	filtered, err := filterMetrics([]byte(body), newTestServer(t).tenantNamespaces("t1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := string(filtered)
+	for _, expected := range []string{
+		`node_cpu_usage_seconds_total 100 1633253812125`,
+		`pod_cpu_usage_seconds_total{namespace="default",pod="a"} 10 1633253812125`,
+		`container_memory_usage_bytes{container="c",id="/kubepods/a",namespace="default",pod="a"} 40`,
+		`container_memory_usage_bytes{container="c",id="/kubepods/d",namespace="` + longNamespace + `",pod="d"} 50`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in filtered metrics:\n%s", expected, out)
+		}
+	}
+	for _, unexpected := range []string{"t2-default", "/system.slice"} {
+		if strings.Contains(out, unexpected) {
+			t.Errorf("unexpected %q in filtered metrics:\n%s", unexpected, out)
+		}
+	}
+}
@@ -17,10 +17,14 @@ limitations under the License.
 package webhook
 
 import (
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/webhook/virtualcluster"
 )
 
 func init() {
 	// AddToManagerFuncs is a list of functions to create webhook and add them to a manager.
-	AddToManagerFuncs = append(AddToManagerFuncs, virtualcluster.Add)
+	AddToManagerFuncs = append(AddToManagerFuncs, func(m manager.Manager, opts Options) error {
+		return virtualcluster.Add(m, opts.CertDir, opts.CertManagerCertificate)
+	})
 }
@@ -25,6 +25,8 @@ import (
 	"crypto/x509/pkix"
 	"encoding/pem"
 	"fmt"
+	"io/ioutil"
+	"math"
 	"math/big"
 	"os"
 	"path/filepath"
@@ -35,6 +37,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -52,7 +55,19 @@ const (
 	VCWebhookServiceName      = "virtualcluster-webhook-service"
 	DefaultVCWebhookServiceNs = "vc-manager"
 	VCWebhookCfgName          = "virtualcluster-validating-webhook-configuration"
+	VCMutatingWebhookCfgName  = "virtualcluster-mutating-webhook-configuration"
 	VCWebhookCSRName          = "virtualcluster-webhook-csr"
+
+	// VCWebhookCertValidity is the validity of the self-signed serving certificate
+	VCWebhookCertValidity = 365 * 24 * time.Hour
+	// VCWebhookCertRenewBefore is how long before expiry the self-signed serving certificate is renewed
+	VCWebhookCertRenewBefore = 30 * 24 * time.Hour
+	// vcWebhookCertCheckPeriod is how often the expiry of the self-signed serving certificate is checked
+	vcWebhookCertCheckPeriod = time.Hour
+
+	// certManagerInjectCAFromAnnotation asks the cert-manager CA injector to set the CA of
+	// the Certificate as the caBundle of the webhook configurations
+	certManagerInjectCAFromAnnotation = "cert-manager.io/inject-ca-from"
 )
 
 var (
@@ -69,28 +84,46 @@ func init() {
 	}
 }
 
-// Add adds the webhook server to the manager as a runnable
-func Add(mgr manager.Manager, certDir string) error {
+// Add adds the webhook server to the manager as a runnable. If certManagerCertificate, the
+// namespace/name of a cert-manager Certificate, is set, its secret is expected to be mounted
+// in certDir and its CA is injected into the webhook configurations by cert-manager.
+// Otherwise a self-signed serving certificate is generated and renewed ahead of expiry.
+func Add(mgr manager.Manager, certDir, certManagerCertificate string) error {
 	// 1. create the webhook service
 	if err := createVirtualClusterWebhookService(mgr.GetClient()); err != nil {
 		return fmt.Errorf("fail to create virtualcluster webhook service: %s", err)
 	}
 
 	// 2. generate the serving certificate for the webhook server
-	caPEM, genCrtErr := genCertificate(certDir)
-	if genCrtErr != nil {
-		return fmt.Errorf("fail to generate certificates for webhook server: %s", genCrtErr)
+	var caPEM []byte
+	if certManagerCertificate != "" {
+		log.Info("serving certificate is provided by cert-manager", "certificate", certManagerCertificate)
+	} else {
+		var genCrtErr error
+		caPEM, genCrtErr = genCertificate(certDir)
+		if genCrtErr != nil {
+			return fmt.Errorf("fail to generate certificates for webhook server: %s", genCrtErr)
+		}
+		if err := mgr.Add(&certRenewer{
+			client:  mgr.GetClient(),
+			reader:  mgr.GetAPIReader(),
+			certDir: certDir,
+			caPEM:   caPEM,
+		}); err != nil {
+			return err
+		}
 	}
 
-	// 3. create the ValidatingWebhookConfiguration
-	log.Info(fmt.Sprintf("will create validatingwebhookconfiguration/%s", VCWebhookCfgName))
-	if err := createValidatingWebhookConfiguration(mgr.GetClient(), caPEM); err != nil {
-		return fmt.Errorf("fail to create validating webhook configuration: %s", err)
+	// 3. create or update the webhook configurations
+	if err := applyWebhookConfigurations(context.TODO(), mgr.GetClient(), mgr.GetAPIReader(), caPEM, certManagerCertificate); err != nil {
+		return fmt.Errorf("fail to apply webhook configurations: %s", err)
 	}
-	log.Info(fmt.Sprintf("successfully created validatingwebhookconfiguration/%s", VCWebhookCfgName))
 
-	// 4. register the validating webhook
-	return (&tenancyv1alpha1.VirtualCluster{}).SetupWebhookWithManager(mgr)
+	// 4. register the defaulting and validating webhooks
+	if err := (&tenancyv1alpha1.VirtualCluster{}).SetupWebhookWithManager(mgr); err != nil {
+		return err
+	}
+	return (&tenancyv1alpha1.ClusterVersion{}).SetupWebhookWithManager(mgr)
 }
 
 // createVirtualClusterWebhookService creates the service for exposing the webhook server
@@ -126,71 +159,211 @@ func createVirtualClusterWebhookService(client client.Client) error {
 	return nil
 }
 
-// createValidatingWebhookConfiguration creates the validatingwebhookconfiguration for the webhook
-func createValidatingWebhookConfiguration(client client.Client, caPEM []byte) error {
-	validatePath := "/validate-tenancy-x-k8s-io-v1alpha1-virtualcluster"
+// webhookClientConfig returns the client config of the webhook served on path
+func webhookClientConfig(path string, caPEM []byte) admv1.WebhookClientConfig {
 	svcPort := int32(constants.VirtualClusterWebhookPort)
+	return admv1.WebhookClientConfig{
+		Service: &admv1.ServiceReference{
+			Name:      VCWebhookServiceName,
+			Namespace: VCWebhookServiceNs,
+			Path:      &path,
+			Port:      &svcPort,
+		},
+		CABundle: caPEM,
+	}
+}
+
+// webhookRules returns the rules matching the operations on the resource of tenancy.x-k8s.io
+func webhookRules(resource string, operations ...admv1.OperationType) []admv1.RuleWithOperations {
+	return []admv1.RuleWithOperations{
+		{
+			Operations: operations,
+			Rule: admv1.Rule{
+				APIGroups:   []string{"tenancy.x-k8s.io"},
+				APIVersions: []string{"v1alpha1"},
+				Resources:   []string{resource},
+			},
+		},
+	}
+}
+
+// webhookConfigurations returns the mutating and validating webhook configurations of
+// the VirtualClusters and the ClusterVersions
+func webhookConfigurations(caPEM []byte, certManagerCertificate string) (*admv1.MutatingWebhookConfiguration, *admv1.ValidatingWebhookConfiguration) {
 	// reject request if the webhook doesn't work
 	failPolicy := admv1.Fail
 	sideEffortsNone := admv1.SideEffectClassNone
+	labels := map[string]string{
+		"virtualcluster-webhook": "true",
+	}
+	var annotations map[string]string
+	if certManagerCertificate != "" {
+		annotations = map[string]string{certManagerInjectCAFromAnnotation: certManagerCertificate}
+	}
 
-	vwhCfg := admv1.ValidatingWebhookConfiguration{
+	mwhCfg := &admv1.MutatingWebhookConfiguration{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: VCWebhookCfgName,
-			Labels: map[string]string{
-				"virtualcluster-webhook": "true",
+			Name:        VCMutatingWebhookCfgName,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Webhooks: []admv1.MutatingWebhook{
+			{
+				Name:                    "virtualcluster.mutating.webhook",
+				ClientConfig:            webhookClientConfig("/mutate-tenancy-x-k8s-io-v1alpha1-virtualcluster", caPEM),
+				FailurePolicy:           &failPolicy,
+				SideEffects:             &sideEffortsNone,
+				AdmissionReviewVersions: []string{"v1", "v1beta1"},
+				Rules:                   webhookRules("virtualclusters", admv1.Create, admv1.Update),
+			},
+			{
+				Name:                    "clusterversion.mutating.webhook",
+				ClientConfig:            webhookClientConfig("/mutate-tenancy-x-k8s-io-v1alpha1-clusterversion", caPEM),
+				FailurePolicy:           &failPolicy,
+				SideEffects:             &sideEffortsNone,
+				AdmissionReviewVersions: []string{"v1", "v1beta1"},
+				Rules:                   webhookRules("clusterversions", admv1.Create, admv1.Update),
 			},
 		},
+	}
+
+	vwhCfg := &admv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        VCWebhookCfgName,
+			Labels:      labels,
+			Annotations: annotations,
+		},
 		Webhooks: []admv1.ValidatingWebhook{
 			{
-				Name: "virtualcluster.validating.webhook",
-				ClientConfig: admv1.WebhookClientConfig{
-					Service: &admv1.ServiceReference{
-						Name:      VCWebhookServiceName,
-						Namespace: VCWebhookServiceNs,
-						Path:      &validatePath,
-						Port:      &svcPort,
-					},
-					CABundle: caPEM,
-				},
+				Name:                    "virtualcluster.validating.webhook",
+				ClientConfig:            webhookClientConfig("/validate-tenancy-x-k8s-io-v1alpha1-virtualcluster", caPEM),
 				FailurePolicy:           &failPolicy,
 				SideEffects:             &sideEffortsNone,
 				AdmissionReviewVersions: []string{"v1", "v1beta1"},
-
-				Rules: []admv1.RuleWithOperations{
-					{
-						Operations: []admv1.OperationType{
-							admv1.OperationAll,
-						},
-						Rule: admv1.Rule{
-							APIGroups:   []string{"tenancy.x-k8s.io"},
-							APIVersions: []string{"v1alpha1"},
-							Resources:   []string{"virtualclusters"},
-						},
-					},
-				},
+				Rules:                   webhookRules("virtualclusters", admv1.OperationAll),
+			},
+			{
+				Name:                    "clusterversion.validating.webhook",
+				ClientConfig:            webhookClientConfig("/validate-tenancy-x-k8s-io-v1alpha1-clusterversion", caPEM),
+				FailurePolicy:           &failPolicy,
+				SideEffects:             &sideEffortsNone,
+				AdmissionReviewVersions: []string{"v1", "v1beta1"},
+				Rules:                   webhookRules("clusterversions", admv1.Create, admv1.Update),
 			},
 		},
 	}
+	return mwhCfg, vwhCfg
+}
 
-	if err := client.Create(context.TODO(), &vwhCfg); err != nil {
-		if !apierrors.IsAlreadyExists(err) {
+// applyWebhookConfigurations creates or updates the webhook configurations. If caPEM is empty,
+// the caBundle injected by cert-manager into the existing configurations is kept.
+func applyWebhookConfigurations(ctx context.Context, cli client.Client, reader client.Reader, caPEM []byte, certManagerCertificate string) error {
+	mwhCfg, vwhCfg := webhookConfigurations(caPEM, certManagerCertificate)
+
+	existingMwhCfg := &admv1.MutatingWebhookConfiguration{}
+	err := reader.Get(ctx, client.ObjectKey{Name: mwhCfg.Name}, existingMwhCfg)
+	switch {
+	case apierrors.IsNotFound(err):
+		if err := cli.Create(ctx, mwhCfg); err != nil {
 			return err
 		}
-		log.Info(fmt.Sprintf("validatingwebhookconfiguration/%s already exist, need remove firstly", VCWebhookCfgName))
-		err := client.Delete(context.TODO(), &vwhCfg)
-		if err != nil {
+	case err != nil:
+		return err
+	default:
+		mwhCfg.ResourceVersion = existingMwhCfg.ResourceVersion
+		for i := range mwhCfg.Webhooks {
+			if len(caPEM) == 0 && i < len(existingMwhCfg.Webhooks) {
+				mwhCfg.Webhooks[i].ClientConfig.CABundle = existingMwhCfg.Webhooks[i].ClientConfig.CABundle
+			}
+		}
+		if err := cli.Update(ctx, mwhCfg); err != nil {
 			return err
 		}
+	}
+	log.Info(fmt.Sprintf("successfully applied mutatingwebhookconfiguration/%s", mwhCfg.Name))
 
-		err = client.Create(context.TODO(), &vwhCfg)
-		if err != nil {
+	existingVwhCfg := &admv1.ValidatingWebhookConfiguration{}
+	err = reader.Get(ctx, client.ObjectKey{Name: vwhCfg.Name}, existingVwhCfg)
+	switch {
+	case apierrors.IsNotFound(err):
+		if err := cli.Create(ctx, vwhCfg); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	default:
+		vwhCfg.ResourceVersion = existingVwhCfg.ResourceVersion
+		for i := range vwhCfg.Webhooks {
+			if len(caPEM) == 0 && i < len(existingVwhCfg.Webhooks) {
+				vwhCfg.Webhooks[i].ClientConfig.CABundle = existingVwhCfg.Webhooks[i].ClientConfig.CABundle
+			}
+		}
+		if err := cli.Update(ctx, vwhCfg); err != nil {
 			return err
 		}
+	}
+	log.Info(fmt.Sprintf("successfully applied validatingwebhookconfiguration/%s", vwhCfg.Name))
+	return nil
+}
+
+// certRenewer renews the self-signed serving certificate of the webhook server before it
+// expires. The webhook server reloads the certificate files once they are rewritten.
+type certRenewer struct {
+	client  client.Client
+	reader  client.Reader
+	certDir string
+	// caPEM is the CA of the serving certificate
+	caPEM []byte
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, every replica renews the
+// certificate it serves
+func (r *certRenewer) NeedLeaderElection() bool {
+	return false
+}
+
+// Start implements manager.Runnable
+func (r *certRenewer) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := r.renewIfExpiring(ctx, time.Now()); err != nil {
+			log.Error(err, "fail to renew the serving certificate")
+		}
+	}, vcWebhookCertCheckPeriod)
+	return nil
+}
 
+// renewIfExpiring renews the serving certificate if it expires within VCWebhookCertRenewBefore
+func (r *certRenewer) renewIfExpiring(ctx context.Context, now time.Time) error {
+	certPath := filepath.Join(r.certDir, VCWebhookCertFileName)
+	certData, err := ioutil.ReadFile(filepath.Clean(certPath))
+	if err != nil {
+		return fmt.Errorf("could not read %q: %v", certPath, err)
+	}
+	certBlock, _ := pem.Decode(certData)
+	if certBlock == nil {
+		return fmt.Errorf("invalid certificate data")
+	}
+	crt, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return err
+	}
+	if crt.NotAfter.Sub(now) > VCWebhookCertRenewBefore {
 		return nil
 	}
-	log.Info(fmt.Sprintf("successfully created validatingwebhookconfiguration/%s", VCWebhookCfgName))
+
+	caPEM, certPEM, keyPEM, err := genSelfSignedCert()
+	if err != nil {
+		return err
+	}
+	// trust both the CAs until the webhook server loads the renewed certificate
+	if err := applyWebhookConfigurations(ctx, r.client, r.reader, append(caPEM, r.caPEM...), ""); err != nil {
+		return fmt.Errorf("fail to apply webhook configurations: %s", err)
+	}
+	if err := genCertAndKeyFile(certPEM, keyPEM, r.certDir); err != nil {
+		return fmt.Errorf("fail to generate certificate and key: %s", err)
+	}
+	r.caPEM = caPEM
+	log.Info("successfully renewed the serving certificate", "previous-not-after", crt.NotAfter)
 	return nil
 }
 
@@ -202,7 +375,11 @@ func genCertificate(certDir string) ([]byte, error) {
 		return nil, err
 	}
 
-	// 5. generate certificate files (i.e., tls.crt and tls.key)
+	// always remove first
+	if err := os.RemoveAll(certDir); err != nil {
+		return nil, fmt.Errorf("fail to remove certificates: %s", err)
+	}
+	// generate certificate files (i.e., tls.crt and tls.key)
 	if err := genCertAndKeyFile(certPEM, keyPEM, certDir); err != nil {
 		return nil, fmt.Errorf("fail to generate certificate and key: %s", err)
 	}
@@ -212,14 +389,24 @@ func genCertificate(certDir string) ([]byte, error) {
 
 // genSelfSignedCert generates the self signed Certificate/Key pair
 func genSelfSignedCert() (caPEMByte, certPEMByte, keyPEMByte []byte, err error) {
+	// the renewed certificates are told apart by their serial numbers
+	caSerial, err := rand.Int(rand.Reader, new(big.Int).SetInt64(math.MaxInt64))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	certSerial, err := rand.Int(rand.Reader, new(big.Int).SetInt64(math.MaxInt64))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
 	// CA config
 	ca := &x509.Certificate{
-		SerialNumber: big.NewInt(2021),
+		SerialNumber: caSerial,
 		Subject: pkix.Name{
 			Organization: []string{"tenancy.x-k8s.io"},
 		},
 		NotBefore:             time.Now(),
-		NotAfter:              time.Now().AddDate(10, 0, 0),
+		NotAfter:              time.Now().Add(VCWebhookCertValidity),
 		IsCA:                  true,
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
 		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
@@ -256,13 +443,13 @@ func genSelfSignedCert() (caPEMByte, certPEMByte, keyPEMByte []byte, err error)
 	// server cert config
 	cert := &x509.Certificate{
 		DNSNames:     dnsNames,
-		SerialNumber: big.NewInt(2021),
+		SerialNumber: certSerial,
 		Subject: pkix.Name{
 			CommonName:   commonName,
 			Organization: []string{"tenancy.x-k8s.io"},
 		},
 		NotBefore:    time.Now(),
-		NotAfter:     time.Now().AddDate(10, 0, 0),
+		NotAfter:     time.Now().Add(VCWebhookCertValidity),
 		SubjectKeyId: []byte{1, 2, 3, 4, 6},
 		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
 		KeyUsage:     x509.KeyUsageDigitalSignature,
@@ -300,10 +487,6 @@ func genSelfSignedCert() (caPEMByte, certPEMByte, keyPEMByte []byte, err error)
 
 // genCertAndKeyFile creates the serving certificate/key files for the webhook server
 func genCertAndKeyFile(certData, keyData []byte, certDir string) error {
-	// always remove first
-	if err := os.RemoveAll(certDir); err != nil {
-		return fmt.Errorf("fail to remove certificates: %s", err)
-	}
 	if err := os.MkdirAll(certDir, 0755); err != nil {
 		return fmt.Errorf("could not create directory %q to store certificates: %v", certDir, err)
 	}
@@ -326,6 +509,7 @@ func genCertAndKeyFile(certData, keyData []byte, certDir string) error {
 	if err != nil {
 		return fmt.Errorf("could not open %q: %v", keyPath, err)
 	}
+	defer kf.Close()
 
 	keyBlock, _ := pem.Decode(keyData)
 	if keyBlock == nil {
@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package virtualcluster
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	admv1 "k8s.io/api/admissionregistration/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func getTestWebhookConfigurations(t *testing.T, cli client.Client) (*admv1.MutatingWebhookConfiguration, *admv1.ValidatingWebhookConfiguration) {
+	t.Helper()
+	mwhCfg := &admv1.MutatingWebhookConfiguration{}
+	if err := cli.Get(context.TODO(), client.ObjectKey{Name: VCMutatingWebhookCfgName}, mwhCfg); err != nil {
+		t.Fatalf("fail to get mutatingwebhookconfiguration: %v", err)
+	}
+	vwhCfg := &admv1.ValidatingWebhookConfiguration{}
+	if err := cli.Get(context.TODO(), client.ObjectKey{Name: VCWebhookCfgName}, vwhCfg); err != nil {
+		t.Fatalf("fail to get validatingwebhookconfiguration: %v", err)
+	}
+	return mwhCfg, vwhCfg
+}
+
+func TestApplyWebhookConfigurationsCertManager(t *testing.T) {
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+	if err := applyWebhookConfigurations(context.TODO(), cli, cli, nil, "vc-manager/webhook-cert"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mwhCfg, vwhCfg := getTestWebhookConfigurations(t, cli)
+	if mwhCfg.Annotations[certManagerInjectCAFromAnnotation] != "vc-manager/webhook-cert" ||
+		vwhCfg.Annotations[certManagerInjectCAFromAnnotation] != "vc-manager/webhook-cert" {
+		t.Errorf("expected the CA to be injected by cert-manager")
+	}
+
+	// the caBundle injected by cert-manager is kept
+	injected := []byte("injected-ca")
+	for i := range vwhCfg.Webhooks {
+		vwhCfg.Webhooks[i].ClientConfig.CABundle = injected
+	}
+	if err := cli.Update(context.TODO(), vwhCfg); err != nil {
+		t.Fatalf("fail to update validatingwebhookconfiguration: %v", err)
+	}
+	if err := applyWebhookConfigurations(context.TODO(), cli, cli, nil, "vc-manager/webhook-cert"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, vwhCfg = getTestWebhookConfigurations(t, cli)
+	for _, wh := range vwhCfg.Webhooks {
+		if !bytes.Equal(wh.ClientConfig.CABundle, injected) {
+			t.Errorf("expected the caBundle of %s to be kept, got %q", wh.Name, wh.ClientConfig.CABundle)
+		}
+	}
+}
+
+func TestCertRenewer(t *testing.T) {
+	certDir := t.TempDir()
+	caPEM, err := genCertificate(certDir)
+	if err != nil {
+		t.Fatalf("fail to generate certificate: %v", err)
+	}
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+	if err := applyWebhookConfigurations(context.TODO(), cli, cli, caPEM, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := &certRenewer{client: cli, reader: cli, certDir: certDir, caPEM: caPEM}
+	certPath := filepath.Join(certDir, VCWebhookCertFileName)
+	certPEM, err := ioutil.ReadFile(certPath)
+	if err != nil {
+		t.Fatalf("fail to read certificate: %v", err)
+	}
+
+	// the certificate is not renewed ahead of the renewal window
+	if err := r.renewIfExpiring(context.TODO(), time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if renewed, _ := ioutil.ReadFile(certPath); !bytes.Equal(renewed, certPEM) {
+		t.Errorf("expected the certificate not to be renewed")
+	}
+
+	if err := r.renewIfExpiring(context.TODO(), time.Now().Add(VCWebhookCertValidity-VCWebhookCertRenewBefore+time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if renewed, _ := ioutil.ReadFile(certPath); bytes.Equal(renewed, certPEM) {
+		t.Errorf("expected the certificate to be renewed")
+	}
+	mwhCfg, vwhCfg := getTestWebhookConfigurations(t, cli)
+	for _, caBundle := range [][]byte{mwhCfg.Webhooks[0].ClientConfig.CABundle, vwhCfg.Webhooks[0].ClientConfig.CABundle} {
+		if !bytes.HasPrefix(caBundle, r.caPEM) || !bytes.HasSuffix(caBundle, caPEM) {
+			t.Errorf("expected both the renewed and the previous CAs to be trusted")
+		}
+	}
+}
@@ -20,16 +20,25 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
+// Options configures the serving certificate of the webhooks
+type Options struct {
+	// CertDir is the directory holding the serving certificate
+	CertDir string
+	// CertManagerCertificate is the namespace/name of the cert-manager Certificate providing
+	// the serving certificate, a self-signed certificate is generated if not set
+	CertManagerCertificate string
+}
+
 // AddToManagerFuncs is a list of functions to add all Controllers to the Manager
-var AddToManagerFuncs []func(manager.Manager, string) error
+var AddToManagerFuncs []func(manager.Manager, Options) error
 
 // AddToManager adds all Controllers to the Manager
 // +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations;validatingwebhookconfigurations,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
-func AddToManager(m manager.Manager, certDir string) error {
+func AddToManager(m manager.Manager, opts Options) error {
 	for _, f := range AddToManagerFuncs {
-		if err := f(m, certDir); err != nil {
+		if err := f(m, opts); err != nil {
 			return err
 		}
 	}